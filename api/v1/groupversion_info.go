@@ -41,6 +41,7 @@ var (
 // Adds the list of known types to scheme.
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(GroupVersion,
+		&OCSConfig{}, &OCSConfigList{},
 		&OCSInitialization{}, &OCSInitializationList{},
 		&StorageCluster{}, &StorageClusterList{},
 		&StorageClusterPeer{}, &StorageClusterPeerList{},
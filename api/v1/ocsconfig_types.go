@@ -0,0 +1,51 @@
+/*
+Copyright 2020 Red Hat OpenShift Container Storage.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OCSConfigSpec defines the desired state of OCSConfig
+type OCSConfigSpec struct {
+	// Tunables are the CSI tunables which are merged into the ocs-operator-config configmap.
+	// They take precedence over the operator and namespace defaults, but are overridden by
+	// the overrides annotation on the StorageCluster.
+	// +optional
+	Tunables map[string]string `json:"tunables,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +operator-sdk:csv:customresourcedefinitions:displayName="OCS Config"
+
+// OCSConfig holds the CSI tunables of the StorageCluster with the same name and namespace,
+// allowing them to evolve independently of the StorageCluster schema.
+type OCSConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec OCSConfigSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// OCSConfigList contains a list of OCSConfig
+type OCSConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OCSConfig `json:"items"`
+}
@@ -59,6 +59,110 @@ type OCSInitializationStatus struct {
 	SCCsCreated                   bool                         `json:"sCCsCreated,omitempty"`
 	RookCephOperatorConfigCreated bool                         `json:"rookCephOperatorConfigCreated,omitempty"`
 	RookCephOperatorConfig        RookCephOperatorConfigStatus `json:"rookCephOperatorConfig,omitempty"`
+	OcsOperatorConfig             OcsOperatorConfigStatus      `json:"ocsOperatorConfig,omitempty"`
+}
+
+type OcsOperatorConfigStatus struct {
+	// ClusterIDMigration records the migration of CSI_CLUSTER_NAME to a new cluster ID, during which the legacy
+	// cluster ID is passed on to CSI as well
+	// +optional
+	ClusterIDMigration *ClusterIDMigrationStatus `json:"clusterIDMigration,omitempty"`
+	// KeyRenames records the transition of the renamed ocs-operator-config keys, during which the old key name
+	// is written with the value of the new one
+	// +optional
+	KeyRenames []ConfigKeyRenameStatus `json:"keyRenames,omitempty"`
+	// MsModeRationale explains why the ms_mode of the CephFS kernel mount options was chosen
+	MsModeRationale string `json:"msModeRationale,omitempty"`
+	// Rollout records the progress of the staged rollout of ocs-operator-config changes
+	// +optional
+	Rollout OcsOperatorConfigRolloutStatus `json:"rollout,omitempty"`
+	// TopologyLabelRenames records the migration of the topology domain labels that have been renamed on the
+	// nodes, during which the previous topology domain labels are passed on to CSI as well
+	// +optional
+	TopologyLabelRenames []TopologyLabelRenameStatus `json:"topologyLabelRenames,omitempty"`
+	// TuningSuggestions are the tunable values suggested from the observed CSI metrics. They are advisory only
+	// and are not applied, the tunables can be changed in the OCSConfig.
+	// +optional
+	TuningSuggestions []TunableSuggestion `json:"tuningSuggestions,omitempty"`
+}
+
+// ClusterIDMigrationStatus is the state of a migration of CSI_CLUSTER_NAME to a new cluster ID
+type ClusterIDMigrationStatus struct {
+	// LegacyClusterID is the cluster ID the existing volumes reference
+	LegacyClusterID string `json:"legacyClusterID"`
+	// ClusterID is the cluster ID the config is migrated to
+	ClusterID string `json:"clusterID"`
+	// StartTime is the time the migration window started
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	// Completed is true once the migration window has passed and the legacy cluster ID is no longer passed on
+	Completed bool `json:"completed,omitempty"`
+}
+
+// ConfigKeyRenameStatus is the state of the transition of a renamed ocs-operator-config key
+type ConfigKeyRenameStatus struct {
+	// OldKey is the name the key had before it was renamed
+	OldKey string `json:"oldKey"`
+	// NewKey is the name of the key
+	NewKey string `json:"newKey"`
+	// StartTime is the time the transition started
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	// Completed is true once the transition period has passed and the old key name is no longer written
+	Completed bool `json:"completed,omitempty"`
+}
+
+// TopologyLabelRenameStatus is the state of the migration of a renamed topology domain label
+type TopologyLabelRenameStatus struct {
+	// OldLabel is the name the node label had before it was renamed
+	OldLabel string `json:"oldLabel"`
+	// NewLabel is the name of the node label
+	NewLabel string `json:"newLabel"`
+	// StartTime is the time the migration started
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	// Completed is true once the transition period has passed and the previous topology domain labels are no
+	// longer passed on
+	Completed bool `json:"completed,omitempty"`
+}
+
+// TunableSuggestion is a suggested value of a tunable of ocs-operator-config
+type TunableSuggestion struct {
+	// Tunable is the name of the tunable
+	Tunable string `json:"tunable"`
+	// CurrentValue is the value of the tunable in ocs-operator-config, or its default if it is not set
+	CurrentValue string `json:"currentValue,omitempty"`
+	// SuggestedValue is the suggested value of the tunable
+	SuggestedValue string `json:"suggestedValue"`
+	// Reason explains which metrics the suggestion is based on
+	Reason string `json:"reason,omitempty"`
+}
+
+// OcsOperatorConfigRolloutStage is a stage of the staged rollout of ocs-operator-config changes
+type OcsOperatorConfigRolloutStage string
+
+const (
+	// RolloutStageConfigUpdatePending means the config change waits for the health check to pass before it is applied
+	RolloutStageConfigUpdatePending OcsOperatorConfigRolloutStage = "ConfigUpdatePending"
+	// RolloutStageRestartPending means the config change is applied and the rook-ceph-operator restart waits
+	// for the health check to pass
+	RolloutStageRestartPending OcsOperatorConfigRolloutStage = "RestartPending"
+	// RolloutStageZoneRestartPending means the rook-ceph-operator replicas are restarted one zone at a time and
+	// the restart of the replicas of the next zone waits for the health check to pass
+	RolloutStageZoneRestartPending OcsOperatorConfigRolloutStage = "ZoneRestartPending"
+	// RolloutStageCompleted means the config change is applied and the rook-ceph-operator has been restarted
+	RolloutStageCompleted OcsOperatorConfigRolloutStage = "Completed"
+)
+
+type OcsOperatorConfigRolloutStatus struct {
+	// Stage is the current stage of the rollout
+	Stage OcsOperatorConfigRolloutStage `json:"stage,omitempty"`
+	// Message explains why the rollout does not proceed, if it is gated by a failing health check
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the rollout entered the current stage
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Zone is the zone whose rook-ceph-operator replicas are restarted in the current stage, if the replicas are
+	// restarted one zone at a time
+	Zone string `json:"zone,omitempty"`
+	// CompletedZones are the zones whose rook-ceph-operator replicas have been restarted by the rollout
+	CompletedZones []string `json:"completedZones,omitempty"`
 }
 
 type RookCephOperatorConfigStatus struct {
@@ -81,6 +81,12 @@ type StorageClusterSpec struct {
 	// optional. When the failure domain and the node labels are missing, the
 	// ocs-operator makes a best effort to determine them automatically.
 	NodeTopologies *NodeTopologyMap `json:"nodeTopologies,omitempty"`
+	// CrushHierarchy maps the node labels of a custom CRUSH hierarchy to the CRUSH bucket types.
+	// The mapped labels take precedence over the default label of a bucket type when the failure
+	// domain and its values are determined. The first bucket type in the list whose label has enough
+	// distinct values across the storage nodes is preferred as the failure domain.
+	// +optional
+	CrushHierarchy []CrushBucketMapping `json:"crushHierarchy,omitempty"`
 	// ArbiterSpec specifies the storage cluster options related to arbiter.
 	// If Arbiter is enabled, ArbiterLocation in the NodeTopologies must be specified.
 	Arbiter ArbiterSpec `json:"arbiter,omitempty"`
@@ -120,6 +126,22 @@ type StorageClusterSpec struct {
 	// DefaultStorageProfile is the default storage profile to use for
 	// the storagerequest as StorageProfile is optional.
 	DefaultStorageProfile string `json:"defaultStorageProfile,omitempty"`
+	// RookCephOperatorPodSelector is the label selector (e.g. "app=rook-ceph-operator") of the rook-ceph-operator
+	// pods that are restarted to pick up config changes. It overrides the selector configured for the operator,
+	// for clusters that run a differently labeled rook-ceph-operator.
+	// +optional
+	RookCephOperatorPodSelector string `json:"rookCephOperatorPodSelector,omitempty"`
+	// OcsOperatorConfigName is the name of the configmap the CSI config is written to, instead of ocs-operator-config,
+	// e.g. to avoid collisions or to migrate the config schema side by side. The rook-ceph-operator Deployment has
+	// to take its environment from the same configmap. The name of the first storagecluster that sets one is used.
+	// +optional
+	OcsOperatorConfigName string `json:"ocsOperatorConfigName,omitempty"`
+	// TopologyNodeGroupOverrides pins groups of nodes to topology label values, which take precedence over the
+	// values the nodes are labeled with when the topology domains of the CSI config are derived. A node matching
+	// several groups takes the values of the first one. The overrides of the first storagecluster that sets any
+	// are used.
+	// +optional
+	TopologyNodeGroupOverrides []TopologyNodeGroupOverride `json:"topologyNodeGroupOverrides,omitempty"`
 }
 
 // CSIDriverSpec defines the CSI driver settings for the StorageCluster.
@@ -127,6 +149,22 @@ type CSIDriverSpec struct {
 	// ReadAffinity defines the read affinity settings for CSI driver.
 	// +kubebuilder:validation:Optional
 	ReadAffinity *rookCephv1.ReadAffinitySpec `json:"readAffinity,omitempty"`
+	// EnableReadAffinity enables or disables the read affinity of the CSI driver. If set, it takes precedence
+	// over both readAffinity.enabled and the default, which enables read affinity for internal mode clusters and
+	// disables it for external mode clusters. If not set, readAffinity.enabled is used if readAffinity is set,
+	// and the default otherwise. The crushLocationLabels of readAffinity are kept either way.
+	// +optional
+	EnableReadAffinity *bool `json:"enableReadAffinity,omitempty"`
+	// ProvisionerImage is the expected image of the CSI provisioner sidecar of a cluster that pins it. Its tag is recorded
+	// in ocs-operator-config, so that a mismatch with the running image can be detected. The image of the first
+	// storagecluster that sets one is used.
+	// +optional
+	ProvisionerImage string `json:"provisionerImage,omitempty"`
+	// AttacherImage is the expected image of the CSI attacher sidecar of a cluster that pins it. Its tag is recorded
+	// in ocs-operator-config, so that a mismatch with the running image can be detected. The image of the first
+	// storagecluster that sets one is used.
+	// +optional
+	AttacherImage string `json:"attacherImage,omitempty"`
 }
 
 // BackingStorageClass defines the backing storageclass for StorageDeviceSet
@@ -245,6 +283,16 @@ type ManageCephBlockPools struct {
 	VirtualizationStorageClassName string `json:"virtualizationStorageClassName,omitempty"`
 	// PoolSpec specifies the pool specification for the default cephBlockPool
 	PoolSpec *rookCephv1.PoolSpec `json:"poolSpec,omitempty"`
+	// RadosNamespace specifies the RBD namespace of the default cephBlockPool that the CSI driver provisions
+	// the volumes in. It is passed to the CSI driver and is not set when empty.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	RadosNamespace string `json:"radosNamespace,omitempty"`
+	// ThickProvision specifies whether the CSI driver thick provisions the RBD volumes, i.e. allocates their full
+	// size when they are created, instead of thin provisioning them. It is passed to the CSI driver and is not set
+	// when unset.
+	// +optional
+	ThickProvision *bool `json:"thickProvision,omitempty"`
 }
 
 // ManageCephNonResilientPools defines how to reconcile ceph non-resilient pools
@@ -286,6 +334,14 @@ type ManageCephFilesystems struct {
 	DataPoolSpec *rookCephv1.PoolSpec `json:"dataPoolSpec,omitempty"`
 	// AdditionalDataPools specifies list of additional named cephfs data pools
 	AdditionalDataPools []rookCephv1.NamedPoolSpec `json:"additionalDataPools,omitempty"`
+	// SubvolumeGroupPinning specifies the policy for pinning the CephFS subvolume groups to MDS ranks.
+	// It is passed to the CSI driver and is not set when empty.
+	// +kubebuilder:validation:Enum=export;distributed;random
+	SubvolumeGroupPinning string `json:"subvolumeGroupPinning,omitempty"`
+	// KernelMountOptions are additional CephFS kernel mount options, appended to the ms_mode chosen by the
+	// operator, which can not be overridden. Options without a value, like wsync, are set to an empty string.
+	// +optional
+	KernelMountOptions map[string]string `json:"kernelMountOptions,omitempty"`
 }
 
 // ManageCephObjectStores defines how to reconcile CephObjectStores
@@ -531,6 +587,13 @@ type KeyRotationSpec struct {
 	Schedule string `json:"schedule,omitempty"`
 }
 
+const (
+	// MirroringModeJournal mirrors the RBD images by replaying their journal
+	MirroringModeJournal = "journal"
+	// MirroringModeSnapshot mirrors the RBD images by their mirror snapshots
+	MirroringModeSnapshot = "snapshot"
+)
+
 type MirroringSpec struct {
 	// If true, data mirroring is enabled for the StorageCluster.
 	// This configuration will only be applied to resources (such as CephBlockPool)
@@ -539,6 +602,12 @@ type MirroringSpec struct {
 	// +optional
 	Enabled bool `json:"enabled,omitempty"`
 
+	// Mode is the RBD mirroring mode of the images, either journal or snapshot based. It is passed to the
+	// CSI driver while mirroring is enabled, and defaults to snapshot.
+	// +kubebuilder:validation:Enum=journal;snapshot
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
 	// PeerSecretNames represents the Kubernetes Secret names of rbd-mirror peers tokens
 	// +optional
 	PeerSecretNames []string `json:"peerSecretNames,omitempty"`
@@ -643,6 +712,14 @@ type NodeTopologyMap struct {
 	// TODO: Move the failureDomain from the status section to here
 	// FailureDomain string `json:"failureDomain,omitempty"`
 
+	// FailureDomainName is the failure domain to use when ocs-operator determines the failure domain.
+	// Besides the standard host, rack and zone, any other name like "datacenter" is taken from the
+	// topology.rook.io/<name> node label, unless the name is mapped to another label in the CrushHierarchy.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	// +optional
+	FailureDomainName string `json:"failureDomainName,omitempty"`
+
 	// ArbiterLocation is the chosen location in the failure domain for placing the arbiter resources.
 	// When the failure domain is not provided as an input, ocs-operator determines the failure domain.
 	ArbiterLocation string `json:"arbiterLocation,omitempty"`
@@ -664,6 +741,14 @@ const (
 	// ConditionVersionMismatch type indicates that there is a mismatch in the storagecluster
 	// and the operator version
 	ConditionVersionMismatch conditionsv1.ConditionType = "VersionMismatch"
+
+	// ConditionMsModeDowngraded type indicates that the CephFS kernel mount ms_mode had to be
+	// downgraded as it is not supported by the external cluster
+	ConditionMsModeDowngraded conditionsv1.ConditionType = "MsModeDowngraded"
+
+	// ConditionTopologyDomainLabelsMissing type indicates that topology is enabled for the non-resilient
+	// pools, but the failure domain key that the topology domain labels are taken from could not be determined
+	ConditionTopologyDomainLabelsMissing conditionsv1.ConditionType = "TopologyDomainLabelsMissing"
 )
 
 // List of constants to show different different reconciliation messages and statuses.
@@ -713,6 +798,29 @@ type ArbiterSpec struct {
 	ArbiterMonPVCTemplate       *corev1.PersistentVolumeClaim `json:"arbiterMonPVCTemplate,omitempty"`
 }
 
+// CrushBucketMapping maps a node label to a CRUSH bucket type
+type CrushBucketMapping struct {
+	// Label is the node label whose values are the names of the CRUSH buckets
+	// +kubebuilder:validation:MinLength=1
+	Label string `json:"label"`
+	// BucketType is the CRUSH bucket type the label values belong to
+	// +kubebuilder:validation:Enum=host;chassis;rack;row;pdu;pod;room;datacenter;zone;region
+	BucketType string `json:"bucketType"`
+}
+
+// TopologyNodeGroupOverride pins the nodes of a node group to topology label values
+type TopologyNodeGroupOverride struct {
+	// Name is the name of the node group
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// NodeSelector selects the nodes of the group, it must not be empty
+	NodeSelector metav1.LabelSelector `json:"nodeSelector"`
+	// TopologyLabels maps the topology labels (e.g. "topology.kubernetes.io/zone") to the values the nodes of
+	// the group are pinned to
+	// +kubebuilder:validation:MinProperties=1
+	TopologyLabels map[string]string `json:"topologyLabels"`
+}
+
 // OverprovisionControlSpec defines the allowed overprovisioning PVC consumption from the underlying cluster.
 // This may be an absolute value or as a percentage of the overall effective capacity.
 // One, and only one of those two (Capacity and Percentage) may be defined.
@@ -80,6 +80,11 @@ func (in *CSIDriverSpec) DeepCopyInto(out *CSIDriverSpec) {
 		*out = new(ceph_rook_iov1.ReadAffinitySpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.EnableReadAffinity != nil {
+		in, out := &in.EnableReadAffinity, &out.EnableReadAffinity
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIDriverSpec.
@@ -92,6 +97,22 @@ func (in *CSIDriverSpec) DeepCopy() *CSIDriverSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterIDMigrationStatus) DeepCopyInto(out *ClusterIDMigrationStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterIDMigrationStatus.
+func (in *ClusterIDMigrationStatus) DeepCopy() *ClusterIDMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterIDMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComponentImageStatus) DeepCopyInto(out *ComponentImageStatus) {
 	*out = *in
@@ -107,6 +128,37 @@ func (in *ComponentImageStatus) DeepCopy() *ComponentImageStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigKeyRenameStatus) DeepCopyInto(out *ConfigKeyRenameStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigKeyRenameStatus.
+func (in *ConfigKeyRenameStatus) DeepCopy() *ConfigKeyRenameStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigKeyRenameStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CrushBucketMapping) DeepCopyInto(out *CrushBucketMapping) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CrushBucketMapping.
+func (in *CrushBucketMapping) DeepCopy() *CrushBucketMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(CrushBucketMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EncryptionSpec) DeepCopyInto(out *EncryptionSpec) {
 	*out = *in
@@ -263,6 +315,11 @@ func (in *ManageCephBlockPools) DeepCopyInto(out *ManageCephBlockPools) {
 		*out = new(ceph_rook_iov1.PoolSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ThickProvision != nil {
+		in, out := &in.ThickProvision, &out.ThickProvision
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephBlockPools.
@@ -365,6 +422,13 @@ func (in *ManageCephFilesystems) DeepCopyInto(out *ManageCephFilesystems) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.KernelMountOptions != nil {
+		in, out := &in.KernelMountOptions, &out.KernelMountOptions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephFilesystems.
@@ -637,6 +701,86 @@ func (in *NodeTopologyMap) DeepCopy() *NodeTopologyMap {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCSConfig) DeepCopyInto(out *OCSConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCSConfig.
+func (in *OCSConfig) DeepCopy() *OCSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OCSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OCSConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCSConfigList) DeepCopyInto(out *OCSConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OCSConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCSConfigList.
+func (in *OCSConfigList) DeepCopy() *OCSConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(OCSConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OCSConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCSConfigSpec) DeepCopyInto(out *OCSConfigSpec) {
+	*out = *in
+	if in.Tunables != nil {
+		in, out := &in.Tunables, &out.Tunables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCSConfigSpec.
+func (in *OCSConfigSpec) DeepCopy() *OCSConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OCSConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OCSInitialization) DeepCopyInto(out *OCSInitialization) {
 	*out = *in
@@ -734,6 +878,7 @@ func (in *OCSInitializationStatus) DeepCopyInto(out *OCSInitializationStatus) {
 		copy(*out, *in)
 	}
 	out.RookCephOperatorConfig = in.RookCephOperatorConfig
+	in.OcsOperatorConfig.DeepCopyInto(&out.OcsOperatorConfig)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCSInitializationStatus.
@@ -746,6 +891,67 @@ func (in *OCSInitializationStatus) DeepCopy() *OCSInitializationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OcsOperatorConfigRolloutStatus) DeepCopyInto(out *OcsOperatorConfigRolloutStatus) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	if in.CompletedZones != nil {
+		in, out := &in.CompletedZones, &out.CompletedZones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OcsOperatorConfigRolloutStatus.
+func (in *OcsOperatorConfigRolloutStatus) DeepCopy() *OcsOperatorConfigRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OcsOperatorConfigRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OcsOperatorConfigStatus) DeepCopyInto(out *OcsOperatorConfigStatus) {
+	*out = *in
+	if in.ClusterIDMigration != nil {
+		in, out := &in.ClusterIDMigration, &out.ClusterIDMigration
+		*out = new(ClusterIDMigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KeyRenames != nil {
+		in, out := &in.KeyRenames, &out.KeyRenames
+		*out = make([]ConfigKeyRenameStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Rollout.DeepCopyInto(&out.Rollout)
+	if in.TopologyLabelRenames != nil {
+		in, out := &in.TopologyLabelRenames, &out.TopologyLabelRenames
+		*out = make([]TopologyLabelRenameStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TuningSuggestions != nil {
+		in, out := &in.TuningSuggestions, &out.TuningSuggestions
+		*out = make([]TunableSuggestion, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OcsOperatorConfigStatus.
+func (in *OcsOperatorConfigStatus) DeepCopy() *OcsOperatorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OcsOperatorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OverprovisionControlSpec) DeepCopyInto(out *OverprovisionControlSpec) {
 	*out = *in
@@ -1131,6 +1337,11 @@ func (in *StorageClusterSpec) DeepCopyInto(out *StorageClusterSpec) {
 		*out = new(MirroringSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CrushHierarchy != nil {
+		in, out := &in.CrushHierarchy, &out.CrushHierarchy
+		*out = make([]CrushBucketMapping, len(*in))
+		copy(*out, *in)
+	}
 	if in.OverprovisionControl != nil {
 		in, out := &in.OverprovisionControl, &out.OverprovisionControl
 		*out = make([]OverprovisionControlSpec, len(*in))
@@ -1150,6 +1361,13 @@ func (in *StorageClusterSpec) DeepCopyInto(out *StorageClusterSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TopologyNodeGroupOverrides != nil {
+		in, out := &in.TopologyNodeGroupOverrides, &out.TopologyNodeGroupOverrides
+		*out = make([]TopologyNodeGroupOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageClusterSpec.
@@ -1285,3 +1503,57 @@ func (in TopologyLabelValues) DeepCopy() TopologyLabelValues {
 	in.DeepCopyInto(out)
 	return *out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyLabelRenameStatus) DeepCopyInto(out *TopologyLabelRenameStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyLabelRenameStatus.
+func (in *TopologyLabelRenameStatus) DeepCopy() *TopologyLabelRenameStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyLabelRenameStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyNodeGroupOverride) DeepCopyInto(out *TopologyNodeGroupOverride) {
+	*out = *in
+	in.NodeSelector.DeepCopyInto(&out.NodeSelector)
+	if in.TopologyLabels != nil {
+		in, out := &in.TopologyLabels, &out.TopologyLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyNodeGroupOverride.
+func (in *TopologyNodeGroupOverride) DeepCopy() *TopologyNodeGroupOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyNodeGroupOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunableSuggestion) DeepCopyInto(out *TunableSuggestion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunableSuggestion.
+func (in *TunableSuggestion) DeepCopy() *TunableSuggestion {
+	if in == nil {
+		return nil
+	}
+	out := new(TunableSuggestion)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,183 @@
+package ocsconfig
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// TunableBoundsConfigMapName is the name of the optional configmap in the operator namespace that
+	// overrides the default bounds of the numeric tunables, or adds bounds for further tunables.
+	// Its keys are the tunables and its values are the bounds as "<min>:<max>", either of which may be empty.
+	TunableBoundsConfigMapName = "ocs-config-tunable-bounds"
+)
+
+// TunableBounds are the inclusive bounds of a numeric tunable. A nil bound is not enforced.
+type TunableBounds struct {
+	Min *int64
+	Max *int64
+}
+
+func newTunableBounds(minValue, maxValue int64) TunableBounds {
+	return TunableBounds{Min: &minValue, Max: &maxValue}
+}
+
+// String returns the bounds in the "<min>:<max>" format of the bounds configmap
+func (b TunableBounds) String() string {
+	bounds := [2]string{}
+	for i, bound := range []*int64{b.Min, b.Max} {
+		if bound != nil {
+			bounds[i] = strconv.FormatInt(*bound, 10)
+		}
+	}
+	return bounds[0] + ":" + bounds[1]
+}
+
+// Contains returns true if the value is within the bounds
+func (b TunableBounds) Contains(value int64) bool {
+	return (b.Min == nil || value >= *b.Min) && (b.Max == nil || value <= *b.Max)
+}
+
+// DefaultTunableBounds are the bounds of the numeric CSI tunables outside of which the CSI drivers are known
+// to misbehave. Each of them can be changed through the bounds configmap.
+var DefaultTunableBounds = map[string]TunableBounds{
+	"CSI_GRPC_TIMEOUT_SECONDS":           newTunableBounds(30, 600),
+	"ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS": newTunableBounds(15, 600),
+	"CSI_PROVISIONER_REPLICAS":           newTunableBounds(1, 3),
+	"CSI_LOG_LEVEL":                      newTunableBounds(0, 5),
+	"CSI_SIDECAR_LOG_LEVEL":              newTunableBounds(0, 5),
+}
+
+// parseTunableBounds parses bounds in the "<min>:<max>" format
+func parseTunableBounds(value string) (TunableBounds, error) {
+	minValue, maxValue, found := strings.Cut(value, ":")
+	if !found {
+		return TunableBounds{}, fmt.Errorf("bounds %q are not in the <min>:<max> format", value)
+	}
+	bounds := TunableBounds{}
+	for _, bound := range []struct {
+		value  string
+		target **int64
+	}{{minValue, &bounds.Min}, {maxValue, &bounds.Max}} {
+		if bound.value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(strings.TrimSpace(bound.value), 10, 64)
+		if err != nil {
+			return TunableBounds{}, fmt.Errorf("bounds %q are not integers", value)
+		}
+		*bound.target = &parsed
+	}
+	if bounds.Min != nil && bounds.Max != nil && *bounds.Min > *bounds.Max {
+		return TunableBounds{}, fmt.Errorf("the minimum of the bounds %q is greater than the maximum", value)
+	}
+	return bounds, nil
+}
+
+// +kubebuilder:webhook:path=/validate-ocs-openshift-io-v1-ocsconfig,mutating=false,failurePolicy=fail,sideEffects=None,groups=ocs.openshift.io,resources=ocsconfigs,verbs=create;update,versions=v1,name=vocsconfig.kb.io,admissionReviewVersions=v1
+
+// TunableBoundsValidator rejects OCSConfigs with numeric tunables outside of their bounds
+type TunableBoundsValidator struct {
+	Client            client.Client
+	Log               logr.Logger
+	OperatorNamespace string
+}
+
+var _ admission.CustomValidator = &TunableBoundsValidator{}
+
+// SetupWebhookWithManager registers the validating webhook of the OCSConfigs with the manager
+func (v *TunableBoundsValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&ocsv1.OCSConfig{}).
+		WithValidator(v).
+		Complete()
+}
+
+// GetTunableBounds returns the default bounds merged with the ones of the bounds configmap in the operator
+// namespace. Invalid entries of the configmap are ignored, so that a typo does not leave the tunables unbounded or
+// block all the updates.
+func GetTunableBounds(ctx context.Context, c client.Reader, log logr.Logger, operatorNamespace string) (map[string]TunableBounds, error) {
+	bounds := maps.Clone(DefaultTunableBounds)
+	boundsConfigMap := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Name: TunableBoundsConfigMapName, Namespace: operatorNamespace}, boundsConfigMap)
+	if kerrors.IsNotFound(err) {
+		return bounds, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for tunable, value := range boundsConfigMap.Data {
+		parsed, err := parseTunableBounds(value)
+		if err != nil {
+			log.Info("Ignoring invalid tunable bounds.", "ConfigMap", TunableBoundsConfigMapName, "Tunable", tunable, "Error", err.Error())
+			continue
+		}
+		bounds[tunable] = parsed
+	}
+	return bounds, nil
+}
+
+// validateTunables checks the numeric tunables against their bounds
+func (v *TunableBoundsValidator) validateTunables(ctx context.Context, obj runtime.Object) error {
+	ocsConfig, ok := obj.(*ocsv1.OCSConfig)
+	if !ok {
+		return fmt.Errorf("expected an OCSConfig but got a %T", obj)
+	}
+	bounds, err := GetTunableBounds(ctx, v.Client, v.Log, v.OperatorNamespace)
+	if err != nil {
+		return err
+	}
+
+	tunablesPath := field.NewPath("spec", "tunables")
+	allErrs := field.ErrorList{}
+	for _, tunable := range slices.Sorted(maps.Keys(ocsConfig.Spec.Tunables)) {
+		tunableBounds, ok := bounds[tunable]
+		if !ok {
+			continue
+		}
+		value := ocsConfig.Spec.Tunables[tunable]
+		parsed, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(tunablesPath.Key(tunable), value, "must be an integer"))
+			continue
+		}
+		if !tunableBounds.Contains(parsed) {
+			allErrs = append(allErrs, field.Invalid(tunablesPath.Key(tunable), value,
+				fmt.Sprintf("must be within the bounds %s, which can be changed in the %s/%s configmap",
+					tunableBounds, v.OperatorNamespace, TunableBoundsConfigMapName)))
+		}
+	}
+	if len(allErrs) > 0 {
+		return kerrors.NewInvalid(ocsv1.GroupVersion.WithKind("OCSConfig").GroupKind(), ocsConfig.Name, allErrs)
+	}
+	return nil
+}
+
+// ValidateCreate validates the tunables of a new OCSConfig
+func (v *TunableBoundsValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validateTunables(ctx, obj)
+}
+
+// ValidateUpdate validates the tunables of an updated OCSConfig
+func (v *TunableBoundsValidator) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validateTunables(ctx, newObj)
+}
+
+// ValidateDelete allows the deletion of any OCSConfig
+func (v *TunableBoundsValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
@@ -0,0 +1,126 @@
+package ocsconfig
+
+import (
+	"context"
+	"testing"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const testOperatorNamespace = "openshift-storage"
+
+func getTestValidator(t *testing.T, objs ...client.Object) *TunableBoundsValidator {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	assert.NoError(t, ocsv1.AddToScheme(scheme))
+	return &TunableBoundsValidator{
+		Client:            fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Log:               log.Log.WithName("ocsconfig_webhook_test"),
+		OperatorNamespace: testOperatorNamespace,
+	}
+}
+
+func newTestOCSConfig(tunables map[string]string) *ocsv1.OCSConfig {
+	return &ocsv1.OCSConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ocs-storagecluster",
+			Namespace: testOperatorNamespace,
+		},
+		Spec: ocsv1.OCSConfigSpec{Tunables: tunables},
+	}
+}
+
+func TestTunableBoundsValidation(t *testing.T) {
+	boundsConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TunableBoundsConfigMapName,
+			Namespace: testOperatorNamespace,
+		},
+		Data: map[string]string{
+			"CSI_GRPC_TIMEOUT_SECONDS":  "60:",
+			"CSI_RBD_MAX_CONCURRENCY":   "1:16",
+			"CSI_PROVISIONER_REPLICAS":  "3:1",
+			"CSI_CEPHFS_MAX_CONCURRENT": "many",
+		},
+	}
+
+	testcases := []struct {
+		label        string
+		objs         []client.Object
+		tunables     map[string]string
+		expectReject bool
+	}{
+		{
+			label:    "values within the default bounds",
+			tunables: map[string]string{"CSI_GRPC_TIMEOUT_SECONDS": "150", "CSI_LOG_LEVEL": "0"},
+		},
+		{
+			label:        "value above the default bounds",
+			tunables:     map[string]string{"CSI_GRPC_TIMEOUT_SECONDS": "3600"},
+			expectReject: true,
+		},
+		{
+			label:        "value below the default bounds",
+			tunables:     map[string]string{"CSI_PROVISIONER_REPLICAS": "0"},
+			expectReject: true,
+		},
+		{
+			label:        "value that is not an integer",
+			tunables:     map[string]string{"CSI_LOG_LEVEL": "debug"},
+			expectReject: true,
+		},
+		{
+			label:    "tunable without bounds",
+			tunables: map[string]string{"CSI_ENABLE_TOPOLOGY": "true"},
+		},
+		{
+			label:    "maximum removed by the bounds configmap",
+			objs:     []client.Object{boundsConfigMap},
+			tunables: map[string]string{"CSI_GRPC_TIMEOUT_SECONDS": "3600"},
+		},
+		{
+			label:        "minimum raised by the bounds configmap",
+			objs:         []client.Object{boundsConfigMap},
+			tunables:     map[string]string{"CSI_GRPC_TIMEOUT_SECONDS": "30"},
+			expectReject: true,
+		},
+		{
+			label:        "tunable bounded by the bounds configmap",
+			objs:         []client.Object{boundsConfigMap},
+			tunables:     map[string]string{"CSI_RBD_MAX_CONCURRENCY": "32"},
+			expectReject: true,
+		},
+		{
+			label:    "invalid bounds of the bounds configmap are ignored",
+			objs:     []client.Object{boundsConfigMap},
+			tunables: map[string]string{"CSI_PROVISIONER_REPLICAS": "2", "CSI_CEPHFS_MAX_CONCURRENT": "1000"},
+		},
+	}
+
+	for _, tc := range testcases {
+		validator := getTestValidator(t, tc.objs...)
+		ocsConfig := newTestOCSConfig(tc.tunables)
+
+		_, err := validator.ValidateCreate(context.TODO(), ocsConfig)
+		assert.Equalf(t, tc.expectReject, err != nil, "[%s]: unexpected result of create: %v", tc.label, err)
+		if tc.expectReject {
+			assert.Truef(t, kerrors.IsInvalid(err), "[%s]: unexpected error %v", tc.label, err)
+		}
+		_, err = validator.ValidateUpdate(context.TODO(), newTestOCSConfig(nil), ocsConfig)
+		assert.Equalf(t, tc.expectReject, err != nil, "[%s]: unexpected result of update: %v", tc.label, err)
+	}
+}
+
+func TestTunableBoundsMessage(t *testing.T) {
+	validator := getTestValidator(t)
+	_, err := validator.ValidateCreate(context.TODO(), newTestOCSConfig(map[string]string{"CSI_GRPC_TIMEOUT_SECONDS": "5"}))
+	assert.ErrorContains(t, err, `spec.tunables[CSI_GRPC_TIMEOUT_SECONDS]: Invalid value: "5": must be within the bounds 30:600`)
+}
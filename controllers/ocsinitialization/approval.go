@@ -0,0 +1,167 @@
+package ocsinitialization
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"time"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// ConditionOcsOperatorConfigChangeDenied is set when a change of the ocs-operator-config data was not applied,
+	// either because the approval webhook denied it or because the webhook could not be reached and fails closed
+	ConditionOcsOperatorConfigChangeDenied conditionsv1.ConditionType = "OcsOperatorConfigChangeDenied"
+
+	// DefaultConfigApprovalWebhookTimeout is the timeout of the approval webhook callout
+	DefaultConfigApprovalWebhookTimeout = 10 * time.Second
+
+	// approvalWebhookUnavailableRequeueDelay is the delay after which a change that could not be submitted to the
+	// approval webhook is submitted again
+	approvalWebhookUnavailableRequeueDelay = 30 * time.Second
+)
+
+// errApprovalWebhookUnavailable is returned when a change of the ocs-operator-config data is skipped because the
+// approval webhook could not be called and fails closed. Nothing else triggers a reconcile once the webhook is
+// reachable again, so the reconcile is requeued.
+var errApprovalWebhookUnavailable = errors.New("approval webhook unavailable")
+
+// isApprovalWebhookUnavailable returns true if the error is caused by an unavailable approval webhook
+func isApprovalWebhookUnavailable(err error) bool {
+	return errors.Is(err, errApprovalWebhookUnavailable)
+}
+
+// configValueChange is the previous and the new value of a changed ocs-operator-config key
+type configValueChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// configApprovalRequest is sent to the approval webhook with the diff of the ocs-operator-config data
+type configApprovalRequest struct {
+	Namespace string                       `json:"namespace"`
+	Name      string                       `json:"name"`
+	Added     map[string]string            `json:"added,omitempty"`
+	Changed   map[string]configValueChange `json:"changed,omitempty"`
+	Removed   []string                     `json:"removed,omitempty"`
+}
+
+// configApprovalResponse is returned by the approval webhook
+type configApprovalResponse struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// newConfigApprovalRequest returns the diff between the current and the desired ocs-operator-config data
+func newConfigApprovalRequest(namespace, name string, currentData, desiredData map[string]string) configApprovalRequest {
+	request := configApprovalRequest{
+		Namespace: namespace,
+		Name:      name,
+		Added:     map[string]string{},
+		Changed:   map[string]configValueChange{},
+	}
+	for key, value := range desiredData {
+		if old, ok := currentData[key]; !ok {
+			request.Added[key] = value
+		} else if old != value {
+			request.Changed[key] = configValueChange{Old: old, New: value}
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(currentData)) {
+		if _, ok := desiredData[key]; !ok {
+			request.Removed = append(request.Removed, key)
+		}
+	}
+	return request
+}
+
+// callConfigApprovalWebhook posts the diff to the approval webhook and returns its decision
+func (r *OCSInitializationReconciler) callConfigApprovalWebhook(request configApprovalRequest) (configApprovalResponse, error) {
+	timeout := r.ConfigApprovalWebhookTimeout
+	if timeout <= 0 {
+		timeout = DefaultConfigApprovalWebhookTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return configApprovalResponse{}, err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, r.ConfigApprovalWebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return configApprovalResponse{}, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpResponse, err := http.DefaultClient.Do(httpRequest)
+	if err != nil {
+		return configApprovalResponse{}, err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return configApprovalResponse{}, fmt.Errorf("approval webhook responded with status %d", httpResponse.StatusCode)
+	}
+	response := configApprovalResponse{}
+	if err := json.NewDecoder(io.LimitReader(httpResponse.Body, 1<<20)).Decode(&response); err != nil {
+		return configApprovalResponse{}, fmt.Errorf("failed to decode the response of the approval webhook: %v", err)
+	}
+	return response, nil
+}
+
+// isOcsOperatorConfigChangeDenied consults the approval webhook, if one is configured, before a change of the
+// ocs-operator-config data is applied. It returns true if the change must be skipped. Creating the configmap is
+// not subject to approval, as there is no config to keep yet. If the webhook cannot be reached the change is
+// skipped with errApprovalWebhookUnavailable, unless ConfigApprovalWebhookFailOpen is set.
+func (r *OCSInitializationReconciler) isOcsOperatorConfigChangeDenied(initialData *ocsv1.OCSInitialization,
+	ocsOperatorConfigData map[string]string) (bool, error) {
+	if r.ConfigApprovalWebhookURL == "" {
+		setOcsOperatorConfigCondition(initialData, ConditionOcsOperatorConfigChangeDenied, false, "", "")
+		return false, nil
+	}
+
+	current := &corev1.ConfigMap{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: r.getOcsOperatorConfigName(), Namespace: initialData.Namespace}, current)
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if reflect.DeepEqual(current.Data, ocsOperatorConfigData) {
+		return false, nil
+	}
+
+	response, err := r.callConfigApprovalWebhook(newConfigApprovalRequest(initialData.Namespace, current.Name, current.Data, ocsOperatorConfigData))
+	if err != nil {
+		if r.ConfigApprovalWebhookFailOpen {
+			r.Log.Error(err, "Failed to call the approval webhook, applying the ocs-operator-config change", "URL", r.ConfigApprovalWebhookURL)
+			setOcsOperatorConfigCondition(initialData, ConditionOcsOperatorConfigChangeDenied, false, "", "")
+			return false, nil
+		}
+		r.Log.Error(err, "Failed to call the approval webhook, skipping the ocs-operator-config change", "URL", r.ConfigApprovalWebhookURL)
+		setOcsOperatorConfigCondition(initialData, ConditionOcsOperatorConfigChangeDenied, true,
+			"ApprovalWebhookUnavailable", fmt.Sprintf("the approval webhook could not be called: %v", err))
+		return true, fmt.Errorf("%w: %v", errApprovalWebhookUnavailable, err)
+	}
+	if !response.Approved {
+		r.Log.Info("The approval webhook denied the ocs-operator-config change", "Reason", response.Reason)
+		setOcsOperatorConfigCondition(initialData, ConditionOcsOperatorConfigChangeDenied, true,
+			"DeniedByApprovalWebhook", fmt.Sprintf("the approval webhook denied the change: %s", response.Reason))
+		return true, nil
+	}
+	setOcsOperatorConfigCondition(initialData, ConditionOcsOperatorConfigChangeDenied, false, "", "")
+	return false, nil
+}
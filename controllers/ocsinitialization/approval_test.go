@@ -0,0 +1,124 @@
+package ocsinitialization
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestOcsOperatorConfigApprovalWebhook(t *testing.T) {
+	testcases := []struct {
+		label           string
+		response        *configApprovalResponse
+		delay           time.Duration
+		failOpen        bool
+		expectApplied   bool
+		expectedReason  string
+		expectCondition bool
+		expectRequeue   bool
+	}{
+		{
+			label:         "change approved",
+			response:      &configApprovalResponse{Approved: true},
+			expectApplied: true,
+		},
+		{
+			label:           "change denied",
+			response:        &configApprovalResponse{Approved: false, Reason: "change freeze"},
+			expectedReason:  "DeniedByApprovalWebhook",
+			expectCondition: true,
+		},
+		{
+			label:           "timeout fails closed",
+			response:        &configApprovalResponse{Approved: true},
+			delay:           time.Second,
+			expectedReason:  "ApprovalWebhookUnavailable",
+			expectCondition: true,
+			expectRequeue:   true,
+		},
+		{
+			label:         "timeout fails open",
+			response:      &configApprovalResponse{Approved: true},
+			delay:         time.Second,
+			failOpen:      true,
+			expectApplied: true,
+		},
+		{
+			label:           "error response fails closed",
+			expectedReason:  "ApprovalWebhookUnavailable",
+			expectCondition: true,
+			expectRequeue:   true,
+		},
+	}
+
+	for _, tc := range testcases {
+		requests := []configApprovalRequest{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			request := configApprovalRequest{}
+			assert.NoErrorf(t, json.NewDecoder(req.Body).Decode(&request), "[%s]: failed to decode the request", tc.label)
+			requests = append(requests, request)
+			select {
+			case <-time.After(tc.delay):
+			case <-req.Context().Done():
+				return
+			}
+			if tc.response == nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			assert.NoError(t, json.NewEncoder(w).Encode(tc.response))
+		}))
+
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+		reconciler.ConfigApprovalWebhookURL = server.URL
+		reconciler.ConfigApprovalWebhookTimeout = 100 * time.Millisecond
+		reconciler.ConfigApprovalWebhookFailOpen = tc.failOpen
+
+		// creating the configmap is not subject to approval
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		assert.Emptyf(t, requests, "[%s]: unexpected approval request on creation", tc.label)
+		assert.Equalf(t, "false", getOcsOperatorConfigData(t, reconciler)[util.EnableNFSKey], "[%s]", tc.label)
+
+		assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(sc), sc))
+		sc.Spec.NFS = &v1.NFSSpec{Enable: true}
+		assert.NoError(t, reconciler.Client.Update(reconciler.ctx, sc))
+		var err error
+		reconciler.clusters, err = util.GetClusters(reconciler.ctx, reconciler.Client)
+		assert.NoError(t, err)
+		err = reconciler.ensureOcsOperatorConfigExists(ocsInit)
+		server.Close()
+		// a webhook that fails closed requeues the change rather than leaving it until the next unrelated event
+		if tc.expectRequeue {
+			assert.Truef(t, isApprovalWebhookUnavailable(err), "[%s]: expected the change to be requeued, got %v", tc.label, err)
+		} else {
+			assert.NoErrorf(t, err, "[%s]: failed to ensure ocs-operator-config", tc.label)
+		}
+
+		// the webhook receives the diff of the change
+		if assert.Lenf(t, requests, 1, "[%s]: unexpected approval requests", tc.label) {
+			assert.Equalf(t, map[string]configValueChange{util.EnableNFSKey: {Old: "false", New: "true"}}, requests[0].Changed,
+				"[%s]: unexpected diff", tc.label)
+		}
+		expectedValue := "false"
+		if tc.expectApplied {
+			expectedValue = "true"
+		}
+		assert.Equalf(t, expectedValue, getOcsOperatorConfigData(t, reconciler)[util.EnableNFSKey], "[%s]: unexpected config", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionOcsOperatorConfigChangeDenied)
+		assert.Equalf(t, tc.expectCondition, condition != nil && condition.Status == corev1.ConditionTrue,
+			"[%s]: unexpected condition %v", tc.label, condition)
+		if tc.expectCondition {
+			assert.Equalf(t, tc.expectedReason, condition.Reason, "[%s]: unexpected condition reason", tc.label)
+		}
+	}
+}
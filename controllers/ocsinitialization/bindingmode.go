@@ -0,0 +1,86 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// ConditionTopologyBindingModeImmediate is set when topology is enabled, but a topology constrained
+	// StorageClass binds its volumes immediately. The volumes are then provisioned before the pod is
+	// scheduled, so the topology does not take effect.
+	ConditionTopologyBindingModeImmediate conditionsv1.ConditionType = "TopologyBindingModeImmediate"
+)
+
+// checkTopologyBindingMode warns about the topology constrained StorageClasses that use the Immediate binding
+// mode while topology is enabled. With CorrectTopologyBindingMode set, they are recreated with the
+// WaitForFirstConsumer binding mode instead, as the binding mode of a StorageClass is immutable.
+func (r *OCSInitializationReconciler) checkTopologyBindingMode(initialData *ocsv1.OCSInitialization,
+	ocsOperatorConfigData map[string]string) error {
+	if ocsOperatorConfigData[util.EnableTopologyKey] != "true" {
+		setOcsOperatorConfigCondition(initialData, ConditionTopologyBindingModeImmediate, false, "", "")
+		return nil
+	}
+
+	storageClasses := &storagev1.StorageClassList{}
+	if err := r.Client.List(r.ctx, storageClasses); err != nil {
+		return err
+	}
+	immediate := []string{}
+	for i := range storageClasses.Items {
+		sc := &storageClasses.Items[i]
+		if !isTopologyConstrainedStorageClass(sc) ||
+			ptr.Deref(sc.VolumeBindingMode, storagev1.VolumeBindingImmediate) != storagev1.VolumeBindingImmediate {
+			continue
+		}
+		if r.CorrectTopologyBindingMode {
+			if err := r.recreateWithWaitForFirstConsumer(sc); err != nil {
+				r.Log.Error(err, "Failed to correct the binding mode of StorageClass", "StorageClass", sc.Name)
+				return err
+			}
+			continue
+		}
+		immediate = append(immediate, sc.Name)
+	}
+
+	if len(immediate) > 0 {
+		r.Log.Info("Topology is enabled, but topology constrained StorageClasses use the Immediate binding mode.",
+			"StorageClasses", immediate)
+	}
+	setOcsOperatorConfigCondition(initialData, ConditionTopologyBindingModeImmediate, len(immediate) > 0,
+		"ImmediateBindingMode",
+		fmt.Sprintf("Topology does not take effect for the StorageClasses %s, they require the %s binding mode",
+			strings.Join(immediate, ", "), storagev1.VolumeBindingWaitForFirstConsumer))
+	return nil
+}
+
+// isTopologyConstrainedStorageClass returns true for the ceph CSI StorageClasses that provision into topology
+// constrained pools
+func isTopologyConstrainedStorageClass(sc *storagev1.StorageClass) bool {
+	return isCephCSIDriver(sc.Provisioner) && sc.Parameters["topologyConstrainedPools"] != ""
+}
+
+// recreateWithWaitForFirstConsumer replaces the StorageClass with a copy that uses the WaitForFirstConsumer binding mode
+func (r *OCSInitializationReconciler) recreateWithWaitForFirstConsumer(sc *storagev1.StorageClass) error {
+	r.Log.Info("Recreating StorageClass with the WaitForFirstConsumer binding mode", "StorageClass", sc.Name)
+	corrected := sc.DeepCopy()
+	corrected.ObjectMeta = metav1.ObjectMeta{
+		Name:            sc.Name,
+		Labels:          sc.Labels,
+		Annotations:     sc.Annotations,
+		OwnerReferences: sc.OwnerReferences,
+	}
+	corrected.VolumeBindingMode = ptr.To(storagev1.VolumeBindingWaitForFirstConsumer)
+	if err := r.Client.Delete(r.ctx, sc); err != nil {
+		return err
+	}
+	return r.Client.Create(r.ctx, corrected)
+}
@@ -0,0 +1,75 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestTopologyConstrainedStorageClass(bindingMode storagev1.VolumeBindingMode) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "ocs-storagecluster-ceph-non-resilient-rbd",
+			Labels: map[string]string{"app": "ocs"},
+		},
+		Provisioner:       util.RbdDriverName,
+		VolumeBindingMode: &bindingMode,
+		Parameters:        map[string]string{"topologyConstrainedPools": "[]"},
+	}
+}
+
+func TestTopologyBindingMode(t *testing.T) {
+	testcases := []struct {
+		label               string
+		bindingMode         storagev1.VolumeBindingMode
+		correct             bool
+		expectCondition     bool
+		expectedBindingMode storagev1.VolumeBindingMode
+	}{
+		{
+			label:               "WaitForFirstConsumer binding mode",
+			bindingMode:         storagev1.VolumeBindingWaitForFirstConsumer,
+			expectedBindingMode: storagev1.VolumeBindingWaitForFirstConsumer,
+		},
+		{
+			label:               "Immediate binding mode",
+			bindingMode:         storagev1.VolumeBindingImmediate,
+			expectCondition:     true,
+			expectedBindingMode: storagev1.VolumeBindingImmediate,
+		},
+		{
+			label:               "Immediate binding mode is corrected",
+			bindingMode:         storagev1.VolumeBindingImmediate,
+			correct:             true,
+			expectedBindingMode: storagev1.VolumeBindingWaitForFirstConsumer,
+		},
+	}
+
+	for _, tc := range testcases {
+		storageClass := newTestTopologyConstrainedStorageClass(tc.bindingMode)
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace), storageClass)
+		resolver := &fakeTopologyResolver{}
+		reconciler.TopologyResolver = resolver
+		reconciler.CorrectTopologyBindingMode = tc.correct
+
+		// without topology the binding mode does not matter
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		assert.Nilf(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionTopologyBindingModeImmediate), "[%s]", tc.label)
+
+		resolver.topology = TopologyConfig{Enabled: true, DomainLabels: "topology.kubernetes.io/zone"}
+		reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionTopologyBindingModeImmediate)
+		assert.Equalf(t, tc.expectCondition, condition != nil, "[%s]: unexpected condition %v", tc.label, condition)
+
+		assert.NoErrorf(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(storageClass), storageClass), "[%s]", tc.label)
+		assert.Equalf(t, tc.expectedBindingMode, *storageClass.VolumeBindingMode, "[%s]: unexpected binding mode", tc.label)
+		assert.Equalf(t, "[]", storageClass.Parameters["topologyConstrainedPools"], "[%s]: parameters not kept", tc.label)
+		assert.Equalf(t, "ocs", storageClass.Labels["app"], "[%s]: labels not kept", tc.label)
+	}
+}
@@ -0,0 +1,145 @@
+package ocsinitialization
+
+import (
+	"reflect"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// ActiveConfigAnnotation is set on ocs-operator-config to the name of the slot configmap that holds the
+	// active config, when blue-green config swapping is enabled
+	ActiveConfigAnnotation = "ocs.openshift.io/active-config"
+
+	// RollbackConfigAnnotation can be set to "true" on the OCSInitialization to swap ocs-operator-config back
+	// to the previous config, when blue-green config swapping is enabled. The rollback is kept until the
+	// inputs of ocs-operator-config change again.
+	RollbackConfigAnnotation = "ocs.openshift.io/ocs-operator-config-rollback"
+
+	// rolledBackInputsHashAnnotation is set on ocs-operator-config to the inputs hash that was rolled back from
+	rolledBackInputsHashAnnotation = "ocs.openshift.io/rolled-back-inputs-hash"
+)
+
+// getOcsOperatorConfigSlots returns the configmaps that alternately hold the active and the previous config of
+// the ocs-operator-config configmap with the given name
+func getOcsOperatorConfigSlots(configName string) [2]string {
+	return [2]string{configName + "-blue", configName + "-green"}
+}
+
+// getStandbyConfigSlot returns the slot that does not hold the active config
+func getStandbyConfigSlot(configName, activeSlot string) string {
+	slots := getOcsOperatorConfigSlots(configName)
+	if activeSlot == slots[0] {
+		return slots[1]
+	}
+	return slots[0]
+}
+
+// stageBlueGreenConfig writes the desired data to the standby slot and returns it as the slot that becomes
+// active, along with its data. If the active slot holds the desired data already, or the active config has
+// been rolled back for the current inputs, the active slot is kept.
+func (r *OCSInitializationReconciler) stageBlueGreenConfig(initialData *ocsv1.OCSInitialization,
+	ocsOperatorConfigData map[string]string, inputsHash string) (map[string]string, string, error) {
+	configName := r.getOcsOperatorConfigName()
+	current := &corev1.ConfigMap{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: configName, Namespace: initialData.Namespace}, current)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, "", err
+	}
+	activeSlot := current.GetAnnotations()[ActiveConfigAnnotation]
+
+	if activeSlot != "" {
+		active := &corev1.ConfigMap{}
+		err := r.Client.Get(r.ctx, types.NamespacedName{Name: activeSlot, Namespace: initialData.Namespace}, active)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, "", err
+		}
+		if err == nil {
+			if current.GetAnnotations()[rolledBackInputsHashAnnotation] == inputsHash {
+				r.Log.Info("Keeping the rolled back ocs-operator-config until its inputs change", "ActiveConfig", activeSlot)
+				return active.Data, activeSlot, nil
+			}
+			if reflect.DeepEqual(active.Data, ocsOperatorConfigData) {
+				return ocsOperatorConfigData, activeSlot, nil
+			}
+		}
+	}
+
+	standbySlot := getStandbyConfigSlot(configName, activeSlot)
+	if err := r.writeConfigSlot(initialData, standbySlot, ocsOperatorConfigData); err != nil {
+		return nil, "", err
+	}
+	r.Log.Info("Swapping the active ocs-operator-config", "PreviousConfig", activeSlot, "ActiveConfig", standbySlot)
+	return ocsOperatorConfigData, standbySlot, nil
+}
+
+// writeConfigSlot writes the data to the slot configmap
+func (r *OCSInitializationReconciler) writeConfigSlot(initialData *ocsv1.OCSInitialization, slot string, data map[string]string) error {
+	slotConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      slot,
+			Namespace: initialData.Namespace,
+		},
+	}
+	_, err := ctrl.CreateOrUpdate(r.ctx, r.Client, slotConfigMap, func() error {
+		slotConfigMap.Data = data
+		setConfigDataChecksum(slotConfigMap)
+		return ctrl.SetControllerReference(initialData, slotConfigMap, r.Scheme)
+	})
+	if err != nil {
+		r.Log.Error(err, "Failed to write ocs-operator-config slot", "ConfigMap", klog.KObj(slotConfigMap))
+	}
+	return err
+}
+
+// rollbackBlueGreenConfig swaps ocs-operator-config back to the standby slot, which holds the previous config,
+// if a rollback was requested on the OCSInitialization. The request is removed once it has been handled.
+func (r *OCSInitializationReconciler) rollbackBlueGreenConfig(initialData *ocsv1.OCSInitialization, inputsHash string) error {
+	if initialData.GetAnnotations()[RollbackConfigAnnotation] != "true" {
+		return nil
+	}
+
+	configName := r.getOcsOperatorConfigName()
+	current := &corev1.ConfigMap{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: configName, Namespace: initialData.Namespace}, current)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	activeSlot := current.GetAnnotations()[ActiveConfigAnnotation]
+	previous := &corev1.ConfigMap{}
+	err = r.Client.Get(r.ctx, types.NamespacedName{Name: getStandbyConfigSlot(configName, activeSlot), Namespace: initialData.Namespace}, previous)
+	if errors.IsNotFound(err) || activeSlot == "" {
+		r.Log.Info("There is no previous ocs-operator-config to roll back to")
+	} else if err != nil {
+		return err
+	} else {
+		r.Log.Info("Rolling back ocs-operator-config", "ActiveConfig", activeSlot, "PreviousConfig", previous.Name)
+		markOcsOperatorConfigRestartPending(current, current.Data, previous.Data)
+		current.Data = previous.Data
+		setConfigDataChecksum(current)
+		util.AddAnnotation(current, ActiveConfigAnnotation, previous.Name)
+		util.AddAnnotation(current, rolledBackInputsHashAnnotation, inputsHash)
+		// the data and the active reference are swapped in a single update
+		if err := r.Client.Update(r.ctx, current); err != nil {
+			r.Log.Error(err, "Failed to roll back ocs-operator-config")
+			return err
+		}
+	}
+
+	updated := initialData.DeepCopy()
+	delete(updated.Annotations, RollbackConfigAnnotation)
+	if err := r.Client.Update(r.ctx, updated); err != nil {
+		return err
+	}
+	// only the metadata changed, the status that is being reconciled is kept
+	initialData.ObjectMeta = updated.ObjectMeta
+	return nil
+}
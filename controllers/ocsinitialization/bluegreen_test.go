@@ -0,0 +1,82 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func getTestConfigMap(t *testing.T, reconciler OCSInitializationReconciler, name string) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: name, Namespace: testOperatorNamespace}, cm))
+	return cm
+}
+
+func TestBlueGreenConfigSwapAndRollback(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+	reconciler.BlueGreenConfig = true
+	resolver := &fakeTopologyResolver{}
+	reconciler.TopologyResolver = resolver
+	slots := getOcsOperatorConfigSlots(util.OcsOperatorConfigName)
+	blue, green := slots[0], slots[1]
+
+	// the initial config is written to the first slot
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	ocsOperatorConfig := getTestConfigMap(t, reconciler, util.OcsOperatorConfigName)
+	assert.Equal(t, blue, ocsOperatorConfig.Annotations[ActiveConfigAnnotation])
+	assert.Equal(t, getTestConfigMap(t, reconciler, blue).Data, ocsOperatorConfig.Data)
+	assert.Equal(t, "false", ocsOperatorConfig.Data[util.EnableTopologyKey])
+
+	// a config change is written to the standby slot, which is swapped in
+	resolver.topology = TopologyConfig{Enabled: true, DomainLabels: "topology.kubernetes.io/zone"}
+	reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	ocsOperatorConfig = getTestConfigMap(t, reconciler, util.OcsOperatorConfigName)
+	assert.Equal(t, green, ocsOperatorConfig.Annotations[ActiveConfigAnnotation])
+	assert.Equal(t, "true", ocsOperatorConfig.Data[util.EnableTopologyKey])
+	assert.Equal(t, getTestConfigMap(t, reconciler, green).Data, ocsOperatorConfig.Data)
+	assert.Equal(t, "false", getTestConfigMap(t, reconciler, blue).Data[util.EnableTopologyKey])
+
+	// the rollback swaps the previous config back in, and is kept while the inputs are unchanged
+	ocsInit.Annotations = map[string]string{RollbackConfigAnnotation: "true"}
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, ocsInit))
+	for range 2 {
+		assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+		ocsOperatorConfig = getTestConfigMap(t, reconciler, util.OcsOperatorConfigName)
+		assert.Equal(t, blue, ocsOperatorConfig.Annotations[ActiveConfigAnnotation])
+		assert.Equal(t, "false", ocsOperatorConfig.Data[util.EnableTopologyKey])
+		assert.Contains(t, getRookCephOperatorRestartPendingKeys(t, reconciler, util.OcsOperatorConfigName), util.EnableTopologyKey)
+		reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+	}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(ocsInit), ocsInit))
+	assert.NotContains(t, ocsInit.Annotations, RollbackConfigAnnotation)
+
+	// the next change of the inputs rolls forward to the standby slot
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(sc), sc))
+	sc.Spec.ManagedResources.CephFilesystems.SubvolumeGroupPinning = "distributed"
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, sc))
+	var err error
+	reconciler.clusters, err = util.GetClusters(reconciler.ctx, reconciler.Client)
+	assert.NoError(t, err)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	ocsOperatorConfig = getTestConfigMap(t, reconciler, util.OcsOperatorConfigName)
+	assert.Equal(t, green, ocsOperatorConfig.Annotations[ActiveConfigAnnotation])
+	assert.NotContains(t, ocsOperatorConfig.Annotations, rolledBackInputsHashAnnotation)
+	assert.Equal(t, "true", ocsOperatorConfig.Data[util.EnableTopologyKey])
+	assert.Equal(t, "distributed", ocsOperatorConfig.Data[util.CephFSSubvolumeGroupPinningKey])
+}
+
+func TestBlueGreenConfigDisabled(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.NotContains(t, getTestConfigMap(t, reconciler, util.OcsOperatorConfigName).Annotations, ActiveConfigAnnotation)
+	for _, slot := range getOcsOperatorConfigSlots(util.OcsOperatorConfigName) {
+		err := reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: slot, Namespace: testOperatorNamespace}, &corev1.ConfigMap{})
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,118 @@
+package ocsinitialization
+
+import (
+	"encoding/json"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// cephFilesystemConfig is the CSI config of a single CephFilesystem
+type cephFilesystemConfig struct {
+	// KernelMountOptions are the kernel mount options for the volumes of the filesystem
+	KernelMountOptions string `json:"kernelMountOptions,omitempty"`
+	// CompressionMode is the compression mode of the default data pool of the filesystem
+	CompressionMode string `json:"compressionMode,omitempty"`
+}
+
+// listCephFilesystems returns the CephFilesystems of the internal storageclusters, along with the storagecluster
+// each of them belongs to
+func (r *OCSInitializationReconciler) listCephFilesystems() ([]rookCephv1.CephFilesystem, []*ocsv1.StorageCluster, error) {
+	cephFilesystems := []rookCephv1.CephFilesystem{}
+	storageClusters := []*ocsv1.StorageCluster{}
+	for i := range r.clusters.GetInternalStorageClusters() {
+		sc := &r.clusters.GetInternalStorageClusters()[i]
+		list := &rookCephv1.CephFilesystemList{}
+		if err := r.Client.List(r.ctx, list, client.InNamespace(sc.Namespace)); err != nil {
+			return nil, nil, err
+		}
+		for _, cephFilesystem := range list.Items {
+			cephFilesystems = append(cephFilesystems, cephFilesystem)
+			storageClusters = append(storageClusters, sc)
+		}
+	}
+	return cephFilesystems, storageClusters, nil
+}
+
+// getCephFSFilesystemsConfigKeyValue returns the CSI config of each CephFilesystem as a JSON object keyed by the
+// filesystem name. A single set of CephFS keys is sufficient for a single filesystem, so an empty string is
+// returned unless there are multiple CephFilesystems.
+func (r *OCSInitializationReconciler) getCephFSFilesystemsConfigKeyValue() (string, error) {
+	cephFilesystems, storageClusters, err := r.listCephFilesystems()
+	if err != nil {
+		return "", err
+	}
+	if len(cephFilesystems) < 2 {
+		return "", nil
+	}
+
+	config := make(map[string]cephFilesystemConfig, len(cephFilesystems))
+	for i, cephFilesystem := range cephFilesystems {
+		kernelMountOptions, _ := util.GetCephFSKernelMountOptions(storageClusters[i])
+		config[cephFilesystem.Name] = cephFilesystemConfig{
+			KernelMountOptions: kernelMountOptions,
+			CompressionMode:    getCephFilesystemCompressionMode(&cephFilesystem),
+		}
+	}
+	// the keys of the map are sorted by the encoder, so equal config is always encoded the same
+	value, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// getCephFilesystemCompressionMode returns the compression mode of the default data pool of the filesystem,
+// or an empty string if it is not set
+func getCephFilesystemCompressionMode(cephFilesystem *rookCephv1.CephFilesystem) string {
+	if len(cephFilesystem.Spec.DataPools) == 0 {
+		return ""
+	}
+	pool := cephFilesystem.Spec.DataPools[0].PoolSpec
+	if mode := pool.Parameters["compression_mode"]; mode != "" {
+		return mode
+	}
+	return pool.CompressionMode
+}
+
+// cephFilesystemMDSLayout is the active MDS layout of a multi-active CephFilesystem, which CSI uses as a hint
+// to pin the subvolumes across the MDS ranks
+type cephFilesystemMDSLayout struct {
+	// ActiveCount is the number of active MDS ranks of the filesystem
+	ActiveCount int32 `json:"activeCount"`
+	// ActiveStandby is true if each active MDS has a standby-replay daemon
+	ActiveStandby bool `json:"activeStandby,omitempty"`
+}
+
+// getCephFSMDSPinningKeyValue returns the active MDS layout of the multi-active CephFilesystems as a JSON object
+// keyed by the filesystem name. Pinning does not apply to a single active MDS, so an empty string is returned
+// unless a filesystem has multiple active MDS ranks.
+func (r *OCSInitializationReconciler) getCephFSMDSPinningKeyValue() (string, error) {
+	cephFilesystems, _, err := r.listCephFilesystems()
+	if err != nil {
+		return "", err
+	}
+
+	layouts := map[string]cephFilesystemMDSLayout{}
+	for _, cephFilesystem := range cephFilesystems {
+		metadataServer := cephFilesystem.Spec.MetadataServer
+		if metadataServer.ActiveCount < 2 {
+			continue
+		}
+		layouts[cephFilesystem.Name] = cephFilesystemMDSLayout{
+			ActiveCount:   metadataServer.ActiveCount,
+			ActiveStandby: metadataServer.ActiveStandby,
+		}
+	}
+	if len(layouts) == 0 {
+		return "", nil
+	}
+	value, err := json.Marshal(layouts)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
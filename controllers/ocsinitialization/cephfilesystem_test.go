@@ -0,0 +1,66 @@
+package ocsinitialization
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOcsOperatorConfigPerCephFilesystem(t *testing.T) {
+	encryptedSC := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	setTestNetworkEncryption(encryptedSC, true)
+	otherSC := newTestStorageCluster("tenant-storagecluster", "tenant-ns")
+
+	encryptedFS := newTestCephFilesystem("ocs-storagecluster-cephfilesystem", nil)
+	encryptedFS.Spec.DataPools = []rookCephv1.NamedPoolSpec{{
+		PoolSpec: rookCephv1.PoolSpec{Parameters: map[string]string{"compression_mode": "aggressive"}},
+	}}
+	compressedFS := newTestCephFilesystem("tenant-cephfilesystem", nil)
+	compressedFS.Namespace = otherSC.Namespace
+	compressedFS.Spec.DataPools = []rookCephv1.NamedPoolSpec{{
+		PoolSpec: rookCephv1.PoolSpec{CompressionMode: "passive"},
+	}}
+
+	// a single filesystem is covered by the CephFS keys
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, encryptedSC, otherSC, encryptedFS)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.NotContains(t, getOcsOperatorConfigData(t, reconciler), util.CephFSFilesystemsConfigKey)
+
+	ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t, encryptedSC, otherSC, encryptedFS, compressedFS)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.Contains(t, data, util.CephFSFilesystemsConfigKey)
+
+	config := map[string]cephFilesystemConfig{}
+	assert.NoError(t, json.Unmarshal([]byte(data[util.CephFSFilesystemsConfigKey]), &config))
+	assert.Equal(t, map[string]cephFilesystemConfig{
+		"ocs-storagecluster-cephfilesystem": {KernelMountOptions: "ms_mode=secure", CompressionMode: "aggressive"},
+		"tenant-cephfilesystem":             {KernelMountOptions: "ms_mode=prefer-crc", CompressionMode: "passive"},
+	}, config)
+}
+
+func TestOcsOperatorConfigCephFSMDSPinning(t *testing.T) {
+	singleMDS := newTestCephFilesystem("ocs-storagecluster-cephfilesystem", nil)
+	singleMDS.Spec.MetadataServer = rookCephv1.MetadataServerSpec{ActiveCount: 1, ActiveStandby: true}
+	multiActiveMDS := newTestCephFilesystem("tenant-cephfilesystem", nil)
+	multiActiveMDS.Spec.MetadataServer = rookCephv1.MetadataServerSpec{ActiveCount: 3, ActiveStandby: true}
+
+	// pinning does not apply to a single active MDS
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace), singleMDS)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.NotContains(t, getOcsOperatorConfigData(t, reconciler), util.CephFSMDSPinningKey)
+
+	ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace), singleMDS, multiActiveMDS)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.Contains(t, data, util.CephFSMDSPinningKey)
+
+	layouts := map[string]cephFilesystemMDSLayout{}
+	assert.NoError(t, json.Unmarshal([]byte(data[util.CephFSMDSPinningKey]), &layouts))
+	assert.Equal(t, map[string]cephFilesystemMDSLayout{
+		"tenant-cephfilesystem": {ActiveCount: 3, ActiveStandby: true},
+	}, layouts)
+}
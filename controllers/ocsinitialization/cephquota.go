@@ -0,0 +1,105 @@
+package ocsinitialization
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// cephPoolQuota is the quota of a Ceph pool, as informational CSI config
+type cephPoolQuota struct {
+	// MaxSize is the maximum size of the pool, in bytes or with a unit suffix
+	MaxSize string `json:"maxSize,omitempty"`
+	// MaxObjects is the maximum number of objects in the pool
+	MaxObjects uint64 `json:"maxObjects,omitempty"`
+}
+
+// getCephPoolQuota returns the quota of the pool, or nil if no quota is set
+func getCephPoolQuota(pool *rookCephv1.PoolSpec) *cephPoolQuota {
+	quota := &cephPoolQuota{}
+	if pool.Quotas.MaxSize != nil {
+		quota.MaxSize = *pool.Quotas.MaxSize
+	} else if pool.Quotas.MaxBytes != nil {
+		// MaxBytes is deprecated in favor of MaxSize, which takes precedence when both are set
+		quota.MaxSize = strconv.FormatUint(*pool.Quotas.MaxBytes, 10)
+	}
+	if pool.Quotas.MaxObjects != nil {
+		quota.MaxObjects = *pool.Quotas.MaxObjects
+	}
+	if *quota == (cephPoolQuota{}) {
+		return nil
+	}
+	return quota
+}
+
+// listCephBlockPools returns the default CephBlockPools of the internal storageclusters that exist
+func (r *OCSInitializationReconciler) listCephBlockPools() ([]rookCephv1.CephBlockPool, error) {
+	cephBlockPools := []rookCephv1.CephBlockPool{}
+	for i := range r.clusters.GetInternalStorageClusters() {
+		sc := &r.clusters.GetInternalStorageClusters()[i]
+		cephBlockPool := &rookCephv1.CephBlockPool{}
+		key := types.NamespacedName{Name: util.GenerateNameForCephBlockPool(sc.Name), Namespace: sc.Namespace}
+		if err := r.Client.Get(r.ctx, key, cephBlockPool); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		cephBlockPools = append(cephBlockPools, *cephBlockPool)
+	}
+	return cephBlockPools, nil
+}
+
+// getRbdPoolQuotaKeyValue returns the quotas of the default CephBlockPools as a JSON object keyed by the pool
+// name, or an empty string if none of them has a quota set
+func (r *OCSInitializationReconciler) getRbdPoolQuotaKeyValue() (string, error) {
+	cephBlockPools, err := r.listCephBlockPools()
+	if err != nil {
+		return "", err
+	}
+
+	quotas := map[string]*cephPoolQuota{}
+	for i := range cephBlockPools {
+		if quota := getCephPoolQuota(&cephBlockPools[i].Spec.PoolSpec); quota != nil {
+			quotas[cephBlockPools[i].Name] = quota
+		}
+	}
+	return marshalCephPoolQuotas(quotas)
+}
+
+// getCephFSQuotaKeyValue returns the quotas of the default data pools of the CephFilesystems as a JSON object
+// keyed by the filesystem name, or an empty string if none of them has a quota set
+func (r *OCSInitializationReconciler) getCephFSQuotaKeyValue() (string, error) {
+	cephFilesystems, _, err := r.listCephFilesystems()
+	if err != nil {
+		return "", err
+	}
+
+	quotas := map[string]*cephPoolQuota{}
+	for _, cephFilesystem := range cephFilesystems {
+		if len(cephFilesystem.Spec.DataPools) == 0 {
+			continue
+		}
+		if quota := getCephPoolQuota(&cephFilesystem.Spec.DataPools[0].PoolSpec); quota != nil {
+			quotas[cephFilesystem.Name] = quota
+		}
+	}
+	return marshalCephPoolQuotas(quotas)
+}
+
+func marshalCephPoolQuotas(quotas map[string]*cephPoolQuota) (string, error) {
+	if len(quotas) == 0 {
+		return "", nil
+	}
+	// the keys of the map are sorted by the encoder, so equal quotas are always encoded the same
+	value, err := json.Marshal(quotas)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
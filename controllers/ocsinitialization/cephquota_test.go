@@ -0,0 +1,87 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestCephBlockPool(name string, quotas rookCephv1.QuotaSpec) *rookCephv1.CephBlockPool {
+	return &rookCephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testOperatorNamespace,
+		},
+		Spec: rookCephv1.NamedBlockPoolSpec{
+			PoolSpec: rookCephv1.PoolSpec{Quotas: quotas},
+		},
+	}
+}
+
+func TestOcsOperatorConfigCephQuotas(t *testing.T) {
+	quotaFS := newTestCephFilesystem("ocs-storagecluster-cephfilesystem", nil)
+	quotaFS.Spec.DataPools = []rookCephv1.NamedPoolSpec{{
+		PoolSpec: rookCephv1.PoolSpec{Quotas: rookCephv1.QuotaSpec{MaxSize: ptr.To("100Gi")}},
+	}}
+
+	testcases := []struct {
+		label             string
+		objs              []client.Object
+		expectRbdQuota    string
+		expectCephFSQuota string
+	}{
+		{
+			label: "no quotas set",
+			objs: []client.Object{
+				newTestCephBlockPool("ocs-storagecluster-cephblockpool", rookCephv1.QuotaSpec{}),
+				newTestCephFilesystem("ocs-storagecluster-cephfilesystem", nil),
+			},
+		},
+		{
+			label: "quota set on the cephblockpool",
+			objs: []client.Object{
+				newTestCephBlockPool("ocs-storagecluster-cephblockpool",
+					rookCephv1.QuotaSpec{MaxSize: ptr.To("10Gi"), MaxObjects: ptr.To(uint64(1000))}),
+			},
+			expectRbdQuota: `{"ocs-storagecluster-cephblockpool":{"maxSize":"10Gi","maxObjects":1000}}`,
+		},
+		{
+			label: "deprecated max bytes quota set on the cephblockpool",
+			objs: []client.Object{
+				newTestCephBlockPool("ocs-storagecluster-cephblockpool", rookCephv1.QuotaSpec{MaxBytes: ptr.To(uint64(1073741824))}),
+			},
+			expectRbdQuota: `{"ocs-storagecluster-cephblockpool":{"maxSize":"1073741824"}}`,
+		},
+		{
+			label: "quota set on a cephblockpool other than the default",
+			objs: []client.Object{
+				newTestCephBlockPool("other-cephblockpool", rookCephv1.QuotaSpec{MaxSize: ptr.To("10Gi")}),
+			},
+		},
+		{
+			label:             "quota set on the cephfilesystem data pool",
+			objs:              []client.Object{quotaFS},
+			expectCephFSQuota: `{"ocs-storagecluster-cephfilesystem":{"maxSize":"100Gi"}}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := append([]client.Object{newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)}, tc.objs...)
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		for key, expected := range map[string]string{util.RbdPoolQuotaKey: tc.expectRbdQuota, util.CephFSQuotaKey: tc.expectCephFSQuota} {
+			if expected == "" {
+				assert.NotContainsf(t, data, key, "[%s]: unexpected quota", tc.label)
+				continue
+			}
+			assert.Equalf(t, expected, data[key], "[%s]: unexpected quota", tc.label)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// managedServiceLabel is set to "true" on the operator namespace of managed service deployments
+	managedServiceLabel = "api.openshift.com/managed"
+	// managedServiceClusterIDLabel holds the cluster identity provided by the managed service
+	managedServiceClusterIDLabel = "api.openshift.com/id"
+
+	// clusterVersionFailureThreshold is the number of consecutive failed ClusterVersion reads that open the circuit
+	clusterVersionFailureThreshold = 3
+	// clusterVersionCooldown is how long the ClusterVersion is not read once the circuit is open
+	clusterVersionCooldown = 5 * time.Minute
+)
+
+// clusterVersionBreaker caches what is used of the ClusterVersion, and is a circuit breaker around its reads.
+// After clusterVersionFailureThreshold consecutive failures the circuit opens, and the ClusterVersion is not read
+// again until the cooldown has passed. Meanwhile the values of the last successful read are used.
+type clusterVersionBreaker struct {
+	// mutex serializes the ClusterVersion reads across concurrent reconciles
+	mutex sync.Mutex
+	// consecutiveFailures is the number of ClusterVersion reads that failed since the last successful one
+	consecutiveFailures int
+	// openUntil is when the cooldown of the open circuit ends, zero while the circuit is closed
+	openUntil time.Time
+	// last holds the values of the last successful ClusterVersion read
+	last clusterVersionInfo
+}
+
+// clusterVersionInfo is what the ocs-operator-config is derived from of the ClusterVersion
+type clusterVersionInfo struct {
+	// clusterID is the cluster identity used for CSI_CLUSTER_NAME
+	clusterID string
+	// ocpVersion is the version of the running OCP cluster
+	ocpVersion string
+}
+
+// getClusterVersionInfo returns the cluster identity used for CSI_CLUSTER_NAME and the OCP version, the
+// ClusterVersion is read once for both. In managed service deployments the identity is provided by the
+// service via the labels of the operator namespace, otherwise it is the cluster ID of the ClusterVersion.
+func (r *OCSInitializationReconciler) getClusterVersionInfo() clusterVersionInfo {
+	info := r.readClusterVersion()
+	if clusterID, isManagedService := r.getManagedServiceClusterID(); isManagedService {
+		info.clusterID = clusterID
+	}
+	return info
+}
+
+// keepExistingClusterID keeps the CSI_CLUSTER_NAME of ocs-operator-config if the cluster ID could not be
+// determined, e.g. while the ClusterVersion reads fail, rather than overwriting it with an empty value
+func (r *OCSInitializationReconciler) keepExistingClusterID(namespace string, ocsOperatorConfigData map[string]string) error {
+	if ocsOperatorConfigData[util.ClusterNameKey] != "" {
+		return nil
+	}
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: r.getOcsOperatorConfigName(), Namespace: namespace}, ocsOperatorConfig)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if existing := ocsOperatorConfig.Data[util.ClusterNameKey]; existing != "" {
+		r.Log.Info("Cluster ID is unknown, keeping the existing one", util.ClusterNameKey, existing)
+		ocsOperatorConfigData[util.ClusterNameKey] = existing
+	}
+	return nil
+}
+
+// readClusterVersion reads the ClusterVersion through the circuit breaker. If the read fails or the circuit is
+// open, the values of the last successful read are returned, which are empty if there has been none.
+func (r *OCSInitializationReconciler) readClusterVersion() clusterVersionInfo {
+	breaker := r.clusterVersionBreaker
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	now := time.Now()
+	if !breaker.openUntil.IsZero() {
+		if now.Before(breaker.openUntil) {
+			r.Log.V(1).Info("Circuit of the ClusterVersion reads is open, using the last known values", "OpenUntil", breaker.openUntil)
+			return breaker.last
+		}
+		r.Log.Info("Cooldown of the ClusterVersion reads has passed, retrying the read")
+	}
+
+	clusterVersion := &configv1.ClusterVersion{}
+	if err := r.Client.Get(r.ctx, types.NamespacedName{Name: "version"}, clusterVersion); err != nil {
+		breaker.consecutiveFailures++
+		if breaker.consecutiveFailures >= clusterVersionFailureThreshold {
+			breaker.openUntil = now.Add(clusterVersionCooldown)
+			r.Log.Error(err, "Failed to get the clusterVersion version of the OCP cluster repeatedly, opening the circuit",
+				"ConsecutiveFailures", breaker.consecutiveFailures, "OpenUntil", breaker.openUntil)
+		} else {
+			r.Log.Error(err, "Failed to get the clusterVersion version of the OCP cluster", "ConsecutiveFailures", breaker.consecutiveFailures)
+		}
+		return breaker.last
+	}
+
+	if !breaker.openUntil.IsZero() {
+		r.Log.Info("Read the ClusterVersion again, closing the circuit", "ConsecutiveFailures", breaker.consecutiveFailures)
+	}
+	breaker.consecutiveFailures = 0
+	breaker.openUntil = time.Time{}
+	breaker.last = clusterVersionInfo{
+		clusterID:  fmt.Sprint(clusterVersion.Spec.ClusterID),
+		ocpVersion: getOCPVersion(clusterVersion),
+	}
+	return breaker.last
+}
+
+// getClusterVersionRequeueDelay returns the time left until the cooldown of the open circuit of the ClusterVersion
+// reads has passed, after which the ClusterVersion is read again. It is zero while the circuit is closed.
+func (r *OCSInitializationReconciler) getClusterVersionRequeueDelay(now time.Time) time.Duration {
+	breaker := r.clusterVersionBreaker
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	if breaker.openUntil.IsZero() {
+		return 0
+	}
+	return max(breaker.openUntil.Sub(now), time.Second)
+}
+
+// getManagedServiceClusterID returns the cluster identity provided by the managed service, and whether
+// the operator is running in managed service mode at all.
+func (r *OCSInitializationReconciler) getManagedServiceClusterID() (string, bool) {
+	namespace := &corev1.Namespace{}
+	if err := r.Client.Get(r.ctx, types.NamespacedName{Name: r.OperatorNamespace}, namespace); err != nil {
+		return "", false
+	}
+	if namespace.GetLabels()[managedServiceLabel] != "true" {
+		return "", false
+	}
+	clusterID := namespace.GetLabels()[managedServiceClusterIDLabel]
+	if clusterID == "" {
+		r.Log.Info("Running in managed service mode, but the cluster identity label is missing",
+			"Namespace", r.OperatorNamespace, "Label", managedServiceClusterIDLabel)
+	}
+	return clusterID, true
+}
@@ -0,0 +1,209 @@
+package ocsinitialization
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestClusterIDInManagedServiceMode(t *testing.T) {
+	testcases := []struct {
+		label             string
+		namespaceLabels   map[string]string
+		expectedClusterID string
+	}{
+		{
+			label:             "not a managed service",
+			namespaceLabels:   map[string]string{managedServiceClusterIDLabel: "ignored"},
+			expectedClusterID: "",
+		},
+		{
+			label: "managed service provides the identity",
+			namespaceLabels: map[string]string{
+				managedServiceLabel:          "true",
+				managedServiceClusterIDLabel: "managed-cluster-id",
+			},
+			expectedClusterID: "managed-cluster-id",
+		},
+	}
+
+	for _, tc := range testcases {
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   testOperatorNamespace,
+				Labels: tc.namespaceLabels,
+			},
+		}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, namespace, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+		err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		// the fake client has no ClusterVersion, so outside managed service mode the cluster ID is empty
+		assert.Equalf(t, tc.expectedClusterID, getOcsOperatorConfigData(t, reconciler)[util.ClusterNameKey], "[%s]: unexpected cluster ID", tc.label)
+	}
+}
+
+func TestClusterVersionCircuitBreaker(t *testing.T) {
+	clusterVersion := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Spec:       configv1.ClusterVersionSpec{ClusterID: "cluster-id"},
+	}
+	_, reconciler := getOcsOperatorConfigTestReconciler(t, clusterVersion)
+
+	// ClusterVersion reads fail while failing is set, and are counted
+	failing := true
+	reads := 0
+	reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*configv1.ClusterVersion); ok {
+				reads++
+				if failing {
+					return fmt.Errorf("injected ClusterVersion read failure")
+				}
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+
+	// the failed reads leave the cluster ID unknown until the threshold opens the circuit
+	for i := 1; i <= clusterVersionFailureThreshold; i++ {
+		assert.Emptyf(t, reconciler.getClusterVersionInfo().clusterID, "failed read %d must not return a cluster ID", i)
+		assert.Equalf(t, i < clusterVersionFailureThreshold, reconciler.clusterVersionBreaker.openUntil.IsZero(),
+			"unexpected state of the circuit after failed read %d", i)
+	}
+	assert.Equal(t, clusterVersionFailureThreshold, reads)
+
+	// while the circuit is open the ClusterVersion is not read
+	assert.Empty(t, reconciler.getClusterVersionInfo().clusterID)
+	assert.Equal(t, clusterVersionFailureThreshold, reads, "the ClusterVersion must not be read while the circuit is open")
+
+	// a failed read after the cooldown opens the circuit again
+	reconciler.clusterVersionBreaker.openUntil = time.Now().Add(-time.Second)
+	assert.Empty(t, reconciler.getClusterVersionInfo().clusterID)
+	assert.Equal(t, 1+clusterVersionFailureThreshold, reads)
+	assert.True(t, reconciler.clusterVersionBreaker.openUntil.After(time.Now()), "the circuit must be open again")
+
+	// a successful read after the cooldown closes the circuit
+	failing = false
+	reconciler.clusterVersionBreaker.openUntil = time.Now().Add(-time.Second)
+	assert.Equal(t, "cluster-id", reconciler.getClusterVersionInfo().clusterID)
+	assert.Equal(t, 2+clusterVersionFailureThreshold, reads)
+	assert.True(t, reconciler.clusterVersionBreaker.openUntil.IsZero(), "the circuit must be closed")
+	assert.Zero(t, reconciler.clusterVersionBreaker.consecutiveFailures)
+}
+
+func TestClusterVersionReadOnce(t *testing.T) {
+	clusterVersion := newTestClusterVersion("4.16.3")
+	clusterVersion.Spec.ClusterID = "cluster-id"
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, clusterVersion, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+	failing := false
+	reads := 0
+	reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*configv1.ClusterVersion); ok {
+				reads++
+				if failing {
+					return fmt.Errorf("injected ClusterVersion read failure")
+				}
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+
+	// the cluster ID and the OCP version are resolved from a single read
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, 1, reads)
+	assert.Equal(t, "cluster-id", getOcsOperatorConfigData(t, reconciler)[util.ClusterNameKey])
+
+	// concurrent reconciles read the ClusterVersion one at a time
+	var wg sync.WaitGroup
+	infos := make([]clusterVersionInfo, 10)
+	for i := range infos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			infos[i] = reconciler.getClusterVersionInfo()
+		}()
+	}
+	wg.Wait()
+	for i := range infos {
+		assert.Equalf(t, clusterVersionInfo{clusterID: "cluster-id", ocpVersion: "4.16.3"}, infos[i], "reconcile %d got unexpected values", i)
+	}
+
+	// failing reads do not clear the last known values
+	failing = true
+	assert.Equal(t, clusterVersionInfo{clusterID: "cluster-id", ocpVersion: "4.16.3"}, reconciler.getClusterVersionInfo())
+}
+
+func TestClusterIDKeptWhenUnknown(t *testing.T) {
+	clusterVersion := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Spec:       configv1.ClusterVersionSpec{ClusterID: "cluster-id"},
+	}
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, clusterVersion, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+		newTestRookCephOperatorPod())
+	failing := false
+	reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*configv1.ClusterVersion); ok && failing {
+				return fmt.Errorf("injected ClusterVersion read failure")
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "cluster-id", getOcsOperatorConfigData(t, reconciler)[util.ClusterNameKey])
+	_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+
+	// after an operator restart there is no last-known cluster ID while the ClusterVersion reads fail
+	failing = true
+	reconciler.clusterVersionBreaker = &clusterVersionBreaker{}
+	reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+	assert.Empty(t, reconciler.getClusterVersionInfo().clusterID)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "cluster-id", getOcsOperatorConfigData(t, reconciler)[util.ClusterNameKey])
+	assert.False(t, isRookCephOperatorRestartPending(t, reconciler))
+}
+
+func TestClusterIDResolvedOnceKnown(t *testing.T) {
+	clusterVersion := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Spec:       configv1.ClusterVersionSpec{ClusterID: "cluster-id"},
+	}
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, clusterVersion, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+	failing := true
+	reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*configv1.ClusterVersion); ok && failing {
+				return fmt.Errorf("injected ClusterVersion read failure")
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+
+	// the reads fail until the circuit opens, the config is written without a cluster ID
+	for range clusterVersionFailureThreshold {
+		assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	}
+	assert.Empty(t, getOcsOperatorConfigData(t, reconciler)[util.ClusterNameKey])
+	delay := reconciler.getClusterVersionRequeueDelay(time.Now())
+	assert.True(t, delay > 0 && delay <= clusterVersionCooldown, "unexpected requeue delay %v while the circuit is open", delay)
+
+	// the unchanged inputs do not skip resolving the config once the cluster ID is known
+	failing = false
+	reconciler.clusterVersionBreaker.openUntil = time.Now().Add(-time.Second)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "cluster-id", getOcsOperatorConfigData(t, reconciler)[util.ClusterNameKey])
+	assert.Zero(t, reconciler.getClusterVersionRequeueDelay(time.Now()))
+}
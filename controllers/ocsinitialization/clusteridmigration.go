@@ -0,0 +1,122 @@
+package ocsinitialization
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ClusterIDMigrationAnnotation can be set on a StorageCluster to migrate CSI_CLUSTER_NAME to a new cluster ID,
+	// e.g. after importing the cluster for DR, while the existing volumes still reference the legacy one. The value
+	// is a JSON object like {"legacyClusterID":"<old>","clusterID":"<new>"}. The new cluster ID is used as long as
+	// the annotation is set, and the legacy one is passed on in CSI_CLUSTER_NAME_LEGACY for the migration period.
+	ClusterIDMigrationAnnotation = "ocs.openshift.io/cluster-id-migration"
+
+	// DefaultClusterIDMigrationPeriod is how long the legacy cluster ID is passed on to CSI during a migration
+	DefaultClusterIDMigrationPeriod = 7 * 24 * time.Hour
+)
+
+// clusterIDMigration is the value of the ClusterIDMigrationAnnotation
+type clusterIDMigration struct {
+	LegacyClusterID string `json:"legacyClusterID"`
+	ClusterID       string `json:"clusterID"`
+}
+
+// getClusterIDMigration returns the cluster ID migration requested on the first storagecluster that sets a valid
+// one, or nil if none does
+func (r *OCSInitializationReconciler) getClusterIDMigration() *clusterIDMigration {
+	for _, sc := range r.clusters.GetStorageClusters() {
+		value, ok := sc.GetAnnotations()[ClusterIDMigrationAnnotation]
+		if !ok {
+			continue
+		}
+		migration := &clusterIDMigration{}
+		err := json.Unmarshal([]byte(value), migration)
+		if err == nil && (migration.LegacyClusterID == "" || migration.ClusterID == "") {
+			err = fmt.Errorf("both legacyClusterID and clusterID are required")
+		}
+		if err != nil {
+			r.Log.Error(err, "Ignoring invalid cluster ID migration", "StorageCluster", klog.KObj(&sc), "Annotation", ClusterIDMigrationAnnotation)
+			continue
+		}
+		return migration
+	}
+	return nil
+}
+
+func (r *OCSInitializationReconciler) getClusterIDMigrationPeriod() time.Duration {
+	if r.ClusterIDMigrationPeriod > 0 {
+		return r.ClusterIDMigrationPeriod
+	}
+	return DefaultClusterIDMigrationPeriod
+}
+
+// reconcileClusterIDMigrationStatus records the requested cluster ID migration in the status. The migration window
+// starts when a migration between a pair of cluster IDs is first observed, and is completed once the migration
+// period has passed. Removing the annotation ends the migration.
+func (r *OCSInitializationReconciler) reconcileClusterIDMigrationStatus(initialData *ocsv1.OCSInitialization, now time.Time) {
+	migration := r.getClusterIDMigration()
+	status := initialData.Status.OcsOperatorConfig.ClusterIDMigration
+	if migration == nil {
+		if status != nil {
+			r.Log.Info("Cluster ID migration ended", "LegacyClusterID", status.LegacyClusterID, "ClusterID", status.ClusterID)
+		}
+		initialData.Status.OcsOperatorConfig.ClusterIDMigration = nil
+		return
+	}
+
+	if status == nil || status.LegacyClusterID != migration.LegacyClusterID || status.ClusterID != migration.ClusterID {
+		r.Log.Info("Starting cluster ID migration", "LegacyClusterID", migration.LegacyClusterID, "ClusterID", migration.ClusterID,
+			"Period", r.getClusterIDMigrationPeriod())
+		status = &ocsv1.ClusterIDMigrationStatus{
+			LegacyClusterID: migration.LegacyClusterID,
+			ClusterID:       migration.ClusterID,
+			StartTime:       metav1.NewTime(now),
+		}
+		initialData.Status.OcsOperatorConfig.ClusterIDMigration = status
+	}
+	if !status.Completed && !now.Before(status.StartTime.Add(r.getClusterIDMigrationPeriod())) {
+		r.Log.Info("Cluster ID migration window has passed, no longer passing on the legacy cluster ID",
+			"LegacyClusterID", status.LegacyClusterID, "ClusterID", status.ClusterID)
+		status.Completed = true
+	}
+}
+
+// getClusterIDMigrationInput returns the state of the cluster ID migration as an input of ocs-operator-config
+func getClusterIDMigrationInput(initialData *ocsv1.OCSInitialization) string {
+	status := initialData.Status.OcsOperatorConfig.ClusterIDMigration
+	if status == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s->%s,completed=%t", status.LegacyClusterID, status.ClusterID, status.Completed)
+}
+
+// applyClusterIDMigration sets CSI_CLUSTER_NAME to the cluster ID of the migration, and CSI_CLUSTER_NAME_LEGACY to
+// the legacy cluster ID until the migration window has passed, so that CSI can resolve the existing volumes
+func applyClusterIDMigration(initialData *ocsv1.OCSInitialization, ocsOperatorConfigData map[string]string) {
+	status := initialData.Status.OcsOperatorConfig.ClusterIDMigration
+	if status == nil {
+		return
+	}
+	ocsOperatorConfigData[util.ClusterNameKey] = status.ClusterID
+	if !status.Completed {
+		ocsOperatorConfigData[util.ClusterNameLegacyKey] = status.LegacyClusterID
+	}
+}
+
+// getClusterIDMigrationRequeueDelay returns the time left in the migration window, after which the legacy cluster
+// ID has to be removed, or zero if there is no migration in progress
+func (r *OCSInitializationReconciler) getClusterIDMigrationRequeueDelay(initialData *ocsv1.OCSInitialization, now time.Time) time.Duration {
+	status := initialData.Status.OcsOperatorConfig.ClusterIDMigration
+	if status == nil || status.Completed {
+		return 0
+	}
+	return max(status.StartTime.Add(r.getClusterIDMigrationPeriod()).Sub(now), time.Second)
+}
@@ -0,0 +1,103 @@
+package ocsinitialization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOcsOperatorConfigClusterIDMigration(t *testing.T) {
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testOperatorNamespace,
+			Labels: map[string]string{
+				managedServiceLabel:          "true",
+				managedServiceClusterIDLabel: "imported-cluster-id",
+			},
+		},
+	}
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Annotations = map[string]string{ClusterIDMigrationAnnotation: `{"legacyClusterID":"primary-cluster-id","clusterID":"imported-cluster-id"}`}
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, namespace, sc)
+	reconciler.ClusterIDMigrationPeriod = time.Hour
+
+	// both cluster IDs are passed on during the migration window
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "imported-cluster-id", data[util.ClusterNameKey])
+	assert.Equal(t, "primary-cluster-id", data[util.ClusterNameLegacyKey])
+	status := ocsInit.Status.OcsOperatorConfig.ClusterIDMigration
+	assert.NotNil(t, status)
+	assert.False(t, status.Completed)
+	startTime := status.StartTime
+
+	// the window is not restarted by later reconciles
+	delay := reconciler.getClusterIDMigrationRequeueDelay(ocsInit, startTime.Add(20*time.Minute))
+	assert.Equal(t, 40*time.Minute, delay)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, startTime, ocsInit.Status.OcsOperatorConfig.ClusterIDMigration.StartTime)
+	assert.Contains(t, getOcsOperatorConfigData(t, reconciler), util.ClusterNameLegacyKey)
+
+	// the legacy cluster ID is removed once the window has passed
+	ocsInit.Status.OcsOperatorConfig.ClusterIDMigration.StartTime = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data = getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "imported-cluster-id", data[util.ClusterNameKey])
+	assert.NotContains(t, data, util.ClusterNameLegacyKey)
+	assert.True(t, ocsInit.Status.OcsOperatorConfig.ClusterIDMigration.Completed)
+	assert.Zero(t, reconciler.getClusterIDMigrationRequeueDelay(ocsInit, time.Now()))
+
+	// a migration to another pair of cluster IDs starts a new window
+	sc.Annotations[ClusterIDMigrationAnnotation] = `{"legacyClusterID":"imported-cluster-id","clusterID":"failback-cluster-id"}`
+	ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t, namespace, sc)
+	ocsInit.Status.OcsOperatorConfig.ClusterIDMigration = status
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data = getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "failback-cluster-id", data[util.ClusterNameKey])
+	assert.Equal(t, "imported-cluster-id", data[util.ClusterNameLegacyKey])
+	assert.False(t, ocsInit.Status.OcsOperatorConfig.ClusterIDMigration.Completed)
+
+	// removing the annotation ends the migration
+	delete(sc.Annotations, ClusterIDMigrationAnnotation)
+	status = ocsInit.Status.OcsOperatorConfig.ClusterIDMigration
+	ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t, namespace, sc)
+	ocsInit.Status.OcsOperatorConfig.ClusterIDMigration = status
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data = getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "imported-cluster-id", data[util.ClusterNameKey])
+	assert.NotContains(t, data, util.ClusterNameLegacyKey)
+	assert.Nil(t, ocsInit.Status.OcsOperatorConfig.ClusterIDMigration)
+}
+
+func TestClusterIDMigrationAnnotation(t *testing.T) {
+	testcases := []struct {
+		label      string
+		annotation string
+		expected   *clusterIDMigration
+	}{
+		{
+			label:      "valid migration",
+			annotation: `{"legacyClusterID":"old","clusterID":"new"}`,
+			expected:   &clusterIDMigration{LegacyClusterID: "old", ClusterID: "new"},
+		},
+		{label: "missing legacy cluster ID", annotation: `{"clusterID":"new"}`},
+		{label: "missing cluster ID", annotation: `{"legacyClusterID":"old"}`},
+		{label: "invalid JSON", annotation: `old:new`},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Annotations = map[string]string{ClusterIDMigrationAnnotation: tc.annotation}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		assert.Equalf(t, tc.expected, reconciler.getClusterIDMigration(), "[%s]: unexpected migration", tc.label)
+		if tc.expected == nil {
+			assert.Nilf(t, ocsInit.Status.OcsOperatorConfig.ClusterIDMigration, "[%s]: unexpected migration status", tc.label)
+			assert.NotContainsf(t, getOcsOperatorConfigData(t, reconciler), util.ClusterNameLegacyKey, "[%s]: unexpected legacy cluster ID", tc.label)
+		}
+	}
+}
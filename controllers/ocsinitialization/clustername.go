@@ -0,0 +1,107 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// ConditionCSIClusterNameMismatch is set while a CSI provisioner Deployment passes a different cluster name
+	// to the CSI driver than the CSI_CLUSTER_NAME of ocs-operator-config
+	ConditionCSIClusterNameMismatch conditionsv1.ConditionType = "CSIClusterNameMismatch"
+
+	// csiClusterNameRepairedAnnotation is set on ocs-operator-config to the CSI_CLUSTER_NAME that the
+	// rook-ceph-operator was restarted for to render the CSI provisioner Deployments again, so that a
+	// mismatch that persists does not restart it over and over
+	csiClusterNameRepairedAnnotation = "ocs.openshift.io/csi-cluster-name-repaired"
+
+	csiClusterNameArg = "--clustername="
+)
+
+// csiProvisionerDeploymentNames are the names of the CSI provisioner Deployments in the operator namespace, which
+// Rook renders with the CSI_CLUSTER_NAME that the CSI drivers record in the volume metadata
+var csiProvisionerDeploymentNames = []string{"csi-rbdplugin-provisioner", "csi-cephfsplugin-provisioner"}
+
+// getDeploymentCSIClusterName returns the cluster name passed to the CSI driver container of the Deployment
+func getDeploymentCSIClusterName(deployment *appsv1.Deployment) (string, bool) {
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		for _, arg := range container.Args {
+			if clusterName, ok := strings.CutPrefix(arg, csiClusterNameArg); ok {
+				return clusterName, true
+			}
+		}
+	}
+	return "", false
+}
+
+// reconcileCSIClusterNameConsistency checks that the CSI provisioner Deployments use the CSI_CLUSTER_NAME of
+// ocs-operator-config. A mismatch is repaired by restarting the rook-ceph-operator, which renders the Deployments
+// again, once per cluster name. A condition is set while the mismatch persists.
+// The clusterID parameter of the StorageClasses is the ID of the Ceph cluster in the CSI config, which does not
+// relate to CSI_CLUSTER_NAME, so the StorageClasses are not checked.
+func (r *OCSInitializationReconciler) reconcileCSIClusterNameConsistency(initialData *ocsv1.OCSInitialization) error {
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: r.getOcsOperatorConfigName(), Namespace: initialData.Namespace}, ocsOperatorConfig)
+	if errors.IsNotFound(err) {
+		setOcsOperatorConfigCondition(initialData, ConditionCSIClusterNameMismatch, false, "", "")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	clusterName := ocsOperatorConfig.Data[util.ClusterNameKey]
+	if clusterName == "" {
+		setOcsOperatorConfigCondition(initialData, ConditionCSIClusterNameMismatch, false, "", "")
+		return nil
+	}
+
+	mismatched := []string{}
+	for _, name := range csiProvisionerDeploymentNames {
+		deployment := &appsv1.Deployment{}
+		err := r.Client.Get(r.ctx, types.NamespacedName{Name: name, Namespace: initialData.Namespace}, deployment)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			r.Log.Error(err, "Failed to get CSI provisioner Deployment", "Deployment", name)
+			return err
+		}
+		if deploymentClusterName, ok := getDeploymentCSIClusterName(deployment); ok && deploymentClusterName != clusterName {
+			mismatched = append(mismatched, fmt.Sprintf("%s (%s)", name, deploymentClusterName))
+		}
+	}
+
+	repairedClusterName, repaired := ocsOperatorConfig.Annotations[csiClusterNameRepairedAnnotation]
+	if len(mismatched) > 0 && repairedClusterName != clusterName {
+		r.Log.Info("CSI provisioner Deployments use a different cluster name, restarting rook-ceph-operator to render them again",
+			util.ClusterNameKey, clusterName, "Deployments", mismatched)
+		// there is no config change, the key is marked pending only to restart the rook-ceph-operator
+		markRookCephOperatorRestartPending(ocsOperatorConfig, nil, map[string]string{util.ClusterNameKey: clusterName})
+		util.AddAnnotation(ocsOperatorConfig, csiClusterNameRepairedAnnotation, clusterName)
+		if err := r.Client.Update(r.ctx, ocsOperatorConfig); err != nil {
+			r.Log.Error(err, "Failed to mark the rook-ceph-operator restart pending for the CSI cluster name")
+			return err
+		}
+	} else if len(mismatched) == 0 && repaired {
+		delete(ocsOperatorConfig.Annotations, csiClusterNameRepairedAnnotation)
+		if err := r.Client.Update(r.ctx, ocsOperatorConfig); err != nil {
+			r.Log.Error(err, "Failed to remove the CSI cluster name repair annotation")
+			return err
+		}
+	} else if len(mismatched) > 0 {
+		r.Log.Info("CSI provisioner Deployments still use a different cluster name after restarting rook-ceph-operator",
+			util.ClusterNameKey, clusterName, "Deployments", mismatched)
+	}
+
+	setOcsOperatorConfigCondition(initialData, ConditionCSIClusterNameMismatch, len(mismatched) > 0, "CSIProvisionerClusterNameMismatch",
+		fmt.Sprintf("the CSI provisioner Deployments %s do not use the %s %q of the %s configmap",
+			strings.Join(mismatched, ", "), util.ClusterNameKey, clusterName, ocsOperatorConfig.Name))
+	return nil
+}
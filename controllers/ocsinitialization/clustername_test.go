@@ -0,0 +1,146 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const testCSIClusterName = "managed-cluster-id"
+
+// newTestManagedServiceNamespace returns an operator namespace that provides testCSIClusterName as the cluster ID
+func newTestManagedServiceNamespace() *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testOperatorNamespace,
+			Labels: map[string]string{
+				managedServiceLabel:          "true",
+				managedServiceClusterIDLabel: testCSIClusterName,
+			},
+		},
+	}
+}
+
+func newTestCSIProvisionerDeployment(name, clusterName string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testOperatorNamespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "csi-provisioner", Args: []string{"--csi-address=$(ADDRESS)"}},
+						{Name: "csi-rbdplugin", Args: []string{"--type=rbd", csiClusterNameArg + clusterName, "--setmetadata=true"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCSIClusterNameConsistency(t *testing.T) {
+	// the clusterID of a StorageClass identifies the Ceph cluster in the CSI config and not the CSI_CLUSTER_NAME
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "ocs-storagecluster-ceph-rbd"},
+		Provisioner: util.RbdDriverName,
+		Parameters:  map[string]string{"clusterID": testOperatorNamespace},
+	}
+
+	testcases := []struct {
+		label          string
+		objs           []client.Object
+		expectMismatch bool
+	}{
+		{
+			label: "no CSI provisioner Deployments",
+		},
+		{
+			label: "CSI provisioner Deployments using the cluster name",
+			objs: []client.Object{
+				newTestCSIProvisionerDeployment("csi-rbdplugin-provisioner", testCSIClusterName),
+				newTestCSIProvisionerDeployment("csi-cephfsplugin-provisioner", testCSIClusterName),
+			},
+		},
+		{
+			label: "StorageClass clusterID differing from the cluster name",
+			objs: []client.Object{
+				newTestCSIProvisionerDeployment("csi-rbdplugin-provisioner", testCSIClusterName),
+				storageClass,
+			},
+		},
+		{
+			label: "CSI provisioner Deployment using a stale cluster name",
+			objs: []client.Object{
+				newTestCSIProvisionerDeployment("csi-rbdplugin-provisioner", "stale-cluster-id"),
+				newTestCSIProvisionerDeployment("csi-cephfsplugin-provisioner", testCSIClusterName),
+			},
+			expectMismatch: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := append([]client.Object{newTestManagedServiceNamespace(), newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)}, tc.objs...)
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		assert.Equalf(t, testCSIClusterName, getOcsOperatorConfigData(t, reconciler)[util.ClusterNameKey], "[%s]: unexpected cluster name", tc.label)
+
+		assert.NoErrorf(t, reconciler.reconcileCSIClusterNameConsistency(ocsInit), "[%s]: failed to check the cluster name", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionCSIClusterNameMismatch)
+		assert.Equalf(t, tc.expectMismatch, condition != nil, "[%s]: unexpected condition %v", tc.label, condition)
+		if tc.expectMismatch {
+			assert.Containsf(t, condition.Message, "csi-rbdplugin-provisioner (stale-cluster-id)", "[%s]: unexpected condition message", tc.label)
+			assert.NotContainsf(t, condition.Message, "csi-cephfsplugin-provisioner", "[%s]: unexpected condition message", tc.label)
+		}
+		ocsOperatorConfig := &corev1.ConfigMap{}
+		assert.NoError(t, reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: util.OcsOperatorConfigName, Namespace: testOperatorNamespace}, ocsOperatorConfig))
+		_, repaired := ocsOperatorConfig.Annotations[csiClusterNameRepairedAnnotation]
+		assert.Equalf(t, tc.expectMismatch, repaired, "[%s]: unexpected repair", tc.label)
+	}
+}
+
+func TestCSIClusterNameRepair(t *testing.T) {
+	deployment := newTestCSIProvisionerDeployment("csi-rbdplugin-provisioner", "stale-cluster-id")
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t,
+		newTestManagedServiceNamespace(),
+		newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+		deployment,
+		newTestRookCephOperatorPod(),
+	)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx, newTestRookCephOperatorPod()))
+
+	// the mismatch is repaired by restarting the rook-ceph-operator
+	assert.NoError(t, reconciler.reconcileCSIClusterNameConsistency(ocsInit))
+	assert.Equal(t, util.ClusterNameKey, getRookCephOperatorRestartPendingKeys(t, reconciler, util.OcsOperatorConfigName))
+	_, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx, newTestRookCephOperatorPod()))
+
+	// a mismatch that persists is only reported, the rook-ceph-operator is not restarted again
+	assert.NoError(t, reconciler.reconcileCSIClusterNameConsistency(ocsInit))
+	assert.False(t, isRookCephOperatorRestartPending(t, reconciler))
+	assert.True(t, conditionsv1.IsStatusConditionTrue(ocsInit.Status.Conditions, ConditionCSIClusterNameMismatch))
+
+	// once Rook renders the Deployment with the cluster name, the condition is cleared
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(deployment), deployment))
+	deployment.Spec.Template.Spec.Containers[1].Args[1] = csiClusterNameArg + testCSIClusterName
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, deployment))
+	assert.NoError(t, reconciler.reconcileCSIClusterNameConsistency(ocsInit))
+	assert.Nil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionCSIClusterNameMismatch))
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: util.OcsOperatorConfigName, Namespace: testOperatorNamespace}, ocsOperatorConfig))
+	assert.NotContains(t, ocsOperatorConfig.Annotations, csiClusterNameRepairedAnnotation)
+}
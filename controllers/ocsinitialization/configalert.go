@@ -0,0 +1,133 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"time"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// ConditionOcsOperatorConfigDegraded is set while the reconcile of ocs-operator-config or of the restart of the
+	// rook-ceph-operator for its changes fails. The CSI drivers keep running with the previous config meanwhile.
+	ConditionOcsOperatorConfigDegraded conditionsv1.ConditionType = "OcsOperatorConfigDegraded"
+
+	// OcsOperatorConfigAlertRuleName is the name of the PrometheusRule alerting on the ocs-operator-config reconcile
+	OcsOperatorConfigAlertRuleName = "ocs-operator-config-rules"
+	// OcsOperatorConfigDegradedAlert fires when the ocs-operator-config reconcile stays degraded
+	OcsOperatorConfigDegradedAlert = "OcsOperatorConfigReconcileDegraded"
+	// OcsOperatorConfigFailingAlert fires when the ocs-operator-config reconcile keeps failing, even if it recovers in
+	// between
+	OcsOperatorConfigFailingAlert = "OcsOperatorConfigReconcileFailing"
+
+	// DefaultConfigDegradedAlertFor is how long the reconcile has to stay degraded before the degraded alert fires
+	DefaultConfigDegradedAlertFor = 10 * time.Minute
+	// configReconcileFailureAlertWindow is the window the reconcile failures of the failing alert are counted in
+	configReconcileFailureAlertWindow = time.Hour
+)
+
+var (
+	ocsOperatorConfigDegradedMetric = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ocs_operator_config_reconcile_degraded",
+		Help: "Whether the reconcile of ocs-operator-config is degraded (1) or not (0)",
+	}, []string{"namespace"})
+	ocsOperatorConfigFailuresMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ocs_operator_config_reconcile_failures_total",
+		Help: "Number of failed reconciles of ocs-operator-config",
+	}, []string{"namespace"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(ocsOperatorConfigDegradedMetric, ocsOperatorConfigFailuresMetric)
+}
+
+// setOcsOperatorConfigDegraded sets the ConditionOcsOperatorConfigDegraded condition and the degraded metric from
+// the result of the ocs-operator-config reconcile, and counts the failure
+func setOcsOperatorConfigDegraded(initialData *ocsv1.OCSInitialization, err error) {
+	degraded := 0.0
+	if err != nil {
+		degraded = 1
+		ocsOperatorConfigFailuresMetric.WithLabelValues(initialData.Namespace).Inc()
+	}
+	ocsOperatorConfigDegradedMetric.WithLabelValues(initialData.Namespace).Set(degraded)
+	message := ""
+	if err != nil {
+		message = fmt.Sprintf("failed to reconcile ocs-operator-config: %v", err)
+	}
+	setOcsOperatorConfigCondition(initialData, ConditionOcsOperatorConfigDegraded, err != nil, "ReconcileFailed", message)
+}
+
+// getOcsOperatorConfigAlertRuleGroups returns the alerting rules on the ocs-operator-config reconcile of the
+// namespace. The failing alert is only returned for a positive failure threshold.
+func getOcsOperatorConfigAlertRuleGroups(namespace string, degradedFor time.Duration, failureThreshold int) []promv1.RuleGroup {
+	rules := []promv1.Rule{
+		{
+			Alert: OcsOperatorConfigDegradedAlert,
+			Expr:  intstr.FromString(fmt.Sprintf(`ocs_operator_config_reconcile_degraded{namespace="%s"} == 1`, namespace)),
+			For:   ptr.To(promv1.Duration(model.Duration(degradedFor).String())),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"message": "The reconcile of ocs-operator-config is degraded.",
+				"description": fmt.Sprintf("The reconcile of ocs-operator-config in namespace %s has been failing for more than %s, "+
+					"the CSI drivers run with the previous config. See the %s condition of the OCSInitialization.",
+					namespace, model.Duration(degradedFor), ConditionOcsOperatorConfigDegraded),
+				"severity_level": "warning",
+				"storage_type":   "ceph",
+			},
+		},
+	}
+	if failureThreshold > 0 {
+		window := model.Duration(configReconcileFailureAlertWindow)
+		rules = append(rules, promv1.Rule{
+			Alert: OcsOperatorConfigFailingAlert,
+			Expr: intstr.FromString(fmt.Sprintf(`increase(ocs_operator_config_reconcile_failures_total{namespace="%s"}[%s]) >= %d`,
+				namespace, window, failureThreshold)),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"message": "The reconcile of ocs-operator-config keeps failing.",
+				"description": fmt.Sprintf("The reconcile of ocs-operator-config in namespace %s failed at least %d times in the last %s.",
+					namespace, failureThreshold, window),
+				"severity_level": "warning",
+				"storage_type":   "ceph",
+			},
+		})
+	}
+	return []promv1.RuleGroup{{Name: "ocs-operator-config.rules", Rules: rules}}
+}
+
+// reconcileOcsOperatorConfigAlertRule creates or updates the PrometheusRule alerting on the ocs-operator-config
+// reconcile with the thresholds of the reconciler, so that the existing alerting picks up a degraded config
+func (r *OCSInitializationReconciler) reconcileOcsOperatorConfigAlertRule(initialData *ocsv1.OCSInitialization) error {
+	degradedFor := r.ConfigDegradedAlertFor
+	if degradedFor <= 0 {
+		degradedFor = DefaultConfigDegradedAlertFor
+	}
+
+	rule := &promv1.PrometheusRule{}
+	rule.Name = OcsOperatorConfigAlertRuleName
+	rule.Namespace = initialData.Namespace
+	_, err := ctrl.CreateOrUpdate(r.ctx, r.Client, rule, func() error {
+		if err := ctrl.SetControllerReference(initialData, rule, r.Scheme); err != nil {
+			return err
+		}
+		util.AddLabel(rule, "prometheus", "k8s")
+		util.AddLabel(rule, "role", "alert-rules")
+		rule.Spec.Groups = getOcsOperatorConfigAlertRuleGroups(initialData.Namespace, degradedFor, r.ConfigReconcileFailureAlertThreshold)
+		return nil
+	})
+	return err
+}
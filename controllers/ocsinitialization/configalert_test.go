@@ -0,0 +1,75 @@
+package ocsinitialization
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func getTestOcsOperatorConfigAlertRules(t *testing.T, reconciler OCSInitializationReconciler) map[string]promv1.Rule {
+	rule := &promv1.PrometheusRule{}
+	key := types.NamespacedName{Name: OcsOperatorConfigAlertRuleName, Namespace: testOperatorNamespace}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, key, rule))
+	rules := map[string]promv1.Rule{}
+	for _, group := range rule.Spec.Groups {
+		for _, r := range group.Rules {
+			rules[r.Alert] = r
+		}
+	}
+	return rules
+}
+
+func TestOcsOperatorConfigAlertRule(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t)
+
+	// the default thresholds
+	assert.NoError(t, reconciler.reconcileOcsOperatorConfigAlertRule(ocsInit))
+	rules := getTestOcsOperatorConfigAlertRules(t, reconciler)
+	assert.Len(t, rules, 1)
+	degraded := rules[OcsOperatorConfigDegradedAlert]
+	assert.Equal(t, `ocs_operator_config_reconcile_degraded{namespace="`+testOperatorNamespace+`"} == 1`, degraded.Expr.StrVal)
+	assert.Equal(t, promv1.Duration("10m"), *degraded.For)
+
+	// the rule is updated with the configured thresholds
+	reconciler.ConfigDegradedAlertFor = 30 * time.Minute
+	reconciler.ConfigReconcileFailureAlertThreshold = 5
+	assert.NoError(t, reconciler.reconcileOcsOperatorConfigAlertRule(ocsInit))
+	rules = getTestOcsOperatorConfigAlertRules(t, reconciler)
+	assert.Len(t, rules, 2)
+	assert.Equal(t, promv1.Duration("30m"), *rules[OcsOperatorConfigDegradedAlert].For)
+	assert.Equal(t, `increase(ocs_operator_config_reconcile_failures_total{namespace="`+testOperatorNamespace+`"}[1h]) >= 5`,
+		rules[OcsOperatorConfigFailingAlert].Expr.StrVal)
+}
+
+func TestOcsOperatorConfigDegraded(t *testing.T) {
+	ocsInit, _ := getOcsOperatorConfigTestReconciler(t)
+	getDegradedMetric := func() float64 {
+		metric := &dto.Metric{}
+		assert.NoError(t, ocsOperatorConfigDegradedMetric.WithLabelValues(testOperatorNamespace).Write(metric))
+		return metric.GetGauge().GetValue()
+	}
+	getFailuresMetric := func() float64 {
+		metric := &dto.Metric{}
+		assert.NoError(t, ocsOperatorConfigFailuresMetric.WithLabelValues(testOperatorNamespace).Write(metric))
+		return metric.GetCounter().GetValue()
+	}
+	failures := getFailuresMetric()
+
+	setOcsOperatorConfigDegraded(ocsInit, errors.New("failed to list the storageclasses"))
+	condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionOcsOperatorConfigDegraded)
+	assert.NotNil(t, condition)
+	assert.Contains(t, condition.Message, "failed to list the storageclasses")
+	assert.Equal(t, 1.0, getDegradedMetric())
+	assert.Equal(t, failures+1, getFailuresMetric())
+
+	setOcsOperatorConfigDegraded(ocsInit, nil)
+	assert.Nil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionOcsOperatorConfigDegraded))
+	assert.Equal(t, 0.0, getDegradedMetric())
+	assert.Equal(t, failures+1, getFailuresMetric())
+}
@@ -0,0 +1,62 @@
+package ocsinitialization
+
+import (
+	"maps"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+)
+
+// ComputeOCSOperatorConfigData returns the ocs-operator-config data that is derived from the given storagecluster
+// and cluster ID, without reading anything from the cluster. It previews the config for a storagecluster spec:
+// the keys that depend on other objects, like the StorageClasses, CephFilesystems and CephObjectStores, and the
+// defaults and overrides of ocs-operator-config are not part of it. The topology is taken from the failure
+// domain of an internal storagecluster with non-resilient pools, as the LSO devices are not looked up.
+func ComputeOCSOperatorConfigData(sc *ocsv1.StorageCluster, clusterID string) map[string]string {
+	clusters := util.NewClusters([]ocsv1.StorageCluster{*sc})
+	ocsOperatorConfigData, _ := computeOcsOperatorConfigData(logr.Discard(), clusters, clusterID, getSpecTopologyConfig(clusters))
+	return ocsOperatorConfigData
+}
+
+// computeOcsOperatorConfigData returns the ocs-operator-config data derived from the storageclusters, the cluster ID
+// and the resolved topology, and the rationale for the chosen ms_mode
+func computeOcsOperatorConfigData(log logr.Logger, clusters *util.Clusters, clusterID string, topology TopologyConfig) (map[string]string, string) {
+	ocsOperatorConfigData := map[string]string{
+		util.ClusterNameKey:              clusterID,
+		util.RookCurrentNamespaceOnlyKey: strconv.FormatBool(!(len(clusters.GetStorageClusters()) > 1)),
+		util.EnableTopologyKey:           strconv.FormatBool(topology.Enabled),
+		util.TopologyDomainLabelsKey:     topology.DomainLabels,
+		util.EnableNFSKey:                getEnableNFSKeyValue(clusters),
+		util.EnableReadAffinityKey:       getEnableReadAffinityKeyValue(clusters),
+		util.DisableCSIDriverKey:         strconv.FormatBool(true),
+	}
+	if pinning := getCephFSSubvolumeGroupPinningKeyValue(log, clusters); pinning != "" {
+		ocsOperatorConfigData[util.CephFSSubvolumeGroupPinningKey] = pinning
+	}
+	if radosNamespace := getRbdRadosNamespaceKeyValue(log, clusters); radosNamespace != "" {
+		ocsOperatorConfigData[util.RbdRadosNamespaceKey] = radosNamespace
+	}
+	if thickProvision := getRbdThickProvisionKeyValue(clusters); thickProvision != "" {
+		ocsOperatorConfigData[util.RbdThickProvisionKey] = thickProvision
+	}
+	if mirrorMode := getRbdMirrorModeKeyValue(log, clusters); mirrorMode != "" {
+		ocsOperatorConfigData[util.RbdMirrorModeKey] = mirrorMode
+	}
+	maps.Copy(ocsOperatorConfigData, getCSIImageKeyValues(clusters))
+	encryptionKeyValues, msModeRationale := getEncryptionKeyValues(clusters)
+	maps.Copy(ocsOperatorConfigData, encryptionKeyValues)
+	return ocsOperatorConfigData, msModeRationale
+}
+
+// getSpecTopologyConfig returns the topology config of the first internal storagecluster with non-resilient pools,
+// the way DefaultTopologyResolver does before it looks at the LSO devices
+func getSpecTopologyConfig(clusters *util.Clusters) TopologyConfig {
+	for _, sc := range clusters.GetInternalStorageClusters() {
+		if sc.Spec.ManagedResources.CephNonResilientPools.Enable {
+			return TopologyConfig{Enabled: true, DomainLabels: sc.Status.FailureDomainKey}
+		}
+	}
+	return TopologyConfig{}
+}
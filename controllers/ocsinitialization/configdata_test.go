@@ -0,0 +1,135 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/ptr"
+)
+
+func TestComputeOCSOperatorConfigData(t *testing.T) {
+	testcases := []struct {
+		label    string
+		mutate   func(sc *v1.StorageCluster)
+		expected map[string]string
+	}{
+		{
+			label:  "prefer-crc without network encryption",
+			mutate: func(sc *v1.StorageCluster) {},
+			expected: map[string]string{
+				util.EnableNetworkEncryptionKey:  "false",
+				util.CephFSKernelMountOptionsKey: "ms_mode=prefer-crc",
+				util.EnableTopologyKey:           "false",
+				util.TopologyDomainLabelsKey:     "",
+				util.EnableReadAffinityKey:       "true",
+			},
+		},
+		{
+			label:  "prefer-crc with network encryption disabled",
+			mutate: func(sc *v1.StorageCluster) { setTestNetworkEncryption(sc, false) },
+			expected: map[string]string{
+				util.EnableNetworkEncryptionKey:  "false",
+				util.CephFSKernelMountOptionsKey: "ms_mode=prefer-crc",
+			},
+		},
+		{
+			label: "secure with network encryption",
+			mutate: func(sc *v1.StorageCluster) {
+				setTestNetworkEncryption(sc, true)
+			},
+			expected: map[string]string{
+				util.EnableNetworkEncryptionKey:  "true",
+				util.CephFSKernelMountOptionsKey: "ms_mode=secure",
+			},
+		},
+		{
+			label: "secure with the additional kernel mount options",
+			mutate: func(sc *v1.StorageCluster) {
+				setTestNetworkEncryption(sc, true)
+				sc.Spec.ManagedResources.CephFilesystems.KernelMountOptions = map[string]string{"recover_session": "clean", "ms_mode": "crc"}
+			},
+			expected: map[string]string{
+				util.EnableNetworkEncryptionKey:  "true",
+				util.CephFSKernelMountOptionsKey: "ms_mode=secure,recover_session=clean",
+			},
+		},
+		{
+			label: "external mode negotiates its own ms_mode",
+			mutate: func(sc *v1.StorageCluster) {
+				sc.Spec.ExternalStorage.Enable = true
+				setTestNetworkEncryption(sc, true)
+			},
+			expected: map[string]string{
+				util.EnableNetworkEncryptionKey:  "false",
+				util.CephFSKernelMountOptionsKey: "ms_mode=prefer-crc",
+				util.EnableReadAffinityKey:       "false",
+			},
+		},
+		{
+			label: "topology with non-resilient pools",
+			mutate: func(sc *v1.StorageCluster) {
+				sc.Spec.ManagedResources.CephNonResilientPools.Enable = true
+				sc.Status.FailureDomainKey = "topology.kubernetes.io/zone"
+			},
+			expected: map[string]string{
+				util.EnableTopologyKey:       "true",
+				util.TopologyDomainLabelsKey: "topology.kubernetes.io/zone",
+				util.EnableReadAffinityKey:   "true",
+			},
+		},
+		{
+			label: "topology with read affinity disabled",
+			mutate: func(sc *v1.StorageCluster) {
+				sc.Spec.ManagedResources.CephNonResilientPools.Enable = true
+				sc.Status.FailureDomainKey = "topology.rook.io/rack"
+				sc.Spec.CSI = &v1.CSIDriverSpec{EnableReadAffinity: ptr.To(false)}
+			},
+			expected: map[string]string{
+				util.EnableTopologyKey:       "true",
+				util.TopologyDomainLabelsKey: "topology.rook.io/rack",
+				util.EnableReadAffinityKey:   "false",
+			},
+		},
+		{
+			label: "no topology for external mode with read affinity enabled",
+			mutate: func(sc *v1.StorageCluster) {
+				sc.Spec.ExternalStorage.Enable = true
+				sc.Spec.ManagedResources.CephNonResilientPools.Enable = true
+				sc.Status.FailureDomainKey = "topology.kubernetes.io/zone"
+				sc.Spec.CSI = &v1.CSIDriverSpec{EnableReadAffinity: ptr.To(true)}
+			},
+			expected: map[string]string{
+				util.EnableTopologyKey:       "false",
+				util.TopologyDomainLabelsKey: "",
+				util.EnableReadAffinityKey:   "true",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		tc.mutate(sc)
+		data := ComputeOCSOperatorConfigData(sc, testCSIClusterName)
+		assert.Equalf(t, testCSIClusterName, data[util.ClusterNameKey], "[%s]: unexpected cluster name", tc.label)
+		assert.Equalf(t, "true", data[util.RookCurrentNamespaceOnlyKey], "[%s]: unexpected current namespace only", tc.label)
+		assert.NotContainsf(t, data, util.EnableCephfsKey, "[%s]: unexpected cluster dependent key", tc.label)
+		for key, value := range tc.expected {
+			assert.Equalf(t, value, data[key], "[%s]: unexpected value of %s", tc.label, key)
+		}
+	}
+}
+
+func TestComputeOCSOperatorConfigDataMatchesReconcile(t *testing.T) {
+	sc := newTestTopologyStorageCluster("topology.kubernetes.io/zone")
+	setTestNetworkEncryption(sc, true)
+	sc.Spec.Mirroring = &v1.MirroringSpec{Enabled: true}
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+
+	for key, value := range ComputeOCSOperatorConfigData(sc, data[util.ClusterNameKey]) {
+		assert.Equalf(t, value, data[key], "unexpected value of %s", key)
+	}
+}
@@ -0,0 +1,140 @@
+package ocsinitialization
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultConfigLockLeaseName is the name of the Lease that external tools are expected to take while they mutate
+	// ocs-operator-config. The lock is disabled unless a Lease name is configured.
+	DefaultConfigLockLeaseName = "ocs-operator-config-lock"
+
+	// configLockLeaseDurationSeconds bounds how long a crashed holder can block others from taking the lock
+	configLockLeaseDurationSeconds = 30
+	// configLockRequeueDelay is the delay after which the reconcile is retried when the config lock was not taken
+	configLockRequeueDelay = 10 * time.Second
+)
+
+// errConfigLockUnavailable is returned when the config lock Lease is held or was taken by someone else
+var errConfigLockUnavailable = errors.New("config lock unavailable")
+
+func isConfigLockUnavailable(err error) bool {
+	return errors.Is(err, errConfigLockUnavailable)
+}
+
+func (r *OCSInitializationReconciler) getLeaseReader() client.Reader {
+	if r.LeaseReader != nil {
+		return r.LeaseReader
+	}
+	return r.Client
+}
+
+// getConfigLockLeaseKey returns the name and namespace of the config lock Lease. The namespace
+// defaults to the operator namespace.
+func (r *OCSInitializationReconciler) getConfigLockLeaseKey() types.NamespacedName {
+	namespace := r.ConfigLockLeaseNamespace
+	if namespace == "" {
+		namespace = r.OperatorNamespace
+	}
+	return types.NamespacedName{Name: r.ConfigLockLeaseName, Namespace: namespace}
+}
+
+// getConfigLockIdentity returns the holder identity of this operator replica, which is its pod name
+func getConfigLockIdentity() string {
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return "ocs-operator"
+}
+
+// acquireConfigLock takes the config lock Lease before ocs-operator-config is mutated. Other operator
+// replicas and external tools take the same Lease, it can be taken over once the holder let it expire.
+// If the Lease is held or was taken by someone else in the meantime, errConfigLockUnavailable is returned.
+// The Lease is read from the API server, as its namespace need not be cached and the cache can be stale.
+// The lock is disabled when no Lease name is configured.
+func (r *OCSInitializationReconciler) acquireConfigLock() error {
+	if r.ConfigLockLeaseName == "" {
+		return nil
+	}
+	key := r.getConfigLockLeaseKey()
+	identity := getConfigLockIdentity()
+	now := metav1.NewMicroTime(time.Now())
+
+	lease := &coordinationv1.Lease{}
+	err := r.getLeaseReader().Get(r.ctx, key, lease)
+	if kerrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       ptr.To(identity),
+				LeaseDurationSeconds: ptr.To[int32](configLockLeaseDurationSeconds),
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		err = r.Client.Create(r.ctx, lease)
+		if kerrors.IsAlreadyExists(err) {
+			return fmt.Errorf("%w: lease %s was created in the meantime: %w", errConfigLockUnavailable, key, err)
+		}
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	if holder := ptr.Deref(lease.Spec.HolderIdentity, ""); holder != "" && holder != identity && !isLeaseExpired(lease, now.Time) {
+		return fmt.Errorf("%w: lease %s is held by %q", errConfigLockUnavailable, key, holder)
+	}
+	lease.Spec.HolderIdentity = ptr.To(identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To[int32](configLockLeaseDurationSeconds)
+	lease.Spec.AcquireTime = &now
+	lease.Spec.RenewTime = &now
+	// the update fails on a conflict if someone else took the lease in the meantime
+	if err := r.Client.Update(r.ctx, lease); kerrors.IsConflict(err) {
+		return fmt.Errorf("%w: lease %s was taken in the meantime: %w", errConfigLockUnavailable, key, err)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// releaseConfigLock gives up the config lock Lease, if it is still held by this replica
+func (r *OCSInitializationReconciler) releaseConfigLock() {
+	if r.ConfigLockLeaseName == "" {
+		return
+	}
+	key := r.getConfigLockLeaseKey()
+	lease := &coordinationv1.Lease{}
+	if err := r.getLeaseReader().Get(r.ctx, key, lease); err != nil {
+		r.Log.Error(err, "Failed to get the config lock lease for release", "Lease", klog.KRef(key.Namespace, key.Name))
+		return
+	}
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != getConfigLockIdentity() {
+		return
+	}
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.AcquireTime = nil
+	lease.Spec.RenewTime = nil
+	if err := r.Client.Update(r.ctx, lease); err != nil {
+		// the lease expires on its own, so there is no need to fail the reconcile
+		r.Log.Error(err, "Failed to release the config lock lease", "Lease", klog.KRef(key.Namespace, key.Name))
+	}
+}
+
+// isLeaseExpired returns true if the holder did not renew the Lease within its duration
+func isLeaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second).Before(now)
+}
@@ -0,0 +1,151 @@
+package ocsinitialization
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func newTestConfigLockLease(holder string, renewTime time.Time) *coordinationv1.Lease {
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultConfigLockLeaseName, Namespace: testOperatorNamespace},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To(holder),
+			LeaseDurationSeconds: ptr.To[int32](configLockLeaseDurationSeconds),
+			RenewTime:            &metav1.MicroTime{Time: renewTime},
+		},
+	}
+}
+
+func getConfigLockHolder(t *testing.T, c client.Client, key types.NamespacedName) string {
+	lease := &coordinationv1.Lease{}
+	assert.NoError(t, c.Get(context.TODO(), key, lease))
+	return ptr.Deref(lease.Spec.HolderIdentity, "")
+}
+
+func TestConfigLockHeldAroundUpdate(t *testing.T) {
+	testcases := []struct {
+		label          string
+		leaseNamespace string
+	}{
+		{
+			label:          "lease in the operator namespace",
+			leaseNamespace: "",
+		},
+		{
+			label:          "lease in a configured namespace",
+			leaseNamespace: "lock-ns",
+		},
+	}
+
+	for _, tc := range testcases {
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+		reconciler.ConfigLockLeaseName = "custom-lock"
+		reconciler.ConfigLockLeaseNamespace = tc.leaseNamespace
+		leaseKey := reconciler.getConfigLockLeaseKey()
+		if tc.leaseNamespace == "" {
+			assert.Equalf(t, testOperatorNamespace, leaseKey.Namespace, "[%s]", tc.label)
+		} else {
+			assert.Equalf(t, tc.leaseNamespace, leaseKey.Namespace, "[%s]", tc.label)
+		}
+
+		// record the lock holder whenever the ocs-operator-config configmap is written
+		holders := []string{}
+		reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if obj.GetName() == util.OcsOperatorConfigName {
+					holders = append(holders, getConfigLockHolder(t, c, leaseKey))
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		})
+
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		assert.Equalf(t, []string{getConfigLockIdentity()}, holders, "[%s]: the lease must be held while the configmap is written", tc.label)
+		assert.Emptyf(t, getConfigLockHolder(t, reconciler.Client, leaseKey), "[%s]: the lease must be released after the update", tc.label)
+	}
+}
+
+func TestConfigLockHeldByOthers(t *testing.T) {
+	// a lease held by an external tool blocks the update
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t,
+		newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+		newTestConfigLockLease("external-tool", time.Now()))
+	reconciler.ConfigLockLeaseName = DefaultConfigLockLeaseName
+
+	err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+	assert.Truef(t, isConfigLockUnavailable(err), "unexpected error %v", err)
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	err = reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: util.OcsOperatorConfigName, Namespace: testOperatorNamespace}, ocsOperatorConfig)
+	assert.True(t, err != nil, "the configmap must not be written while the lock is held by others")
+	assert.Equal(t, "external-tool", getConfigLockHolder(t, reconciler.Client, reconciler.getConfigLockLeaseKey()))
+
+	// an expired lease is taken over
+	ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t,
+		newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+		newTestConfigLockLease("external-tool", time.Now().Add(-time.Hour)))
+	reconciler.ConfigLockLeaseName = DefaultConfigLockLeaseName
+
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "false", getOcsOperatorConfigData(t, reconciler)[util.EnableNFSKey])
+	assert.Empty(t, getConfigLockHolder(t, reconciler.Client, reconciler.getConfigLockLeaseKey()))
+}
+
+func TestConfigLockRaces(t *testing.T) {
+	leaseResource := coordinationv1.Resource("leases")
+	testcases := []struct {
+		label string
+		lease *coordinationv1.Lease
+		funcs interceptor.Funcs
+	}{
+		{
+			label: "lease created by someone else in the meantime",
+			funcs: interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					if _, ok := obj.(*coordinationv1.Lease); ok {
+						return kerrors.NewAlreadyExists(leaseResource, obj.GetName())
+					}
+					return c.Create(ctx, obj, opts...)
+				},
+			},
+		},
+		{
+			label: "expired lease taken by someone else in the meantime",
+			lease: newTestConfigLockLease("external-tool", time.Now().Add(-time.Hour)),
+			funcs: interceptor.Funcs{
+				Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					if _, ok := obj.(*coordinationv1.Lease); ok {
+						return kerrors.NewConflict(leaseResource, obj.GetName(), fmt.Errorf("the object has been modified"))
+					}
+					return c.Update(ctx, obj, opts...)
+				},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := []client.Object{newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)}
+		if tc.lease != nil {
+			objs = append(objs, tc.lease)
+		}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		reconciler.ConfigLockLeaseName = DefaultConfigLockLeaseName
+		reconciler.LeaseReader = reconciler.Client
+		reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), tc.funcs)
+
+		err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+		assert.Truef(t, isConfigLockUnavailable(err), "[%s]: unexpected error %v", tc.label, err)
+	}
+}
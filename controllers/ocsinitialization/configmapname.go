@@ -0,0 +1,45 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+)
+
+// validateOcsOperatorConfigName returns an error if the name cannot be used for the ocs-operator-config configmap,
+// i.e. it is not a valid configmap name or it is the name of another configmap of the operator
+func validateOcsOperatorConfigName(name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid configmap name %q: %s", name, strings.Join(errs, ", "))
+	}
+	slots := getOcsOperatorConfigSlots(util.OcsOperatorConfigName)
+	if name == util.RookCephOperatorConfigName || name == OcsOperatorConfigDefaultsName || slices.Contains(slots[:], name) {
+		return fmt.Errorf("configmap name %q is reserved", name)
+	}
+	return nil
+}
+
+// getOcsOperatorConfigName returns the name of the ocs-operator-config configmap. The name set on the first
+// storagecluster that sets a valid one overrides util.OcsOperatorConfigName. The configmap of a previous name is
+// left in place, so that the config can be migrated side by side, and is removed along with the OCSInitialization.
+func (r *OCSInitializationReconciler) getOcsOperatorConfigName() string {
+	if r.clusters == nil {
+		return util.OcsOperatorConfigName
+	}
+	for _, sc := range r.clusters.GetStorageClusters() {
+		if sc.Spec.OcsOperatorConfigName == "" {
+			continue
+		}
+		if err := validateOcsOperatorConfigName(sc.Spec.OcsOperatorConfigName); err != nil {
+			r.Log.Error(err, "Ignoring the invalid ocs-operator-config name", "StorageCluster", klog.KRef(sc.Namespace, sc.Name))
+			continue
+		}
+		return sc.Spec.OcsOperatorConfigName
+	}
+	return util.OcsOperatorConfigName
+}
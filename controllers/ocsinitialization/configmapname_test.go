@@ -0,0 +1,85 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestOcsOperatorConfigName(t *testing.T) {
+	testcases := []struct {
+		label        string
+		configName   string
+		expectedName string
+	}{
+		{
+			label:        "default name",
+			expectedName: util.OcsOperatorConfigName,
+		},
+		{
+			label:        "overridden name",
+			configName:   "tenant-ocs-operator-config",
+			expectedName: "tenant-ocs-operator-config",
+		},
+		{
+			label:        "invalid name",
+			configName:   "Tenant_Config",
+			expectedName: util.OcsOperatorConfigName,
+		},
+		{
+			label:        "reserved name",
+			configName:   util.RookCephOperatorConfigName,
+			expectedName: util.OcsOperatorConfigName,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Spec.OcsOperatorConfigName = tc.configName
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, newTestRookCephOperatorPod())
+		assert.Equalf(t, tc.expectedName, reconciler.getOcsOperatorConfigName(), "[%s]: unexpected configmap name", tc.label)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		configMap := &corev1.ConfigMap{}
+		key := types.NamespacedName{Name: tc.expectedName, Namespace: testOperatorNamespace}
+		assert.NoErrorf(t, reconciler.Client.Get(reconciler.ctx, key, configMap), "[%s]: configmap is not written", tc.label)
+		assert.Equalf(t, "false", configMap.Data[util.EnableTopologyKey], "[%s]: unexpected config", tc.label)
+		if tc.expectedName != util.OcsOperatorConfigName {
+			key.Name = util.OcsOperatorConfigName
+			err := reconciler.Client.Get(reconciler.ctx, key, &corev1.ConfigMap{})
+			assert.Truef(t, errors.IsNotFound(err), "[%s]: the default configmap is written", tc.label)
+		}
+
+		// the restart is triggered by the configmap of the resolved name
+		assert.NotEmptyf(t, getRookCephOperatorRestartPendingKeys(t, reconciler, tc.expectedName), "[%s]: restart is not pending", tc.label)
+		_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to restart the rook-ceph-operator", tc.label)
+		assert.Truef(t, isRookCephOperatorPodRestarted(t, reconciler), "[%s]: rook-ceph-operator is not restarted", tc.label)
+		assert.Emptyf(t, getRookCephOperatorRestartPendingKeys(t, reconciler, tc.expectedName), "[%s]: restart is still pending", tc.label)
+	}
+}
+
+func TestOcsOperatorConfigNameEnvFrom(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Spec.OcsOperatorConfigName = "tenant-ocs-operator-config"
+	deployment := newTestRookCephOperatorDeployment(newTestConfigMapEnvFrom(util.OcsOperatorConfigName))
+
+	// the reference to the default configmap does not consume the config of the overridden name
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, deployment)
+	assert.NoError(t, reconciler.reconcileRookCephOperatorEnvFrom(ocsInit))
+	assert.NotNil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionOcsOperatorConfigNotConsumed))
+
+	// and is replaced by the remediation
+	reconciler.RemediateRookCephOperatorEnvFrom = true
+	assert.NoError(t, reconciler.reconcileRookCephOperatorEnvFrom(ocsInit))
+	assert.Nil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionOcsOperatorConfigNotConsumed))
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(deployment), deployment))
+	assert.Equal(t, []corev1.EnvFromSource{newTestConfigMapEnvFrom("tenant-ocs-operator-config")},
+		deployment.Spec.Template.Spec.Containers[0].EnvFrom)
+}
@@ -0,0 +1,92 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// ConditionTopologyModeUnsupported is set when the topology domain labels can not be switched, because
+	// a CSI driver does not advertise requiresRepublish. The drivers only pick up the new topology segments
+	// of already published volumes when they are republished, so the previous labels are kept.
+	ConditionTopologyModeUnsupported conditionsv1.ConditionType = "TopologyModeUnsupported"
+)
+
+// topologyCSIDriverNames are the CSI drivers that consume the topology config
+var topologyCSIDriverNames = []string{util.RbdDriverName, util.CephFSDriverName}
+
+// gateTopologyOnCSIDriverCapabilities keeps the current topology config when switching the domain labels of
+// an enabled topology requires a capability that one of the CSIDriver objects does not advertise.
+// Drivers that are not deployed yet have no published volumes, so they do not restrict the topology.
+func (r *OCSInitializationReconciler) gateTopologyOnCSIDriverCapabilities(initialData *ocsv1.OCSInitialization,
+	ocsOperatorConfigData map[string]string) error {
+	current := &corev1.ConfigMap{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: r.getOcsOperatorConfigName(), Namespace: initialData.Namespace}, current)
+	if errors.IsNotFound(err) {
+		setOcsOperatorConfigCondition(initialData, ConditionTopologyModeUnsupported, false, "", "")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !isTopologyDomainLabelsSwitch(current.Data, ocsOperatorConfigData) {
+		setOcsOperatorConfigCondition(initialData, ConditionTopologyModeUnsupported, false, "", "")
+		return nil
+	}
+
+	csiDrivers, err := r.getTopologyCSIDrivers()
+	if err != nil {
+		return err
+	}
+	unsupported := []string{}
+	for _, csiDriver := range csiDrivers {
+		if csiDriver.Spec.RequiresRepublish == nil || !*csiDriver.Spec.RequiresRepublish {
+			unsupported = append(unsupported, csiDriver.Name)
+		}
+	}
+
+	if len(unsupported) > 0 {
+		r.Log.Info("Keeping the current topology domain labels, the CSI drivers do not support republishing volumes.",
+			"CurrentLabels", current.Data[util.TopologyDomainLabelsKey],
+			"RequestedLabels", ocsOperatorConfigData[util.TopologyDomainLabelsKey],
+			"CSIDrivers", unsupported)
+		ocsOperatorConfigData[util.TopologyDomainLabelsKey] = current.Data[util.TopologyDomainLabelsKey]
+	}
+	setOcsOperatorConfigCondition(initialData, ConditionTopologyModeUnsupported, len(unsupported) > 0,
+		"RequiresRepublishNotAdvertised",
+		fmt.Sprintf("Switching the topology domain labels requires requiresRepublish on the CSIDrivers %s",
+			strings.Join(unsupported, ", ")))
+	return nil
+}
+
+// isTopologyDomainLabelsSwitch returns true if the domain labels of an enabled topology are changed
+func isTopologyDomainLabelsSwitch(currentData, desiredData map[string]string) bool {
+	return currentData[util.EnableTopologyKey] == "true" && desiredData[util.EnableTopologyKey] == "true" &&
+		currentData[util.TopologyDomainLabelsKey] != "" &&
+		currentData[util.TopologyDomainLabelsKey] != desiredData[util.TopologyDomainLabelsKey]
+}
+
+// getTopologyCSIDrivers returns the deployed CSIDriver objects that consume the topology config
+func (r *OCSInitializationReconciler) getTopologyCSIDrivers() ([]storagev1.CSIDriver, error) {
+	csiDrivers := []storagev1.CSIDriver{}
+	for _, driverName := range topologyCSIDriverNames {
+		csiDriver := &storagev1.CSIDriver{}
+		err := r.Client.Get(r.ctx, types.NamespacedName{Name: driverName}, csiDriver)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		csiDrivers = append(csiDrivers, *csiDriver)
+	}
+	return csiDrivers, nil
+}
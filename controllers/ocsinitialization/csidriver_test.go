@@ -0,0 +1,81 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestCSIDriver(name string, requiresRepublish *bool) *storagev1.CSIDriver {
+	return &storagev1.CSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       storagev1.CSIDriverSpec{RequiresRepublish: requiresRepublish},
+	}
+}
+
+func TestTopologyGatedOnCSIDriverCapabilities(t *testing.T) {
+	testcases := []struct {
+		label         string
+		csiDrivers    []client.Object
+		expectSwitch  bool
+		expectedLabel string
+	}{
+		{
+			label:         "no CSIDrivers are deployed yet",
+			expectSwitch:  true,
+			expectedLabel: "topology.rook.io/rack",
+		},
+		{
+			label: "all CSIDrivers advertise requiresRepublish",
+			csiDrivers: []client.Object{
+				newTestCSIDriver(util.RbdDriverName, ptr.To(true)),
+				newTestCSIDriver(util.CephFSDriverName, ptr.To(true)),
+			},
+			expectSwitch:  true,
+			expectedLabel: "topology.rook.io/rack",
+		},
+		{
+			label: "a CSIDriver does not require republish",
+			csiDrivers: []client.Object{
+				newTestCSIDriver(util.RbdDriverName, ptr.To(true)),
+				newTestCSIDriver(util.CephFSDriverName, ptr.To(false)),
+			},
+			expectSwitch:  false,
+			expectedLabel: "topology.kubernetes.io/zone",
+		},
+		{
+			label:         "a CSIDriver does not advertise requiresRepublish",
+			csiDrivers:    []client.Object{newTestCSIDriver(util.RbdDriverName, nil)},
+			expectSwitch:  false,
+			expectedLabel: "topology.kubernetes.io/zone",
+		},
+	}
+
+	for _, tc := range testcases {
+		resolver := &fakeTopologyResolver{topology: TopologyConfig{Enabled: true, DomainLabels: "topology.kubernetes.io/zone"}}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t,
+			append(tc.csiDrivers, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))...)
+		reconciler.TopologyResolver = resolver
+
+		// enabling topology does not affect published volumes, so it is never restricted
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		assert.Equalf(t, "topology.kubernetes.io/zone", getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey], "[%s]", tc.label)
+		assert.Nilf(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionTopologyModeUnsupported), "[%s]", tc.label)
+
+		// switching the domain labels of the enabled topology depends on the CSIDrivers
+		resolver.topology.DomainLabels = "topology.rook.io/rack"
+		reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		data := getOcsOperatorConfigData(t, reconciler)
+		assert.Equalf(t, "true", data[util.EnableTopologyKey], "[%s]: unexpected topology state", tc.label)
+		assert.Equalf(t, tc.expectedLabel, data[util.TopologyDomainLabelsKey], "[%s]: unexpected domain labels", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionTopologyModeUnsupported)
+		assert.Equalf(t, tc.expectSwitch, condition == nil, "[%s]: unexpected condition %v", tc.label, condition)
+	}
+}
@@ -0,0 +1,48 @@
+package ocsinitialization
+
+import (
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+)
+
+// getImageTag returns the tag of an image reference, or its digest if it is pinned by digest. An image without
+// either is pulled with the latest tag.
+func getImageTag(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[i+1:]
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return "latest"
+}
+
+// getCSIImageKeyValues returns the tags of the CSI sidecar images expected on the storageclusters. The tags are
+// informational, so that a mismatch with the running CSI images can be detected externally. Each image is taken
+// from the first storagecluster that sets it, and no keys are returned for the images that are not set.
+func getCSIImageKeyValues(clusters *util.Clusters) map[string]string {
+	keyValues := map[string]string{}
+	for _, image := range []struct {
+		tagKey string
+		image  func(*ocsv1.CSIDriverSpec) string
+	}{
+		{util.CSIProvisionerImageTagKey, func(csi *ocsv1.CSIDriverSpec) string { return csi.ProvisionerImage }},
+		{util.CSIAttacherImageTagKey, func(csi *ocsv1.CSIDriverSpec) string { return csi.AttacherImage }},
+	} {
+		for _, sc := range clusters.GetStorageClusters() {
+			if sc.Spec.CSI == nil {
+				continue
+			}
+			expected := strings.TrimSpace(image.image(sc.Spec.CSI))
+			if expected == "" {
+				continue
+			}
+			keyValues[image.tagKey] = getImageTag(expected)
+			break
+		}
+	}
+	return keyValues
+}
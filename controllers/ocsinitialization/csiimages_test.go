@@ -0,0 +1,69 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestOcsOperatorConfigCSIImages(t *testing.T) {
+	testcases := []struct {
+		label    string
+		csi      []*v1.CSIDriverSpec
+		expected map[string]string
+	}{
+		{
+			label:    "default images",
+			csi:      []*v1.CSIDriverSpec{nil, {}},
+			expected: map[string]string{},
+		},
+		{
+			label: "overridden provisioner image",
+			csi:   []*v1.CSIDriverSpec{{ProvisionerImage: "registry.example.com:5000/sig-storage/csi-provisioner:v5.1.0"}},
+			expected: map[string]string{
+				util.CSIProvisionerImageTagKey: "v5.1.0",
+			},
+		},
+		{
+			label: "overridden images of different storageclusters",
+			csi: []*v1.CSIDriverSpec{
+				{AttacherImage: "registry.example.com/sig-storage/csi-attacher@sha256:0123abcd"},
+				{AttacherImage: "registry.example.com/sig-storage/csi-attacher:v4.7.0", ProvisionerImage: "csi-provisioner"},
+			},
+			expected: map[string]string{
+				util.CSIAttacherImageTagKey:    "sha256:0123abcd",
+				util.CSIProvisionerImageTagKey: "latest",
+			},
+		},
+	}
+
+	keys := []string{util.CSIProvisionerImageTagKey, util.CSIAttacherImageTagKey}
+	for _, tc := range testcases {
+		objs := []client.Object{newTestRookCephOperatorPod()}
+		for i, csi := range tc.csi {
+			// the storageclusters are listed in name order
+			sc := newTestStorageCluster(string(rune('a'+i))+"-storagecluster", testOperatorNamespace)
+			sc.Spec.CSI = csi
+			objs = append(objs, sc)
+		}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		for _, key := range keys {
+			if expected, ok := tc.expected[key]; ok {
+				assert.Equalf(t, expected, data[key], "[%s]: unexpected value of %s", tc.label, key)
+			} else {
+				assert.NotContainsf(t, data, key, "[%s]: unexpected key %s", tc.label, key)
+			}
+		}
+		// the tags are informational and do not restart rook-ceph-operator
+		pendingKeys := getRookCephOperatorRestartPendingKeys(t, reconciler, util.OcsOperatorConfigName)
+		for _, key := range keys {
+			assert.NotContainsf(t, pendingKeys, key, "[%s]: %s restarts rook-ceph-operator", tc.label, key)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// ConditionTopologyDomainLabelsWithoutCSINodePlugins is set when some of the topology domain labels are not
+	// carried by any of the nodes running the CSI node plugins, which are not passed on to CSI
+	ConditionTopologyDomainLabelsWithoutCSINodePlugins conditionsv1.ConditionType = "TopologyDomainLabelsWithoutCSINodePlugins"
+)
+
+// csiNodePluginDaemonSetNames are the names of the CSI node plugin DaemonSets in the operator namespace
+var csiNodePluginDaemonSetNames = []string{"csi-rbdplugin", "csi-cephfsplugin"}
+
+// daemonSetTolerations are the tolerations that the DaemonSet controller adds to the pods of every DaemonSet
+var daemonSetTolerations = []corev1.Toleration{
+	{Key: corev1.TaintNodeNotReady, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	{Key: corev1.TaintNodeUnreachable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	{Key: corev1.TaintNodeDiskPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodeMemoryPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodePIDPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodeUnschedulable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+}
+
+// nodeSelectorOperators maps the operators of the node affinity to the ones of the label selectors
+var nodeSelectorOperators = map[corev1.NodeSelectorOperator]selection.Operator{
+	corev1.NodeSelectorOpIn:           selection.In,
+	corev1.NodeSelectorOpNotIn:        selection.NotIn,
+	corev1.NodeSelectorOpExists:       selection.Exists,
+	corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	corev1.NodeSelectorOpGt:           selection.GreaterThan,
+	corev1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// getCSINodePluginNodes returns the nodes that the CSI node plugins are scheduled to, as determined by the node
+// selector, the required node affinity and the tolerations of the node plugin DaemonSets. Volumes cannot be used
+// on a node without CSI presence, so such a node contributes no topology domain. Before the node plugins are
+// deployed, all the nodes whose taints the DaemonSet pods tolerate by default are considered.
+func (r *OCSInitializationReconciler) getCSINodePluginNodes(nodes []corev1.Node) ([]corev1.Node, error) {
+	nodes, err := r.getTopologyDomainNodes(nodes)
+	if err != nil {
+		return nil, err
+	}
+	podSpecs, err := r.getCSINodePluginPodSpecs()
+	if err != nil {
+		return nil, err
+	}
+	if len(podSpecs) == 0 {
+		podSpecs = []*corev1.PodSpec{{}}
+	}
+	pluginNodes := []corev1.Node{}
+	for i := range nodes {
+		if slices.ContainsFunc(podSpecs, func(podSpec *corev1.PodSpec) bool {
+			return isCSINodePluginNode(podSpec, &nodes[i])
+		}) {
+			pluginNodes = append(pluginNodes, nodes[i])
+		}
+	}
+	return pluginNodes, nil
+}
+
+// getCSINodePluginPodSpecs returns the pod specs of the CSI node plugin DaemonSets that exist
+func (r *OCSInitializationReconciler) getCSINodePluginPodSpecs() ([]*corev1.PodSpec, error) {
+	podSpecs := []*corev1.PodSpec{}
+	for _, name := range csiNodePluginDaemonSetNames {
+		daemonSet := &appsv1.DaemonSet{}
+		err := r.Client.Get(r.ctx, types.NamespacedName{Name: name, Namespace: r.OperatorNamespace}, daemonSet)
+		if kerrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		podSpecs = append(podSpecs, &daemonSet.Spec.Template.Spec)
+	}
+	return podSpecs, nil
+}
+
+// isCSINodePluginNode returns true if a pod with the spec of a node plugin DaemonSet is scheduled to the node
+func isCSINodePluginNode(podSpec *corev1.PodSpec, node *corev1.Node) bool {
+	if !labels.SelectorFromSet(podSpec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return false
+	}
+	if affinity := podSpec.Affinity; affinity != nil && affinity.NodeAffinity != nil &&
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		if !slices.ContainsFunc(terms, func(term corev1.NodeSelectorTerm) bool { return matchesNodeSelectorTerm(term, node) }) {
+			return false
+		}
+	}
+	tolerations := append(slices.Clone(podSpec.Tolerations), daemonSetTolerations...)
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+		if !slices.ContainsFunc(tolerations, func(toleration corev1.Toleration) bool { return toleration.ToleratesTaint(taint) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesNodeSelectorTerm returns true if the node matches all the requirements of the term, a term without any
+// requirements matches no node
+func matchesNodeSelectorTerm(term corev1.NodeSelectorTerm, node *corev1.Node) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, requirement := range term.MatchExpressions {
+		if !matchesNodeSelectorRequirement(requirement, labels.Set(node.Labels)) {
+			return false
+		}
+	}
+	for _, requirement := range term.MatchFields {
+		// the node name is the only field supported by the scheduler
+		if requirement.Key != metav1.ObjectNameField ||
+			!matchesNodeSelectorRequirement(requirement, labels.Set{metav1.ObjectNameField: node.Name}) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesNodeSelectorRequirement returns true if the values match the requirement, an invalid requirement
+// matches nothing
+func matchesNodeSelectorRequirement(requirement corev1.NodeSelectorRequirement, values labels.Set) bool {
+	operator, ok := nodeSelectorOperators[requirement.Operator]
+	if !ok {
+		return false
+	}
+	selectorRequirement, err := labels.NewRequirement(requirement.Key, operator, requirement.Values)
+	if err != nil {
+		return false
+	}
+	return selectorRequirement.Matches(values)
+}
+
+// getCSINodePluginNodeNames returns the sorted names of the nodes as an input of ocs-operator-config
+func getCSINodePluginNodeNames(nodes []corev1.Node) []string {
+	names := []string{}
+	for i := range nodes {
+		names = append(names, nodes[i].Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// restrictTopologyDomainLabelsToCSINodePlugins removes the topology domain labels that only nodes not running the
+// CSI node plugins carry, as the domains of such a label have no CSI presence and must not be advertised. A label
+// that none of the nodes carry is left alone, it is no domain without CSI presence.
+func (r *OCSInitializationReconciler) restrictTopologyDomainLabelsToCSINodePlugins(initialData *ocsv1.OCSInitialization,
+	ocsOperatorConfigData map[string]string, nodes, pluginNodes []corev1.Node) {
+	removed := []string{}
+	if ocsOperatorConfigData[util.EnableTopologyKey] == "true" {
+		domainLabels := []string{}
+		for _, label := range splitTopologyDomainLabels(ocsOperatorConfigData[util.TopologyDomainLabelsKey]) {
+			if isNodeLabelCarried(nodes, label) && !isNodeLabelCarried(pluginNodes, label) {
+				removed = append(removed, label)
+			} else {
+				domainLabels = append(domainLabels, label)
+			}
+		}
+		if len(removed) > 0 {
+			r.Log.Info("Removing the topology domain labels which none of the nodes running the CSI node plugins carry",
+				"RemovedLabels", removed, "DomainLabels", domainLabels)
+			ocsOperatorConfigData[util.TopologyDomainLabelsKey] = strings.Join(domainLabels, ",")
+		}
+	}
+	setOcsOperatorConfigCondition(initialData, ConditionTopologyDomainLabelsWithoutCSINodePlugins, len(removed) > 0,
+		"NoCSINodePluginPresence", fmt.Sprintf("the topology domain labels %s are not carried by any of the nodes running the CSI node plugins",
+			strings.Join(removed, ", ")))
+}
+
+// isNodeLabelCarried returns true if any of the nodes carries the label
+func isNodeLabelCarried(nodes []corev1.Node, label string) bool {
+	return slices.ContainsFunc(nodes, func(node corev1.Node) bool {
+		_, ok := node.Labels[label]
+		return ok
+	})
+}
@@ -0,0 +1,157 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestNodeAffinity(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+	return &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		},
+	}
+}
+
+func TestCSINodePluginNodes(t *testing.T) {
+	storageTaint := corev1.Taint{Key: "node.ocs.openshift.io/storage", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+	storageToleration := corev1.Toleration{Key: "node.ocs.openshift.io/storage", Operator: corev1.TolerationOpEqual,
+		Value: "true", Effect: corev1.TaintEffectNoSchedule}
+
+	testcases := []struct {
+		label       string
+		podSpecs    []corev1.PodSpec
+		expectNodes []string
+	}{
+		{
+			label:       "node plugins are not deployed yet",
+			expectNodes: []string{"node-a", "node-b", "node-c", "not-ready"},
+		},
+		{
+			label:       "node plugin without any placement",
+			podSpecs:    []corev1.PodSpec{{}},
+			expectNodes: []string{"node-a", "node-b", "node-c", "not-ready"},
+		},
+		{
+			label:       "node plugin tolerating the storage taint",
+			podSpecs:    []corev1.PodSpec{{Tolerations: []corev1.Toleration{storageToleration}}},
+			expectNodes: []string{"node-a", "node-b", "node-c", "not-ready", "storage"},
+		},
+		{
+			label: "required node affinity excludes a zone",
+			podSpecs: []corev1.PodSpec{{
+				Affinity: newTestNodeAffinity(corev1.NodeSelectorTerm{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpNotIn, Values: []string{"zone-c"}},
+					},
+				}),
+			}},
+			expectNodes: []string{"node-a", "node-b", "not-ready"},
+		},
+		{
+			label: "node affinity terms are ORed",
+			podSpecs: []corev1.PodSpec{{
+				Affinity: newTestNodeAffinity(
+					corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"zone-a"}},
+					}},
+					corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{
+						{Key: metav1.ObjectNameField, Operator: corev1.NodeSelectorOpIn, Values: []string{"node-c"}},
+					}},
+				),
+			}},
+			expectNodes: []string{"node-a", "node-c"},
+		},
+		{
+			label: "node plugins are scheduled to the union of their nodes",
+			podSpecs: []corev1.PodSpec{
+				{NodeSelector: map[string]string{corev1.LabelTopologyZone: "zone-b"}},
+				{NodeSelector: map[string]string{corev1.LabelTopologyZone: "zone-c"}},
+			},
+			expectNodes: []string{"node-b", "node-c"},
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := []client.Object{
+			newTestZoneNode("node-a", "zone-a"),
+			newTestZoneNode("node-b", "zone-b"),
+			newTestZoneNode("node-c", "zone-c"),
+			// the DaemonSet pods tolerate the taints set by the node lifecycle controller by default
+			newTestNode("not-ready", corev1.Taint{Key: corev1.TaintNodeNotReady, Effect: corev1.TaintEffectNoExecute}),
+			newTestNode("storage", storageTaint),
+		}
+		for i, name := range []string{"csi-rbdplugin", "csi-cephfsplugin"}[:len(tc.podSpecs)] {
+			daemonSet := newTestCSINodePluginDaemonSet(name, nil)
+			daemonSet.Spec.Template.Spec = tc.podSpecs[i]
+			objs = append(objs, daemonSet)
+		}
+		_, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+
+		nodes, err := reconciler.listNodes()
+		assert.NoErrorf(t, err, "[%s]: failed to list the nodes", tc.label)
+		pluginNodes, err := reconciler.getCSINodePluginNodes(nodes)
+		assert.NoErrorf(t, err, "[%s]: failed to get the nodes running the CSI node plugins", tc.label)
+		assert.Equalf(t, tc.expectNodes, getCSINodePluginNodeNames(pluginNodes), "[%s]: unexpected nodes", tc.label)
+	}
+}
+
+func TestTopologyDomainLabelsRestrictedToCSINodePlugins(t *testing.T) {
+	storageNodeLabel := map[string]string{"cluster.ocs.openshift.io/openshift-storage": ""}
+	newTestStorageZoneNode := func(name, zone string) *corev1.Node {
+		node := newTestZoneNode(name, zone)
+		node.Labels["cluster.ocs.openshift.io/openshift-storage"] = ""
+		return node
+	}
+	// only the compute node, which does not run the node plugins, is labeled with a rack
+	computeNode := newTestZoneNode("compute", "zone-a")
+	computeNode.Labels["topology.rook.io/rack"] = "rack-a"
+
+	testcases := []struct {
+		label            string
+		daemonSets       []client.Object
+		expectedLabels   string
+		expectRestricted bool
+	}{
+		{
+			label:          "node plugins run on all the nodes",
+			daemonSets:     []client.Object{newTestCSINodePluginDaemonSet("csi-rbdplugin", nil)},
+			expectedLabels: "topology.kubernetes.io/zone,topology.rook.io/rack",
+		},
+		{
+			label: "node plugins only run on the storage nodes",
+			daemonSets: []client.Object{
+				newTestCSINodePluginDaemonSet("csi-rbdplugin", storageNodeLabel),
+				newTestCSINodePluginDaemonSet("csi-cephfsplugin", storageNodeLabel),
+			},
+			expectedLabels:   "topology.kubernetes.io/zone",
+			expectRestricted: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := append([]client.Object{
+			newTestTopologyStorageCluster(""),
+			newTestConfigMap(OcsOperatorConfigDefaultsName, testOperatorNamespace, map[string]string{
+				util.TopologyDomainLabelsKey: "topology.kubernetes.io/zone,topology.rook.io/rack",
+			}),
+			newTestStorageZoneNode("node-a", "zone-a"),
+			newTestStorageZoneNode("node-b", "zone-b"),
+			computeNode.DeepCopy(),
+		}, tc.daemonSets...)
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		assert.Equalf(t, tc.expectedLabels, getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey],
+			"[%s]: unexpected topology domain labels", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionTopologyDomainLabelsWithoutCSINodePlugins)
+		assert.Equalf(t, tc.expectRestricted, condition != nil,
+			"[%s]: unexpected condition %v", tc.label, condition)
+	}
+}
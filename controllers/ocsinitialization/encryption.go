@@ -0,0 +1,88 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+)
+
+const (
+	// ConditionIncompleteEncryptionConfig is set when a defaults or overrides source configures only some of
+	// the network encryption keys. The keys of such a source are ignored, as CSI would otherwise see a mix
+	// of the old and the new encryption mode.
+	ConditionIncompleteEncryptionConfig conditionsv1.ConditionType = "IncompleteEncryptionConfig"
+)
+
+// encryptionKeys are the ocs-operator-config keys derived from the network encryption mode. They are
+// always computed and applied together, so that a mode switch lands in a single update of the configmap.
+var encryptionKeys = []string{util.EnableNetworkEncryptionKey, util.CephFSKernelMountOptionsKey}
+
+// getEncryptionKeyValues returns the values of all the encryption keys, and the rationale for the chosen ms_mode.
+// Network encryption is enabled when any of the internal storageclusters enables it, external clusters
+// negotiate their own ms_mode.
+func getEncryptionKeyValues(clusters *util.Clusters) (map[string]string, string) {
+	// the kernel mount options are derived from the storagecluster that enables encryption, if any, or else from
+	// the first internal storagecluster, which carries the additional kernel mount options
+	source := &ocsv1.StorageCluster{}
+	if internalStorageClusters := clusters.GetInternalStorageClusters(); len(internalStorageClusters) > 0 {
+		source = &internalStorageClusters[0]
+	}
+	enabled := false
+	for i := range clusters.GetInternalStorageClusters() {
+		sc := &clusters.GetInternalStorageClusters()[i]
+		if sc.Spec.Network != nil && sc.Spec.Network.Connections != nil &&
+			sc.Spec.Network.Connections.Encryption != nil && sc.Spec.Network.Connections.Encryption.Enabled {
+			source = sc
+			enabled = true
+			break
+		}
+	}
+	kernelMountOptions, rationale := util.GetCephFSKernelMountOptions(source)
+	return map[string]string{
+		util.EnableNetworkEncryptionKey:  strconv.FormatBool(enabled),
+		util.CephFSKernelMountOptionsKey: kernelMountOptions,
+	}, rationale
+}
+
+// recordMsModeRationale records why the ms_mode of the resolved config was chosen in the OCSInitialization status
+func (r *OCSInitializationReconciler) recordMsModeRationale(initialData *ocsv1.OCSInitialization,
+	builtInOptions, rationale string, ocsOperatorConfigData map[string]string) {
+	if resolved := ocsOperatorConfigData[util.CephFSKernelMountOptionsKey]; resolved != builtInOptions {
+		rationale = "set by the ocs-operator-config defaults or overrides"
+	}
+	r.Log.V(1).Info("Chose the CephFS kernel mount options", "Options", ocsOperatorConfigData[util.CephFSKernelMountOptionsKey], "Rationale", rationale)
+	initialData.Status.OcsOperatorConfig.MsModeRationale = rationale
+}
+
+// splitEncryptionKeys removes the encryption keys from the values of a defaults or overrides source.
+// They are returned only when the source configures all of them, otherwise complete is false.
+func splitEncryptionKeys(values map[string]string) (remaining, encryption map[string]string, complete bool) {
+	remaining = make(map[string]string, len(values))
+	encryption = make(map[string]string, len(encryptionKeys))
+	for key, value := range values {
+		remaining[key] = value
+	}
+	for _, key := range encryptionKeys {
+		if value, ok := remaining[key]; ok {
+			encryption[key] = value
+			delete(remaining, key)
+		}
+	}
+	if len(encryption) > 0 && len(encryption) < len(encryptionKeys) {
+		return remaining, nil, false
+	}
+	return remaining, encryption, true
+}
+
+// validateEncryptionSources sets or removes the ConditionIncompleteEncryptionConfig condition
+func validateEncryptionSources(initialData *ocsv1.OCSInitialization, incompleteSources []string) {
+	setOcsOperatorConfigCondition(initialData, ConditionIncompleteEncryptionConfig, len(incompleteSources) > 0,
+		"PartialEncryptionKeys",
+		fmt.Sprintf("The keys %s must be configured together, ignoring them from: %s",
+			strings.Join(encryptionKeys, ", "), strings.Join(incompleteSources, "; ")))
+}
@@ -0,0 +1,156 @@
+package ocsinitialization
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func setTestNetworkEncryption(sc *v1.StorageCluster, enabled bool) {
+	sc.Spec.Network = &rookCephv1.NetworkSpec{
+		Connections: &rookCephv1.ConnectionsSpec{
+			Encryption: &rookCephv1.EncryptionSpec{Enabled: enabled},
+		},
+	}
+}
+
+func TestEncryptionKeysSwitchedAtomically(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	setTestNetworkEncryption(sc, true)
+
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, newTestRookCephOperatorPod())
+
+	// record the data of every write of the ocs-operator-config configmap, and the restarts in between
+	writes := []map[string]string{}
+	restarts := 0
+	recordWrite := func(obj client.Object) {
+		if cm, ok := obj.(*corev1.ConfigMap); ok && cm.Name == util.OcsOperatorConfigName {
+			writes = append(writes, cm.Data)
+		}
+	}
+	reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			recordWrite(obj)
+			return c.Create(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			recordWrite(obj)
+			return c.Update(ctx, obj, opts...)
+		},
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			if _, ok := obj.(*corev1.Pod); ok {
+				restarts++
+			}
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "true", data[util.EnableNetworkEncryptionKey])
+	assert.Equal(t, "ms_mode=secure", data[util.CephFSKernelMountOptionsKey])
+	_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, restarts)
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx, newTestRookCephOperatorPod()))
+
+	// switch encryption off
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(sc), sc))
+	setTestNetworkEncryption(sc, false)
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, sc))
+	reconciler.clusters, err = util.GetClusters(reconciler.ctx, reconciler.Client)
+	assert.NoError(t, err)
+	writes = writes[:0]
+
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Len(t, writes, 1, "the mode switch must be applied in a single update")
+	data = getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "false", data[util.EnableNetworkEncryptionKey])
+	assert.Equal(t, "ms_mode=prefer-crc", data[util.CephFSKernelMountOptionsKey])
+	pending := getRookCephOperatorRestartPendingKeys(t, reconciler, util.OcsOperatorConfigName)
+	assert.Contains(t, pending, util.EnableNetworkEncryptionKey)
+	assert.Contains(t, pending, util.CephFSKernelMountOptionsKey)
+
+	_, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, restarts, "the rook-ceph-operator must be restarted once for the whole mode switch")
+
+	// no write ever persisted a mix of the two modes
+	for _, written := range writes {
+		secure := written[util.CephFSKernelMountOptionsKey] == "ms_mode=secure"
+		assert.Equal(t, strconv.FormatBool(secure), written[util.EnableNetworkEncryptionKey], "inconsistent encryption keys %v", written)
+	}
+}
+
+func TestPartialEncryptionKeysIgnored(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	setTestNetworkEncryption(sc, true)
+	defaults := newTestConfigMap(OcsOperatorConfigDefaultsName, testOperatorNamespace, map[string]string{
+		util.CephFSKernelMountOptionsKey: "ms_mode=crc",
+	})
+
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, defaults)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "true", data[util.EnableNetworkEncryptionKey])
+	assert.Equal(t, "ms_mode=secure", data[util.CephFSKernelMountOptionsKey], "a partial default must not be applied")
+	assert.NotNil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionIncompleteEncryptionConfig))
+
+	// setting all the encryption keys together is applied
+	defaults.Data[util.EnableNetworkEncryptionKey] = "false"
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, defaults))
+
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data = getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "false", data[util.EnableNetworkEncryptionKey])
+	assert.Equal(t, "ms_mode=crc", data[util.CephFSKernelMountOptionsKey])
+	assert.Nil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionIncompleteEncryptionConfig))
+}
+
+func TestMsModeRationaleRecorded(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	setTestNetworkEncryption(sc, true)
+
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	_, expected := util.GetCephFSKernelMountOptions(sc)
+	assert.Equal(t, expected, ocsInit.Status.OcsOperatorConfig.MsModeRationale)
+
+	// a default of the encryption keys is reflected in the rationale
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx, newTestConfigMap(OcsOperatorConfigDefaultsName, testOperatorNamespace,
+		map[string]string{util.EnableNetworkEncryptionKey: "true", util.CephFSKernelMountOptionsKey: "ms_mode=crc"})))
+
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "set by the ocs-operator-config defaults or overrides", ocsInit.Status.OcsOperatorConfig.MsModeRationale)
+}
+
+func TestAdditionalKernelMountOptions(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Spec.ManagedResources.CephFilesystems.KernelMountOptions = map[string]string{
+		"wsync": "", "recover_session": "clean", "ms_mode": "legacy",
+	}
+
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx,
+		types.NamespacedName{Name: util.OcsOperatorConfigName, Namespace: testOperatorNamespace}, ocsOperatorConfig))
+	assert.Equal(t, "ms_mode=prefer-crc,recover_session=clean,wsync", ocsOperatorConfig.Data[util.CephFSKernelMountOptionsKey])
+
+	// the merged options do not change between reconciles
+	reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	unchanged := &corev1.ConfigMap{}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(ocsOperatorConfig), unchanged))
+	assert.Equal(t, ocsOperatorConfig.ResourceVersion, unchanged.ResourceVersion)
+}
@@ -0,0 +1,90 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"slices"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// ConditionOcsOperatorConfigNotConsumed is set when the rook-ceph-operator Deployment does not reference
+	// ocs-operator-config with envFrom, in which case Rook ignores all the config changes
+	ConditionOcsOperatorConfigNotConsumed conditionsv1.ConditionType = "OcsOperatorConfigNotConsumed"
+
+	// ConditionRookCephOperatorEnvFromRemediated is set while the envFrom reference to ocs-operator-config
+	// that was added to the rook-ceph-operator Deployment by the remediation is in place
+	ConditionRookCephOperatorEnvFromRemediated conditionsv1.ConditionType = "RookCephOperatorEnvFromRemediated"
+
+	// envFromRemediatedAnnotation is set on the rook-ceph-operator Deployment when the envFrom reference was added
+	envFromRemediatedAnnotation = "ocs.openshift.io/envfrom-remediated"
+)
+
+// reconcileRookCephOperatorEnvFrom checks that the rook-ceph-operator container takes its environment from
+// ocs-operator-config. With RemediateRookCephOperatorEnvFrom set, a missing reference is added to the Deployment,
+// which rolls out the rook-ceph-operator with the config. A reference to the default ocs-operator-config is replaced
+// when the name of the configmap is overridden on a storagecluster. Otherwise a condition is set.
+func (r *OCSInitializationReconciler) reconcileRookCephOperatorEnvFrom(initialData *ocsv1.OCSInitialization) error {
+	deployment := &appsv1.Deployment{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: rookCephOperatorName, Namespace: initialData.Namespace}, deployment)
+	if errors.IsNotFound(err) {
+		setOcsOperatorConfigCondition(initialData, ConditionOcsOperatorConfigNotConsumed, false, "", "")
+		setOcsOperatorConfigCondition(initialData, ConditionRookCephOperatorEnvFromRemediated, false, "", "")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	containers := deployment.Spec.Template.Spec.Containers
+	i := slices.IndexFunc(containers, func(container corev1.Container) bool { return container.Name == rookCephOperatorName })
+	if i < 0 {
+		return fmt.Errorf("deployment %s/%s has no %s container", deployment.Namespace, deployment.Name, rookCephOperatorName)
+	}
+	configName := r.getOcsOperatorConfigName()
+	references := func(name string) func(corev1.EnvFromSource) bool {
+		return func(envFrom corev1.EnvFromSource) bool {
+			return envFrom.ConfigMapRef != nil && envFrom.ConfigMapRef.Name == name
+		}
+	}
+	missing := !slices.ContainsFunc(containers[i].EnvFrom, references(configName))
+
+	if missing && r.RemediateRookCephOperatorEnvFrom {
+		envFrom := corev1.EnvFromSource{
+			ConfigMapRef: &corev1.ConfigMapEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configName},
+			},
+		}
+		if j := slices.IndexFunc(containers[i].EnvFrom, references(util.OcsOperatorConfigName)); j >= 0 {
+			r.Log.Info("Replacing the envFrom reference to ocs-operator-config of the rook-ceph-operator Deployment", "ConfigMap", configName)
+			containers[i].EnvFrom[j] = envFrom
+		} else {
+			r.Log.Info("Adding the missing envFrom reference to ocs-operator-config to the rook-ceph-operator Deployment", "ConfigMap", configName)
+			containers[i].EnvFrom = append(containers[i].EnvFrom, envFrom)
+		}
+		util.AddAnnotation(deployment, envFromRemediatedAnnotation, configName)
+		if err := r.Client.Update(r.ctx, deployment); err != nil {
+			r.Log.Error(err, "Failed to add the envFrom reference to the rook-ceph-operator Deployment")
+			return err
+		}
+		missing = false
+	} else if missing {
+		r.Log.Info("The rook-ceph-operator Deployment does not reference ocs-operator-config, Rook ignores the config changes",
+			"ConfigMap", configName)
+	}
+
+	setOcsOperatorConfigCondition(initialData, ConditionOcsOperatorConfigNotConsumed, missing,
+		"EnvFromMissing", fmt.Sprintf("the %s container of Deployment %s does not take its environment from the %s configmap",
+			rookCephOperatorName, deployment.Name, configName))
+	_, remediated := deployment.GetAnnotations()[envFromRemediatedAnnotation]
+	setOcsOperatorConfigCondition(initialData, ConditionRookCephOperatorEnvFromRemediated, remediated && !missing,
+		"EnvFromAdded", fmt.Sprintf("the missing envFrom reference to the %s configmap was added to Deployment %s",
+			configName, deployment.Name))
+	return nil
+}
@@ -0,0 +1,91 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestRookCephOperatorDeployment(envFrom ...corev1.EnvFromSource) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rookCephOperatorName,
+			Namespace: testOperatorNamespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: rookCephOperatorName, EnvFrom: envFrom}},
+				},
+			},
+		},
+	}
+}
+
+func newTestConfigMapEnvFrom(name string) corev1.EnvFromSource {
+	return corev1.EnvFromSource{
+		ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}},
+	}
+}
+
+func TestRookCephOperatorEnvFrom(t *testing.T) {
+	testcases := []struct {
+		label            string
+		envFrom          []corev1.EnvFromSource
+		remediate        bool
+		expectReference  bool
+		expectNotConsume bool
+		expectRemediated bool
+	}{
+		{
+			label:           "Deployment referencing ocs-operator-config",
+			envFrom:         []corev1.EnvFromSource{newTestConfigMapEnvFrom(util.OcsOperatorConfigName)},
+			remediate:       true,
+			expectReference: true,
+		},
+		{
+			label:            "Deployment missing the reference",
+			envFrom:          []corev1.EnvFromSource{newTestConfigMapEnvFrom(util.RookCephOperatorConfigName)},
+			expectNotConsume: true,
+		},
+		{
+			label:            "Deployment missing the reference is remediated",
+			envFrom:          []corev1.EnvFromSource{newTestConfigMapEnvFrom(util.RookCephOperatorConfigName)},
+			remediate:        true,
+			expectReference:  true,
+			expectRemediated: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		deployment := newTestRookCephOperatorDeployment(tc.envFrom...)
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, deployment)
+		reconciler.RemediateRookCephOperatorEnvFrom = tc.remediate
+
+		// the result is stable across reconciles
+		for range 2 {
+			assert.NoErrorf(t, reconciler.reconcileRookCephOperatorEnvFrom(ocsInit), "[%s]: failed to check envFrom", tc.label)
+
+			assert.NoErrorf(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(deployment), deployment), "[%s]", tc.label)
+			envFrom := deployment.Spec.Template.Spec.Containers[0].EnvFrom
+			assert.Equalf(t, tc.expectReference, len(envFrom) > 0 && envFrom[len(envFrom)-1].ConfigMapRef.Name == util.OcsOperatorConfigName,
+				"[%s]: unexpected envFrom %v", tc.label, envFrom)
+			expectedLen := len(tc.envFrom)
+			if tc.expectRemediated {
+				expectedLen++
+			}
+			assert.Lenf(t, envFrom, expectedLen, "[%s]: existing envFrom not kept", tc.label)
+
+			notConsumed := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionOcsOperatorConfigNotConsumed)
+			assert.Equalf(t, tc.expectNotConsume, notConsumed != nil, "[%s]: unexpected not consumed condition", tc.label)
+			remediated := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionRookCephOperatorEnvFromRemediated)
+			assert.Equalf(t, tc.expectRemediated, remediated != nil, "[%s]: unexpected remediated condition", tc.label)
+		}
+	}
+}
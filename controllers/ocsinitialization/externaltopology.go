@@ -0,0 +1,77 @@
+package ocsinitialization
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ConditionExternalTopologyMissing is set when the non-resilient StorageClass of an external storagecluster
+	// exists, but the external cluster details it was created from do not describe the topology. Topology is
+	// not enabled for such a storagecluster, as CSI could not place the volumes in the topology domains.
+	ConditionExternalTopologyMissing conditionsv1.ConditionType = "ExternalTopologyMissing"
+)
+
+// externalTopologyConstrainedPool is a pool of the topologyConstrainedPools parameter of the non-resilient
+// StorageClass of an external storagecluster
+type externalTopologyConstrainedPool struct {
+	PoolName       string `json:"poolName"`
+	DomainSegments []struct {
+		DomainLabel string `json:"domainLabel"`
+		DomainValue string `json:"value"`
+	} `json:"domainSegments"`
+}
+
+// validateExternalTopologyData returns an error if the parameters of the non-resilient StorageClass of an external
+// storagecluster do not describe the topology, i.e. a known failure domain label and the pools of its domains
+func validateExternalTopologyData(storageClass *storagev1.StorageClass) error {
+	if getFailureDomainKeyFromStorageClassParameter(storageClass) == "" {
+		return fmt.Errorf("unknown topologyFailureDomainLabel %q", storageClass.Parameters["topologyFailureDomainLabel"])
+	}
+	if strings.TrimSpace(storageClass.Parameters["topologyFailureDomainValues"]) == "" {
+		return fmt.Errorf("no topologyFailureDomainValues")
+	}
+	pools := []externalTopologyConstrainedPool{}
+	if err := json.Unmarshal([]byte(storageClass.Parameters["topologyConstrainedPools"]), &pools); err != nil {
+		return fmt.Errorf("invalid topologyConstrainedPools: %v", err)
+	}
+	if len(pools) == 0 {
+		return fmt.Errorf("no topologyConstrainedPools")
+	}
+	for _, pool := range pools {
+		if pool.PoolName == "" || len(pool.DomainSegments) == 0 || pool.DomainSegments[0].DomainValue == "" {
+			return fmt.Errorf("topologyConstrainedPools without a pool name or domain value")
+		}
+	}
+	return nil
+}
+
+// checkExternalTopology sets the ConditionExternalTopologyMissing condition for the external storageclusters whose
+// non-resilient StorageClass exists without the topology of the external cluster. DefaultTopologyResolver does not
+// enable topology for them.
+func (r *OCSInitializationReconciler) checkExternalTopology(initialData *ocsv1.OCSInitialization) {
+	missing := []string{}
+	for i := range r.clusters.GetExternalStorageClusters() {
+		sc := &r.clusters.GetExternalStorageClusters()[i]
+		storageClass := util.GetStorageClassWithName(r.ctx, r.Client, util.GenerateNameForNonResilientCephBlockPoolStorageClass(sc))
+		if storageClass == nil {
+			continue
+		}
+		if err := validateExternalTopologyData(storageClass); err != nil {
+			r.Log.Info("Not enabling topology for the external storagecluster without topology data", "StorageCluster", klog.KObj(sc),
+				"StorageClass", storageClass.Name, "Reason", err.Error())
+			missing = append(missing, fmt.Sprintf("%s/%s: %v", sc.Namespace, sc.Name, err))
+		}
+	}
+	setOcsOperatorConfigCondition(initialData, ConditionExternalTopologyMissing, len(missing) > 0, "ExternalTopologyDataMissing",
+		fmt.Sprintf("topology is not enabled for the external storageclusters without topology data in their external cluster details: %s",
+			strings.Join(missing, "; ")))
+}
@@ -0,0 +1,95 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const testExternalTopologyConstrainedPools = `[{"poolName":"pool-a","domainSegments":[{"domainLabel":"zone","value":"zone-a"}]},` +
+	`{"poolName":"pool-b","domainSegments":[{"domainLabel":"zone","value":"zone-b"}]}]`
+
+func newTestExternalNonResilientStorageClass(parameters map[string]string) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "ocs-storagecluster-ceph-non-resilient-rbd"},
+		Provisioner: util.RbdDriverName,
+		Parameters:  parameters,
+	}
+}
+
+func TestExternalTopologyData(t *testing.T) {
+	testcases := []struct {
+		label           string
+		storageClass    *storagev1.StorageClass
+		expectTopology  bool
+		expectCondition bool
+	}{
+		{
+			label: "external config with topology data",
+			storageClass: newTestExternalNonResilientStorageClass(map[string]string{
+				"topologyFailureDomainLabel":  "zone",
+				"topologyFailureDomainValues": "zone-a,zone-b",
+				"topologyPools":               "pool-a,pool-b",
+				"topologyConstrainedPools":    testExternalTopologyConstrainedPools,
+			}),
+			expectTopology: true,
+		},
+		{
+			label: "external config without a failure domain label",
+			storageClass: newTestExternalNonResilientStorageClass(map[string]string{
+				"topologyFailureDomainValues": "zone-a,zone-b",
+				"topologyPools":               "pool-a,pool-b",
+				"topologyConstrainedPools":    testExternalTopologyConstrainedPools,
+			}),
+			expectCondition: true,
+		},
+		{
+			label: "external config without topology pools",
+			storageClass: newTestExternalNonResilientStorageClass(map[string]string{
+				"topologyFailureDomainLabel":  "zone",
+				"topologyFailureDomainValues": "zone-a",
+				"topologyConstrainedPools":    `[{"poolName":"","domainSegments":[{"domainLabel":"zone","value":"zone-a"}]}]`,
+			}),
+			expectCondition: true,
+		},
+		{
+			label: "external config with invalid topology constrained pools",
+			storageClass: newTestExternalNonResilientStorageClass(map[string]string{
+				"topologyFailureDomainLabel":  "zone",
+				"topologyFailureDomainValues": "zone-a,zone-b",
+				"topologyConstrainedPools":    "pool-a,pool-b",
+			}),
+			expectCondition: true,
+		},
+		{
+			label: "external config without a non-resilient storageclass",
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Spec.ExternalStorage.Enable = true
+		objs := []client.Object{sc}
+		if tc.storageClass != nil {
+			objs = append(objs, tc.storageClass)
+		}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		if tc.expectTopology {
+			assert.Equalf(t, "true", data[util.EnableTopologyKey], "[%s]: topology is not enabled", tc.label)
+			assert.Equalf(t, corev1.LabelTopologyZone, data[util.TopologyDomainLabelsKey], "[%s]: unexpected topology domain labels", tc.label)
+		} else {
+			assert.Equalf(t, "false", data[util.EnableTopologyKey], "[%s]: topology is enabled", tc.label)
+		}
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionExternalTopologyMissing)
+		assert.Equalf(t, tc.expectCondition, condition != nil, "[%s]: unexpected condition %v", tc.label, condition)
+	}
+}
@@ -0,0 +1,109 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultConfigKeyRenameTransitionPeriod is how long the old name of a renamed ocs-operator-config key is written
+const DefaultConfigKeyRenameTransitionPeriod = 30 * 24 * time.Hour
+
+// configKeyRename is the rename of an ocs-operator-config key
+type configKeyRename struct {
+	oldKey string
+	newKey string
+}
+
+// ocsOperatorConfigKeyRenames are the renamed ocs-operator-config keys. The CSI components of the previous version
+// read the old name, so during the transition period of a rename the key is written with both names, after which
+// only the new name is written. A key that is renamed is added here.
+var ocsOperatorConfigKeyRenames = []configKeyRename{}
+
+func (r *OCSInitializationReconciler) getConfigKeyRenameTransitionPeriod() time.Duration {
+	if r.ConfigKeyRenameTransitionPeriod > 0 {
+		return r.ConfigKeyRenameTransitionPeriod
+	}
+	return DefaultConfigKeyRenameTransitionPeriod
+}
+
+// reconcileConfigKeyRenameStatus records the transition of each key rename in the status. The transition starts
+// when the rename is first observed, i.e. after the upgrade to the operator version that renamed the key, and is
+// completed once the transition period has passed.
+func (r *OCSInitializationReconciler) reconcileConfigKeyRenameStatus(initialData *ocsv1.OCSInitialization, now time.Time) {
+	existing := initialData.Status.OcsOperatorConfig.KeyRenames
+	statuses := []ocsv1.ConfigKeyRenameStatus{}
+	for _, rename := range ocsOperatorConfigKeyRenames {
+		idx := slices.IndexFunc(existing, func(status ocsv1.ConfigKeyRenameStatus) bool {
+			return status.OldKey == rename.oldKey && status.NewKey == rename.newKey
+		})
+		var status ocsv1.ConfigKeyRenameStatus
+		if idx >= 0 {
+			status = existing[idx]
+		} else {
+			r.Log.Info("Starting the transition of a renamed ocs-operator-config key", "OldKey", rename.oldKey, "NewKey", rename.newKey,
+				"Period", r.getConfigKeyRenameTransitionPeriod())
+			status = ocsv1.ConfigKeyRenameStatus{OldKey: rename.oldKey, NewKey: rename.newKey, StartTime: metav1.NewTime(now)}
+		}
+		if !status.Completed && !now.Before(status.StartTime.Add(r.getConfigKeyRenameTransitionPeriod())) {
+			r.Log.Info("Transition of a renamed ocs-operator-config key has passed, no longer writing the old key",
+				"OldKey", status.OldKey, "NewKey", status.NewKey)
+			status.Completed = true
+		}
+		statuses = append(statuses, status)
+	}
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	initialData.Status.OcsOperatorConfig.KeyRenames = statuses
+}
+
+// getConfigKeyRenameInput returns the state of the key rename transitions as an input of ocs-operator-config
+func getConfigKeyRenameInput(initialData *ocsv1.OCSInitialization) string {
+	inputs := []string{}
+	for _, status := range initialData.Status.OcsOperatorConfig.KeyRenames {
+		inputs = append(inputs, fmt.Sprintf("%s->%s,completed=%t", status.OldKey, status.NewKey, status.Completed))
+	}
+	return strings.Join(inputs, ";")
+}
+
+// applyConfigKeyRenames writes the value of each renamed key with its old name as well until the transition has
+// passed, and removes the old name afterwards. A value that is only set with the old name, e.g. by the defaults
+// or overrides, is taken for the new name.
+func applyConfigKeyRenames(initialData *ocsv1.OCSInitialization, ocsOperatorConfigData map[string]string) {
+	for _, status := range initialData.Status.OcsOperatorConfig.KeyRenames {
+		value, ok := ocsOperatorConfigData[status.NewKey]
+		if !ok {
+			value, ok = ocsOperatorConfigData[status.OldKey]
+		}
+		if !ok {
+			continue
+		}
+		ocsOperatorConfigData[status.NewKey] = value
+		if status.Completed {
+			delete(ocsOperatorConfigData, status.OldKey)
+		} else {
+			ocsOperatorConfigData[status.OldKey] = value
+		}
+	}
+}
+
+// getConfigKeyRenameRequeueDelay returns the time left until the first transition in progress has passed, after
+// which its old key has to be removed, or zero if there is no transition in progress
+func (r *OCSInitializationReconciler) getConfigKeyRenameRequeueDelay(initialData *ocsv1.OCSInitialization, now time.Time) time.Duration {
+	delay := time.Duration(0)
+	for _, status := range initialData.Status.OcsOperatorConfig.KeyRenames {
+		if status.Completed {
+			continue
+		}
+		if remaining := max(status.StartTime.Add(r.getConfigKeyRenameTransitionPeriod()).Sub(now), time.Second); delay == 0 || remaining < delay {
+			delay = remaining
+		}
+	}
+	return delay
+}
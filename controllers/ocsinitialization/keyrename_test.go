@@ -0,0 +1,107 @@
+package ocsinitialization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testOldEnableNFSKey = "ROOK_CSI_NFS_ENABLE"
+
+func setTestConfigKeyRenames(t *testing.T, renames ...configKeyRename) {
+	previous := ocsOperatorConfigKeyRenames
+	ocsOperatorConfigKeyRenames = renames
+	t.Cleanup(func() { ocsOperatorConfigKeyRenames = previous })
+}
+
+func TestOcsOperatorConfigKeyRename(t *testing.T) {
+	setTestConfigKeyRenames(t, configKeyRename{oldKey: testOldEnableNFSKey, newKey: util.EnableNFSKey})
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+	reconciler.ConfigKeyRenameTransitionPeriod = time.Hour
+
+	// both key names are written with the same value during the transition
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.Contains(t, data, util.EnableNFSKey)
+	assert.Equal(t, data[util.EnableNFSKey], data[testOldEnableNFSKey])
+	assert.Len(t, ocsInit.Status.OcsOperatorConfig.KeyRenames, 1)
+	status := ocsInit.Status.OcsOperatorConfig.KeyRenames[0]
+	assert.Equal(t, testOldEnableNFSKey, status.OldKey)
+	assert.Equal(t, util.EnableNFSKey, status.NewKey)
+	assert.False(t, status.Completed)
+
+	// the transition is not restarted by later reconciles
+	delay := reconciler.getConfigKeyRenameRequeueDelay(ocsInit, status.StartTime.Add(20*time.Minute))
+	assert.Equal(t, 40*time.Minute, delay)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, status.StartTime, ocsInit.Status.OcsOperatorConfig.KeyRenames[0].StartTime)
+	assert.Contains(t, getOcsOperatorConfigData(t, reconciler), testOldEnableNFSKey)
+
+	// only the new key name is written once the transition has passed
+	ocsInit.Status.OcsOperatorConfig.KeyRenames[0].StartTime = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data = getOcsOperatorConfigData(t, reconciler)
+	assert.Contains(t, data, util.EnableNFSKey)
+	assert.NotContains(t, data, testOldEnableNFSKey)
+	assert.True(t, ocsInit.Status.OcsOperatorConfig.KeyRenames[0].Completed)
+	assert.Zero(t, reconciler.getConfigKeyRenameRequeueDelay(ocsInit, time.Now()))
+
+	// the transition ends with the rename
+	setTestConfigKeyRenames(t)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Nil(t, ocsInit.Status.OcsOperatorConfig.KeyRenames)
+}
+
+func TestApplyConfigKeyRenames(t *testing.T) {
+	setTestConfigKeyRenames(t, configKeyRename{oldKey: "OLD_KEY", newKey: "NEW_KEY"})
+	testcases := []struct {
+		label     string
+		completed bool
+		data      map[string]string
+		expected  map[string]string
+	}{
+		{
+			label:    "new key is written with both names",
+			data:     map[string]string{"NEW_KEY": "value"},
+			expected: map[string]string{"NEW_KEY": "value", "OLD_KEY": "value"},
+		},
+		{
+			label:    "new key takes precedence over the old name",
+			data:     map[string]string{"NEW_KEY": "value", "OLD_KEY": "old-value"},
+			expected: map[string]string{"NEW_KEY": "value", "OLD_KEY": "value"},
+		},
+		{
+			label:    "value set with the old name is taken for the new one",
+			data:     map[string]string{"OLD_KEY": "old-value"},
+			expected: map[string]string{"NEW_KEY": "old-value", "OLD_KEY": "old-value"},
+		},
+		{
+			label:    "unset key is not written",
+			data:     map[string]string{},
+			expected: map[string]string{},
+		},
+		{
+			label:     "old name is removed after the transition",
+			completed: true,
+			data:      map[string]string{"NEW_KEY": "value", "OLD_KEY": "old-value"},
+			expected:  map[string]string{"NEW_KEY": "value"},
+		},
+		{
+			label:     "value set with the old name is moved to the new one after the transition",
+			completed: true,
+			data:      map[string]string{"OLD_KEY": "old-value"},
+			expected:  map[string]string{"NEW_KEY": "old-value"},
+		},
+	}
+
+	for _, tc := range testcases {
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t)
+		reconciler.reconcileConfigKeyRenameStatus(ocsInit, time.Now())
+		ocsInit.Status.OcsOperatorConfig.KeyRenames[0].Completed = tc.completed
+		applyConfigKeyRenames(ocsInit, tc.data)
+		assert.Equalf(t, tc.expected, tc.data, "[%s]: unexpected config data", tc.label)
+	}
+}
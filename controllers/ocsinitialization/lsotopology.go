@@ -0,0 +1,114 @@
+package ocsinitialization
+
+import (
+	"context"
+	"slices"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// lsoProvisioner is the provisioner of the StorageClasses of the local volumes created by the Local Storage Operator
+const lsoProvisioner = "kubernetes.io/no-provisioner"
+
+// getStorageDeviceSetStorageClassNames returns the names of the StorageClasses of the device sets of the storagecluster
+func getStorageDeviceSetStorageClassNames(sc *ocsv1.StorageCluster) []string {
+	names := sets.New[string]()
+	for _, deviceSet := range sc.Spec.StorageDeviceSets {
+		for _, template := range []*corev1.PersistentVolumeClaim{&deviceSet.DataPVCTemplate, deviceSet.MetadataPVCTemplate, deviceSet.WalPVCTemplate} {
+			if template == nil {
+				continue
+			}
+			if name := template.Spec.StorageClassName; name != nil && *name != "" {
+				names.Insert(*name)
+			}
+		}
+	}
+	return sets.List(names)
+}
+
+// getLocalVolumeNodeNames returns the names of the nodes the local volume is bound to by its node affinity
+func getLocalVolumeNodeNames(pv *corev1.PersistentVolume) []string {
+	if pv.Spec.Local == nil || pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return nil
+	}
+	nodeNames := []string{}
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expression := range term.MatchExpressions {
+			if expression.Key == corev1.LabelHostname && expression.Operator == corev1.NodeSelectorOpIn {
+				nodeNames = append(nodeNames, expression.Values...)
+			}
+		}
+	}
+	return nodeNames
+}
+
+// getLSODeviceNodeNames returns the sorted names of the nodes holding the local disks of the OSDs of the
+// storagecluster, which are the nodes of the bound local volumes of its LSO device set StorageClasses.
+// It is empty if the storagecluster does not use LSO devices.
+func getLSODeviceNodeNames(ctx context.Context, cl client.Client, sc *ocsv1.StorageCluster) ([]string, error) {
+	lsoStorageClassNames := []string{}
+	for _, name := range getStorageDeviceSetStorageClassNames(sc) {
+		storageClass := &storagev1.StorageClass{}
+		err := cl.Get(ctx, types.NamespacedName{Name: name}, storageClass)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if storageClass.Provisioner == lsoProvisioner {
+			lsoStorageClassNames = append(lsoStorageClassNames, name)
+		}
+	}
+	if len(lsoStorageClassNames) == 0 {
+		return nil, nil
+	}
+
+	pvList := &corev1.PersistentVolumeList{}
+	if err := cl.List(ctx, pvList); err != nil {
+		return nil, err
+	}
+	nodeNames := sets.New[string]()
+	for i := range pvList.Items {
+		pv := &pvList.Items[i]
+		if pv.Status.Phase != corev1.VolumeBound || !slices.Contains(lsoStorageClassNames, pv.Spec.StorageClassName) ||
+			pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.Namespace != sc.Namespace {
+			continue
+		}
+		nodeNames.Insert(getLocalVolumeNodeNames(pv)...)
+	}
+	return sets.List(nodeNames), nil
+}
+
+// resolveLSODeviceDomainLabel returns the topology domain label that reflects the placement of the local disks
+// of a storagecluster using LSO devices. The failure domain label is kept if all the nodes holding the disks
+// carry it. Otherwise the failure domain label does not map to the disks, and as the local volumes are bound
+// to their nodes, each node holding disks is its own domain. The labels of the nodes are taken with the topology
+// node group overrides applied.
+func resolveLSODeviceDomainLabel(ctx context.Context, cl client.Client, sc *ocsv1.StorageCluster, domainLabel string,
+	overrides []topologyNodeGroupOverride) (string, error) {
+	nodeNames, err := getLSODeviceNodeNames(ctx, cl, sc)
+	if err != nil || len(nodeNames) == 0 || domainLabel == corev1.LabelHostname {
+		return domainLabel, err
+	}
+	for _, nodeName := range nodeNames {
+		node := &corev1.Node{}
+		err := cl.Get(ctx, types.NamespacedName{Name: nodeName}, node)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		node = applyTopologyNodeGroupOverrides(overrides, node)
+		if _, ok := node.Labels[domainLabel]; domainLabel == "" || !ok {
+			return corev1.LabelHostname, nil
+		}
+	}
+	return domainLabel, nil
+}
@@ -0,0 +1,138 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const testLSOStorageClassName = "localblock"
+
+func newTestLSOStorageCluster(failureDomainKey, storageClassName string) *v1.StorageCluster {
+	sc := newTestTopologyStorageCluster(failureDomainKey)
+	sc.Spec.StorageDeviceSets = []v1.StorageDeviceSet{{
+		Name: "ocs-deviceset",
+		DataPVCTemplate: corev1.PersistentVolumeClaim{
+			Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: ptr.To(storageClassName)},
+		},
+	}}
+	return sc
+}
+
+func newTestLocalVolume(name, nodeName, claimNamespace string) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{
+			StorageClassName:       testLSOStorageClassName,
+			PersistentVolumeSource: corev1.PersistentVolumeSource{Local: &corev1.LocalVolumeSource{Path: "/mnt/local-storage/localblock/sdb"}},
+			ClaimRef:               &corev1.ObjectReference{Namespace: claimNamespace, Name: name + "-claim"},
+			NodeAffinity: &corev1.VolumeNodeAffinity{
+				Required: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key:      corev1.LabelHostname,
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{nodeName},
+						}},
+					}},
+				},
+			},
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+	}
+}
+
+func newTestRackNode(name, rack string) *corev1.Node {
+	node := newTestNode(name)
+	node.Labels = map[string]string{corev1.LabelHostname: name}
+	if rack != "" {
+		node.Labels["topology.rook.io/rack"] = rack
+	}
+	return node
+}
+
+func TestTopologyWithLSODeviceLocality(t *testing.T) {
+	lsoStorageClass := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: testLSOStorageClassName}, Provisioner: lsoProvisioner}
+	dynamicStorageClass := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "gp3-csi"}, Provisioner: "ebs.csi.aws.com"}
+	nodes := []client.Object{newTestRackNode("node-a", "rack0"), newTestRackNode("node-b", "rack1"), newTestRackNode("node-c", "")}
+
+	testcases := []struct {
+		label          string
+		storageCluster *v1.StorageCluster
+		volumes        []client.Object
+		expectedLabels string
+	}{
+		{
+			label:          "dynamically provisioned devices",
+			storageCluster: newTestLSOStorageCluster("topology.rook.io/rack", dynamicStorageClass.Name),
+			expectedLabels: "topology.rook.io/rack",
+		},
+		{
+			label:          "LSO devices on nodes carrying the failure domain label",
+			storageCluster: newTestLSOStorageCluster("topology.rook.io/rack", testLSOStorageClassName),
+			volumes: []client.Object{
+				newTestLocalVolume("local-pv-a", "node-a", testOperatorNamespace),
+				newTestLocalVolume("local-pv-b", "node-b", testOperatorNamespace),
+			},
+			expectedLabels: "topology.rook.io/rack",
+		},
+		{
+			label:          "LSO devices on a node without the failure domain label",
+			storageCluster: newTestLSOStorageCluster("topology.rook.io/rack", testLSOStorageClassName),
+			volumes: []client.Object{
+				newTestLocalVolume("local-pv-a", "node-a", testOperatorNamespace),
+				newTestLocalVolume("local-pv-c", "node-c", testOperatorNamespace),
+			},
+			expectedLabels: corev1.LabelHostname,
+		},
+		{
+			label:          "local volumes not claimed by the storagecluster are ignored",
+			storageCluster: newTestLSOStorageCluster("topology.rook.io/rack", testLSOStorageClassName),
+			volumes: []client.Object{
+				newTestLocalVolume("local-pv-a", "node-a", testOperatorNamespace),
+				newTestLocalVolume("local-pv-c", "node-c", "other-namespace"),
+			},
+			expectedLabels: "topology.rook.io/rack",
+		},
+		{
+			label:          "LSO devices with host failure domain",
+			storageCluster: newTestLSOStorageCluster(corev1.LabelHostname, testLSOStorageClassName),
+			volumes:        []client.Object{newTestLocalVolume("local-pv-c", "node-c", testOperatorNamespace)},
+			expectedLabels: corev1.LabelHostname,
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := append([]client.Object{tc.storageCluster, lsoStorageClass, dynamicStorageClass}, nodes...)
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, append(objs, tc.volumes...)...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		assert.Equalf(t, "true", data[util.EnableTopologyKey], "[%s]: unexpected topology", tc.label)
+		assert.Equalf(t, tc.expectedLabels, data[util.TopologyDomainLabelsKey], "[%s]: unexpected topology domain labels", tc.label)
+	}
+}
+
+func TestTopologyFollowsNewLSODevices(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t,
+		newTestLSOStorageCluster("topology.rook.io/rack", testLSOStorageClassName),
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: testLSOStorageClassName}, Provisioner: lsoProvisioner},
+		newTestRackNode("node-a", "rack0"),
+		newTestRackNode("node-c", ""),
+		newTestLocalVolume("local-pv-a", "node-a", testOperatorNamespace),
+	)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "topology.rook.io/rack", getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey])
+
+	// a disk bound on a node without the failure domain label changes the inputs of the config
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx, newTestLocalVolume("local-pv-c", "node-c", testOperatorNamespace)))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, corev1.LabelHostname, getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey])
+}
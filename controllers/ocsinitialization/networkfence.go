@@ -0,0 +1,47 @@
+package ocsinitialization
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// NetworkFenceClassCrdName is the name of the csi-addons CRD that configures network fencing
+	NetworkFenceClassCrdName = "networkfenceclasses.csiaddons.openshift.io"
+)
+
+var networkFenceClassGVK = schema.GroupVersionKind{
+	Group:   "csiaddons.openshift.io",
+	Version: "v1alpha1",
+	Kind:    "NetworkFenceClass",
+}
+
+// isNetworkFencingConfigured returns true if there is a NetworkFenceClass for one of the ceph CSI drivers
+func (r *OCSInitializationReconciler) isNetworkFencingConfigured() (bool, error) {
+	networkFenceClasses, err := r.listNetworkFenceClasses()
+	if err != nil {
+		return false, err
+	}
+	for _, networkFenceClass := range networkFenceClasses {
+		provisioner, _, _ := unstructured.NestedString(networkFenceClass.Object, "spec", "provisioner")
+		if strings.HasSuffix(provisioner, "rbd.csi.ceph.com") || strings.HasSuffix(provisioner, "cephfs.csi.ceph.com") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// listNetworkFenceClasses returns the NetworkFenceClasses, if the csi-addons CRD is installed
+func (r *OCSInitializationReconciler) listNetworkFenceClasses() ([]unstructured.Unstructured, error) {
+	if !r.AvailableCrds[NetworkFenceClassCrdName] {
+		return nil, nil
+	}
+	networkFenceClasses := &unstructured.UnstructuredList{}
+	networkFenceClasses.SetGroupVersionKind(networkFenceClassGVK.GroupVersion().WithKind("NetworkFenceClassList"))
+	if err := r.Client.List(r.ctx, networkFenceClasses); err != nil {
+		return nil, err
+	}
+	return networkFenceClasses.Items, nil
+}
@@ -0,0 +1,63 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestNetworkFenceClass(name, provisioner string) *unstructured.Unstructured {
+	networkFenceClass := &unstructured.Unstructured{}
+	networkFenceClass.SetGroupVersionKind(networkFenceClassGVK)
+	networkFenceClass.SetName(name)
+	_ = unstructured.SetNestedField(networkFenceClass.Object, provisioner, "spec", "provisioner")
+	return networkFenceClass
+}
+
+func TestNetworkFencingKey(t *testing.T) {
+	testcases := []struct {
+		label         string
+		crdAvailable  bool
+		objs          []client.Object
+		expectFencing bool
+	}{
+		{
+			label:         "csi-addons CRD is not installed",
+			crdAvailable:  false,
+			expectFencing: false,
+		},
+		{
+			label:         "no NetworkFenceClass",
+			crdAvailable:  true,
+			expectFencing: false,
+		},
+		{
+			label:         "NetworkFenceClass for another driver",
+			crdAvailable:  true,
+			objs:          []client.Object{newTestNetworkFenceClass("other", "other.csi.example.com")},
+			expectFencing: false,
+		},
+		{
+			label:         "NetworkFenceClass for the ceph rbd driver",
+			crdAvailable:  true,
+			objs:          []client.Object{newTestNetworkFenceClass("rbd", "openshift-storage.rbd.csi.ceph.com")},
+			expectFencing: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := append(tc.objs, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		reconciler.AvailableCrds = map[string]bool{NetworkFenceClassCrdName: tc.crdAvailable}
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		value, ok := getOcsOperatorConfigData(t, reconciler)[util.EnableNetworkFencingKey]
+		assert.Equalf(t, tc.expectFencing, ok, "[%s]: unexpected presence of the network fencing key", tc.label)
+		if tc.expectFencing {
+			assert.Equalf(t, "true", value, "[%s]: unexpected value of the network fencing key", tc.label)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// topologyNodeLabels holds the node labels that the last resolved ocs-operator-config was derived from, so that
+// the Node watch only triggers a reconcile when one of them changes
+type topologyNodeLabels struct {
+	// mutex serializes the access of the reconciles and the Node watch
+	mutex sync.Mutex
+	// labels are the node labels of the last resolved ocs-operator-config
+	labels sets.Set[string]
+}
+
+// get returns the node labels of the last resolved ocs-operator-config, along with the ones that are always used
+func (t *topologyNodeLabels) get() sets.Set[string] {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return getTopologyNodeLabels(nil, "").Union(t.labels)
+}
+
+// set records the node labels of a resolved ocs-operator-config
+func (t *topologyNodeLabels) set(labels sets.Set[string]) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.labels = labels
+}
+
+// getTopologyNodeLabels returns the node labels that the topology config is derived from: the zone label, the
+// labels of the topology label renames, the topology domain labels of the platform defaults and the given ones,
+// and the failure domain label, the CRUSH hierarchy labels and the labels that the topology node group overrides
+// of the storageclusters select and set
+func getTopologyNodeLabels(storageClusters []ocsv1.StorageCluster, domainLabels string) sets.Set[string] {
+	nodeLabels := sets.New(corev1.LabelTopologyZone)
+	for _, rename := range topologyLabelRenames {
+		nodeLabels.Insert(rename.oldLabel, rename.newLabel)
+	}
+	for _, defaults := range platformDefaults {
+		nodeLabels.Insert(splitTopologyDomainLabels(defaults[util.TopologyDomainLabelsKey])...)
+	}
+	nodeLabels.Insert(splitTopologyDomainLabels(domainLabels)...)
+
+	for i := range storageClusters {
+		sc := &storageClusters[i]
+		if sc.Status.FailureDomainKey != "" {
+			nodeLabels.Insert(sc.Status.FailureDomainKey)
+		}
+		for _, mapping := range sc.Spec.CrushHierarchy {
+			nodeLabels.Insert(mapping.Label)
+		}
+		for _, override := range sc.Spec.TopologyNodeGroupOverrides {
+			nodeLabels.Insert(slices.Collect(maps.Keys(override.NodeSelector.MatchLabels))...)
+			for _, expression := range override.NodeSelector.MatchExpressions {
+				nodeLabels.Insert(expression.Key)
+			}
+			nodeLabels.Insert(slices.Collect(maps.Keys(override.TopologyLabels))...)
+		}
+	}
+	return nodeLabels
+}
+
+// isTopologyNodeLabelChanged returns true if any of the node labels differs between the nodes
+func isTopologyNodeLabelChanged(oldNode, newNode *corev1.Node, nodeLabels sets.Set[string]) bool {
+	for label := range nodeLabels {
+		oldValue, oldOk := oldNode.Labels[label]
+		newValue, newOk := newNode.Labels[label]
+		if oldOk != newOk || oldValue != newValue {
+			return true
+		}
+	}
+	return false
+}
+
+// formatNodeTopologyLabels returns the values of the node labels of all the nodes as an input of ocs-operator-config
+func formatNodeTopologyLabels(nodes []corev1.Node, nodeLabels sets.Set[string]) string {
+	labels := sets.List(nodeLabels)
+	formatted := []string{}
+	for i := range nodes {
+		values := []string{}
+		for _, label := range labels {
+			if value, ok := nodes[i].Labels[label]; ok {
+				values = append(values, fmt.Sprintf("%s=%s", label, value))
+			}
+		}
+		formatted = append(formatted, fmt.Sprintf("%s[%s]", nodes[i].Name, strings.Join(values, ",")))
+	}
+	slices.Sort(formatted)
+	return strings.Join(formatted, ";")
+}
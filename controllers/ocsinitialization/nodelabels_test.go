@@ -0,0 +1,89 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTopologyNodeLabels(t *testing.T) {
+	sc := newTestTopologyStorageCluster("example.com/row")
+	sc.Spec.CrushHierarchy = []v1.CrushBucketMapping{{Label: "example.com/room", BucketType: "room"}}
+	sc.Spec.TopologyNodeGroupOverrides = []v1.TopologyNodeGroupOverride{{
+		Name: "edge",
+		NodeSelector: metav1.LabelSelector{
+			MatchLabels: map[string]string{"example.com/edge": "true"},
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "example.com/site", Operator: metav1.LabelSelectorOpExists},
+			},
+		},
+		TopologyLabels: map[string]string{"example.com/pod": "edge"},
+	}}
+
+	nodeLabels := getTopologyNodeLabels([]v1.StorageCluster{*sc}, "kubernetes.io/hostname,example.com/chassis")
+	for _, label := range []string{
+		corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone, "topology.rook.io/rack", "kubernetes.io/hostname",
+		"example.com/chassis", "example.com/row", "example.com/room", "example.com/edge", "example.com/site", "example.com/pod",
+	} {
+		assert.Truef(t, nodeLabels.Has(label), "label %s is expected to be a topology node label", label)
+	}
+	assert.False(t, nodeLabels.Has("example.com/unrelated"))
+
+	testcases := []struct {
+		label         string
+		oldLabels     map[string]string
+		newLabels     map[string]string
+		expectChanged bool
+	}{
+		{
+			label:         "rack label changed",
+			oldLabels:     map[string]string{"topology.rook.io/rack": "rack-a"},
+			newLabels:     map[string]string{"topology.rook.io/rack": "rack-b"},
+			expectChanged: true,
+		},
+		{
+			label:         "CRUSH hierarchy label added",
+			oldLabels:     map[string]string{},
+			newLabels:     map[string]string{"example.com/room": "room-1"},
+			expectChanged: true,
+		},
+		{
+			label:         "override group label removed",
+			oldLabels:     map[string]string{"example.com/edge": "true"},
+			newLabels:     map[string]string{},
+			expectChanged: true,
+		},
+		{
+			label:     "unrelated label changed",
+			oldLabels: map[string]string{"example.com/unrelated": "a"},
+			newLabels: map[string]string{"example.com/unrelated": "b"},
+		},
+	}
+
+	for _, tc := range testcases {
+		oldNode, newNode := newTestNode("worker-0"), newTestNode("worker-0")
+		oldNode.Labels, newNode.Labels = tc.oldLabels, tc.newLabels
+		assert.Equalf(t, tc.expectChanged, isTopologyNodeLabelChanged(oldNode, newNode, nodeLabels), "[%s]", tc.label)
+	}
+}
+
+func TestOcsOperatorConfigResolvedOnTopologyNodeLabelChange(t *testing.T) {
+	nodes := []*corev1.Node{newTestNode("worker-0"), newTestNode("worker-1"), newTestNode("worker-2")}
+	for _, node := range nodes {
+		node.Labels = map[string]string{"topology.rook.io/rack": "rack-a"}
+	}
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestTopologyStorageCluster("topology.rook.io/rack"),
+		nodes[0], nodes[1], nodes[2])
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	hash := reconciler.lastOcsOperatorConfig.inputsHash
+	assert.True(t, reconciler.topologyNodeLabels.get().Has("topology.rook.io/rack"))
+
+	// the inputs change with the rack of a node, which is not a zone
+	nodes[2].Labels["topology.rook.io/rack"] = "rack-b"
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, nodes[2]))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.NotEqual(t, hash, reconciler.lastOcsOperatorConfig.inputsHash)
+}
@@ -0,0 +1,108 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	"github.com/blang/semver/v4"
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ConditionKernelMountOptionsUnsupported is set when some of the CephFS kernel mount options are not
+	// supported by the OS image of a node, in which case they are removed from the config
+	ConditionKernelMountOptionsUnsupported conditionsv1.ConditionType = "KernelMountOptionsUnsupported"
+)
+
+// kernelMountOptionMinRHCOSVersions holds the minimum RHCOS version whose kernel supports a CephFS kernel
+// mount option. Options that are not listed are supported by all the RHCOS versions.
+var kernelMountOptionMinRHCOSVersions = map[string]semver.Version{
+	// the msgr2 protocol of the ms_mode option is supported by the kernel from RHCOS 4.10
+	"ms_mode": semver.MustParse("4.10.0"),
+}
+
+// rhcosOSImageRegexp matches the OS image of RHCOS nodes, e.g. "Red Hat Enterprise Linux CoreOS 414.92.202310210434-0 (Plow)",
+// whose first version component is the major and minor OCP version the image belongs to
+var rhcosOSImageRegexp = regexp.MustCompile(`CoreOS (\d)(\d+)\.`)
+
+// getRHCOSVersion returns the RHCOS version of the OS image of a node, and false if it is not an RHCOS image
+func getRHCOSVersion(osImage string) (semver.Version, bool) {
+	match := rhcosOSImageRegexp.FindStringSubmatch(osImage)
+	if match == nil {
+		return semver.Version{}, false
+	}
+	version, err := semver.ParseTolerant(match[1] + "." + match[2])
+	if err != nil {
+		return semver.Version{}, false
+	}
+	return version, true
+}
+
+// getNodeOSImages returns the sorted, distinct OS images of the schedulable nodes
+func getNodeOSImages(nodes []corev1.Node) []string {
+	osImages := []string{}
+	for i := range nodes {
+		if isSchedulableNode(&nodes[i]) && nodes[i].Status.NodeInfo.OSImage != "" {
+			osImages = append(osImages, nodes[i].Status.NodeInfo.OSImage)
+		}
+	}
+	slices.Sort(osImages)
+	return slices.Compact(osImages)
+}
+
+// validateKernelMountOptionsOnNodeOSImages removes the CephFS kernel mount options that the OS image of any of
+// the schedulable nodes does not support, as the volumes could not be mounted on those nodes. Nodes that do not
+// run RHCOS are not validated.
+func (r *OCSInitializationReconciler) validateKernelMountOptionsOnNodeOSImages(initialData *ocsv1.OCSInitialization,
+	ocsOperatorConfigData map[string]string, nodes []corev1.Node) {
+	mountOptions := ocsOperatorConfigData[util.CephFSKernelMountOptionsKey]
+	if mountOptions == "" {
+		setOcsOperatorConfigCondition(initialData, ConditionKernelMountOptionsUnsupported, false, "", "")
+		return
+	}
+	osImages := getNodeOSImages(nodes)
+
+	supported := []string{}
+	unsupported := []string{}
+	incompatibleImages := []string{}
+	for _, option := range strings.Split(mountOptions, ",") {
+		name, _, _ := strings.Cut(option, "=")
+		minVersion, ok := kernelMountOptionMinRHCOSVersions[name]
+		if !ok {
+			supported = append(supported, option)
+			continue
+		}
+		images := slices.DeleteFunc(slices.Clone(osImages), func(osImage string) bool {
+			version, isRHCOS := getRHCOSVersion(osImage)
+			return !isRHCOS || version.GTE(minVersion)
+		})
+		if len(images) == 0 {
+			supported = append(supported, option)
+			continue
+		}
+		unsupported = append(unsupported, option)
+		incompatibleImages = append(incompatibleImages, images...)
+	}
+	slices.Sort(incompatibleImages)
+	incompatibleImages = slices.Compact(incompatibleImages)
+
+	if len(unsupported) > 0 {
+		r.Log.Info("Removing the CephFS kernel mount options not supported by the OS image of some of the nodes",
+			"Options", unsupported, "OSImages", incompatibleImages)
+		if len(supported) > 0 {
+			ocsOperatorConfigData[util.CephFSKernelMountOptionsKey] = strings.Join(supported, ",")
+		} else {
+			delete(ocsOperatorConfigData, util.CephFSKernelMountOptionsKey)
+		}
+	}
+	setOcsOperatorConfigCondition(initialData, ConditionKernelMountOptionsUnsupported, len(unsupported) > 0,
+		"IncompatibleNodeOSImage",
+		fmt.Sprintf("The CephFS kernel mount options %s are not supported by the node OS images %s",
+			strings.Join(unsupported, ","), strings.Join(incompatibleImages, ", ")))
+}
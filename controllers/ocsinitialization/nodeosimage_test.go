@@ -0,0 +1,114 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestOSImageNode(name, osImage string, taints ...corev1.Taint) *corev1.Node {
+	node := newTestNode(name, taints...)
+	node.Status.NodeInfo.OSImage = osImage
+	return node
+}
+
+func TestKernelMountOptionsOnNodeOSImages(t *testing.T) {
+	const (
+		rhcos414 = "Red Hat Enterprise Linux CoreOS 414.92.202310210434-0 (Plow)"
+		rhcos49  = "Red Hat Enterprise Linux CoreOS 49.84.202201102104-0 (Ootpa)"
+		rhel     = "Red Hat Enterprise Linux 8.6 (Ootpa)"
+	)
+	masterTaint := corev1.Taint{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule}
+
+	testcases := []struct {
+		label                string
+		nodes                []client.Object
+		overrides            string
+		expectedMountOptions string
+		expectCondition      bool
+	}{
+		{
+			label: "nodes running compatible OS images",
+			nodes: []client.Object{
+				newTestOSImageNode("node1", rhcos414),
+				newTestOSImageNode("node2", rhcos414),
+			},
+			expectedMountOptions: "ms_mode=prefer-crc",
+		},
+		{
+			label: "nodes that do not run RHCOS are not validated",
+			nodes: []client.Object{
+				newTestOSImageNode("node1", rhcos414),
+				newTestOSImageNode("node2", rhel),
+				newTestOSImageNode("node3", ""),
+			},
+			expectedMountOptions: "ms_mode=prefer-crc",
+		},
+		{
+			label: "unschedulable node running an incompatible OS image",
+			nodes: []client.Object{
+				newTestOSImageNode("node1", rhcos414),
+				newTestOSImageNode("node2", rhcos49, masterTaint),
+			},
+			expectedMountOptions: "ms_mode=prefer-crc",
+		},
+		{
+			label: "node running an incompatible OS image",
+			nodes: []client.Object{
+				newTestOSImageNode("node1", rhcos414),
+				newTestOSImageNode("node2", rhcos49),
+			},
+			expectCondition: true,
+		},
+		{
+			label: "supported mount options are kept",
+			nodes: []client.Object{
+				newTestOSImageNode("node1", rhcos414),
+				newTestOSImageNode("node2", rhcos49),
+			},
+			overrides:            "ms_mode=prefer-crc,recover_session=clean",
+			expectedMountOptions: "recover_session=clean",
+			expectCondition:      true,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		if tc.overrides != "" {
+			// the encryption keys are only overridden together
+			sc.Annotations = map[string]string{
+				OcsOperatorConfigOverridesAnnotation: `{"` + util.EnableNetworkEncryptionKey + `":"false","` +
+					util.CephFSKernelMountOptionsKey + `":"` + tc.overrides + `"}`,
+			}
+		}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, append(tc.nodes, sc)...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		if tc.expectedMountOptions == "" {
+			assert.NotContainsf(t, data, util.CephFSKernelMountOptionsKey, "[%s]: unexpected kernel mount options", tc.label)
+		} else {
+			assert.Equalf(t, tc.expectedMountOptions, data[util.CephFSKernelMountOptionsKey], "[%s]: unexpected kernel mount options", tc.label)
+		}
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionKernelMountOptionsUnsupported)
+		assert.Equalf(t, tc.expectCondition, condition != nil && condition.Status == corev1.ConditionTrue,
+			"[%s]: unexpected condition %v", tc.label, condition)
+		if tc.expectCondition {
+			assert.Containsf(t, condition.Message, rhcos49, "[%s]: the incompatible OS image is not reported", tc.label)
+		}
+	}
+}
+
+func TestRHCOSVersionOfOSImage(t *testing.T) {
+	version, ok := getRHCOSVersion("Red Hat Enterprise Linux CoreOS 416.94.202405291527-0 (Plow)")
+	assert.True(t, ok)
+	assert.Equal(t, "4.16.0", version.String())
+
+	_, ok = getRHCOSVersion("Red Hat Enterprise Linux 8.6 (Ootpa)")
+	assert.False(t, ok)
+}
@@ -0,0 +1,129 @@
+package ocsinitialization
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// servingCertSecretAnnotation is the annotation of the RGW service that has OpenShift issue its serving certificate
+const servingCertSecretAnnotation = "service.beta.openshift.io/serving-cert-secret-name"
+
+// cephObjectStoreEndpointsChangedPredicate passes the updates of the endpoints in the CephObjectStore status,
+// which do not change the generation
+var cephObjectStoreEndpointsChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldStore, oldOk := e.ObjectOld.(*rookCephv1.CephObjectStore)
+		newStore, newOk := e.ObjectNew.(*rookCephv1.CephObjectStore)
+		if !oldOk || !newOk {
+			return false
+		}
+		return !slices.Equal(oldStore.Status.Endpoints.Secure, newStore.Status.Endpoints.Secure) ||
+			!slices.Equal(oldStore.Status.Endpoints.Insecure, newStore.Status.Endpoints.Insecure)
+	},
+}
+
+// getManagedCephObjectStore returns the CephObjectStore managed by the first internal storagecluster that has
+// one, or nil if there is none
+func (r *OCSInitializationReconciler) getManagedCephObjectStore() (*rookCephv1.CephObjectStore, error) {
+	for i := range r.clusters.GetInternalStorageClusters() {
+		sc := &r.clusters.GetInternalStorageClusters()[i]
+		cephObjectStore := &rookCephv1.CephObjectStore{}
+		err := r.Client.Get(r.ctx, types.NamespacedName{Name: util.GenerateNameForCephObjectStore(sc), Namespace: sc.Namespace}, cephObjectStore)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return cephObjectStore, nil
+	}
+	return nil, nil
+}
+
+// getRgwKeyValues returns the endpoint and TLS config of the managed CephObjectStore for object bucket
+// provisioning. No keys are returned if there is no object store, or Rook has not published its endpoints yet.
+// The secure endpoint is preferred over the insecure one.
+func (r *OCSInitializationReconciler) getRgwKeyValues() (map[string]string, error) {
+	cephObjectStore, err := r.getManagedCephObjectStore()
+	if err != nil || cephObjectStore == nil {
+		return nil, err
+	}
+
+	endpoints := cephObjectStore.Status.Endpoints
+	keyValues := map[string]string{}
+	switch {
+	case len(endpoints.Secure) > 0:
+		keyValues[util.RgwEndpointKey] = endpoints.Secure[0]
+	case len(endpoints.Insecure) > 0:
+		keyValues[util.RgwEndpointKey] = endpoints.Insecure[0]
+	default:
+		r.Log.Info("The CephObjectStore has no endpoints yet, skipping the RGW keys", "CephObjectStore", cephObjectStore.Name)
+		return nil, nil
+	}
+	secure := len(endpoints.Secure) > 0
+	keyValues[util.RgwTLSEnabledKey] = strconv.FormatBool(secure)
+	if !secure {
+		return keyValues, nil
+	}
+
+	gateway := cephObjectStore.Spec.Gateway
+	certSecret := gateway.SSLCertificateRef
+	if certSecret == "" && gateway.Service != nil {
+		certSecret = gateway.Service.Annotations[servingCertSecretAnnotation]
+	}
+	if certSecret != "" {
+		keyValues[util.RgwTLSCertSecretKey] = certSecret
+	}
+	if gateway.CaBundleRef != "" {
+		keyValues[util.RgwCABundleSecretKey] = gateway.CaBundleRef
+	}
+	return keyValues, nil
+}
+
+// getManagedCephObjectZone returns the CephObjectZone of the managed CephObjectStore if it is part of an RGW
+// multisite configuration, or nil for a single-site object store
+func (r *OCSInitializationReconciler) getManagedCephObjectZone(cephObjectStore *rookCephv1.CephObjectStore) (*rookCephv1.CephObjectZone, error) {
+	if cephObjectStore == nil || cephObjectStore.Spec.Zone.Name == "" {
+		return nil, nil
+	}
+	cephObjectZone := &rookCephv1.CephObjectZone{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: cephObjectStore.Spec.Zone.Name, Namespace: cephObjectStore.Namespace}, cephObjectZone)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return cephObjectZone, nil
+}
+
+// getRgwMultisiteKeyValues returns the zone and zonegroup of the managed CephObjectStore for object bucket
+// provisioning, if it is part of an RGW multisite configuration. No keys are returned for a single-site object
+// store. The zonegroup is only returned once the CephObjectZone exists.
+func (r *OCSInitializationReconciler) getRgwMultisiteKeyValues() (map[string]string, error) {
+	cephObjectStore, err := r.getManagedCephObjectStore()
+	if err != nil || cephObjectStore == nil || cephObjectStore.Spec.Zone.Name == "" {
+		return nil, err
+	}
+	keyValues := map[string]string{util.RgwZoneKey: cephObjectStore.Spec.Zone.Name}
+
+	cephObjectZone, err := r.getManagedCephObjectZone(cephObjectStore)
+	if err != nil {
+		return nil, err
+	}
+	if cephObjectZone == nil {
+		r.Log.Info("The CephObjectZone of the CephObjectStore does not exist yet, skipping the RGW zonegroup key",
+			"CephObjectStore", cephObjectStore.Name, "CephObjectZone", cephObjectStore.Spec.Zone.Name)
+		return keyValues, nil
+	}
+	if cephObjectZone.Spec.ZoneGroup != "" {
+		keyValues[util.RgwZoneGroupKey] = cephObjectZone.Spec.ZoneGroup
+	}
+	return keyValues, nil
+}
@@ -0,0 +1,196 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestCephObjectStore(name string, endpoints rookCephv1.ObjectEndpoints) *rookCephv1.CephObjectStore {
+	return &rookCephv1.CephObjectStore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testOperatorNamespace,
+		},
+		Spec: rookCephv1.ObjectStoreSpec{
+			Gateway: rookCephv1.GatewaySpec{
+				Service: &rookCephv1.RGWServiceSpec{
+					Annotations: map[string]string{servingCertSecretAnnotation: "ocs-storagecluster-cos-ceph-rgw-tls-cert"},
+				},
+			},
+		},
+		Status: &rookCephv1.ObjectStoreStatus{Endpoints: endpoints},
+	}
+}
+
+func TestOcsOperatorConfigRgw(t *testing.T) {
+	rgwKeys := []string{util.RgwEndpointKey, util.RgwTLSEnabledKey, util.RgwTLSCertSecretKey, util.RgwCABundleSecretKey}
+	secureEndpoints := rookCephv1.ObjectEndpoints{
+		Insecure: []string{"http://rook-ceph-rgw-ocs-storagecluster-cephobjectstore.openshift-storage.svc:80"},
+		Secure:   []string{"https://rook-ceph-rgw-ocs-storagecluster-cephobjectstore.openshift-storage.svc:443"},
+	}
+
+	testcases := []struct {
+		label    string
+		objs     []client.Object
+		expected map[string]string
+	}{
+		{
+			label: "no CephObjectStore",
+		},
+		{
+			label: "CephObjectStore that is not managed by the storagecluster",
+			objs:  []client.Object{newTestCephObjectStore("external-cephobjectstore", secureEndpoints)},
+		},
+		{
+			label: "CephObjectStore without endpoints",
+			objs:  []client.Object{newTestCephObjectStore("ocs-storagecluster-cephobjectstore", rookCephv1.ObjectEndpoints{})},
+		},
+		{
+			label: "CephObjectStore with an insecure endpoint",
+			objs: []client.Object{newTestCephObjectStore("ocs-storagecluster-cephobjectstore", rookCephv1.ObjectEndpoints{
+				Insecure: secureEndpoints.Insecure,
+			})},
+			expected: map[string]string{
+				util.RgwEndpointKey:   secureEndpoints.Insecure[0],
+				util.RgwTLSEnabledKey: "false",
+			},
+		},
+		{
+			label: "CephObjectStore with a secure endpoint",
+			objs:  []client.Object{newTestCephObjectStore("ocs-storagecluster-cephobjectstore", secureEndpoints)},
+			expected: map[string]string{
+				util.RgwEndpointKey:      secureEndpoints.Secure[0],
+				util.RgwTLSEnabledKey:    "true",
+				util.RgwTLSCertSecretKey: "ocs-storagecluster-cos-ceph-rgw-tls-cert",
+			},
+		},
+		{
+			label: "CephObjectStore with its own certificate and CA bundle",
+			objs: []client.Object{func() client.Object {
+				cephObjectStore := newTestCephObjectStore("ocs-storagecluster-cephobjectstore", secureEndpoints)
+				cephObjectStore.Spec.Gateway.SSLCertificateRef = "rgw-cert"
+				cephObjectStore.Spec.Gateway.CaBundleRef = "rgw-ca-bundle"
+				return cephObjectStore
+			}()},
+			expected: map[string]string{
+				util.RgwEndpointKey:       secureEndpoints.Secure[0],
+				util.RgwTLSEnabledKey:     "true",
+				util.RgwTLSCertSecretKey:  "rgw-cert",
+				util.RgwCABundleSecretKey: "rgw-ca-bundle",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := append([]client.Object{newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)}, tc.objs...)
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		for _, key := range rgwKeys {
+			value, ok := tc.expected[key]
+			if !ok {
+				assert.NotContainsf(t, data, key, "[%s]: unexpected RGW key %s", tc.label, key)
+				continue
+			}
+			assert.Equalf(t, value, data[key], "[%s]: unexpected value of the RGW key %s", tc.label, key)
+		}
+	}
+}
+
+func TestOcsOperatorConfigRgwRemoved(t *testing.T) {
+	cephObjectStore := newTestCephObjectStore("ocs-storagecluster-cephobjectstore", rookCephv1.ObjectEndpoints{
+		Insecure: []string{"http://rook-ceph-rgw-ocs-storagecluster-cephobjectstore.openshift-storage.svc:80"},
+	})
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace), cephObjectStore)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Contains(t, getOcsOperatorConfigData(t, reconciler), util.RgwEndpointKey)
+
+	// deleting the object store changes the inputs, and the keys are dropped
+	assert.NoError(t, reconciler.Client.Delete(reconciler.ctx, cephObjectStore))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.NotContains(t, data, util.RgwEndpointKey)
+	assert.NotContains(t, data, util.RgwTLSEnabledKey)
+}
+
+func newTestCephObjectZone(name, zoneGroup string) *rookCephv1.CephObjectZone {
+	return &rookCephv1.CephObjectZone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testOperatorNamespace,
+		},
+		Spec: rookCephv1.ObjectZoneSpec{ZoneGroup: zoneGroup},
+	}
+}
+
+func TestOcsOperatorConfigRgwMultisite(t *testing.T) {
+	multisiteKeys := []string{util.RgwZoneKey, util.RgwZoneGroupKey}
+	newMultisiteCephObjectStore := func(zone string) *rookCephv1.CephObjectStore {
+		cephObjectStore := newTestCephObjectStore("ocs-storagecluster-cephobjectstore", rookCephv1.ObjectEndpoints{})
+		cephObjectStore.Spec.Zone.Name = zone
+		return cephObjectStore
+	}
+
+	testcases := []struct {
+		label    string
+		objs     []client.Object
+		expected map[string]string
+	}{
+		{
+			label: "single-site CephObjectStore",
+			objs:  []client.Object{newMultisiteCephObjectStore(""), newTestCephObjectZone("zone-a", "zonegroup-a")},
+		},
+		{
+			label: "multisite CephObjectStore",
+			objs:  []client.Object{newMultisiteCephObjectStore("zone-a"), newTestCephObjectZone("zone-a", "zonegroup-a")},
+			expected: map[string]string{
+				util.RgwZoneKey:      "zone-a",
+				util.RgwZoneGroupKey: "zonegroup-a",
+			},
+		},
+		{
+			label:    "multisite CephObjectStore whose zone does not exist yet",
+			objs:     []client.Object{newMultisiteCephObjectStore("zone-a")},
+			expected: map[string]string{util.RgwZoneKey: "zone-a"},
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := append([]client.Object{newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)}, tc.objs...)
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		for _, key := range multisiteKeys {
+			value, ok := tc.expected[key]
+			if !ok {
+				assert.NotContainsf(t, data, key, "[%s]: unexpected RGW multisite key %s", tc.label, key)
+				continue
+			}
+			assert.Equalf(t, value, data[key], "[%s]: unexpected value of the RGW multisite key %s", tc.label, key)
+		}
+	}
+}
+
+func TestOcsOperatorConfigRgwZoneGroupChanged(t *testing.T) {
+	cephObjectStore := newTestCephObjectStore("ocs-storagecluster-cephobjectstore", rookCephv1.ObjectEndpoints{})
+	cephObjectStore.Spec.Zone.Name = "zone-a"
+	cephObjectZone := newTestCephObjectZone("zone-a", "zonegroup-a")
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+		cephObjectStore, cephObjectZone)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "zonegroup-a", getOcsOperatorConfigData(t, reconciler)[util.RgwZoneGroupKey])
+
+	// moving the zone to another zonegroup changes the inputs, and the key follows
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(cephObjectZone), cephObjectZone))
+	cephObjectZone.Spec.ZoneGroup = "zonegroup-b"
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, cephObjectZone))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "zonegroup-b", getOcsOperatorConfigData(t, reconciler)[util.RgwZoneGroupKey])
+}
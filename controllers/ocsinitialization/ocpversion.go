@@ -0,0 +1,53 @@
+package ocsinitialization
+
+import (
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	"github.com/blang/semver/v4"
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// ocsOperatorConfigKeyMinOCPVersions holds the minimum OCP version for the ocs-operator-config keys
+// that are only correct on some OCP versions. Keys that are not listed apply to all OCP versions.
+var ocsOperatorConfigKeyMinOCPVersions = map[string]semver.Version{
+	// topology constrained provisioning, used by replica-1 pools, is supported from OCP 4.14
+	util.EnableTopologyKey:       semver.MustParse("4.14.0"),
+	util.TopologyDomainLabelsKey: semver.MustParse("4.14.0"),
+	util.TopologyZoneCountKey:    semver.MustParse("4.14.0"),
+}
+
+// getOCPVersion returns the version of the running OCP cluster from the ClusterVersion, which is the newest
+// completed update of the history. The desired version is not running yet while an upgrade is in progress.
+// It is an empty string if no update has completed yet.
+func getOCPVersion(clusterVersion *configv1.ClusterVersion) string {
+	// the history is ordered with the newest update first
+	for _, update := range clusterVersion.Status.History {
+		if update.State == configv1.CompletedUpdate {
+			return update.Version
+		}
+	}
+	return ""
+}
+
+// removeKeysNotApplicableToOCPVersion removes the ocs-operator-config keys that require a newer OCP
+// version than the one running. If the OCP version is unknown, all the keys are kept.
+func (r *OCSInitializationReconciler) removeKeysNotApplicableToOCPVersion(ocsOperatorConfigData map[string]string, ocpVersion string) {
+	if ocpVersion == "" {
+		return
+	}
+	version, err := semver.ParseTolerant(ocpVersion)
+	if err != nil {
+		r.Log.Error(err, "Failed to parse the OCP version, not gating ocs-operator-config keys on it", "Version", ocpVersion)
+		return
+	}
+	// pre-releases of an OCP version are treated as that version
+	version.Pre, version.Build = nil, nil
+
+	for key, minVersion := range ocsOperatorConfigKeyMinOCPVersions {
+		if _, ok := ocsOperatorConfigData[key]; ok && version.LT(minVersion) {
+			r.Log.Info("Skipping ocs-operator-config key not applicable to the OCP version",
+				"Key", key, "OCPVersion", ocpVersion, "MinOCPVersion", minVersion.String())
+			delete(ocsOperatorConfigData, key)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestClusterVersion(version string) *configv1.ClusterVersion {
+	return &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Status: configv1.ClusterVersionStatus{
+			Desired: configv1.Release{Version: version},
+			History: []configv1.UpdateHistory{{State: configv1.CompletedUpdate, Version: version}},
+		},
+	}
+}
+
+func TestOcsOperatorConfigKeysGatedOnOCPVersion(t *testing.T) {
+	testcases := []struct {
+		label          string
+		ocpVersion     string
+		expectTopology bool
+	}{
+		{
+			label:          "unknown OCP version keeps all keys",
+			ocpVersion:     "",
+			expectTopology: true,
+		},
+		{
+			label:          "old OCP version skips the topology keys",
+			ocpVersion:     "4.12.5",
+			expectTopology: false,
+		},
+		{
+			label:          "pre-release of the minimum OCP version keeps the topology keys",
+			ocpVersion:     "4.14.0-rc.2",
+			expectTopology: true,
+		},
+		{
+			label:          "new OCP version keeps the topology keys",
+			ocpVersion:     "4.16.3",
+			expectTopology: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t,
+			newTestTopologyStorageCluster("topology.kubernetes.io/zone"), newTestClusterVersion(tc.ocpVersion))
+		err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		for _, key := range []string{util.EnableTopologyKey, util.TopologyDomainLabelsKey} {
+			_, ok := data[key]
+			assert.Equalf(t, tc.expectTopology, ok, "[%s]: unexpected presence of key %s", tc.label, key)
+		}
+		_, ok := data[util.EnableNFSKey]
+		assert.Truef(t, ok, "[%s]: keys without a minimum OCP version must always be set", tc.label)
+	}
+}
+
+func TestOCPVersionFromHistory(t *testing.T) {
+	testcases := []struct {
+		label    string
+		history  []configv1.UpdateHistory
+		expected string
+	}{
+		{
+			label:    "no completed update",
+			history:  []configv1.UpdateHistory{{State: configv1.PartialUpdate, Version: "4.14.0"}},
+			expected: "",
+		},
+		{
+			label: "upgrade in progress uses the completed version",
+			history: []configv1.UpdateHistory{
+				{State: configv1.PartialUpdate, Version: "4.16.0"},
+				{State: configv1.CompletedUpdate, Version: "4.15.9"},
+				{State: configv1.CompletedUpdate, Version: "4.14.2"},
+			},
+			expected: "4.15.9",
+		},
+		{
+			label: "newest completed update",
+			history: []configv1.UpdateHistory{
+				{State: configv1.CompletedUpdate, Version: "4.16.0"},
+				{State: configv1.CompletedUpdate, Version: "4.15.9"},
+			},
+			expected: "4.16.0",
+		},
+	}
+
+	for _, tc := range testcases {
+		clusterVersion := newTestClusterVersion("4.16.0")
+		clusterVersion.Status.History = tc.history
+		assert.Equalf(t, tc.expected, getOCPVersion(clusterVersion), "[%s]: unexpected OCP version", tc.label)
+	}
+}
@@ -3,12 +3,16 @@ package ocsinitialization
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
 	"github.com/red-hat-storage/ocs-operator/v4/controllers/defaults"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/ocsconfig"
 	"github.com/red-hat-storage/ocs-operator/v4/controllers/platform"
 	"github.com/red-hat-storage/ocs-operator/v4/controllers/storagecluster"
 	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
@@ -20,14 +24,19 @@ import (
 	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"gopkg.in/yaml.v3"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
 	"open-cluster-management.io/api/cluster/v1alpha1"
@@ -35,6 +44,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -72,15 +82,96 @@ type OCSInitializationReconciler struct {
 	SecurityClient    secv1client.SecurityV1Interface
 	OperatorNamespace string
 	AvailableCrds     map[string]bool
+	PodExecutor       util.PodExecutor
+	TopologyResolver  TopologyResolver
+	// NodeReader lists the nodes page by page for resolving the topology, the cache is listed at once if unset
+	NodeReader client.Reader
+	// PVCReader lists the PVCs page by page for the restart quiesce without caching them, the client is used if unset
+	PVCReader client.Reader
+	// RolloutHealthChecker gates the stages of a staged rollout of ocs-operator-config changes
+	RolloutHealthChecker RolloutHealthChecker
+	// LeaseReader reads the config lock Lease without caching it, the client is used if unset
+	LeaseReader client.Reader
+	// ConfigLockLeaseName is the name of the Lease held while ocs-operator-config is mutated, empty disables the lock
+	ConfigLockLeaseName string
+	// ConfigLockLeaseNamespace is the namespace of the config lock Lease, it defaults to the operator namespace
+	ConfigLockLeaseNamespace string
+	// CorrectTopologyBindingMode recreates topology constrained StorageClasses with the Immediate binding mode
+	// using WaitForFirstConsumer when topology is enabled, instead of only warning about them
+	CorrectTopologyBindingMode bool
+	// BlueGreenConfig writes ocs-operator-config changes to a standby configmap before they are swapped in,
+	// so that they can be rolled back to the previous configmap
+	BlueGreenConfig bool
+	// RemediateRookCephOperatorEnvFrom adds the envFrom reference to ocs-operator-config to the rook-ceph-operator
+	// Deployment if it is missing, instead of only reporting it
+	RemediateRookCephOperatorEnvFrom bool
+	// ConfigApprovalWebhookURL is the URL of an external service that approves or denies each change of the
+	// ocs-operator-config data before it is applied, empty disables the callout
+	ConfigApprovalWebhookURL string
+	// ConfigApprovalWebhookTimeout is the timeout of the callout, DefaultConfigApprovalWebhookTimeout is used if unset
+	ConfigApprovalWebhookTimeout time.Duration
+	// ConfigApprovalWebhookFailOpen applies the changes when the approval webhook cannot be reached, instead of skipping them
+	ConfigApprovalWebhookFailOpen bool
+	// ValueTransformers is the chain of transformers applied to the computed ocs-operator-config values before
+	// they are written
+	ValueTransformers []ValueTransformer
+	// ClusterIDMigrationPeriod is how long the legacy cluster ID of a cluster ID migration is passed on to CSI,
+	// DefaultClusterIDMigrationPeriod is used if unset
+	ClusterIDMigrationPeriod time.Duration
+	// ConfigKeyRenameTransitionPeriod is how long a renamed ocs-operator-config key is written with its old name as
+	// well, and the previous topology domain labels of a renamed node topology label are passed on,
+	// DefaultConfigKeyRenameTransitionPeriod is used if unset
+	ConfigKeyRenameTransitionPeriod time.Duration
+	// RookCephOperatorPodSelector selects the rook-ceph-operator pods that are restarted unless a StorageCluster
+	// overrides it; the pods labeled with either DefaultRookCephOperatorPodSelector or the app.kubernetes.io/name
+	// label of the rook-ceph-operator are selected if unset
+	RookCephOperatorPodSelector labels.Selector
+	// RookCephOperatorRestartCooldown is the minimum time between the start of a rook-ceph-operator pod and a
+	// restart for a config change, which protects against a flapping config; zero disables the cooldown
+	RookCephOperatorRestartCooldown time.Duration
+	// AdjustTopologyForZoneImbalance removes the zone label from the topology domain labels when the zones have
+	// heterogeneous sizes and a finer grained domain label is configured, instead of only reporting the imbalance
+	AdjustTopologyForZoneImbalance bool
+	// ConfigDegradedAlertFor is how long the ocs-operator-config reconcile has to stay degraded before the alert of
+	// the managed PrometheusRule fires, DefaultConfigDegradedAlertFor is used if unset
+	ConfigDegradedAlertFor time.Duration
+	// ConfigReconcileFailureAlertThreshold is the number of ocs-operator-config reconcile failures within an hour
+	// from which the failing alert of the managed PrometheusRule fires, zero disables the alert
+	ConfigReconcileFailureAlertThreshold int
+	// CSIMetricsSource enables the tuning advisor, which suggests tunable values from the CSI metrics in the status
+	CSIMetricsSource CSIMetricsSource
+
+	// recorder reports the changes of ocs-operator-config as events on the storageclusters
+	recorder *util.EventReporter
+
+	lastOcsOperatorConfig ocsOperatorConfigObservation
+	// clusterVersionBreaker caches the ClusterVersion values, and backs off its reads while they keep failing
+	clusterVersionBreaker *clusterVersionBreaker
+	// topologyNodeLabels are the node labels that the Node watch triggers a reconcile on
+	topologyNodeLabels *topologyNodeLabels
+	// restartQuiesceStart is when the pending rook-ceph-operator restart started waiting for CSI provisioning to quiesce
+	restartQuiesceStart time.Time
+	// zonalRestartStart is when the zone by zone restart of multiple rook-ceph-operator replicas started, the
+	// pods created before it have not been restarted yet. It is recorded on the rook-ceph-operator Deployment.
+	zonalRestartStart time.Time
+	// restartID identifies the rook-ceph-operator restart in progress across its logs, event and annotations
+	restartID string
 }
 
 // +kubebuilder:rbac:groups=ocs.openshift.io,resources=*,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=security.openshift.io,resources=securitycontextconstraints,verbs=get;create;update
 // +kubebuilder:rbac:groups=security.openshift.io,resourceNames=privileged,resources=securitycontextconstraints,verbs=get;create;update
 // +kubebuilder:rbac:groups="monitoring.coreos.com",resources={alertmanagers,prometheuses},verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups="monitoring.coreos.com",resources=servicemonitors,verbs=get;list;watch;update;patch;create;delete
 // +kubebuilder:rbac:groups=operators.coreos.com,resources=clusterserviceversions,verbs=get;list;watch;delete;update;patch
 // +kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=clusterclaims,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=resourcequotas,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods/exec,verbs=create
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=csidrivers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;create;update
+// +kubebuilder:rbac:groups=ceph.rook.io,resources=cephobjectzones,verbs=get;list;watch
 
 // Reconcile reads that state of the cluster for a OCSInitialization object and makes changes based on the state read
 // and what is in the OCSInitialization.Spec
@@ -95,14 +186,16 @@ func (r *OCSInitializationReconciler) Reconcile(ctx context.Context, request rec
 
 	r.Log.Info("Reconciling OCSInitialization.", "OCSInitialization", klog.KRef(request.Namespace, request.Name))
 
-	crd := &metav1.PartialObjectMetadata{}
-	crd.SetGroupVersionKind(extv1.SchemeGroupVersion.WithKind("CustomResourceDefinition"))
-	crd.Name = ClusterClaimCrdName
-	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(crd), crd); client.IgnoreNotFound(err) != nil {
-		r.Log.Error(err, "Failed to get CRD", "CRD", ClusterClaimCrdName)
-		return reconcile.Result{}, err
+	for _, crdName := range []string{ClusterClaimCrdName, NetworkFenceClassCrdName} {
+		crd := &metav1.PartialObjectMetadata{}
+		crd.SetGroupVersionKind(extv1.SchemeGroupVersion.WithKind("CustomResourceDefinition"))
+		crd.Name = crdName
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(crd), crd); client.IgnoreNotFound(err) != nil {
+			r.Log.Error(err, "Failed to get CRD", "CRD", crdName)
+			return reconcile.Result{}, err
+		}
+		util.AssertEqual(r.AvailableCrds[crdName], crd.UID != "", util.ExitCodeThatShouldRestartTheProcess)
 	}
-	util.AssertEqual(r.AvailableCrds[ClusterClaimCrdName], crd.UID != "", util.ExitCodeThatShouldRestartTheProcess)
 
 	initNamespacedName := InitNamespacedName()
 	instance := &ocsv1.OCSInitialization{}
@@ -205,12 +298,93 @@ func (r *OCSInitializationReconciler) Reconcile(ctx context.Context, request rec
 		return reconcile.Result{}, err
 	}
 
-	err = r.ensureOcsOperatorConfigExists(instance)
+	// the alert rule is in place before the config reconcile it alerts on can fail
+	err = r.reconcileOcsOperatorConfigAlertRule(instance)
 	if err != nil {
+		r.Log.Error(err, "Failed to ensure the ocs-operator-config PrometheusRule")
+		return reconcile.Result{}, err
+	}
+
+	err = r.ensureOcsOperatorConfigExists(instance)
+	if isIncompleteNodeList(err) {
+		// the config is kept as it is rather than resolving the topology from a partial set of nodes
+		r.Log.Info("Skipping the ocs-operator-config update, the node list is incomplete", "Error", err.Error())
+		return reconcile.Result{RequeueAfter: incompleteNodeListRequeueDelay}, nil
+	} else if isApprovalWebhookUnavailable(err) {
+		// the change is submitted again once the approval webhook can be reached
+		r.Log.Info("Skipping the ocs-operator-config change, the approval webhook is unavailable", "Error", err.Error())
+		if uErr := r.Client.Status().Update(ctx, instance); uErr != nil {
+			r.Log.Error(uErr, "Failed to update conditions of OCSInitialization resource.", "OCSInitialization", klog.KRef(instance.Namespace, instance.Name))
+		}
+		return reconcile.Result{RequeueAfter: approvalWebhookUnavailableRequeueDelay}, nil
+	} else if isConfigLockUnavailable(err) {
+		// the config is updated once the holder of the lock is done with it
+		r.Log.Info("Skipping the ocs-operator-config update, the config lock is unavailable", "Error", err.Error())
+		return reconcile.Result{RequeueAfter: configLockRequeueDelay}, nil
+	} else if err != nil {
 		r.Log.Error(err, "Failed to ensure ocs-operator-config ConfigMap")
+		setOcsOperatorConfigDegraded(instance, err)
+		if uErr := r.Client.Status().Update(ctx, instance); uErr != nil {
+			r.Log.Error(uErr, "Failed to update conditions of OCSInitialization resource.", "OCSInitialization", klog.KRef(instance.Namespace, instance.Name))
+		}
+		return reconcile.Result{}, err
+	}
+
+	err = r.reconcileRookCephOperatorEnvFrom(instance)
+	if err != nil {
+		r.Log.Error(err, "Failed to check the envFrom reference of the rook-ceph-operator Deployment")
 		return reconcile.Result{}, err
 	}
 
+	err = r.reconcileCSIClusterNameConsistency(instance)
+	if err != nil {
+		r.Log.Error(err, "Failed to check the CSI cluster name of the CSI provisioner Deployments")
+		return reconcile.Result{}, err
+	}
+
+	r.reconcileTuningSuggestions(instance)
+
+	// Restart the rook-ceph-operator once for all the configmaps that changed in this or an earlier reconcile
+	rookCephOperatorRestartResult, err := r.reconcileRookCephOperatorRestart(instance)
+	if err != nil {
+		r.Log.Error(err, "Failed to restart rook-ceph-operator pod")
+		setOcsOperatorConfigDegraded(instance, err)
+		// the stale config condition is kept along with the failure, which is retried with a backoff
+		if uErr := r.Client.Status().Update(ctx, instance); uErr != nil {
+			r.Log.Error(uErr, "Failed to update conditions of OCSInitialization resource.", "OCSInitialization", klog.KRef(instance.Namespace, instance.Name))
+		}
+		return reconcile.Result{}, err
+	}
+	setOcsOperatorConfigDegraded(instance, nil)
+	// Retry a config change of a staged rollout that is waiting for the health check to pass
+	if rookCephOperatorRestartResult.IsZero() && instance.Status.OcsOperatorConfig.Rollout.Stage == ocsv1.RolloutStageConfigUpdatePending {
+		rookCephOperatorRestartResult = reconcile.Result{RequeueAfter: rookCephOperatorRestartRequeueDelay}
+	}
+	// Refresh the tuning suggestions, the metrics change without any event
+	if rookCephOperatorRestartResult.IsZero() && r.CSIMetricsSource != nil {
+		rookCephOperatorRestartResult = reconcile.Result{RequeueAfter: tuningAnalysisInterval}
+	}
+	// Remove the legacy cluster ID once the migration window has passed
+	if delay := r.getClusterIDMigrationRequeueDelay(instance, time.Now()); delay > 0 &&
+		(rookCephOperatorRestartResult.IsZero() || delay < rookCephOperatorRestartResult.RequeueAfter) {
+		rookCephOperatorRestartResult = reconcile.Result{RequeueAfter: delay}
+	}
+	// Remove the old names of the renamed keys once their transition has passed
+	if delay := r.getConfigKeyRenameRequeueDelay(instance, time.Now()); delay > 0 &&
+		(rookCephOperatorRestartResult.IsZero() || delay < rookCephOperatorRestartResult.RequeueAfter) {
+		rookCephOperatorRestartResult = reconcile.Result{RequeueAfter: delay}
+	}
+	// Remove the previous topology domain labels once the transition of the renamed topology labels has passed
+	if delay := r.getTopologyLabelRenameRequeueDelay(instance, time.Now()); delay > 0 &&
+		(rookCephOperatorRestartResult.IsZero() || delay < rookCephOperatorRestartResult.RequeueAfter) {
+		rookCephOperatorRestartResult = reconcile.Result{RequeueAfter: delay}
+	}
+	// Read the cluster ID again once the cooldown of the open ClusterVersion circuit has passed
+	if delay := r.getClusterVersionRequeueDelay(time.Now()); delay > 0 &&
+		(rookCephOperatorRestartResult.IsZero() || delay < rookCephOperatorRestartResult.RequeueAfter) {
+		rookCephOperatorRestartResult = reconcile.Result{RequeueAfter: delay}
+	}
+
 	err = r.reconcileUXBackendSecret(instance)
 	if err != nil {
 		r.Log.Error(err, "Failed to ensure uxbackend secret")
@@ -271,12 +445,15 @@ func (r *OCSInitializationReconciler) Reconcile(ctx context.Context, request rec
 	instance.Status.Phase = util.PhaseReady
 	err = r.Client.Status().Update(ctx, instance)
 
-	return reconcile.Result{}, err
+	return rookCephOperatorRestartResult, err
 }
 
 // SetupWithManager sets up a controller with a manager
 func (r *OCSInitializationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	operatorNamespace = r.OperatorNamespace
+	r.recorder = util.NewEventReporter(mgr.GetEventRecorderFor("controller_ocsinitialization"))
+	r.clusterVersionBreaker = &clusterVersionBreaker{}
+	r.topologyNodeLabels = &topologyNodeLabels{}
 	prometheusPredicate := predicate.NewPredicateFuncs(
 		func(client client.Object) bool {
 			return strings.HasPrefix(client.GetName(), PrometheusOperatorCSVNamePrefix)
@@ -292,19 +469,28 @@ func (r *OCSInitializationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	)
 
 	ocsInitializationController := ctrl.NewControllerManagedBy(mgr).
-		For(&ocsv1.OCSInitialization{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// the annotations requesting a config rollback or a restart throttle bypass are handled right away
+		For(&ocsv1.OCSInitialization{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.Secret{}).
 		Owns(&promv1.Prometheus{}).
 		Owns(&corev1.ConfigMap{}).
 		Owns(&promv1.Alertmanager{}).
 		Owns(&promv1.ServiceMonitor{}).
+		Owns(&promv1.PrometheusRule{}).
 		// Watcher for storagecluster required to update
-		// ocs-operator-config configmap if storagecluster spec changes
+		// ocs-operator-config configmap if storagecluster spec or the config overrides annotation changes
 		Watches(
 			&ocsv1.StorageCluster{},
 			enqueueOCSInit,
-			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+			builder.WithPredicates(
+				util.ComposePredicates(
+					predicate.GenerationChangedPredicate{},
+					predicate.AnnotationChangedPredicate{},
+					predicate.LabelChangedPredicate{},
+				),
+			),
 		).
 		// Watcher for storageClass required to update values related to replica-1
 		// in ocs-operator-config configmap, if storageClass changes
@@ -325,23 +511,145 @@ func (r *OCSInitializationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		).
 		// Watcher for rook-ceph-operator-config cm
 		Watches(
-			&corev1.ConfigMap{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      util.RookCephOperatorConfigName,
-					Namespace: r.OperatorNamespace,
-				},
-			},
+			&corev1.ConfigMap{},
+			enqueueOCSInit,
+			builder.WithPredicates(
+				util.NamePredicate(util.RookCephOperatorConfigName),
+				util.NamespacePredicate(r.OperatorNamespace),
+			),
+		).
+		// Watcher for ocs-operator-config cm, so that out-of-band edits are reverted to the computed config
+		Watches(
+			&corev1.ConfigMap{},
 			enqueueOCSInit,
+			builder.WithPredicates(ocsOperatorConfigPredicate(r.OperatorNamespace)),
 		).
-		// Watcher for ocs-operator-config cm
+		// Watcher for ocs-operator-config-defaults cm in the operator or any storagecluster namespace
 		Watches(
-			&corev1.ConfigMap{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      util.OcsOperatorConfigName,
-					Namespace: r.OperatorNamespace,
+			&corev1.ConfigMap{},
+			enqueueOCSInit,
+			builder.WithPredicates(util.NamePredicate(OcsOperatorConfigDefaultsName)),
+		).
+		// Watcher for the bounds of the OCSConfig tunables in the operator namespace
+		Watches(
+			&corev1.ConfigMap{},
+			enqueueOCSInit,
+			builder.WithPredicates(
+				util.NamePredicate(ocsconfig.TunableBoundsConfigMapName),
+				util.NamespacePredicate(r.OperatorNamespace),
+			),
+		).
+		// Watcher for the KMS configmap of the storageclusters, referenced by the RBD volume encryption config
+		Watches(
+			&corev1.ConfigMap{},
+			enqueueOCSInit,
+			builder.WithPredicates(util.NamePredicate(defaults.KMSConfigMapName)),
+		).
+		// Watcher for OCSConfig required to update ocs-operator-config configmap if the tunables change
+		Watches(
+			&ocsv1.OCSConfig{},
+			enqueueOCSInit,
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		// Watcher for the operator namespace, whose labels carry the managed service cluster identity
+		Watches(
+			&corev1.Namespace{},
+			enqueueOCSInit,
+			builder.WithPredicates(
+				util.NamePredicate(r.OperatorNamespace),
+				predicate.LabelChangedPredicate{},
+			),
+		).
+		// Watcher for nodes, required to detect single-node clusters, the topology domains and the OS images
+		Watches(
+			&corev1.Node{},
+			enqueueOCSInit,
+			builder.WithPredicates(
+				predicate.Funcs{
+					UpdateFunc: func(e event.UpdateEvent) bool {
+						oldNode, newNode := e.ObjectOld.(*corev1.Node), e.ObjectNew.(*corev1.Node)
+						return isSchedulableNode(oldNode) != isSchedulableNode(newNode) ||
+							oldNode.Status.NodeInfo.OSImage != newNode.Status.NodeInfo.OSImage ||
+							isTopologyNodeLabelChanged(oldNode, newNode, r.topologyNodeLabels.get())
+					},
 				},
-			},
+			),
+		).
+		// Watcher for the CSIDrivers, whose capabilities restrict the topology config
+		Watches(
+			&storagev1.CSIDriver{},
 			enqueueOCSInit,
+			builder.WithPredicates(
+				predicate.NewPredicateFuncs(func(obj client.Object) bool {
+					return slices.Contains(topologyCSIDriverNames, obj.GetName())
+				}),
+			),
+		).
+		// Watcher for the CSI node plugin DaemonSets, whose placement restricts the topology domains
+		Watches(
+			&appsv1.DaemonSet{},
+			enqueueOCSInit,
+			builder.WithPredicates(
+				util.NamespacePredicate(r.OperatorNamespace),
+				predicate.NewPredicateFuncs(func(obj client.Object) bool {
+					return slices.Contains(csiNodePluginDaemonSetNames, obj.GetName())
+				}),
+				predicate.GenerationChangedPredicate{},
+			),
+		).
+		// Watcher for the CSI provisioner Deployments, which have to use the CSI_CLUSTER_NAME of ocs-operator-config
+		Watches(
+			&appsv1.Deployment{},
+			enqueueOCSInit,
+			builder.WithPredicates(
+				util.NamespacePredicate(r.OperatorNamespace),
+				predicate.NewPredicateFuncs(func(obj client.Object) bool {
+					return slices.Contains(csiProvisionerDeploymentNames, obj.GetName())
+				}),
+				predicate.GenerationChangedPredicate{},
+			),
+		).
+		// Watcher for the PersistentVolumes of the CSI drivers, which defer the removal of topology domain labels,
+		// and for the local volumes of LSO devices, whose placement determines the topology domains
+		Watches(
+			&corev1.PersistentVolume{},
+			enqueueOCSInit,
+			builder.WithPredicates(topologyVolumePredicate),
+		).
+		// Watcher for the OSD pods, whose nodes keep contributing their zone to the topology domains while cordoned
+		Watches(
+			&corev1.Pod{},
+			enqueueOCSInit,
+			builder.WithPredicates(osdPodPredicate),
+		).
+		// Watcher for the CephFilesystems, whose snapshot schedules, per filesystem config and MDS layout are passed on to CSI
+		Watches(
+			&rookCephv1.CephFilesystem{},
+			enqueueOCSInit,
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		// Watcher for the CephBlockPools, whose quotas are passed on to CSI
+		Watches(
+			&rookCephv1.CephBlockPool{},
+			enqueueOCSInit,
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		// Watcher for the CephObjectStores, whose endpoints are passed on for object bucket provisioning
+		Watches(
+			&rookCephv1.CephObjectStore{},
+			enqueueOCSInit,
+			builder.WithPredicates(
+				util.ComposePredicates(
+					predicate.GenerationChangedPredicate{},
+					cephObjectStoreEndpointsChangedPredicate,
+				),
+			),
+		).
+		// Watcher for the CephObjectZones, whose zonegroup is passed on for object bucket provisioning with RGW multisite
+		Watches(
+			&rookCephv1.CephObjectZone{},
+			enqueueOCSInit,
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
 		).
 		// Watcher for prometheus operator csv
 		Watches(
@@ -353,12 +661,25 @@ func (r *OCSInitializationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			&extv1.CustomResourceDefinition{},
 			enqueueOCSInit,
 			builder.WithPredicates(
-				util.NamePredicate(ClusterClaimCrdName),
-				util.EventTypePredicate(
-					!r.AvailableCrds[ClusterClaimCrdName],
-					false,
-					true,
-					false,
+				util.ComposePredicates(
+					predicate.And(
+						util.NamePredicate(ClusterClaimCrdName),
+						util.EventTypePredicate(
+							!r.AvailableCrds[ClusterClaimCrdName],
+							false,
+							true,
+							false,
+						),
+					),
+					predicate.And(
+						util.NamePredicate(NetworkFenceClassCrdName),
+						util.EventTypePredicate(
+							!r.AvailableCrds[NetworkFenceClassCrdName],
+							false,
+							true,
+							false,
+						),
+					),
 				),
 			),
 			builder.OnlyMetadata,
@@ -374,6 +695,15 @@ func (r *OCSInitializationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			),
 		)
 	}
+	if r.AvailableCrds[NetworkFenceClassCrdName] {
+		networkFenceClass := &unstructured.Unstructured{}
+		networkFenceClass.SetGroupVersionKind(networkFenceClassGVK)
+		ocsInitializationController = ocsInitializationController.Watches(
+			networkFenceClass,
+			enqueueOCSInit,
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		)
+	}
 	return ocsInitializationController.Complete(r)
 }
 
@@ -427,7 +757,6 @@ func (r *OCSInitializationReconciler) ensureRookCephOperatorConfigExists(initial
 		if rookCephOperatorConfig.Data == nil {
 			rookCephOperatorConfig.Data = make(map[string]string)
 		}
-
 		csiPluginDefaults := defaults.DaemonPlacements[defaults.CsiPluginKey]
 		csiPluginTolerations := r.getCsiTolerations(defaults.CsiPluginKey)
 		if err := updateTolerationsConfigFunc(rookCephOperatorConfig,
@@ -498,111 +827,371 @@ func (r *OCSInitializationReconciler) getCsiTolerations(csiTolerationKey string)
 // It is not meant to be modified by the user
 // The values are set considering all storageclusters into account.
 // The needed keys from the configmap are passed to rook-ceph operator pod as env variables.
-// When any value in the configmap is updated, the rook-ceph-operator pod is restarted to pick up the new values.
+// When a value that requires a restart is updated, a restart of the rook-ceph-operator pod is marked as pending
+// so that it picks up the new values.
 func (r *OCSInitializationReconciler) ensureOcsOperatorConfigExists(initialData *ocsv1.OCSInitialization) error {
 
+	r.reconcileClusterIDMigrationStatus(initialData, time.Now())
+	r.reconcileConfigKeyRenameStatus(initialData, time.Now())
+
+	// the nodes are listed once, all of the config is resolved from the same view of them
+	nodes, err := r.listNodes()
+	if err != nil {
+		r.Log.Error(err, "Failed to list the nodes")
+		return err
+	}
+	r.reconcileTopologyLabelRenameStatus(initialData, nodes, time.Now())
+	pluginNodes, err := r.getCSINodePluginNodes(nodes)
+	if err != nil {
+		r.Log.Error(err, "Failed to determine the nodes running the CSI node plugins")
+		return err
+	}
+
+	// an unknown cluster ID is part of the inputs, so that the config is resolved again once it is known
+	clusterVersion := r.getClusterVersionInfo()
+	inputsHash, err := r.getOcsOperatorConfigInputsHash(initialData, nodes, pluginNodes, clusterVersion)
+	if err != nil {
+		r.Log.Error(err, "Failed to compute the ocs-operator-config inputs hash")
+		return err
+	}
+	if r.BlueGreenConfig {
+		if err := r.rollbackBlueGreenConfig(initialData, inputsHash); err != nil {
+			r.Log.Error(err, "Failed to roll back ocs-operator-config")
+			return err
+		}
+	}
+	if r.isOcsOperatorConfigUnchanged(initialData.Namespace, inputsHash) {
+		r.Log.V(1).Info("ocs-operator-config configmap and its inputs are unchanged, skipping")
+		return nil
+	}
+	// forget the last observation until this reconcile completes successfully
+	r.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+
 	enableCephfsVal, err := r.getEnableCephfsKeyValue()
 	if err != nil {
 		r.Log.Error(err, "Failed to get enableCephfsKeyValue")
 		return err
 	}
 
-	ocsOperatorConfigData := map[string]string{
-		util.ClusterNameKey:              util.GetClusterID(r.ctx, r.Client, &r.Log),
-		util.RookCurrentNamespaceOnlyKey: strconv.FormatBool(!(len(r.clusters.GetStorageClusters()) > 1)),
-		util.EnableTopologyKey:           r.getEnableTopologyKeyValue(),
-		util.TopologyDomainLabelsKey:     r.getTopologyDomainLabelsKeyValue(),
-		util.EnableNFSKey:                r.getEnableNFSKeyValue(),
-		util.EnableCephfsKey:             enableCephfsVal,
-		util.DisableCSIDriverKey:         strconv.FormatBool(true),
+	networkFencing, err := r.isNetworkFencingConfigured()
+	if err != nil {
+		r.Log.Error(err, "Failed to determine if network fencing is configured")
+		return err
+	}
+
+	topology, err := r.getTopologyResolver().ResolveTopology(r.ctx, r.Client, r.clusters.GetStorageClusters())
+	if err != nil {
+		r.Log.Error(err, "Failed to resolve the topology")
+		return err
+	}
+	r.checkExternalTopology(initialData)
+	r.checkTopologyNodeGroupOverrides(initialData)
+
+	// all the encryption keys are part of this single update, a restart only happens once all of them landed
+	ocsOperatorConfigData, msModeRationale := computeOcsOperatorConfigData(r.Log, r.clusters, clusterVersion.clusterID, topology)
+	builtInKernelMountOptions := ocsOperatorConfigData[util.CephFSKernelMountOptionsKey]
+	ocsOperatorConfigData[util.EnableCephfsKey] = enableCephfsVal
+	if err := r.keepExistingClusterID(initialData.Namespace, ocsOperatorConfigData); err != nil {
+		r.Log.Error(err, "Failed to get the cluster ID of ocs-operator-config")
+		return err
+	}
+	applyClusterIDMigration(initialData, ocsOperatorConfigData)
+	if networkFencing {
+		ocsOperatorConfigData[util.EnableNetworkFencingKey] = "true"
+	}
+	snapshotSchedule, err := r.getCephFSSnapshotScheduleKeyValue()
+	if err != nil {
+		r.Log.Error(err, "Failed to get the CephFS snapshot schedules")
+		return err
+	}
+	if snapshotSchedule != "" {
+		ocsOperatorConfigData[util.CephFSSnapshotScheduleKey] = snapshotSchedule
+	}
+	filesystemsConfig, err := r.getCephFSFilesystemsConfigKeyValue()
+	if err != nil {
+		r.Log.Error(err, "Failed to get the per CephFilesystem config")
+		return err
+	}
+	if filesystemsConfig != "" {
+		ocsOperatorConfigData[util.CephFSFilesystemsConfigKey] = filesystemsConfig
+	}
+	mdsPinning, err := r.getCephFSMDSPinningKeyValue()
+	if err != nil {
+		r.Log.Error(err, "Failed to get the MDS layout of the CephFilesystems")
+		return err
+	}
+	if mdsPinning != "" {
+		ocsOperatorConfigData[util.CephFSMDSPinningKey] = mdsPinning
+	}
+	cephFSQuota, err := r.getCephFSQuotaKeyValue()
+	if err != nil {
+		r.Log.Error(err, "Failed to get the quotas of the CephFilesystems")
+		return err
+	}
+	if cephFSQuota != "" {
+		ocsOperatorConfigData[util.CephFSQuotaKey] = cephFSQuota
+	}
+	rbdPoolQuota, err := r.getRbdPoolQuotaKeyValue()
+	if err != nil {
+		r.Log.Error(err, "Failed to get the quotas of the CephBlockPools")
+		return err
+	}
+	if rbdPoolQuota != "" {
+		ocsOperatorConfigData[util.RbdPoolQuotaKey] = rbdPoolQuota
+	}
+	rgwKeyValues, err := r.getRgwKeyValues()
+	if err != nil {
+		r.Log.Error(err, "Failed to get the RGW config of the CephObjectStore")
+		return err
+	}
+	maps.Copy(ocsOperatorConfigData, rgwKeyValues)
+	rgwMultisiteKeyValues, err := r.getRgwMultisiteKeyValues()
+	if err != nil {
+		r.Log.Error(err, "Failed to get the RGW multisite config of the CephObjectStore")
+		return err
+	}
+	maps.Copy(ocsOperatorConfigData, rgwMultisiteKeyValues)
+	rbdEncryptionKMSConfig, err := r.getRbdEncryptionKMSConfigKeyValue()
+	if err != nil {
+		r.Log.Error(err, "Failed to get the KMS config of the RBD volume encryption")
+		return err
+	}
+	if rbdEncryptionKMSConfig != "" {
+		ocsOperatorConfigData[util.RbdEncryptionKMSConfigKey] = rbdEncryptionKMSConfig
+	}
+	r.detectStretchTopology(initialData, ocsOperatorConfigData, pluginNodes)
+
+	r.alignTopologyWithMirroringPeer(initialData, ocsOperatorConfigData)
+
+	r.seedPlatformDefaults(ocsOperatorConfigData, pluginNodes)
+
+	ocsOperatorConfigData, err = r.resolveOcsOperatorConfigData(initialData, ocsOperatorConfigData)
+	if err != nil {
+		r.Log.Error(err, "Failed to resolve ocs-operator-config defaults and overrides")
+		return err
+	}
+	r.applyTopologyLabelRenames(initialData, ocsOperatorConfigData)
+
+	r.recordMsModeRationale(initialData, builtInKernelMountOptions, msModeRationale, ocsOperatorConfigData)
+
+	if err := r.disableTopologyOnSingleNodeCluster(initialData, ocsOperatorConfigData, nodes); err != nil {
+		r.Log.Error(err, "Failed to determine if the cluster is a single-node cluster")
+		return err
+	}
+
+	if err := r.gateTopologyOnCSIDriverCapabilities(initialData, ocsOperatorConfigData); err != nil {
+		r.Log.Error(err, "Failed to check the topology against the CSIDriver capabilities")
+		return err
+	}
+
+	r.checkZoneSizeBalance(initialData, ocsOperatorConfigData, nodes)
+
+	if err := r.expandTopologyDomainLabels(initialData, ocsOperatorConfigData); err != nil {
+		r.Log.Error(err, "Failed to expand the topology domain labels")
+		return err
+	}
+
+	r.validateKernelMountOptionsOnNodeOSImages(initialData, ocsOperatorConfigData, nodes)
+
+	r.restrictTopologyDomainLabelsToCSINodePlugins(initialData, ocsOperatorConfigData, nodes, pluginNodes)
+
+	if err := r.guardTopologyDomainLabels(initialData, ocsOperatorConfigData); err != nil {
+		r.Log.Error(err, "Failed to check the topology domain labels")
+		return err
+	}
+
+	if err := r.checkTopologyBindingMode(initialData, ocsOperatorConfigData); err != nil {
+		r.Log.Error(err, "Failed to check the binding mode of the topology constrained StorageClasses")
+		return err
+	}
+
+	r.removeKeysNotApplicableToOCPVersion(ocsOperatorConfigData, clusterVersion.ocpVersion)
+
+	if err := r.applyValueTransformers(ocsOperatorConfigData); err != nil {
+		r.Log.Error(err, "Failed to transform the ocs-operator-config values")
+		return err
+	}
+
+	applyConfigKeyRenames(initialData, ocsOperatorConfigData)
+
+	r.topologyNodeLabels.set(getTopologyNodeLabels(r.clusters.GetStorageClusters(), ocsOperatorConfigData[util.TopologyDomainLabelsKey]))
+
+	// a gated change is not recorded as observed, so it is retried until the health check passes
+	if gated, err := r.isOcsOperatorConfigUpdateGated(initialData, ocsOperatorConfigData); err != nil {
+		r.Log.Error(err, "Failed to check the staged rollout of ocs-operator-config")
+		return err
+	} else if gated {
+		return nil
+	}
+
+	// a denied change is not recorded as observed either, so that it is submitted again on the next reconcile
+	if denied, err := r.isOcsOperatorConfigChangeDenied(initialData, ocsOperatorConfigData); isApprovalWebhookUnavailable(err) {
+		return err
+	} else if err != nil {
+		r.Log.Error(err, "Failed to get the approval of the ocs-operator-config change")
+		return err
+	} else if denied {
+		return nil
+	}
+
+	if err := r.acquireConfigLock(); isConfigLockUnavailable(err) {
+		return err
+	} else if err != nil {
+		r.Log.Error(err, "Failed to acquire the config lock lease", "Lease", r.getConfigLockLeaseKey())
+		return err
+	}
+	defer r.releaseConfigLock()
+
+	activeSlot := ""
+	if r.BlueGreenConfig {
+		ocsOperatorConfigData, activeSlot, err = r.stageBlueGreenConfig(initialData, ocsOperatorConfigData, inputsHash)
+		if err != nil {
+			r.Log.Error(err, "Failed to stage the ocs-operator-config")
+			return err
+		}
 	}
 
 	ocsOperatorConfig := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      util.OcsOperatorConfigName,
+			Name:      r.getOcsOperatorConfigName(),
 			Namespace: initialData.Namespace,
 		},
 	}
+	var previousData map[string]string
 	opResult, err := ctrl.CreateOrUpdate(r.ctx, r.Client, ocsOperatorConfig, func() error {
 
+		previousData = ocsOperatorConfig.Data
 		if !reflect.DeepEqual(ocsOperatorConfig.Data, ocsOperatorConfigData) {
-			r.Log.Info("Updating ocs-operator-config configmap")
+			changedKeys := markOcsOperatorConfigRestartPending(ocsOperatorConfig, ocsOperatorConfig.Data, ocsOperatorConfigData)
+			r.Log.Info("Updating ocs-operator-config configmap", "ChangedKeys", sets.List(changedKeys),
+				"RestartRequiredKeys", sets.List(changedKeys.Intersection(ocsOperatorConfigRestartRequiredKeys)))
 			ocsOperatorConfig.Data = ocsOperatorConfigData
 		}
-
-		// This configmap was controlled by the storageCluster before 4.15.
-		// We are required to remove storageCluster as a controller before adding OCSInitialization as controller.
-		if existing := metav1.GetControllerOfNoCopy(ocsOperatorConfig); existing != nil && existing.Kind == "StorageCluster" {
-			existing.BlockOwnerDeletion = nil
-			existing.Controller = nil
+		setConfigDataChecksum(ocsOperatorConfig)
+		// the data and the active reference are swapped in a single update
+		if activeSlot != "" {
+			util.AddAnnotation(ocsOperatorConfig, ActiveConfigAnnotation, activeSlot)
+			if ocsOperatorConfig.Annotations[rolledBackInputsHashAnnotation] != inputsHash {
+				delete(ocsOperatorConfig.Annotations, rolledBackInputsHashAnnotation)
+			}
 		}
 
-		return ctrl.SetControllerReference(initialData, ocsOperatorConfig, r.Scheme)
+		// This configmap was controlled by the storageCluster before 4.15.
+		// We are required to remove any other controller before adding OCSInitialization as controller.
+		return r.transferOcsOperatorConfigOwnership(initialData, ocsOperatorConfig)
 	})
 	if err != nil {
 		r.Log.Error(err, "Failed to create/update ocs-operator-config configmap", "OperationResult", opResult)
 		return err
 	}
-	// If configmap is created or updated, restart the rook-ceph-operator pod to pick up the new change
-	if opResult == controllerutil.OperationResultCreated || opResult == controllerutil.OperationResultUpdated {
-		r.Log.Info("ocs-operator-config configmap created/updated. Restarting rook-ceph-operator pod to pick up the new values")
-		util.RestartPod(r.ctx, r.Client, &r.Log, "rook-ceph-operator", initialData.Namespace)
+	if opResult != controllerutil.OperationResultNone {
+		r.Log.Info("ocs-operator-config configmap created/updated", "OperationResult", opResult)
+		r.reportOcsOperatorConfigChange(opResult, previousData, ocsOperatorConfig.Data)
+	}
+
+	r.lastOcsOperatorConfig = ocsOperatorConfigObservation{
+		resourceVersion: ocsOperatorConfig.ResourceVersion,
+		inputsHash:      inputsHash,
 	}
 
 	return nil
 }
 
-func (r *OCSInitializationReconciler) getEnableTopologyKeyValue() string {
+func getEnableNFSKeyValue(clusters *util.Clusters) string {
 
-	for _, sc := range r.clusters.GetStorageClusters() {
-		if !sc.Spec.ExternalStorage.Enable && sc.Spec.ManagedResources.CephNonResilientPools.Enable {
-			// In internal mode return true even if one of the storageCluster has enabled it via the CR
+	// return true even if one of the storagecluster is using NFS
+	for _, sc := range clusters.GetStorageClusters() {
+		if sc.Spec.NFS != nil && sc.Spec.NFS.Enable {
 			return "true"
-		} else if sc.Spec.ExternalStorage.Enable {
-			// In external mode, check if the non-resilient storageClass exists
-			scName := util.GenerateNameForNonResilientCephBlockPoolStorageClass(&sc)
-			storageClass := util.GetStorageClassWithName(r.ctx, r.Client, scName)
-			if storageClass != nil {
-				return "true"
-			}
 		}
 	}
 
 	return "false"
 }
 
-// In case of multiple storageClusters when replica-1 is enabled for both an internal and an external cluster, different failure domain keys can lead to complications.
-// To prevent this, when gathering information for the external cluster, ensure that the failure domain is specified to match that of the internal cluster (sc.Status.FailureDomain).
-func (r *OCSInitializationReconciler) getTopologyDomainLabelsKeyValue() string {
-
-	for _, sc := range r.clusters.GetStorageClusters() {
-		if !sc.Spec.ExternalStorage.Enable && sc.Spec.ManagedResources.CephNonResilientPools.Enable {
-			// In internal mode return the failure domain key directly from the storageCluster
-			return sc.Status.FailureDomainKey
-		} else if sc.Spec.ExternalStorage.Enable {
-			// In external mode, check if the non-resilient storageClass exists
-			// determine the failure domain key from the storageClass parameter
-			scName := util.GenerateNameForNonResilientCephBlockPoolStorageClass(&sc)
-			storageClass := util.GetStorageClassWithName(r.ctx, r.Client, scName)
-			if storageClass != nil {
-				return getFailureDomainKeyFromStorageClassParameter(storageClass)
-			}
+// getEnableReadAffinityKeyValue returns true if the read affinity of the CSI driver is enabled for any of the
+// storageclusters, honoring their explicit EnableReadAffinity toggle before the default derived from external mode
+func getEnableReadAffinityKeyValue(clusters *util.Clusters) string {
+	for _, sc := range clusters.GetStorageClusters() {
+		if util.GetReadAffinityOptions(&sc).Enabled {
+			return "true"
 		}
 	}
+	return "false"
+}
+
+// cephFSSubvolumeGroupPinningPolicies are the allowed policies for pinning the CephFS subvolume groups
+var cephFSSubvolumeGroupPinningPolicies = []string{"export", "distributed", "random"}
 
+// getCephFSSubvolumeGroupPinningKeyValue returns the pinning policy of the first internal storagecluster that
+// sets one, or an empty string if none does. Policies that are not allowed are ignored.
+func getCephFSSubvolumeGroupPinningKeyValue(log logr.Logger, clusters *util.Clusters) string {
+	for _, sc := range clusters.GetInternalStorageClusters() {
+		policy := sc.Spec.ManagedResources.CephFilesystems.SubvolumeGroupPinning
+		if policy == "" {
+			continue
+		}
+		if !slices.Contains(cephFSSubvolumeGroupPinningPolicies, policy) {
+			log.Info("Ignoring unknown CephFS subvolume group pinning policy.", "StorageCluster", klog.KObj(&sc),
+				"Policy", policy, "AllowedPolicies", cephFSSubvolumeGroupPinningPolicies)
+			continue
+		}
+		return policy
+	}
 	return ""
 }
 
-func (r *OCSInitializationReconciler) getEnableNFSKeyValue() string {
+// getRbdRadosNamespaceKeyValue returns the RBD namespace of the first internal storagecluster that sets one,
+// or an empty string if none does. Names that are not legal namespace names are ignored.
+func getRbdRadosNamespaceKeyValue(log logr.Logger, clusters *util.Clusters) string {
+	for _, sc := range clusters.GetInternalStorageClusters() {
+		radosNamespace := sc.Spec.ManagedResources.CephBlockPools.RadosNamespace
+		if radosNamespace == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Label(radosNamespace); len(errs) > 0 {
+			log.Info("Ignoring invalid RBD namespace.", "StorageCluster", klog.KObj(&sc),
+				"RadosNamespace", radosNamespace, "Errors", errs)
+			continue
+		}
+		return radosNamespace
+	}
+	return ""
+}
 
-	// return true even if one of the storagecluster is using NFS
-	for _, sc := range r.clusters.GetStorageClusters() {
-		if sc.Spec.NFS != nil && sc.Spec.NFS.Enable {
-			return "true"
+// getRbdThickProvisionKeyValue returns whether the first internal storagecluster that sets the provisioning mode
+// thick provisions the RBD volumes, or an empty string if none sets it
+func getRbdThickProvisionKeyValue(clusters *util.Clusters) string {
+	for _, sc := range clusters.GetInternalStorageClusters() {
+		if thickProvision := sc.Spec.ManagedResources.CephBlockPools.ThickProvision; thickProvision != nil {
+			return strconv.FormatBool(*thickProvision)
 		}
 	}
+	return ""
+}
 
-	return "false"
+// rbdMirrorModes are the allowed RBD mirroring modes
+var rbdMirrorModes = []string{ocsv1.MirroringModeJournal, ocsv1.MirroringModeSnapshot}
+
+// getRbdMirrorModeKeyValue returns the RBD mirroring mode of the first internal storagecluster that has mirroring
+// enabled, or an empty string if none does. Modes that are not allowed are ignored.
+func getRbdMirrorModeKeyValue(log logr.Logger, clusters *util.Clusters) string {
+	for _, sc := range clusters.GetInternalStorageClusters() {
+		if sc.Spec.Mirroring == nil || !sc.Spec.Mirroring.Enabled {
+			continue
+		}
+		mode := sc.Spec.Mirroring.Mode
+		if mode == "" {
+			return ocsv1.MirroringModeSnapshot
+		}
+		if !slices.Contains(rbdMirrorModes, mode) {
+			log.Info("Ignoring unknown RBD mirroring mode.", "StorageCluster", klog.KObj(&sc),
+				"Mode", mode, "AllowedModes", rbdMirrorModes)
+			continue
+		}
+		return mode
+	}
+	return ""
 }
 
 func (r *OCSInitializationReconciler) getEnableCephfsKeyValue() (string, error) {
@@ -623,18 +1212,6 @@ func (r *OCSInitializationReconciler) getEnableCephfsKeyValue() (string, error)
 	return "false", nil
 }
 
-func getFailureDomainKeyFromStorageClassParameter(sc *storagev1.StorageClass) string {
-	failuredomain := sc.Parameters["topologyFailureDomainLabel"]
-	if failuredomain == "zone" {
-		return "topology.kubernetes.io/zone"
-	} else if failuredomain == "rack" {
-		return "topology.rook.io/rack"
-	} else if failuredomain == "hostname" || failuredomain == "host" {
-		return "kubernetes.io/hostname"
-	}
-	return ""
-}
-
 func (r *OCSInitializationReconciler) reconcileUXBackendSecret(initialData *ocsv1.OCSInitialization) error {
 
 	var err error
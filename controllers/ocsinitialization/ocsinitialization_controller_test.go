@@ -14,8 +14,10 @@ import (
 	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
 	"github.com/red-hat-storage/ocs-operator/v4/controllers/platform"
 	statusutil "github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	extensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -56,7 +58,11 @@ func getTestParams(mockNamespace bool, t *testing.T) (v1.OCSInitialization, reco
 		},
 	}
 
-	reconciler := getReconciler(t, &ocs)
+	// a pending config change fails the reconcile unless there is a rook-ceph-operator to restart
+	rookCephOperatorPod := newTestRookCephOperatorPod()
+	rookCephOperatorPod.Namespace = request.Namespace
+
+	reconciler := getReconciler(t, &ocs, rookCephOperatorPod)
 	//The fake client stores the objects after adding a resource version to
 	//them. This is a breaking change introduced in
 	//https://github.com/kubernetes-sigs/controller-runtime/pull/1306.
@@ -76,10 +82,12 @@ func getReconciler(t *testing.T, objs ...client.Object) OCSInitializationReconci
 	log := logf.Log.WithName("controller_storagecluster_test")
 
 	return OCSInitializationReconciler{
-		Scheme:         scheme,
-		Client:         client,
-		SecurityClient: secClient,
-		Log:            log,
+		Scheme:                scheme,
+		Client:                client,
+		SecurityClient:        secClient,
+		Log:                   log,
+		clusterVersionBreaker: &clusterVersionBreaker{},
+		topologyNodeLabels:    &topologyNodeLabels{},
 	}
 }
 
@@ -126,6 +134,21 @@ func createFakeScheme(t *testing.T) *runtime.Scheme {
 		assert.Fail(t, "failed to add storagev1 scheme")
 	}
 
+	err = rookCephv1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add rookCephv1 scheme")
+	}
+
+	err = configv1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add configv1 scheme")
+	}
+
+	err = coordinationv1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add coordinationv1 scheme")
+	}
+
 	return scheme
 }
 
@@ -0,0 +1,497 @@
+package ocsinitialization
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/ocsconfig"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// OcsOperatorConfigDefaultsName is the name of the optional configmap holding defaults for the
+	// ocs-operator-config keys. There is a single ocs-operator-config for the whole cluster, which all the
+	// defaults are merged into. The configmap in the operator namespace may set any key, while the ones in
+	// the storagecluster namespaces may only set the ocsOperatorConfigTunableKeys.
+	OcsOperatorConfigDefaultsName = "ocs-operator-config-defaults"
+
+	// OcsOperatorConfigOverridesAnnotation can be set on a StorageCluster to a JSON object of
+	// ocs-operator-config keys and values that take precedence over any configured defaults. Only the
+	// ocsOperatorConfigOverridableKeys are taken from it.
+	OcsOperatorConfigOverridesAnnotation = "ocs.openshift.io/ocs-operator-config-overrides"
+
+	// ConfigDataChecksumAnnotation is set on ocs-operator-config and its slot configmaps to the checksum of the
+	// sorted serialization of their data, which only changes when the data does. A restart of the
+	// rook-ceph-operator sets it on the Deployment to the checksum of ocs-operator-config, and a rollout restart on
+	// its pod template as well.
+	ConfigDataChecksumAnnotation = "ocs.openshift.io/config-data-checksum"
+)
+
+// ocsOperatorConfigObservation is what was observed after the last successful reconcile of the ocs-operator-config configmap
+type ocsOperatorConfigObservation struct {
+	// resourceVersion is the resourceVersion of the configmap after it was last written or verified
+	resourceVersion string
+	// inputsHash is the hash of the resourceVersions of all the objects the config data is computed from
+	inputsHash string
+}
+
+// getOcsOperatorConfigInputsHash returns a hash over the resourceVersions of all the objects the
+// ocs-operator-config data is derived from. If the hash is unchanged, so is the desired config data.
+func (r *OCSInitializationReconciler) getOcsOperatorConfigInputsHash(initialData *ocsv1.OCSInitialization, nodes, pluginNodes []corev1.Node,
+	clusterVersion clusterVersionInfo) (string, error) {
+	inputs := []string{}
+
+	operatorNamespace := &corev1.Namespace{}
+	if err := r.Client.Get(r.ctx, types.NamespacedName{Name: r.OperatorNamespace}, operatorNamespace); err == nil {
+		inputs = append(inputs, fmt.Sprintf("Namespace/%s@%s", r.OperatorNamespace, operatorNamespace.ResourceVersion))
+	} else if !errors.IsNotFound(err) {
+		return "", err
+	}
+
+	inputs = append(inputs, fmt.Sprintf("OCPVersion=%s", clusterVersion.ocpVersion))
+	inputs = append(inputs, fmt.Sprintf("ClusterID=%s", clusterVersion.clusterID))
+	// the migration window passes without any change of the storageclusters
+	inputs = append(inputs, fmt.Sprintf("ClusterIDMigration=%s", getClusterIDMigrationInput(initialData)))
+	// as do the transitions of the renamed keys
+	inputs = append(inputs, fmt.Sprintf("KeyRenames=%s", getConfigKeyRenameInput(initialData)))
+	inputs = append(inputs, fmt.Sprintf("TopologyLabelRenames=%s", getTopologyLabelRenameInput(initialData)))
+
+	for _, sc := range r.clusters.GetStorageClusters() {
+		inputs = append(inputs, fmt.Sprintf("StorageCluster/%s/%s@%s", sc.Namespace, sc.Name, sc.ResourceVersion))
+	}
+
+	storageClasses := &storagev1.StorageClassList{}
+	if err := r.Client.List(r.ctx, storageClasses); err != nil {
+		return "", err
+	}
+	for _, sc := range storageClasses.Items {
+		inputs = append(inputs, fmt.Sprintf("StorageClass/%s@%s", sc.Name, sc.ResourceVersion))
+	}
+
+	// only the number of nodes, the nodes running the CSI node plugins and their zones, the zone sizes, OS images
+	// and topology labels matter, the nodes themselves change too often
+	inputs = append(inputs, fmt.Sprintf("Nodes=%d", len(nodes)))
+	inputs = append(inputs, fmt.Sprintf("NodeZones=%s", strings.Join(getZonesOfNodes(pluginNodes), ",")))
+	inputs = append(inputs, fmt.Sprintf("CSINodePluginNodes=%s", strings.Join(getCSINodePluginNodeNames(pluginNodes), ",")))
+	inputs = append(inputs, fmt.Sprintf("NodeOSImages=%s", strings.Join(getNodeOSImages(nodes), ",")))
+	inputs = append(inputs, fmt.Sprintf("NodeZoneSizes=%s", formatZoneSizes(getZoneSizes(nodes))))
+	nodeLabels := r.topologyNodeLabels.get().Union(getTopologyNodeLabels(r.clusters.GetStorageClusters(), ""))
+	inputs = append(inputs, fmt.Sprintf("NodeTopologyLabels=%s", formatNodeTopologyLabels(nodes, nodeLabels)))
+
+	volumeTopologyKeys, err := r.getBoundVolumeTopologyKeys()
+	if err != nil {
+		return "", err
+	}
+	inputs = append(inputs, fmt.Sprintf("BoundVolumeTopologyKeys=%s", strings.Join(volumeTopologyKeys, ",")))
+	for i := range r.clusters.GetInternalStorageClusters() {
+		sc := &r.clusters.GetInternalStorageClusters()[i]
+		lsoDeviceNodeNames, err := getLSODeviceNodeNames(r.ctx, r.Client, sc)
+		if err != nil {
+			return "", err
+		}
+		if len(lsoDeviceNodeNames) == 0 {
+			continue
+		}
+		domainLabel, err := resolveLSODeviceDomainLabel(r.ctx, r.Client, sc, sc.Status.FailureDomainKey, r.getTopologyNodeGroupOverrides())
+		if err != nil {
+			return "", err
+		}
+		inputs = append(inputs, fmt.Sprintf("LSODeviceNodes/%s/%s=%s@%s", sc.Namespace, sc.Name, strings.Join(lsoDeviceNodeNames, ","), domainLabel))
+	}
+
+	csiDrivers, err := r.getTopologyCSIDrivers()
+	if err != nil {
+		return "", err
+	}
+	for _, csiDriver := range csiDrivers {
+		inputs = append(inputs, fmt.Sprintf("CSIDriver/%s@%s", csiDriver.Name, csiDriver.ResourceVersion))
+	}
+
+	ocsConfigs := &ocsv1.OCSConfigList{}
+	if err := r.Client.List(r.ctx, ocsConfigs); err != nil {
+		return "", err
+	}
+	for _, ocsConfig := range ocsConfigs.Items {
+		inputs = append(inputs, fmt.Sprintf("OCSConfig/%s/%s@%s", ocsConfig.Namespace, ocsConfig.Name, ocsConfig.ResourceVersion))
+	}
+
+	networkFenceClasses, err := r.listNetworkFenceClasses()
+	if err != nil {
+		return "", err
+	}
+	for _, networkFenceClass := range networkFenceClasses {
+		inputs = append(inputs, fmt.Sprintf("NetworkFenceClass/%s@%s", networkFenceClass.GetName(), networkFenceClass.GetResourceVersion()))
+	}
+
+	cephFilesystems, _, err := r.listCephFilesystems()
+	if err != nil {
+		return "", err
+	}
+	for _, cephFilesystem := range cephFilesystems {
+		inputs = append(inputs, fmt.Sprintf("CephFilesystem/%s/%s@%s", cephFilesystem.Namespace, cephFilesystem.Name, cephFilesystem.ResourceVersion))
+	}
+
+	cephBlockPools, err := r.listCephBlockPools()
+	if err != nil {
+		return "", err
+	}
+	for _, cephBlockPool := range cephBlockPools {
+		inputs = append(inputs, fmt.Sprintf("CephBlockPool/%s/%s@%s", cephBlockPool.Namespace, cephBlockPool.Name, cephBlockPool.ResourceVersion))
+	}
+
+	cephObjectStore, err := r.getManagedCephObjectStore()
+	if err != nil {
+		return "", err
+	}
+	if cephObjectStore != nil {
+		inputs = append(inputs, fmt.Sprintf("CephObjectStore/%s/%s@%s", cephObjectStore.Namespace, cephObjectStore.Name, cephObjectStore.ResourceVersion))
+	}
+	cephObjectZone, err := r.getManagedCephObjectZone(cephObjectStore)
+	if err != nil {
+		return "", err
+	}
+	if cephObjectZone != nil {
+		inputs = append(inputs, fmt.Sprintf("CephObjectZone/%s/%s@%s", cephObjectZone.Namespace, cephObjectZone.Name, cephObjectZone.ResourceVersion))
+	}
+
+	_, kmsConfigMap, err := r.getRbdEncryptionKMSConfigMap()
+	if err != nil {
+		return "", err
+	}
+	if kmsConfigMap != nil {
+		inputs = append(inputs, fmt.Sprintf("ConfigMap/%s/%s@%s", kmsConfigMap.Namespace, kmsConfigMap.Name, kmsConfigMap.ResourceVersion))
+	}
+
+	boundsConfigMap := &corev1.ConfigMap{}
+	err = r.Client.Get(r.ctx, types.NamespacedName{Name: ocsconfig.TunableBoundsConfigMapName, Namespace: r.OperatorNamespace}, boundsConfigMap)
+	if err == nil {
+		inputs = append(inputs, fmt.Sprintf("ConfigMap/%s/%s@%s", r.OperatorNamespace, ocsconfig.TunableBoundsConfigMapName, boundsConfigMap.ResourceVersion))
+	} else if !errors.IsNotFound(err) {
+		return "", err
+	}
+
+	for _, namespace := range append([]string{r.OperatorNamespace}, r.clusters.GetNamespaces()...) {
+		defaultsConfigMap := &corev1.ConfigMap{}
+		err := r.Client.Get(r.ctx, types.NamespacedName{Name: OcsOperatorConfigDefaultsName, Namespace: namespace}, defaultsConfigMap)
+		if err == nil {
+			inputs = append(inputs, fmt.Sprintf("ConfigMap/%s/%s@%s", namespace, OcsOperatorConfigDefaultsName, defaultsConfigMap.ResourceVersion))
+		} else if !errors.IsNotFound(err) {
+			return "", err
+		}
+	}
+
+	return hashOcsOperatorConfigInputs(inputs), nil
+}
+
+// hashOcsOperatorConfigInputs hashes the inputs independently of their order, as the objects are not
+// guaranteed to be listed in the same order every time
+func hashOcsOperatorConfigInputs(inputs []string) string {
+	return util.CalculateMD5Hash(slices.Sorted(slices.Values(inputs)))
+}
+
+// serializeOcsOperatorConfigData serializes the config data as a JSON array of key and value pairs sorted by
+// the key, so that equal data is always serialized the same
+func serializeOcsOperatorConfigData(data map[string]string) string {
+	pairs := make([][2]string, 0, len(data))
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		pairs = append(pairs, [2]string{key, data[key]})
+	}
+	// marshaling a slice of strings cannot fail, only unsupported types and values can
+	serialized, _ := json.Marshal(pairs)
+	return string(serialized)
+}
+
+// getOcsOperatorConfigChanges returns the changed keys of the config data with their old and new values,
+// sorted by key, with "<unset>" for the value of a key that is added or removed
+func getOcsOperatorConfigChanges(oldData, newData map[string]string) []string {
+	changes := []string{}
+	for _, key := range sets.List(getChangedConfigKeys(oldData, newData)) {
+		oldValue, ok := oldData[key]
+		if !ok {
+			oldValue = "<unset>"
+		}
+		newValue, ok := newData[key]
+		if !ok {
+			newValue = "<unset>"
+		}
+		changes = append(changes, fmt.Sprintf("%s: %q -> %q", key, oldValue, newValue))
+	}
+	return changes
+}
+
+// reportOcsOperatorConfigChange reports a created or updated ocs-operator-config as an event on each storagecluster,
+// naming the changed keys with their old and new values. Every change is reported, as it is an audit trail of the
+// CSI config. An update that only changes the annotations is not reported.
+func (r *OCSInitializationReconciler) reportOcsOperatorConfigChange(opResult controllerutil.OperationResult, oldData, newData map[string]string) {
+	changes := getOcsOperatorConfigChanges(oldData, newData)
+	if r.recorder == nil || len(changes) == 0 {
+		return
+	}
+	message := fmt.Sprintf("%s configmap %s: %s", r.getOcsOperatorConfigName(), opResult, strings.Join(changes, ", "))
+	for i := range r.clusters.GetStorageClusters() {
+		r.recorder.Report(&r.clusters.GetStorageClusters()[i], corev1.EventTypeNormal, util.EventReasonOcsOperatorConfigUpdated, message)
+	}
+}
+
+// setConfigDataChecksum sets the checksum of the data of the configmap in the ConfigDataChecksumAnnotation
+func setConfigDataChecksum(cm *corev1.ConfigMap) {
+	util.AddAnnotation(cm, ConfigDataChecksumAnnotation, util.CalculateMD5Hash(serializeOcsOperatorConfigData(cm.Data)))
+}
+
+// getOcsOperatorConfigChecksum returns the checksum of the ocs-operator-config data, or an empty string if the
+// configmap does not exist
+func (r *OCSInitializationReconciler) getOcsOperatorConfigChecksum(namespace string) (string, error) {
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: r.getOcsOperatorConfigName(), Namespace: namespace}, ocsOperatorConfig)
+	if errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return ocsOperatorConfig.GetAnnotations()[ConfigDataChecksumAnnotation], nil
+}
+
+// ocsOperatorConfigPredicate passes the events of the ocs-operator-config configmap in the operator namespace only.
+// An out-of-band edit changes its resourceVersion, so the next reconcile recomputes the config and reverts the edit,
+// marking the reverted keys for the restart of rook-ceph-operator like any other update. A configmap whose name is
+// overridden on a storagecluster is watched as one of the configmaps owned by the OCSInitialization.
+func ocsOperatorConfigPredicate(namespace string) predicate.Predicate {
+	return predicate.And(util.NamePredicate(util.OcsOperatorConfigName), util.NamespacePredicate(namespace))
+}
+
+// isOcsOperatorConfigUnchanged returns true if neither the ocs-operator-config configmap nor any of the inputs
+// have changed since the last successful reconcile, in which case there is no need to compute the desired data.
+func (r *OCSInitializationReconciler) isOcsOperatorConfigUnchanged(namespace, inputsHash string) bool {
+	if r.lastOcsOperatorConfig.resourceVersion == "" || r.lastOcsOperatorConfig.inputsHash != inputsHash {
+		return false
+	}
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	if err := r.Client.Get(r.ctx, types.NamespacedName{Name: r.getOcsOperatorConfigName(), Namespace: namespace}, ocsOperatorConfig); err != nil {
+		return false
+	}
+	return ocsOperatorConfig.ResourceVersion == r.lastOcsOperatorConfig.resourceVersion
+}
+
+// resolveOcsOperatorConfigData layers the user provided defaults and overrides on top of the built-in
+// values computed by the operator. The merge order, from lowest to highest precedence, is:
+//  1. built-in values computed by the operator from all the storageclusters, seeded with the platform defaults
+//  2. operator-wide defaults from the ocs-operator-config-defaults configmap in the operator namespace
+//  3. defaults from the ocs-operator-config-defaults configmaps in the storagecluster namespaces, limited to the
+//     tunable keys. When namespaces set a key to different values, the first namespace in name order wins.
+//  4. per-StorageCluster tuning preset from the ocs.openshift.io/tuning-preset annotation, adjusting the values above
+//     within the tunable keys
+//  5. per-StorageCluster tunables from the OCSConfig with the same name and namespace as the storagecluster, limited
+//     to the tunable keys within the bounds of the OCSConfig webhook
+//  6. per-StorageCluster overrides from the ocs.openshift.io/ocs-operator-config-overrides annotation
+//
+// Storageclusters are processed in namespace/name order, so with multiple storageclusters the
+// result is deterministic even when they configure the same key.
+//
+// If the topology domain labels are set to different values by more than one of these sources, the one
+// with the highest precedence wins and the ConditionMultipleTopologySources condition is set.
+//
+// The encryption keys are only taken from a source that configures all of them, a source configuring just
+// some of them would leave CSI with half of a mode switch. Such a source sets ConditionIncompleteEncryptionConfig.
+func (r *OCSInitializationReconciler) resolveOcsOperatorConfigData(initialData *ocsv1.OCSInitialization,
+	builtIn map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(builtIn))
+	for key, value := range builtIn {
+		resolved[key] = value
+	}
+
+	topologySources := []topologySource{}
+	incompleteEncryptionSources := []string{}
+	merge := func(source string, values map[string]string) {
+		values, encryption, complete := splitEncryptionKeys(values)
+		if !complete {
+			r.Log.Info("Ignoring the partially configured encryption keys of an ocs-operator-config source.", "Source", source)
+			incompleteEncryptionSources = append(incompleteEncryptionSources, source)
+		}
+		for key, value := range values {
+			resolved[key] = value
+		}
+		for key, value := range encryption {
+			resolved[key] = value
+		}
+		if labels, ok := values[util.TopologyDomainLabelsKey]; ok {
+			topologySources = append(topologySources, topologySource{name: source, labels: labels})
+		}
+	}
+
+	operatorDefaults, err := r.getOcsOperatorConfigDefaults(r.OperatorNamespace)
+	if err != nil {
+		return nil, err
+	}
+	merge(fmt.Sprintf("ConfigMap %s/%s", r.OperatorNamespace, OcsOperatorConfigDefaultsName), operatorDefaults)
+
+	restrictedSources := []string{}
+	conflicts := []string{}
+	namespaceDefaultSources := map[string]string{}
+	namespaceDefaultValues := map[string]string{}
+	for _, namespace := range slices.Sorted(slices.Values(r.clusters.GetNamespaces())) {
+		if namespace == r.OperatorNamespace {
+			continue
+		}
+		namespaceDefaults, err := r.getOcsOperatorConfigDefaults(namespace)
+		if err != nil {
+			return nil, err
+		}
+		source := fmt.Sprintf("ConfigMap %s/%s", namespace, OcsOperatorConfigDefaultsName)
+		tunables, ignored := filterTunableKeys(namespaceDefaults, resolved)
+		if len(ignored) > 0 {
+			r.Log.Info("Warning: Ignoring the keys of a namespace defaults configmap that are not tunable", "Source", source, "Keys", ignored)
+			restrictedSources = append(restrictedSources, fmt.Sprintf("%s (%s)", source, strings.Join(ignored, ", ")))
+		}
+		for _, key := range slices.Sorted(maps.Keys(tunables)) {
+			if first, ok := namespaceDefaultSources[key]; ok {
+				if namespaceDefaultValues[key] != tunables[key] {
+					conflicts = append(conflicts, fmt.Sprintf("%s of %s over %s", key, first, source))
+				}
+				delete(tunables, key)
+				continue
+			}
+			namespaceDefaultSources[key] = source
+			namespaceDefaultValues[key] = tunables[key]
+		}
+		merge(source, withEncryptionKeys(tunables, resolved))
+	}
+
+	tunableBounds, err := ocsconfig.GetTunableBounds(r.ctx, r.Client, r.Log, r.OperatorNamespace)
+	if err != nil {
+		r.Log.Error(err, "Failed to get the bounds of the OCSConfig tunables")
+		return nil, err
+	}
+	outOfBounds := []string{}
+	invalidPresets := []string{}
+	for i := range r.clusters.GetStorageClusters() {
+		sc := &r.clusters.GetStorageClusters()[i]
+		preset, err := getTuningPresetValues(sc, resolved)
+		if err != nil {
+			r.Log.Info("Warning: Ignoring the invalid tuning preset", "Reason", err.Error())
+			invalidPresets = append(invalidPresets, err.Error())
+		}
+		presetSource := fmt.Sprintf("tuning preset %s of StorageCluster %s/%s", sc.GetAnnotations()[TuningPresetAnnotation], sc.Namespace, sc.Name)
+		preset, ignored := filterTunableKeys(preset, resolved)
+		if len(ignored) > 0 {
+			r.Log.Info("Warning: Ignoring the keys of a tuning preset that are not tunable", "Source", presetSource, "Keys", ignored)
+			restrictedSources = append(restrictedSources, fmt.Sprintf("%s (%s)", presetSource, strings.Join(ignored, ", ")))
+		}
+		merge(presetSource, withEncryptionKeys(preset, resolved))
+
+		tunables, err := r.getOCSConfigTunables(sc)
+		if err != nil {
+			return nil, err
+		}
+		source := fmt.Sprintf("OCSConfig %s/%s", sc.Namespace, sc.Name)
+		tunables, ignored = filterTunableKeys(tunables, resolved)
+		if len(ignored) > 0 {
+			r.Log.Info("Warning: Ignoring the keys of an OCSConfig that are not tunable", "Source", source, "Keys", ignored)
+			restrictedSources = append(restrictedSources, fmt.Sprintf("%s (%s)", source, strings.Join(ignored, ", ")))
+		}
+		tunables, tunablesOutOfBounds := filterTunableBounds(tunables, tunableBounds)
+		if len(tunablesOutOfBounds) > 0 {
+			r.Log.Info("Warning: Ignoring the tunables of an OCSConfig outside of their bounds", "Source", source, "Tunables", tunablesOutOfBounds)
+			outOfBounds = append(outOfBounds, fmt.Sprintf("%s (%s)", source, strings.Join(tunablesOutOfBounds, ", ")))
+		}
+		merge(source, withEncryptionKeys(tunables, resolved))
+
+		overrides, err := getOcsOperatorConfigOverrides(sc)
+		if err != nil {
+			return nil, err
+		}
+		source = fmt.Sprintf("annotation %s on StorageCluster %s/%s", OcsOperatorConfigOverridesAnnotation, sc.Namespace, sc.Name)
+		overrides, ignored = filterOverridableKeys(overrides, resolved)
+		if len(ignored) > 0 {
+			r.Log.Info("Warning: Ignoring the keys of an overrides annotation that may not be overridden", "Source", source, "Keys", ignored)
+			restrictedSources = append(restrictedSources, fmt.Sprintf("%s (%s)", source, strings.Join(ignored, ", ")))
+			if r.recorder != nil {
+				r.recorder.Report(sc, corev1.EventTypeWarning, util.EventReasonOcsOperatorConfigKeysIgnored,
+					fmt.Sprintf("ignoring the keys %s of annotation %s, they may not be overridden", strings.Join(ignored, ", "),
+						OcsOperatorConfigOverridesAnnotation))
+			}
+		}
+		merge(source, withEncryptionKeys(overrides, resolved))
+	}
+
+	r.validateTopologySources(initialData, topologySources)
+	validateEncryptionSources(initialData, incompleteEncryptionSources)
+	validateTunableSources(initialData, restrictedSources, conflicts, outOfBounds)
+	validateTuningPresets(initialData, invalidPresets)
+
+	return resolved, nil
+}
+
+// getOcsOperatorConfigDefaults returns the data of the ocs-operator-config-defaults configmap in the given namespace.
+// A missing configmap is not an error and results in no defaults.
+func (r *OCSInitializationReconciler) getOcsOperatorConfigDefaults(namespace string) (map[string]string, error) {
+	if namespace == "" {
+		return nil, nil
+	}
+	defaultsConfigMap := &corev1.ConfigMap{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: OcsOperatorConfigDefaultsName, Namespace: namespace}, defaultsConfigMap)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		r.Log.Error(err, "Failed to get ocs-operator-config defaults configmap", "Namespace", namespace)
+		return nil, err
+	}
+	return defaultsConfigMap.Data, nil
+}
+
+// getOCSConfigTunables returns the tunables of the OCSConfig belonging to the StorageCluster.
+// A missing OCSConfig is not an error and results in no tunables.
+func (r *OCSInitializationReconciler) getOCSConfigTunables(sc *ocsv1.StorageCluster) (map[string]string, error) {
+	ocsConfig := &ocsv1.OCSConfig{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: sc.Name, Namespace: sc.Namespace}, ocsConfig)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		r.Log.Error(err, "Failed to get OCSConfig", "OCSConfig", klog.KRef(sc.Namespace, sc.Name))
+		return nil, err
+	}
+	return ocsConfig.Spec.Tunables, nil
+}
+
+// getOcsOperatorConfigOverrides parses the per-StorageCluster overrides annotation
+func getOcsOperatorConfigOverrides(sc *ocsv1.StorageCluster) (map[string]string, error) {
+	value, ok := sc.GetAnnotations()[OcsOperatorConfigOverridesAnnotation]
+	if !ok || value == "" {
+		return nil, nil
+	}
+	overrides := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse annotation %q on StorageCluster %s/%s: %v",
+			OcsOperatorConfigOverridesAnnotation, sc.Namespace, sc.Name, err)
+	}
+	return overrides, nil
+}
+
+// setOcsOperatorConfigCondition sets the condition on the OCSInitialization while the reported situation is
+// active and removes it otherwise, so that it only shows up when there is something to report.
+func setOcsOperatorConfigCondition(initialData *ocsv1.OCSInitialization, conditionType conditionsv1.ConditionType,
+	active bool, reason, message string) {
+	if !active {
+		conditionsv1.RemoveStatusCondition(&initialData.Status.Conditions, conditionType)
+		return
+	}
+	conditionsv1.SetStatusCondition(&initialData.Status.Conditions, conditionsv1.Condition{
+		Type:    conditionType,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+}
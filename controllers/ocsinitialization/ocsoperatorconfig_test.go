@@ -0,0 +1,532 @@
+package ocsinitialization
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const testOperatorNamespace = "openshift-storage"
+
+func newTestStorageCluster(name, namespace string) *v1.StorageCluster {
+	return &v1.StorageCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+}
+
+func newTestConfigMap(name, namespace string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Data: data,
+	}
+}
+
+// getOcsOperatorConfigTestReconciler returns a reconciler that is ready for calling ensureOcsOperatorConfigExists
+func getOcsOperatorConfigTestReconciler(t *testing.T, objs ...client.Object) (*v1.OCSInitialization, OCSInitializationReconciler) {
+	ocsInit := &v1.OCSInitialization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ocsinit",
+			Namespace: testOperatorNamespace,
+		},
+	}
+	reconciler := getReconciler(t, append(objs, ocsInit)...)
+	reconciler.OperatorNamespace = testOperatorNamespace
+	reconciler.ctx = context.TODO()
+
+	var err error
+	reconciler.clusters, err = util.GetClusters(reconciler.ctx, reconciler.Client)
+	assert.NoError(t, err)
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(ocsInit), ocsInit))
+
+	return ocsInit, reconciler
+}
+
+func getOcsOperatorConfigData(t *testing.T, reconciler OCSInitializationReconciler) map[string]string {
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	err := reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: util.OcsOperatorConfigName, Namespace: testOperatorNamespace}, ocsOperatorConfig)
+	assert.NoError(t, err)
+	return ocsOperatorConfig.Data
+}
+
+func TestOcsOperatorConfigPrecedence(t *testing.T) {
+	overriddenSC := newTestStorageCluster("ocs-storagecluster", "tenant-ns")
+	overriddenSC.Annotations = map[string]string{
+		OcsOperatorConfigOverridesAnnotation: `{"` + util.EnableReadAffinityKey + `":"per-storagecluster"}`,
+	}
+
+	testcases := []struct {
+		label    string
+		objs     []client.Object
+		expected map[string]string
+	}{
+		{
+			label: "built-in values only",
+			objs:  []client.Object{newTestStorageCluster("ocs-storagecluster", "tenant-ns")},
+			expected: map[string]string{
+				util.EnableNFSKey:        "false",
+				util.EnableTopologyKey:   "false",
+				util.DisableCSIDriverKey: "true",
+			},
+		},
+		{
+			label: "operator defaults override built-in values",
+			objs: []client.Object{
+				newTestStorageCluster("ocs-storagecluster", "tenant-ns"),
+				newTestConfigMap(OcsOperatorConfigDefaultsName, testOperatorNamespace, map[string]string{
+					util.EnableNFSKey:          "operator-default",
+					util.EnableTopologyKey:     "operator-default",
+					util.EnableReadAffinityKey: "operator-default",
+				}),
+			},
+			expected: map[string]string{
+				util.EnableNFSKey:        "operator-default",
+				util.EnableTopologyKey:   "operator-default",
+				util.DisableCSIDriverKey: "true",
+			},
+		},
+		{
+			label: "namespace defaults override operator defaults",
+			objs: []client.Object{
+				newTestStorageCluster("ocs-storagecluster", "tenant-ns"),
+				newTestConfigMap(OcsOperatorConfigDefaultsName, testOperatorNamespace, map[string]string{
+					util.EnableNFSKey:          "operator-default",
+					util.EnableTopologyKey:     "operator-default",
+					util.EnableReadAffinityKey: "operator-default",
+				}),
+				newTestConfigMap(OcsOperatorConfigDefaultsName, "tenant-ns", map[string]string{
+					util.EnableReadAffinityKey: "namespace-default",
+				}),
+			},
+			expected: map[string]string{
+				util.EnableNFSKey:          "operator-default",
+				util.EnableTopologyKey:     "operator-default",
+				util.EnableReadAffinityKey: "namespace-default",
+				util.DisableCSIDriverKey:   "true",
+			},
+		},
+		{
+			label: "per-storagecluster overrides take precedence over all defaults",
+			objs: []client.Object{
+				overriddenSC,
+				newTestConfigMap(OcsOperatorConfigDefaultsName, testOperatorNamespace, map[string]string{
+					util.EnableNFSKey:          "operator-default",
+					util.EnableTopologyKey:     "operator-default",
+					util.EnableReadAffinityKey: "operator-default",
+				}),
+				newTestConfigMap(OcsOperatorConfigDefaultsName, "tenant-ns", map[string]string{
+					util.EnableReadAffinityKey: "namespace-default",
+				}),
+			},
+			expected: map[string]string{
+				util.EnableNFSKey:          "operator-default",
+				util.EnableTopologyKey:     "operator-default",
+				util.EnableReadAffinityKey: "per-storagecluster",
+				util.DisableCSIDriverKey:   "true",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, tc.objs...)
+		err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		for key, value := range tc.expected {
+			assert.Equalf(t, value, data[key], "[%s]: unexpected value for key %s", tc.label, key)
+		}
+	}
+}
+
+func TestOcsOperatorConfigInvalidOverrides(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Annotations = map[string]string{OcsOperatorConfigOverridesAnnotation: "not-json"}
+
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+	err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+	assert.Error(t, err)
+}
+
+// testGRPCTimeoutTunable is a numeric tunable of the OCSConfigs
+const testGRPCTimeoutTunable = "CSI_GRPC_TIMEOUT_SECONDS"
+
+func TestOcsOperatorConfigFromOCSConfig(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Annotations = map[string]string{
+		OcsOperatorConfigOverridesAnnotation: `{"` + util.EnableReadAffinityKey + `":"per-storagecluster"}`,
+	}
+	ocsConfig := &v1.OCSConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sc.Name,
+			Namespace: sc.Namespace,
+		},
+		Spec: v1.OCSConfigSpec{
+			Tunables: map[string]string{
+				testGRPCTimeoutTunable:     "60",
+				util.EnableReadAffinityKey: "ocsconfig",
+				util.EnableNFSKey:          "ocsconfig",
+			},
+		},
+	}
+	defaults := newTestConfigMap(OcsOperatorConfigDefaultsName, testOperatorNamespace, map[string]string{
+		testGRPCTimeoutTunable: "30",
+	})
+
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, ocsConfig, defaults)
+	err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+	assert.NoError(t, err)
+
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "60", data[testGRPCTimeoutTunable], "OCSConfig tunables should override the defaults")
+	assert.Equal(t, "per-storagecluster", data[util.EnableReadAffinityKey], "overrides annotation should override the OCSConfig tunables")
+	assert.Equal(t, "false", data[util.EnableNFSKey], "OCSConfig keys that are not tunable should be ignored")
+	assert.NotNil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionRestrictedConfigKeysIgnored))
+
+	// an update of the OCSConfig is reflected in the next reconcile
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(ocsConfig), ocsConfig))
+	ocsConfig.Spec.Tunables[testGRPCTimeoutTunable] = "120"
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, ocsConfig))
+
+	err = reconciler.ensureOcsOperatorConfigExists(ocsInit)
+	assert.NoError(t, err)
+	assert.Equal(t, "120", getOcsOperatorConfigData(t, reconciler)[testGRPCTimeoutTunable])
+
+	// removing the OCSConfig falls back to the defaults
+	assert.NoError(t, reconciler.Client.Delete(reconciler.ctx, ocsConfig))
+	err = reconciler.ensureOcsOperatorConfigExists(ocsInit)
+	assert.NoError(t, err)
+	assert.Equal(t, "30", getOcsOperatorConfigData(t, reconciler)[testGRPCTimeoutTunable])
+	assert.Nil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionRestrictedConfigKeysIgnored))
+}
+
+func TestOcsOperatorConfigChangeDetection(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+
+	// count how often the desired data is computed, which requires reading the OCSConfig of the storagecluster
+	computations := 0
+	reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*v1.OCSConfig); ok {
+				computations++
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+
+	err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, computations, "the first reconcile must compute the desired data")
+
+	err = reconciler.ensureOcsOperatorConfigExists(ocsInit)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, computations, "nothing changed, the reconcile should have been short-circuited")
+
+	// an out of band edit of the configmap changes its resourceVersion and is reverted by the full path
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: util.OcsOperatorConfigName, Namespace: testOperatorNamespace}, ocsOperatorConfig))
+	ocsOperatorConfig.Data[util.EnableNFSKey] = "edited"
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, ocsOperatorConfig))
+
+	err = reconciler.ensureOcsOperatorConfigExists(ocsInit)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, computations, "the configmap changed, the full comparison must run")
+	assert.Equal(t, "false", getOcsOperatorConfigData(t, reconciler)[util.EnableNFSKey])
+
+	// a change of the inputs also results in the full path
+	sc := &v1.StorageCluster{}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: "ocs-storagecluster", Namespace: testOperatorNamespace}, sc))
+	sc.Spec.NFS = &v1.NFSSpec{Enable: true}
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, sc))
+	reconciler.clusters, err = util.GetClusters(reconciler.ctx, reconciler.Client)
+	assert.NoError(t, err)
+
+	err = reconciler.ensureOcsOperatorConfigExists(ocsInit)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, computations, "the inputs changed, the full comparison must run")
+	assert.Equal(t, "true", getOcsOperatorConfigData(t, reconciler)[util.EnableNFSKey])
+}
+
+func TestOcsOperatorConfigChangeEvents(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+	recorder := record.NewFakeRecorder(10)
+	reconciler.recorder = util.NewEventReporter(recorder)
+
+	// the created configmap is reported with all its keys
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.Contains(t, event, "Normal "+util.EventReasonOcsOperatorConfigUpdated+" ocs-operator-config configmap created: ")
+	assert.Contains(t, event, util.EnableNFSKey+`: "<unset>" -> "false"`)
+
+	// an update is reported with the changed keys only
+	sc := &v1.StorageCluster{}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: "ocs-storagecluster", Namespace: testOperatorNamespace}, sc))
+	sc.Spec.NFS = &v1.NFSSpec{Enable: true}
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, sc))
+	var err error
+	reconciler.clusters, err = util.GetClusters(reconciler.ctx, reconciler.Client)
+	assert.NoError(t, err)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Len(t, recorder.Events, 1)
+	assert.Equal(t, "Normal "+util.EventReasonOcsOperatorConfigUpdated+" ocs-operator-config configmap updated: "+
+		util.EnableNFSKey+`: "false" -> "true"`, <-recorder.Events)
+
+	// the same change reverted and applied again is reported every time
+	for _, enable := range []bool{false, true} {
+		assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(sc), sc))
+		sc.Spec.NFS.Enable = enable
+		assert.NoError(t, reconciler.Client.Update(reconciler.ctx, sc))
+		reconciler.clusters, err = util.GetClusters(reconciler.ctx, reconciler.Client)
+		assert.NoError(t, err)
+		assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+		assert.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, util.EnableNFSKey)
+	}
+
+	// an unchanged config is not reported
+	reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Empty(t, recorder.Events)
+}
+
+func TestOcsOperatorConfigSubvolumeGroupPinning(t *testing.T) {
+	testcases := []struct {
+		label    string
+		policy   string
+		expected string
+		present  bool
+	}{
+		{label: "export policy", policy: "export", expected: "export", present: true},
+		{label: "distributed policy", policy: "distributed", expected: "distributed", present: true},
+		{label: "random policy", policy: "random", expected: "random", present: true},
+		{label: "unset policy", policy: "", present: false},
+		{label: "unknown policy", policy: "round-robin", present: false},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Spec.ManagedResources.CephFilesystems.SubvolumeGroupPinning = tc.policy
+
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		value, ok := getOcsOperatorConfigData(t, reconciler)[util.CephFSSubvolumeGroupPinningKey]
+		assert.Equalf(t, tc.present, ok, "[%s]: unexpected presence of the pinning key", tc.label)
+		assert.Equalf(t, tc.expected, value, "[%s]: unexpected pinning policy", tc.label)
+	}
+}
+
+func TestOcsOperatorConfigRbdRadosNamespace(t *testing.T) {
+	testcases := []struct {
+		label          string
+		radosNamespace string
+		present        bool
+	}{
+		{label: "rados namespace set", radosNamespace: "tenant-a", present: true},
+		{label: "rados namespace unset", radosNamespace: "", present: false},
+		{label: "invalid rados namespace", radosNamespace: "Tenant_A", present: false},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Spec.ManagedResources.CephBlockPools.RadosNamespace = tc.radosNamespace
+
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		value, ok := getOcsOperatorConfigData(t, reconciler)[util.RbdRadosNamespaceKey]
+		assert.Equalf(t, tc.present, ok, "[%s]: unexpected presence of the rados namespace key", tc.label)
+		if tc.present {
+			assert.Equalf(t, tc.radosNamespace, value, "[%s]: unexpected rados namespace", tc.label)
+		}
+	}
+}
+
+func TestOcsOperatorConfigRbdThickProvision(t *testing.T) {
+	testcases := []struct {
+		label          string
+		thickProvision *bool
+		expected       string
+		present        bool
+	}{
+		{label: "thick provisioning", thickProvision: ptr.To(true), expected: "true", present: true},
+		{label: "thin provisioning", thickProvision: ptr.To(false), expected: "false", present: true},
+		{label: "provisioning mode unset", thickProvision: nil, present: false},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Spec.ManagedResources.CephBlockPools.ThickProvision = tc.thickProvision
+
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		value, ok := getOcsOperatorConfigData(t, reconciler)[util.RbdThickProvisionKey]
+		assert.Equalf(t, tc.present, ok, "[%s]: unexpected presence of the thick provision key", tc.label)
+		assert.Equalf(t, tc.expected, value, "[%s]: unexpected provisioning mode", tc.label)
+	}
+}
+
+func TestOcsOperatorConfigRbdMirrorMode(t *testing.T) {
+	testcases := []struct {
+		label     string
+		mirroring *v1.MirroringSpec
+		expected  string
+		present   bool
+	}{
+		{label: "journal mode", mirroring: &v1.MirroringSpec{Enabled: true, Mode: "journal"}, expected: "journal", present: true},
+		{label: "snapshot mode", mirroring: &v1.MirroringSpec{Enabled: true, Mode: "snapshot"}, expected: "snapshot", present: true},
+		{label: "default mode", mirroring: &v1.MirroringSpec{Enabled: true}, expected: "snapshot", present: true},
+		{label: "unknown mode", mirroring: &v1.MirroringSpec{Enabled: true, Mode: "image"}, present: false},
+		{label: "mirroring disabled", mirroring: &v1.MirroringSpec{Enabled: false, Mode: "journal"}, present: false},
+		{label: "mirroring unset", present: false},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Spec.Mirroring = tc.mirroring
+
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		value, ok := getOcsOperatorConfigData(t, reconciler)[util.RbdMirrorModeKey]
+		assert.Equalf(t, tc.present, ok, "[%s]: unexpected presence of the mirror mode key", tc.label)
+		assert.Equalf(t, tc.expected, value, "[%s]: unexpected mirror mode", tc.label)
+	}
+}
+
+func TestOcsOperatorConfigReadAffinity(t *testing.T) {
+	testcases := []struct {
+		label              string
+		external           bool
+		readAffinity       *rookCephv1.ReadAffinitySpec
+		enableReadAffinity *bool
+		expected           string
+	}{
+		{label: "internal mode default", expected: "true"},
+		{label: "external mode default", external: true, expected: "false"},
+		{label: "internal mode opted out", enableReadAffinity: ptr.To(false), expected: "false"},
+		{label: "external mode opted in", external: true, enableReadAffinity: ptr.To(true), expected: "true"},
+		{label: "read affinity spec disabled", readAffinity: &rookCephv1.ReadAffinitySpec{Enabled: false}, expected: "false"},
+		{
+			label:              "toggle takes precedence over read affinity spec",
+			readAffinity:       &rookCephv1.ReadAffinitySpec{Enabled: true},
+			enableReadAffinity: ptr.To(false),
+			expected:           "false",
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Spec.ExternalStorage.Enable = tc.external
+		sc.Spec.CSI = &v1.CSIDriverSpec{ReadAffinity: tc.readAffinity, EnableReadAffinity: tc.enableReadAffinity}
+
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		assert.Equalf(t, tc.expected, getOcsOperatorConfigData(t, reconciler)[util.EnableReadAffinityKey],
+			"[%s]: unexpected read affinity", tc.label)
+	}
+}
+
+func TestOcsOperatorConfigSerializationIsStable(t *testing.T) {
+	keys := []string{util.EnableNFSKey, util.ClusterNameKey, util.EnableTopologyKey, util.TopologyDomainLabelsKey, util.EnableCephfsKey}
+	expected := `[["CSI_CLUSTER_NAME","1"],["CSI_ENABLE_TOPOLOGY","2"],["CSI_TOPOLOGY_DOMAIN_LABELS","3"],` +
+		`["ROOK_CSI_ENABLE_CEPHFS","4"],["ROOK_CSI_ENABLE_NFS","0"]]`
+	for run := 0; run < 20; run++ {
+		// the data is built in a different insertion order, and maps are iterated in random order anyway
+		data := map[string]string{}
+		for i := range keys {
+			j := (i + run) % len(keys)
+			data[keys[j]] = strconv.Itoa(j)
+		}
+		assert.Equalf(t, expected, serializeOcsOperatorConfigData(data), "run %d: unstable serialization", run)
+	}
+
+	inputs := []string{"StorageCluster/ns/a@1", "StorageClass/b@2", "CephFilesystem/ns/c@3"}
+	hash := hashOcsOperatorConfigInputs(inputs)
+	assert.Equal(t, hash, hashOcsOperatorConfigInputs([]string{inputs[2], inputs[0], inputs[1]}), "the inputs hash depends on the order")
+	assert.Equal(t, []string{"StorageCluster/ns/a@1", "StorageClass/b@2", "CephFilesystem/ns/c@3"}, inputs, "the inputs were modified")
+}
+
+func TestOcsOperatorConfigDataChecksum(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+	getConfigMap := func() *corev1.ConfigMap {
+		ocsOperatorConfig := &corev1.ConfigMap{}
+		assert.NoError(t, reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: util.OcsOperatorConfigName, Namespace: testOperatorNamespace}, ocsOperatorConfig))
+		return ocsOperatorConfig
+	}
+
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	ocsOperatorConfig := getConfigMap()
+	checksum := ocsOperatorConfig.Annotations[ConfigDataChecksumAnnotation]
+	assert.Equal(t, util.CalculateMD5Hash(serializeOcsOperatorConfigData(ocsOperatorConfig.Data)), checksum)
+
+	// recomputing the same data neither changes the checksum nor updates the configmap
+	for run := 0; run < 5; run++ {
+		reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+		assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+		recomputed := getConfigMap()
+		assert.Equalf(t, checksum, recomputed.Annotations[ConfigDataChecksumAnnotation], "run %d: unstable checksum", run)
+		assert.Equalf(t, ocsOperatorConfig.ResourceVersion, recomputed.ResourceVersion, "run %d: unexpected update", run)
+	}
+}
+
+func TestOcsOperatorConfigPredicate(t *testing.T) {
+	configMapPredicate := ocsOperatorConfigPredicate(testOperatorNamespace)
+	for _, tc := range []struct {
+		label     string
+		configMap *corev1.ConfigMap
+		expected  bool
+	}{
+		{label: "ocs-operator-config", configMap: newTestConfigMap(util.OcsOperatorConfigName, testOperatorNamespace, nil), expected: true},
+		{label: "other configmap", configMap: newTestConfigMap("other", testOperatorNamespace, nil)},
+		{label: "ocs-operator-config in another namespace", configMap: newTestConfigMap(util.OcsOperatorConfigName, "other-ns", nil)},
+	} {
+		assert.Equalf(t, tc.expected, configMapPredicate.Update(event.UpdateEvent{ObjectOld: tc.configMap, ObjectNew: tc.configMap}),
+			"[%s]: unexpected update predicate", tc.label)
+		assert.Equalf(t, tc.expected, configMapPredicate.Delete(event.DeleteEvent{Object: tc.configMap}),
+			"[%s]: unexpected delete predicate", tc.label)
+	}
+}
+
+func TestOcsOperatorConfigDriftCorrected(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+		newTestRookCephOperatorPod(), newTestRookCephOperatorDeployment())
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	expected := getOcsOperatorConfigData(t, reconciler)
+	_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+
+	// an out-of-band edit of the configmap is reverted without any change of the inputs
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	key := types.NamespacedName{Name: util.OcsOperatorConfigName, Namespace: testOperatorNamespace}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, key, ocsOperatorConfig))
+	ocsOperatorConfig.Data[util.CephFSKernelMountOptionsKey] = "ms_mode=legacy"
+	ocsOperatorConfig.Data["EXPERIMENTAL_KEY"] = "true"
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, ocsOperatorConfig))
+
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, expected, getOcsOperatorConfigData(t, reconciler))
+	// the reverted key requires a restart like any other update
+	assert.Equal(t, util.CephFSKernelMountOptionsKey, getRookCephOperatorRestartPendingKeys(t, reconciler, util.OcsOperatorConfigName))
+}
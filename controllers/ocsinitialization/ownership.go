@@ -0,0 +1,61 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// isSolelyControlledBy returns true if the OCSInitialization is the only controller of the object
+func isSolelyControlledBy(initialData *ocsv1.OCSInitialization, configMap *corev1.ConfigMap) bool {
+	controlled := false
+	for _, ref := range configMap.OwnerReferences {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		if ref.UID != initialData.UID || ref.Kind != "OCSInitialization" {
+			return false
+		}
+		controlled = true
+	}
+	return controlled
+}
+
+// transferOcsOperatorConfigOwnership makes the OCSInitialization the only controller of ocs-operator-config. The
+// configmap was controlled by the storagecluster before 4.15, and clusters upgraded from older releases can carry
+// more than one controller reference, e.g. of both the storagecluster and an OCSInitialization that has since been
+// recreated, over which the controllers would fight. All the other controller references are kept as plain owner
+// references, and a warning is reported for each displaced owner. A configmap that is solely controlled by the
+// OCSInitialization is left untouched, so that it is not updated only for its owner references.
+func (r *OCSInitializationReconciler) transferOcsOperatorConfigOwnership(initialData *ocsv1.OCSInitialization, configMap *corev1.ConfigMap) error {
+	if isSolelyControlledBy(initialData, configMap) {
+		return nil
+	}
+
+	displaced := []string{}
+	for i := range configMap.OwnerReferences {
+		ref := &configMap.OwnerReferences[i]
+		if ref.Controller == nil || !*ref.Controller || ref.UID == initialData.UID && ref.Kind == "OCSInitialization" {
+			continue
+		}
+		ref.BlockOwnerDeletion = nil
+		ref.Controller = nil
+		displaced = append(displaced, fmt.Sprintf("%s %s (uid %s)", ref.Kind, ref.Name, ref.UID))
+	}
+	if len(displaced) > 0 {
+		message := fmt.Sprintf("Displaced the controllers %s of configmap %s, which is controlled by OCSInitialization %s",
+			strings.Join(displaced, ", "), configMap.Name, initialData.Name)
+		r.Log.Info("Warning: displacing the previous controllers of the ocs-operator-config configmap",
+			"ConfigMap", configMap.Name, "DisplacedControllers", displaced, "OCSInitialization", initialData.Name)
+		if r.recorder != nil {
+			r.recorder.Report(initialData, corev1.EventTypeWarning, util.EventReasonOcsOperatorConfigOwnerDisplaced, message)
+		}
+	}
+
+	return ctrl.SetControllerReference(initialData, configMap, r.Scheme)
+}
@@ -0,0 +1,118 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+)
+
+func newTestControllerReference(kind, name string, uid types.UID) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion:         "ocs.openshift.io/v1",
+		Kind:               kind,
+		Name:               name,
+		UID:                uid,
+		Controller:         ptr.To(true),
+		BlockOwnerDeletion: ptr.To(true),
+	}
+}
+
+func TestOcsOperatorConfigOwnershipTransfer(t *testing.T) {
+	testcases := []struct {
+		label           string
+		ownerReferences []metav1.OwnerReference
+		expectOwners    int
+		expectDisplaced []string
+	}{
+		{
+			label:        "new configmap",
+			expectOwners: 1,
+		},
+		{
+			label:           "configmap controlled by the storagecluster",
+			ownerReferences: []metav1.OwnerReference{newTestControllerReference("StorageCluster", "ocs-storagecluster", "sc-uid")},
+			expectOwners:    2,
+			expectDisplaced: []string{"StorageCluster ocs-storagecluster (uid sc-uid)"},
+		},
+		{
+			label: "configmap controlled by the storagecluster and a previous OCSInitialization",
+			ownerReferences: []metav1.OwnerReference{
+				newTestControllerReference("StorageCluster", "ocs-storagecluster", "sc-uid"),
+				newTestControllerReference("OCSInitialization", "ocsinit", "previous-uid"),
+			},
+			// the reference to the previous OCSInitialization of the same name is replaced
+			expectOwners:    2,
+			expectDisplaced: []string{"StorageCluster ocs-storagecluster (uid sc-uid)", "OCSInitialization ocsinit (uid previous-uid)"},
+		},
+		{
+			label: "configmap controlled by the OCSInitialization and the storagecluster",
+			ownerReferences: []metav1.OwnerReference{
+				newTestControllerReference("OCSInitialization", "ocsinit", "ocsinit-uid"),
+				newTestControllerReference("StorageCluster", "ocs-storagecluster", "sc-uid"),
+			},
+			expectOwners:    2,
+			expectDisplaced: []string{"StorageCluster ocs-storagecluster (uid sc-uid)"},
+		},
+	}
+
+	for _, tc := range testcases {
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t)
+		ocsInit.UID = "ocsinit-uid"
+		recorder := record.NewFakeRecorder(10)
+		reconciler.recorder = util.NewEventReporter(recorder)
+		configMap := newTestConfigMap(util.OcsOperatorConfigName, testOperatorNamespace, nil)
+		configMap.OwnerReferences = tc.ownerReferences
+
+		assert.NoErrorf(t, reconciler.transferOcsOperatorConfigOwnership(ocsInit, configMap), "[%s]: failed to transfer the ownership", tc.label)
+		assert.Truef(t, isSolelyControlledBy(ocsInit, configMap), "[%s]: unexpected owner references %v", tc.label, configMap.OwnerReferences)
+		assert.Lenf(t, configMap.OwnerReferences, tc.expectOwners, "[%s]: owner references must not be dropped", tc.label)
+		if len(tc.expectDisplaced) == 0 {
+			assert.Emptyf(t, recorder.Events, "[%s]: unexpected event", tc.label)
+			continue
+		}
+		assert.Lenf(t, recorder.Events, 1, "[%s]: the displaced controllers are not reported", tc.label)
+		event := <-recorder.Events
+		assert.Containsf(t, event, "Warning "+util.EventReasonOcsOperatorConfigOwnerDisplaced, "[%s]: unexpected event", tc.label)
+		for _, displaced := range tc.expectDisplaced {
+			assert.Containsf(t, event, displaced, "[%s]: the displaced controller is not named", tc.label)
+		}
+
+		// the transfer is a no-op once the OCSInitialization is the only controller
+		transferred := configMap.DeepCopy()
+		assert.NoErrorf(t, reconciler.transferOcsOperatorConfigOwnership(ocsInit, configMap), "[%s]: failed to transfer the ownership", tc.label)
+		assert.Equalf(t, transferred, configMap, "[%s]: unexpected change of the configmap", tc.label)
+		assert.Emptyf(t, recorder.Events, "[%s]: unexpected event", tc.label)
+	}
+}
+
+func TestOcsOperatorConfigSolelyOwnedUnchanged(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	key := types.NamespacedName{Name: util.OcsOperatorConfigName, Namespace: testOperatorNamespace}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, key, ocsOperatorConfig))
+	assert.True(t, isSolelyControlledBy(ocsInit, ocsOperatorConfig))
+
+	// an upgraded configmap additionally controlled by the storagecluster is transferred once
+	ocsOperatorConfig.OwnerReferences = append(ocsOperatorConfig.OwnerReferences,
+		newTestControllerReference("StorageCluster", "ocs-storagecluster", "sc-uid"))
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, ocsOperatorConfig))
+	reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, key, ocsOperatorConfig))
+	assert.True(t, isSolelyControlledBy(ocsInit, ocsOperatorConfig))
+	assert.Len(t, ocsOperatorConfig.OwnerReferences, 2)
+
+	// and left untouched afterwards
+	resourceVersion := ocsOperatorConfig.ResourceVersion
+	reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, key, ocsOperatorConfig))
+	assert.Equal(t, resourceVersion, ocsOperatorConfig.ResourceVersion)
+}
@@ -0,0 +1,56 @@
+package ocsinitialization
+
+import (
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/platform"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// platformDefaults are the ocs-operator-config values that are usual for a platform. The cloud
+// platforms spread their nodes over zones, while bare metal nodes are commonly organized in racks.
+var platformDefaults = map[configv1.PlatformType]map[string]string{
+	configv1.AWSPlatformType:       {util.TopologyDomainLabelsKey: corev1.LabelTopologyZone},
+	configv1.AzurePlatformType:     {util.TopologyDomainLabelsKey: corev1.LabelTopologyZone},
+	configv1.GCPPlatformType:       {util.TopologyDomainLabelsKey: corev1.LabelTopologyZone},
+	configv1.BareMetalPlatformType: {util.TopologyDomainLabelsKey: "topology.rook.io/rack"},
+}
+
+// seedPlatformDefaults fills in the defaults of the platform the cluster is running on, for the keys
+// that could not be derived from the spec of the storageclusters. The defaults and overrides configured
+// by the user are layered on top, so they take precedence over the platform defaults as well.
+// The topology domain labels are only seeded with topology enabled, and if all the nodes running the CSI node
+// plugins carry them, as a rack label is not set on every bare metal cluster.
+func (r *OCSInitializationReconciler) seedPlatformDefaults(ocsOperatorConfigData map[string]string, pluginNodes []corev1.Node) {
+	platformType, err := platform.GetPlatformType()
+	if err != nil {
+		r.Log.V(1).Info("Not seeding platform defaults into ocs-operator-config", "Reason", err.Error())
+		return
+	}
+	for key, value := range platformDefaults[platformType] {
+		if ocsOperatorConfigData[key] != "" {
+			continue
+		}
+		if key == util.TopologyDomainLabelsKey && !isTopologyDomainLabelsSeedable(ocsOperatorConfigData, pluginNodes, value) {
+			continue
+		}
+		ocsOperatorConfigData[key] = value
+	}
+}
+
+// isTopologyDomainLabelsSeedable returns true if topology is enabled and all the nodes running the CSI node plugins
+// carry the labels
+func isTopologyDomainLabelsSeedable(ocsOperatorConfigData map[string]string, pluginNodes []corev1.Node, labels string) bool {
+	if ocsOperatorConfigData[util.EnableTopologyKey] != "true" || len(pluginNodes) == 0 {
+		return false
+	}
+	for i := range pluginNodes {
+		for _, label := range splitTopologyDomainLabels(labels) {
+			if _, ok := pluginNodes[i].Labels[label]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
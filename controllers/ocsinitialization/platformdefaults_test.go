@@ -0,0 +1,73 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/platform"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestOcsOperatorConfigPlatformDefaults(t *testing.T) {
+	testcases := []struct {
+		platform       configv1.PlatformType
+		expectedLabels string
+	}{
+		{platform: configv1.AWSPlatformType, expectedLabels: "topology.kubernetes.io/zone"},
+		{platform: configv1.AzurePlatformType, expectedLabels: "topology.kubernetes.io/zone"},
+		{platform: configv1.GCPPlatformType, expectedLabels: "topology.kubernetes.io/zone"},
+		{platform: configv1.BareMetalPlatformType, expectedLabels: "topology.rook.io/rack"},
+		{platform: configv1.NonePlatformType, expectedLabels: ""},
+	}
+
+	labeledNode := func(name string) *corev1.Node {
+		node := newTestZoneNode(name, "zone-a")
+		node.Labels["topology.rook.io/rack"] = "rack-a"
+		return node
+	}
+
+	for _, tc := range testcases {
+		platform.SetFakePlatformInstanceForTesting(true, tc.platform)
+
+		// the platform default is seeded when the storageclusters do not determine the labels
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestTopologyStorageCluster(""),
+			labeledNode("worker-0"), labeledNode("worker-1"))
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.platform)
+		assert.Equalf(t, tc.expectedLabels, getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey],
+			"[%s]: unexpected seeded topology domain labels", tc.platform)
+
+		// nothing is seeded with topology disabled
+		ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+			labeledNode("worker-0"), labeledNode("worker-1"))
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.platform)
+		assert.Emptyf(t, getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey],
+			"[%s]: the topology domain labels must not be seeded with topology disabled", tc.platform)
+
+		// nor if some of the schedulable nodes do not carry the labels
+		ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t, newTestTopologyStorageCluster(""),
+			labeledNode("worker-0"), newTestNode("worker-1"))
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.platform)
+		assert.Emptyf(t, getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey],
+			"[%s]: the topology domain labels must not be seeded without the node labels", tc.platform)
+
+		// the failure domain of a storagecluster takes precedence over the platform default
+		ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t, newTestTopologyStorageCluster("kubernetes.io/hostname"))
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.platform)
+		assert.Equalf(t, "kubernetes.io/hostname", getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey],
+			"[%s]: the storagecluster failure domain must not be replaced", tc.platform)
+
+		// so do the defaults configured by the user
+		ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t,
+			newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+			newTestConfigMap(OcsOperatorConfigDefaultsName, testOperatorNamespace, map[string]string{
+				util.TopologyDomainLabelsKey: "topology.kubernetes.io/region",
+			}))
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.platform)
+		assert.Equalf(t, "topology.kubernetes.io/region", getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey],
+			"[%s]: the configured defaults must override the platform default", tc.platform)
+
+		platform.UnsetFakePlatformInstanceForTesting()
+	}
+}
@@ -0,0 +1,156 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RestartQuiesceTimeoutAnnotation can be set on a StorageCluster to a duration (e.g. "5m") for which a
+	// rook-ceph-operator restart waits for the in-flight CSI provisioning operations to complete. Once the
+	// timeout expires the restart proceeds anyway. With multiple storageclusters the longest timeout applies.
+	RestartQuiesceTimeoutAnnotation = "ocs.openshift.io/rook-ceph-operator-restart-quiesce-timeout"
+
+	// restartQuiescePollInterval is the interval at which the in-flight CSI provisioning operations are checked
+	restartQuiescePollInterval = 5 * time.Second
+
+	// pvcListPageSize is the number of PVCs that are listed per request
+	pvcListPageSize = 500
+
+	// selectedNodeAnnotation is set on a PVC of a WaitForFirstConsumer class to the node selected for its volume
+	selectedNodeAnnotation = "volume.kubernetes.io/selected-node"
+)
+
+// csiProvisionerAnnotations are the annotations set on a PVC to the provisioner that is asked to provision it
+var csiProvisionerAnnotations = []string{
+	"volume.kubernetes.io/storage-provisioner",
+	"volume.beta.kubernetes.io/storage-provisioner",
+}
+
+// getRestartQuiesceDelay returns the delay after which the rook-ceph-operator restart has to be retried while
+// CSI provisioning operations are in flight, or zero if the restart can proceed.
+func (r *OCSInitializationReconciler) getRestartQuiesceDelay() (time.Duration, error) {
+	timeout := r.getRestartQuiesceTimeout()
+	if timeout == 0 {
+		r.restartQuiesceStart = time.Time{}
+		return 0, nil
+	}
+
+	inFlight, err := r.getInFlightCSIProvisioning()
+	if err != nil {
+		return 0, err
+	}
+	if len(inFlight) == 0 {
+		r.restartQuiesceStart = time.Time{}
+		return 0, nil
+	}
+
+	now := time.Now()
+	if r.restartQuiesceStart.IsZero() {
+		r.restartQuiesceStart = now
+	}
+	waited := now.Sub(r.restartQuiesceStart)
+	if waited >= timeout {
+		r.Log.Info("Timed out waiting for CSI provisioning to quiesce, restarting rook-ceph-operator anyway",
+			"Timeout", timeout, "InFlight", inFlight)
+		r.restartQuiesceStart = time.Time{}
+		return 0, nil
+	}
+
+	r.Log.Info("Waiting for CSI provisioning to quiesce before restarting rook-ceph-operator",
+		"InFlight", inFlight, "Waited", waited, "Timeout", timeout)
+	return min(restartQuiescePollInterval, timeout-waited), nil
+}
+
+// getRestartQuiesceTimeout returns the longest quiesce timeout configured on the storageclusters,
+// or zero if none of them asks to wait
+func (r *OCSInitializationReconciler) getRestartQuiesceTimeout() time.Duration {
+	var timeout time.Duration
+	for _, sc := range r.clusters.GetStorageClusters() {
+		value, ok := sc.GetAnnotations()[RestartQuiesceTimeoutAnnotation]
+		if !ok {
+			continue
+		}
+		scTimeout, err := time.ParseDuration(value)
+		if err != nil {
+			r.Log.Error(err, "Ignoring invalid restart quiesce timeout", "StorageCluster", sc.Name, "Annotation", RestartQuiesceTimeoutAnnotation)
+			continue
+		}
+		timeout = max(timeout, scTimeout)
+	}
+	return timeout
+}
+
+func (r *OCSInitializationReconciler) getPVCReader() client.Reader {
+	if r.PVCReader != nil {
+		return r.PVCReader
+	}
+	return r.Client
+}
+
+// getInFlightCSIProvisioning returns the PVCs that are being provisioned by one of the ceph CSI drivers. A pending
+// PVC of a WaitForFirstConsumer class is only being provisioned once a node has been selected for it, until then it
+// waits for a pod rather than for the CSI driver. The PVCs are listed page by page through the PVC reader, so that
+// the operator does not cache all the PVCs of the cluster.
+func (r *OCSInitializationReconciler) getInFlightCSIProvisioning() ([]string, error) {
+	storageClasses := &storagev1.StorageClassList{}
+	if err := r.Client.List(r.ctx, storageClasses); err != nil {
+		r.Log.Error(err, "Failed to list StorageClasses")
+		return nil, err
+	}
+	waitForFirstConsumer := sets.New[string]()
+	for _, storageClass := range storageClasses.Items {
+		if ptr.Deref(storageClass.VolumeBindingMode, storagev1.VolumeBindingImmediate) == storagev1.VolumeBindingWaitForFirstConsumer {
+			waitForFirstConsumer.Insert(storageClass.Name)
+		}
+	}
+
+	inFlight := []string{}
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	for {
+		if err := r.getPVCReader().List(r.ctx, pvcs, client.Limit(pvcListPageSize), client.Continue(pvcs.Continue)); err != nil {
+			r.Log.Error(err, "Failed to list PersistentVolumeClaims")
+			return nil, err
+		}
+		for _, pvc := range pvcs.Items {
+			if pvc.Status.Phase != corev1.ClaimPending || !isCephCSIProvisioning(&pvc) {
+				continue
+			}
+			if _, selected := pvc.GetAnnotations()[selectedNodeAnnotation]; !selected &&
+				waitForFirstConsumer.Has(ptr.Deref(pvc.Spec.StorageClassName, "")) {
+				continue
+			}
+			inFlight = append(inFlight, fmt.Sprintf("%s/%s", pvc.Namespace, pvc.Name))
+		}
+		if pvcs.Continue == "" {
+			return inFlight, nil
+		}
+	}
+}
+
+// isCephCSIProvisioning returns true if one of the ceph CSI drivers is asked to provision the PVC
+func isCephCSIProvisioning(pvc *corev1.PersistentVolumeClaim) bool {
+	for _, annotation := range csiProvisionerAnnotations {
+		if isCephCSIDriver(pvc.GetAnnotations()[annotation]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCephCSIDriver returns true for the names of the ceph CSI drivers, which are prefixed by the operator namespace
+func isCephCSIDriver(driverName string) bool {
+	for _, suffix := range []string{".rbd.csi.ceph.com", ".cephfs.csi.ceph.com", ".nfs.csi.ceph.com"} {
+		if strings.HasSuffix(driverName, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package ocsinitialization
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestPendingPVC(name, provisioner string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "app-ns",
+			Annotations: map[string]string{"volume.kubernetes.io/storage-provisioner": provisioner},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+}
+
+func TestRestartWaitsForCSIProvisioningToQuiesce(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Annotations = map[string]string{RestartQuiesceTimeoutAnnotation: "5m"}
+	pvc := newTestPendingPVC("db", util.RbdDriverName)
+	otherPVC := newTestPendingPVC("other", "ebs.csi.aws.com")
+
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, pvc, otherPVC, newTestRookCephOperatorPod())
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+
+	// the provisioning of the rbd PVC is in flight, the restart waits
+	result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.NotZero(t, result.RequeueAfter)
+	assert.False(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.True(t, isRookCephOperatorRestartPending(t, reconciler))
+
+	// once the PVC is bound, the restart proceeds
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(pvc), pvc))
+	pvc.Status.Phase = corev1.ClaimBound
+	assert.NoError(t, reconciler.Client.Status().Update(reconciler.ctx, pvc))
+
+	result, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.False(t, isRookCephOperatorRestartPending(t, reconciler))
+	assert.True(t, reconciler.restartQuiesceStart.IsZero())
+}
+
+func TestRestartQuiesceTimeout(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Annotations = map[string]string{RestartQuiesceTimeoutAnnotation: "1m"}
+
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, newTestPendingPVC("db", util.CephFSDriverName), newTestRookCephOperatorPod())
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+
+	result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.NotZero(t, result.RequeueAfter)
+	assert.LessOrEqual(t, result.RequeueAfter, restartQuiescePollInterval)
+	assert.False(t, isRookCephOperatorPodRestarted(t, reconciler))
+
+	// the provisioning never completes, the restart proceeds once the timeout expired
+	reconciler.restartQuiesceStart = time.Now().Add(-2 * time.Minute)
+	result, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.False(t, isRookCephOperatorRestartPending(t, reconciler))
+}
+
+func TestRestartWithoutQuiesceTimeout(t *testing.T) {
+	// without the annotation in-flight provisioning does not delay the restart
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t,
+		newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+		newTestPendingPVC("db", util.RbdDriverName), newTestRookCephOperatorPod())
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+
+	result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+}
+
+func TestGetInFlightCSIProvisioning(t *testing.T) {
+	newTestPVCOfClass := func(name, storageClassName string, annotations map[string]string) *corev1.PersistentVolumeClaim {
+		pvc := newTestPendingPVC(name, util.RbdDriverName)
+		pvc.Spec.StorageClassName = ptr.To(storageClassName)
+		maps.Copy(pvc.Annotations, annotations)
+		return pvc
+	}
+	boundPVC := newTestPVCOfClass("bound", "immediate", nil)
+	boundPVC.Status.Phase = corev1.ClaimBound
+
+	_, reconciler := getOcsOperatorConfigTestReconciler(t,
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "immediate"}, Provisioner: util.RbdDriverName},
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "wffc"}, Provisioner: util.RbdDriverName,
+			VolumeBindingMode: ptr.To(storagev1.VolumeBindingWaitForFirstConsumer)},
+		newTestPVCOfClass("immediate", "immediate", nil),
+		newTestPVCOfClass("waiting-for-consumer", "wffc", nil),
+		newTestPVCOfClass("node-selected", "wffc", map[string]string{selectedNodeAnnotation: "node-a"}),
+		newTestPendingPVC("other-driver", "ebs.csi.aws.com"),
+		boundPVC,
+	)
+	reconciler.PVCReader = reconciler.Client
+
+	inFlight, err := reconciler.getInFlightCSIProvisioning()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"app-ns/immediate", "app-ns/node-selected"}, inFlight)
+}
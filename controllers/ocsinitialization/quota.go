@@ -0,0 +1,94 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConditionRestartBlockedByQuota is set while the rook-ceph-operator restart is deferred, because a
+	// ResourceQuota of the operator namespace has no room left to reschedule the rook-ceph-operator pod
+	ConditionRestartBlockedByQuota conditionsv1.ConditionType = "RookCephOperatorRestartBlockedByQuota"
+)
+
+// getRestartQuotaBlockReason returns a message naming the ResourceQuotas of the namespace that do not
+// have the room to schedule a replacement rook-ceph-operator pod, or an empty string if there are none.
+// The deleted pod keeps counting against the quotas until it terminated, so the replacement needs room
+// of its own.
+func (r *OCSInitializationReconciler) getRestartQuotaBlockReason(namespace string) (string, error) {
+	quotas := &corev1.ResourceQuotaList{}
+	if err := r.Client.List(r.ctx, quotas, client.InNamespace(namespace)); err != nil {
+		r.Log.Error(err, "Failed to list ResourceQuotas", "Namespace", namespace)
+		return "", err
+	}
+	if len(quotas.Items) == 0 {
+		return "", nil
+	}
+
+	pods, err := r.listRookCephOperatorPods(namespace)
+	if err != nil {
+		return "", err
+	}
+	var pod *corev1.Pod
+	if len(pods.Items) > 0 {
+		pod = &pods.Items[0]
+	}
+	required := getPodQuotaUsage(pod)
+
+	blocked := []string{}
+	for _, quota := range quotas.Items {
+		// sorted, so that the message does not change between reconciles
+		for _, name := range slices.Sorted(maps.Keys(quota.Status.Hard)) {
+			hard := quota.Status.Hard[name]
+			needed, ok := required[name]
+			if !ok {
+				continue
+			}
+			used := quota.Status.Used[name]
+			used.Add(needed)
+			if used.Cmp(hard) > 0 {
+				blocked = append(blocked, fmt.Sprintf("%s (%s: used %s, hard %s)",
+					quota.Name, name, quota.Status.Used.Name(name, resource.DecimalSI), hard.String()))
+			}
+		}
+	}
+	if len(blocked) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("ResourceQuotas of namespace %s have no room to reschedule the rook-ceph-operator pod: %s",
+		namespace, strings.Join(blocked, ", ")), nil
+}
+
+// getPodQuotaUsage returns the quota resources that the pod consumes. Without a pod only its count is known.
+func getPodQuotaUsage(pod *corev1.Pod) corev1.ResourceList {
+	usage := corev1.ResourceList{
+		corev1.ResourcePods:               resource.MustParse("1"),
+		corev1.ResourceName("count/pods"): resource.MustParse("1"),
+	}
+	if pod == nil {
+		return usage
+	}
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			addQuotaUsage(usage, name, quantity)
+			addQuotaUsage(usage, corev1.ResourceName("requests."+string(name)), quantity)
+		}
+		for name, quantity := range container.Resources.Limits {
+			addQuotaUsage(usage, corev1.ResourceName("limits."+string(name)), quantity)
+		}
+	}
+	return usage
+}
+
+func addQuotaUsage(usage corev1.ResourceList, name corev1.ResourceName, quantity resource.Quantity) {
+	total := usage[name]
+	total.Add(quantity)
+	usage[name] = total
+}
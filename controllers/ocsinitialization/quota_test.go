@@ -0,0 +1,84 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestResourceQuota(hard, used corev1.ResourceList) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "compute-quota",
+			Namespace: testOperatorNamespace,
+		},
+		Spec:   corev1.ResourceQuotaSpec{Hard: hard},
+		Status: corev1.ResourceQuotaStatus{Hard: hard, Used: used},
+	}
+}
+
+func TestRestartDeferredOnResourceQuota(t *testing.T) {
+	testcases := []struct {
+		label         string
+		quota         *corev1.ResourceQuota
+		expectRestart bool
+	}{
+		{
+			label:         "namespace without quota",
+			expectRestart: true,
+		},
+		{
+			label: "quota with room for another pod",
+			quota: newTestResourceQuota(
+				corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10"), corev1.ResourceRequestsMemory: resource.MustParse("2Gi")},
+				corev1.ResourceList{corev1.ResourcePods: resource.MustParse("5"), corev1.ResourceRequestsMemory: resource.MustParse("1Gi")},
+			),
+			expectRestart: true,
+		},
+		{
+			label: "pod count quota exhausted",
+			quota: newTestResourceQuota(
+				corev1.ResourceList{corev1.ResourcePods: resource.MustParse("5")},
+				corev1.ResourceList{corev1.ResourcePods: resource.MustParse("5")},
+			),
+			expectRestart: false,
+		},
+		{
+			label: "memory quota exhausted",
+			quota: newTestResourceQuota(
+				corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10"), corev1.ResourceRequestsMemory: resource.MustParse("2Gi")},
+				corev1.ResourceList{corev1.ResourcePods: resource.MustParse("5"), corev1.ResourceRequestsMemory: resource.MustParse("1800Mi")},
+			),
+			expectRestart: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		pod := newTestRookCephOperatorPod()
+		pod.Spec.Containers = []corev1.Container{{
+			Name: rookCephOperatorName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+			},
+		}}
+		objs := []client.Object{newTestStorageCluster("ocs-storagecluster", testOperatorNamespace), pod}
+		if tc.quota != nil {
+			objs = append(objs, tc.quota)
+		}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to reconcile the restart", tc.label)
+		assert.Equalf(t, tc.expectRestart, isRookCephOperatorPodRestarted(t, reconciler), "[%s]: unexpected restart", tc.label)
+		assert.Equalf(t, tc.expectRestart, !isRookCephOperatorRestartPending(t, reconciler), "[%s]: unexpected pending restart", tc.label)
+		assert.Equalf(t, tc.expectRestart, result.RequeueAfter == 0, "[%s]: unexpected requeue", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionRestartBlockedByQuota)
+		assert.Equalf(t, tc.expectRestart, condition == nil, "[%s]: unexpected condition %v", tc.label, condition)
+	}
+}
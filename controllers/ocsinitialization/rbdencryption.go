@@ -0,0 +1,84 @@
+package ocsinitialization
+
+import (
+	"encoding/json"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/defaults"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/storagecluster"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// rbdEncryptionKMSConfig references the KMS config of the volume level RBD encryption
+type rbdEncryptionKMSConfig struct {
+	// Namespace is the namespace of the KMS configmap and the token secret
+	Namespace string `json:"namespace"`
+	// ConfigMapName is the name of the configmap with the KMS connection details of the CSI drivers
+	ConfigMapName string `json:"configMapName"`
+	// KMSID is the ID of the KMS connection in the configmap, as used by the encryptionKMSID of the StorageClass
+	KMSID string `json:"kmsID,omitempty"`
+	// TokenSecretName is the name of the secret with the KMS token, only used by the Vault token auth method
+	TokenSecretName string `json:"tokenSecretName,omitempty"`
+}
+
+// getRbdEncryptionStorageCluster returns the first internal storagecluster with volume level encryption
+// through a KMS, or nil if there is none
+func (r *OCSInitializationReconciler) getRbdEncryptionStorageCluster() *ocsv1.StorageCluster {
+	for i := range r.clusters.GetInternalStorageClusters() {
+		sc := &r.clusters.GetInternalStorageClusters()[i]
+		if sc.Spec.Encryption.StorageClass && sc.Spec.Encryption.KeyManagementService.Enable {
+			return sc
+		}
+	}
+	return nil
+}
+
+// getRbdEncryptionKMSConfigMap returns the storagecluster with volume level encryption along with its KMS
+// configmap. The storagecluster is nil if the encryption is off, the configmap is nil if it does not exist.
+func (r *OCSInitializationReconciler) getRbdEncryptionKMSConfigMap() (*ocsv1.StorageCluster, *corev1.ConfigMap, error) {
+	sc := r.getRbdEncryptionStorageCluster()
+	if sc == nil {
+		return nil, nil, nil
+	}
+	kmsConfigMap, err := util.GetKMSConfigMap(defaults.KMSConfigMapName, sc, r.Client)
+	if errors.IsNotFound(err) {
+		return sc, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	return sc, kmsConfigMap, nil
+}
+
+// getRbdEncryptionKMSConfigKeyValue returns the reference to the KMS config of the volume level RBD encryption
+// as JSON, or an empty string if the encryption is off or its KMS is not configured yet
+func (r *OCSInitializationReconciler) getRbdEncryptionKMSConfigKeyValue() (string, error) {
+	sc, kmsConfigMap, err := r.getRbdEncryptionKMSConfigMap()
+	if err != nil || sc == nil {
+		return "", err
+	}
+	if kmsConfigMap == nil {
+		r.Log.Info("Volume level encryption is enabled, but the KMS configmap does not exist yet",
+			"StorageCluster", sc.Name, "ConfigMap", defaults.KMSConfigMapName)
+		return "", nil
+	}
+
+	config := rbdEncryptionKMSConfig{
+		Namespace:     sc.Namespace,
+		ConfigMapName: storagecluster.CSIKMSConfigMapName,
+		KMSID:         kmsConfigMap.Data["KMS_SERVICE_NAME"],
+	}
+	// the token based auth is the default of Vault
+	authMethod := kmsConfigMap.Data["VAULT_AUTH_METHOD"]
+	if kmsConfigMap.Data[storagecluster.KMSProviderKey] == storagecluster.VaultKMSProvider &&
+		(authMethod == "" || authMethod == storagecluster.VaultTokenAuthMethod) {
+		config.TokenSecretName = storagecluster.CSIKMSTokenSecretName
+	}
+	value, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
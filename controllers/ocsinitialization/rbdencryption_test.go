@@ -0,0 +1,83 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/defaults"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestKMSConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      defaults.KMSConfigMapName,
+			Namespace: testOperatorNamespace,
+		},
+		Data: data,
+	}
+}
+
+func TestOcsOperatorConfigRbdEncryptionKMSConfig(t *testing.T) {
+	testcases := []struct {
+		label        string
+		storageClass bool
+		kms          bool
+		objs         []client.Object
+		expected     string
+	}{
+		{
+			label: "encryption off",
+			objs:  []client.Object{newTestKMSConfigMap(map[string]string{"KMS_PROVIDER": "vault"})},
+		},
+		{
+			label: "cluster wide encryption only",
+			kms:   true,
+			objs:  []client.Object{newTestKMSConfigMap(map[string]string{"KMS_PROVIDER": "vault"})},
+		},
+		{
+			label:        "volume encryption without a KMS configmap",
+			storageClass: true,
+			kms:          true,
+		},
+		{
+			label:        "volume encryption with Vault token auth",
+			storageClass: true,
+			kms:          true,
+			objs: []client.Object{newTestKMSConfigMap(map[string]string{
+				"KMS_PROVIDER":     "vault",
+				"KMS_SERVICE_NAME": "vault-connection",
+			})},
+			expected: `{"namespace":"openshift-storage","configMapName":"csi-kms-connection-details","kmsID":"vault-connection","tokenSecretName":"ceph-csi-kms-token"}`,
+		},
+		{
+			label:        "volume encryption with Vault kubernetes auth",
+			storageClass: true,
+			kms:          true,
+			objs: []client.Object{newTestKMSConfigMap(map[string]string{
+				"KMS_PROVIDER":      "vault",
+				"KMS_SERVICE_NAME":  "vault-connection",
+				"VAULT_AUTH_METHOD": "kubernetes",
+			})},
+			expected: `{"namespace":"openshift-storage","configMapName":"csi-kms-connection-details","kmsID":"vault-connection"}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Spec.Encryption.StorageClass = tc.storageClass
+		sc.Spec.Encryption.KeyManagementService.Enable = tc.kms
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, append(tc.objs, sc)...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		if tc.expected == "" {
+			assert.NotContainsf(t, data, util.RbdEncryptionKMSConfigKey, "[%s]: unexpected KMS config key", tc.label)
+			continue
+		}
+		assert.Equalf(t, tc.expected, data[util.RbdEncryptionKMSConfigKey], "[%s]: unexpected KMS config", tc.label)
+	}
+}
@@ -0,0 +1,34 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// RookCephOperatorRestartIDAnnotation is set to the ID of the last rook-ceph-operator restart on the configmaps
+// whose changes it picked up, and on the pod template of the rook-ceph-operator Deployment it rolled out. The same
+// ID is logged and reported in the event of the restart, so that a config change, the restart and the subsequent
+// behavior of Rook can be correlated by grepping for a single ID.
+const RookCephOperatorRestartIDAnnotation = "ocs.openshift.io/rook-ceph-operator-restart-id"
+
+// newRestartID returns a unique ID for a rook-ceph-operator restart
+func newRestartID() string {
+	return uuid.New().String()
+}
+
+// reportRookCephOperatorRestart reports the completed rook-ceph-operator restart as an event on the OCSInitialization
+func (r *OCSInitializationReconciler) reportRookCephOperatorRestart(initialData *ocsv1.OCSInitialization, changedKeys []string) {
+	r.Log.Info("Restarted rook-ceph-operator", "RestartID", r.restartID, "ChangedKeys", changedKeys)
+	if r.recorder == nil {
+		return
+	}
+	r.recorder.Report(initialData, corev1.EventTypeNormal, util.EventReasonRookCephOperatorRestarted,
+		fmt.Sprintf("Restarted rook-ceph-operator with restart ID %s to pick up the changed keys %s",
+			r.restartID, strings.Join(changedKeys, ", ")))
+}
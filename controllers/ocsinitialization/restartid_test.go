@@ -0,0 +1,67 @@
+package ocsinitialization
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func getRookCephOperatorRestartID(t *testing.T, reconciler OCSInitializationReconciler, configMapName string) string {
+	cm := &corev1.ConfigMap{}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: configMapName, Namespace: testOperatorNamespace}, cm))
+	return cm.Annotations[RookCephOperatorRestartIDAnnotation]
+}
+
+func TestRookCephOperatorRestartID(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+		newTestRookCephOperatorDeployment())
+	logs := []string{}
+	reconciler.Log = funcr.New(func(prefix, args string) { logs = append(logs, args) }, funcr.Options{})
+	recorder := record.NewFakeRecorder(10)
+	reconciler.recorder = util.NewEventReporter(recorder)
+
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.True(t, isRookCephOperatorRestartPending(t, reconciler))
+	assert.Empty(t, getRookCephOperatorRestartID(t, reconciler, util.OcsOperatorConfigName))
+	for len(recorder.Events) > 0 {
+		<-recorder.Events
+	}
+
+	_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+	restartID := getRookCephOperatorRestartID(t, reconciler, util.OcsOperatorConfigName)
+	assert.NotEmpty(t, restartID)
+
+	// the same ID is logged, reported and set on the rolled out pod template
+	restartLogs := 0
+	for _, log := range logs {
+		if strings.Contains(log, restartID) {
+			restartLogs++
+		}
+	}
+	assert.GreaterOrEqual(t, restartLogs, 2, "the restart ID is not logged: %v", logs)
+	assert.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.Contains(t, event, "Normal "+util.EventReasonRookCephOperatorRestarted)
+	assert.Contains(t, event, restartID)
+	deployment := newTestRookCephOperatorDeployment()
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(deployment), deployment))
+	assert.Equal(t, restartID, deployment.Spec.Template.Annotations[RookCephOperatorRestartIDAnnotation])
+
+	// each restart gets a new ID
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: util.OcsOperatorConfigName, Namespace: testOperatorNamespace}, ocsOperatorConfig))
+	util.AddAnnotation(ocsOperatorConfig, rookCephOperatorRestartPendingAnnotation, util.EnableTopologyKey)
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, ocsOperatorConfig))
+	_, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.NotEqual(t, restartID, getRookCephOperatorRestartID(t, reconciler, util.OcsOperatorConfigName))
+}
@@ -0,0 +1,55 @@
+package ocsinitialization
+
+import (
+	"time"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+)
+
+// BypassRestartThrottleAnnotation can be set to "true" on the OCSInitialization to restart the rook-ceph-operator
+// for a pending config change right away, ignoring the restart cooldown. The annotation is removed once the
+// rook-ceph-operator has been restarted, so it only bypasses the cooldown for a single restart.
+const BypassRestartThrottleAnnotation = "ocs.openshift.io/bypass-restart-throttle"
+
+// getRestartCooldownDelay returns the time left of the restart cooldown, which starts when the newest
+// rook-ceph-operator pod was created, or zero if the restart can proceed. The cooldown does not apply
+// while a zone by zone restart is in progress, which itself replaces the pods.
+func (r *OCSInitializationReconciler) getRestartCooldownDelay(namespace string, now time.Time) (time.Duration, error) {
+	if r.RookCephOperatorRestartCooldown <= 0 || !r.zonalRestartStart.IsZero() {
+		return 0, nil
+	}
+	pods, err := r.listRookCephOperatorPods(namespace)
+	if err != nil {
+		r.Log.Error(err, "Failed to list rook-ceph-operator pods")
+		return 0, err
+	}
+	var lastStart time.Time
+	for _, pod := range pods.Items {
+		if pod.CreationTimestamp.After(lastStart) {
+			lastStart = pod.CreationTimestamp.Time
+		}
+	}
+	return max(lastStart.Add(r.RookCephOperatorRestartCooldown).Sub(now), 0), nil
+}
+
+// isRestartThrottleBypassed returns true if the restart cooldown is bypassed on the OCSInitialization
+func isRestartThrottleBypassed(initialData *ocsv1.OCSInitialization) bool {
+	return initialData.GetAnnotations()[BypassRestartThrottleAnnotation] == "true"
+}
+
+// clearRestartThrottleBypass removes the bypass of the restart cooldown from the OCSInitialization once the
+// rook-ceph-operator has been restarted
+func (r *OCSInitializationReconciler) clearRestartThrottleBypass(initialData *ocsv1.OCSInitialization) error {
+	if _, ok := initialData.GetAnnotations()[BypassRestartThrottleAnnotation]; !ok {
+		return nil
+	}
+	updated := initialData.DeepCopy()
+	delete(updated.Annotations, BypassRestartThrottleAnnotation)
+	if err := r.Client.Update(r.ctx, updated); err != nil {
+		r.Log.Error(err, "Failed to remove the restart throttle bypass annotation")
+		return err
+	}
+	// only the metadata changed, the status that is being reconciled is kept
+	initialData.ObjectMeta = updated.ObjectMeta
+	return nil
+}
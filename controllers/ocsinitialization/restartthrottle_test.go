@@ -0,0 +1,88 @@
+package ocsinitialization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestStartedRookCephOperatorPod(started time.Time) *corev1.Pod {
+	pod := newTestRookCephOperatorPod()
+	pod.CreationTimestamp = metav1.NewTime(started)
+	return pod
+}
+
+func TestRestartCooldown(t *testing.T) {
+	testcases := []struct {
+		label         string
+		cooldown      time.Duration
+		started       time.Duration
+		expectRestart bool
+	}{
+		{label: "restart within the cooldown is deferred", cooldown: time.Hour, started: 10 * time.Minute},
+		{label: "restart after the cooldown proceeds", cooldown: time.Hour, started: 2 * time.Hour, expectRestart: true},
+		{label: "restart without a cooldown proceeds", started: time.Minute, expectRestart: true},
+	}
+
+	for _, tc := range testcases {
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+			newTestStartedRookCephOperatorPod(time.Now().Add(-tc.started)), newTestRookCephOperatorDeployment())
+		reconciler.RookCephOperatorRestartCooldown = tc.cooldown
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to reconcile the restart", tc.label)
+		assert.Equalf(t, tc.expectRestart, isRookCephOperatorPodRestarted(t, reconciler), "[%s]: unexpected restart", tc.label)
+		assert.Equalf(t, !tc.expectRestart, isRookCephOperatorRestartPending(t, reconciler), "[%s]: unexpected pending restart", tc.label)
+		if !tc.expectRestart {
+			assert.Greaterf(t, result.RequeueAfter, tc.cooldown-tc.started-time.Minute, "[%s]: unexpected requeue", tc.label)
+			assert.LessOrEqualf(t, result.RequeueAfter, tc.cooldown-tc.started, "[%s]: unexpected requeue", tc.label)
+		}
+	}
+}
+
+func TestRestartThrottleBypass(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+		newTestStartedRookCephOperatorPod(time.Now()), newTestRookCephOperatorDeployment())
+	reconciler.RookCephOperatorRestartCooldown = time.Hour
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+
+	_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.False(t, isRookCephOperatorPodRestarted(t, reconciler))
+
+	// the bypass restarts right away and is removed afterwards
+	ocsInit.Annotations = map[string]string{BypassRestartThrottleAnnotation: "true"}
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, ocsInit))
+	result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.False(t, isRookCephOperatorRestartPending(t, reconciler))
+	assert.NotContains(t, ocsInit.Annotations, BypassRestartThrottleAnnotation)
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(ocsInit), ocsInit))
+	assert.NotContains(t, ocsInit.Annotations, BypassRestartThrottleAnnotation)
+
+	// the next restart is throttled again
+	deployment := newTestRookCephOperatorDeployment()
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(deployment), deployment))
+	delete(deployment.Spec.Template.Annotations, restartedAtAnnotation)
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, deployment))
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx,
+		types.NamespacedName{Name: util.OcsOperatorConfigName, Namespace: testOperatorNamespace}, ocsOperatorConfig))
+	util.AddAnnotation(ocsOperatorConfig, rookCephOperatorRestartPendingAnnotation, util.EnableTopologyKey)
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, ocsOperatorConfig))
+
+	result, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.NotZero(t, result.RequeueAfter)
+	assert.False(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.True(t, isRookCephOperatorRestartPending(t, reconciler))
+}
@@ -0,0 +1,574 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	rookCephOperatorName = "rook-ceph-operator"
+
+	// rookCephOperatorRestartPendingAnnotation is set on the configmaps consumed by the rook-ceph-operator to the
+	// comma separated list of keys that have changed since the rook-ceph-operator was last restarted.
+	// It makes sure a deferred restart is not lost across reconciles.
+	rookCephOperatorRestartPendingAnnotation = "ocs.openshift.io/rook-ceph-operator-restart-pending"
+
+	// RestartCephHealthAnnotation can be set on a StorageCluster to a comma separated list of ceph health
+	// statuses (e.g. "HEALTH_OK,HEALTH_WARN") in which the rook-ceph-operator is allowed to be restarted.
+	// When set, restarts are deferred until the CephCluster of the StorageCluster reports one of these statuses.
+	RestartCephHealthAnnotation = "ocs.openshift.io/rook-ceph-operator-restart-ceph-health"
+
+	// MaintenanceModeLabel can be set to "true" on a StorageCluster to suppress restarts of the rook-ceph-operator
+	// while the storagecluster is under maintenance. Config changes are still applied, and the pending
+	// restart happens once the label is removed.
+	MaintenanceModeLabel = "ocs.openshift.io/maintenance"
+
+	// ConditionMaintenanceMode is set while a StorageCluster is labeled as under maintenance
+	ConditionMaintenanceMode conditionsv1.ConditionType = "MaintenanceMode"
+
+	// RookCephOperatorRestartPolicyAnnotation can be set on a StorageCluster to choose how the rook-ceph-operator
+	// is restarted. "Delete", the default, replaces the pod. "Signal" sends a signal to the operator process
+	// instead, and falls back to replacing the pod if that is not possible.
+	RookCephOperatorRestartPolicyAnnotation = "ocs.openshift.io/rook-ceph-operator-restart-policy"
+
+	// RookCephOperatorRestartSignalAnnotation can be set on a StorageCluster to the signal sent to the
+	// rook-ceph-operator with the "Signal" restart policy. Defaults to HUP.
+	RookCephOperatorRestartSignalAnnotation = "ocs.openshift.io/rook-ceph-operator-restart-signal"
+
+	restartPolicyDelete = "Delete"
+	restartPolicySignal = "Signal"
+
+	// restartedAtAnnotation is set on the pod template of a Deployment to the time of a rollout restart
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+	// rookCephOperatorRestartRequeueDelay is the delay after which a deferred restart is retried
+	rookCephOperatorRestartRequeueDelay = 30 * time.Second
+
+	// DefaultRookCephOperatorPodSelector is the legacy label selector of the rook-ceph-operator pods. The pods that
+	// match either it or rookCephOperatorNamePodSelector are restarted, unless another selector is set on the
+	// OCSInitializationReconciler or a StorageCluster.
+	DefaultRookCephOperatorPodSelector = "app=" + rookCephOperatorName
+
+	// rookCephOperatorNamePodSelector is the label selector of the rook-ceph-operator pods that are labeled with
+	// the recommended app.kubernetes.io/name label instead of the legacy app label
+	rookCephOperatorNamePodSelector = "app.kubernetes.io/name=" + rookCephOperatorName
+)
+
+// ocsOperatorConfigRestartRequiredKeys are the keys of ocs-operator-config whose changes the CSI drivers only pick
+// up once the rook-ceph-operator is restarted: the topology, the network encryption and CephFS kernel mount
+// options, the legacy cluster ID of a cluster ID migration and the previous topology domain labels of a renamed
+// topology label. Changes of the other keys do not restart the rook-ceph-operator on their own, e.g. a churning
+// CSI_CLUSTER_NAME must not bounce the CSI stack.
+var ocsOperatorConfigRestartRequiredKeys = sets.New(
+	util.EnableTopologyKey,
+	util.TopologyDomainLabelsKey,
+	util.EnableNetworkEncryptionKey,
+	util.CephFSKernelMountOptionsKey,
+	util.CephFSFilesystemsConfigKey,
+	util.ClusterNameLegacyKey,
+	util.TopologyDomainLabelsLegacyKey,
+)
+
+// getChangedConfigKeys returns the keys that differ between the old and new data of a configmap
+func getChangedConfigKeys(oldData, newData map[string]string) sets.Set[string] {
+	changedKeys := sets.New[string]()
+	for key, value := range newData {
+		if oldValue, ok := oldData[key]; !ok || oldValue != value {
+			changedKeys.Insert(key)
+		}
+	}
+	for key := range oldData {
+		if _, ok := newData[key]; !ok {
+			changedKeys.Insert(key)
+		}
+	}
+	return changedKeys
+}
+
+// markRookCephOperatorRestartPending records the keys that differ between the old and new data of a configmap
+// consumed by the rook-ceph-operator, so that the operator is restarted to pick up the new values.
+// Keys that are still pending from an earlier change are kept.
+func markRookCephOperatorRestartPending(cm *corev1.ConfigMap, oldData, newData map[string]string) {
+	addRookCephOperatorRestartPendingKeys(cm, getChangedConfigKeys(oldData, newData))
+}
+
+// markOcsOperatorConfigRestartPending records the changed keys of ocs-operator-config that require a restart of
+// the rook-ceph-operator, and returns all the changed keys
+func markOcsOperatorConfigRestartPending(cm *corev1.ConfigMap, oldData, newData map[string]string) sets.Set[string] {
+	changedKeys := getChangedConfigKeys(oldData, newData)
+	addRookCephOperatorRestartPendingKeys(cm, changedKeys.Intersection(ocsOperatorConfigRestartRequiredKeys))
+	return changedKeys
+}
+
+// addRookCephOperatorRestartPendingKeys adds the keys to the pending restart of the configmap
+func addRookCephOperatorRestartPendingKeys(cm *corev1.ConfigMap, keys sets.Set[string]) {
+	if keys.Len() == 0 {
+		return
+	}
+	pendingKeys := keys.Clone()
+	if pending, ok := cm.GetAnnotations()[rookCephOperatorRestartPendingAnnotation]; ok && pending != "" {
+		pendingKeys.Insert(strings.Split(pending, ",")...)
+	}
+	util.AddAnnotation(cm, rookCephOperatorRestartPendingAnnotation, strings.Join(sets.List(pendingKeys), ","))
+}
+
+// reconcileRookCephOperatorRestart restarts the rook-ceph-operator pod at most once for all the configmaps
+// that have a pending restart, no matter how many of them changed. If the restart has to be deferred,
+// a non-zero result is returned so the request is requeued, and the restart remains pending on the configmaps.
+// The restart is deferred while a ResourceQuota, or the Pod Security Admission level or SCC of the security
+// context, would block rescheduling the rook-ceph-operator pod.
+// The restart is deferred as well until the restart cooldown has passed, unless it is bypassed on the OCSInitialization.
+// It optionally waits for the in-flight CSI provisioning operations to complete, bounded by a timeout,
+// and for the rollout health check to pass with a staged rollout.
+// While a StorageCluster is under maintenance the restart is suppressed without a requeue, as removing
+// the maintenance label triggers a new reconcile.
+// Each restart gets a new restart ID, which is logged, reported and set on the configmaps whose changes it picked up.
+func (r *OCSInitializationReconciler) reconcileRookCephOperatorRestart(initialData *ocsv1.OCSInitialization) (reconcile.Result, error) {
+	namespace := initialData.Namespace
+	maintenanceReason := r.getMaintenanceModeReason()
+	setOcsOperatorConfigCondition(initialData, ConditionMaintenanceMode, maintenanceReason != "",
+		"StorageClusterUnderMaintenance", maintenanceReason)
+
+	pendingConfigMaps := []*corev1.ConfigMap{}
+	changedKeys := []string{}
+	ocsOperatorConfigName := r.getOcsOperatorConfigName()
+	for _, name := range []string{util.RookCephOperatorConfigName, ocsOperatorConfigName} {
+		cm := &corev1.ConfigMap{}
+		err := r.Client.Get(r.ctx, types.NamespacedName{Name: name, Namespace: namespace}, cm)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			r.Log.Error(err, "Failed to get configmap", "ConfigMap", klog.KRef(namespace, name))
+			return reconcile.Result{}, err
+		}
+		pending, ok := cm.GetAnnotations()[rookCephOperatorRestartPendingAnnotation]
+		if !ok {
+			continue
+		}
+		pendingConfigMaps = append(pendingConfigMaps, cm)
+		for _, key := range strings.Split(pending, ",") {
+			changedKeys = append(changedKeys, fmt.Sprintf("%s/%s", name, key))
+		}
+	}
+	if len(pendingConfigMaps) == 0 {
+		setOcsOperatorConfigCondition(initialData, ConditionRestartBlockedByQuota, false, "", "")
+		setOcsOperatorConfigCondition(initialData, ConditionRestartBlockedBySecurityContext, false, "", "")
+		// a staged config change of keys that do not require a restart is completed once it is applied
+		if initialData.Status.OcsOperatorConfig.Rollout.Stage == ocsv1.RolloutStageRestartPending {
+			setRolloutStage(initialData, ocsv1.RolloutStageCompleted, "")
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if maintenanceReason != "" {
+		r.Log.Info("Suppressing rook-ceph-operator pod restart", "Reason", maintenanceReason, "ChangedKeys", changedKeys)
+		return reconcile.Result{}, nil
+	}
+
+	if deferReason := r.getRookCephOperatorRestartDeferReason(); deferReason != "" {
+		r.Log.Info("Deferring rook-ceph-operator pod restart", "Reason", deferReason, "ChangedKeys", changedKeys)
+		return reconcile.Result{RequeueAfter: rookCephOperatorRestartRequeueDelay}, nil
+	}
+
+	if err := r.resumeZonalRestart(namespace); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	cooldownDelay, err := r.getRestartCooldownDelay(namespace, time.Now())
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if cooldownDelay > 0 && isRestartThrottleBypassed(initialData) {
+		r.Log.Info("BYPASSING the rook-ceph-operator restart cooldown, restarting right away as requested by the "+
+			BypassRestartThrottleAnnotation+" annotation", "RemainingCooldown", cooldownDelay, "ChangedKeys", changedKeys)
+	} else if cooldownDelay > 0 {
+		r.Log.Info("Deferring rook-ceph-operator pod restart until the restart cooldown has passed",
+			"RemainingCooldown", cooldownDelay, "ChangedKeys", changedKeys)
+		return reconcile.Result{RequeueAfter: cooldownDelay}, nil
+	}
+
+	quotaReason, err := r.getRestartQuotaBlockReason(namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	setOcsOperatorConfigCondition(initialData, ConditionRestartBlockedByQuota, quotaReason != "", "ResourceQuotaExhausted", quotaReason)
+	if quotaReason != "" {
+		r.Log.Info("Deferring rook-ceph-operator pod restart", "Reason", quotaReason, "ChangedKeys", changedKeys)
+		return reconcile.Result{RequeueAfter: rookCephOperatorRestartRequeueDelay}, nil
+	}
+
+	securityContextReason, err := r.getRestartSecurityContextBlockReason(namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	setOcsOperatorConfigCondition(initialData, ConditionRestartBlockedBySecurityContext, securityContextReason != "",
+		"SecurityContextNotAdmitted", securityContextReason)
+	if securityContextReason != "" {
+		r.Log.Info("Deferring rook-ceph-operator pod restart", "Reason", securityContextReason, "ChangedKeys", changedKeys)
+		return reconcile.Result{RequeueAfter: rookCephOperatorRestartRequeueDelay}, nil
+	}
+
+	quiesceDelay, err := r.getRestartQuiesceDelay()
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if quiesceDelay > 0 {
+		return reconcile.Result{RequeueAfter: quiesceDelay}, nil
+	}
+
+	// the restart of the replicas of each zone is gated on its own once a zonal restart is in progress
+	stagedRollout := r.isStagedRolloutEnabled()
+	if stagedRollout && r.zonalRestartStart.IsZero() && !r.checkRolloutStage(initialData, ocsv1.RolloutStageRestartPending, "") {
+		return reconcile.Result{RequeueAfter: rookCephOperatorRestartRequeueDelay}, nil
+	}
+
+	if r.zonalRestartStart.IsZero() {
+		r.restartID = newRestartID()
+		r.Log.Info("Restarting rook-ceph-operator pod to pick up the changed configmaps", "RestartID", r.restartID,
+			"ChangedKeys", changedKeys)
+	}
+	if restarted, err := r.restartRookCephOperator(initialData, namespace); isRookCephOperatorNotFound(err) {
+		r.Log.Info("Warning: the configmaps changed, but there is no rook-ceph-operator to restart", "Reason", err.Error(),
+			"ChangedKeys", changedKeys)
+		setOcsOperatorConfigCondition(initialData, ConditionCSIConfigStale, true, "RookCephOperatorNotFound",
+			fmt.Sprintf("the CSI config may be stale, the changed keys %s were not picked up: %v", strings.Join(changedKeys, ", "), err))
+		return reconcile.Result{}, err
+	} else if err != nil {
+		return reconcile.Result{}, err
+	} else if !restarted {
+		return reconcile.Result{RequeueAfter: zonalRestartPollInterval}, nil
+	}
+
+	for _, cm := range pendingConfigMaps {
+		previousResourceVersion := cm.ResourceVersion
+		delete(cm.Annotations, rookCephOperatorRestartPendingAnnotation)
+		util.AddAnnotation(cm, RookCephOperatorRestartIDAnnotation, r.restartID)
+		if err := r.Client.Update(r.ctx, cm); err != nil {
+			r.Log.Error(err, "Failed to clear pending restart annotation on configmap", "ConfigMap", klog.KObj(cm))
+			return reconcile.Result{}, err
+		}
+		// clearing the annotation is not a change of the ocs-operator-config that has to be reconciled again
+		if cm.Name == ocsOperatorConfigName && r.lastOcsOperatorConfig.resourceVersion == previousResourceVersion {
+			r.lastOcsOperatorConfig.resourceVersion = cm.ResourceVersion
+		}
+	}
+	r.reportRookCephOperatorRestart(initialData, changedKeys)
+	setOcsOperatorConfigCondition(initialData, ConditionCSIConfigStale, false, "", "")
+	if err := r.clearRestartThrottleBypass(initialData); err != nil {
+		return reconcile.Result{}, err
+	}
+	if stagedRollout {
+		completeRolloutZone(initialData)
+		setRolloutStage(initialData, ocsv1.RolloutStageCompleted, "")
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// restartRookCephOperator restarts the rook-ceph-operator according to the configured restart policy. A single
+// replica is restarted through a rollout of the Deployment. Multiple replicas are deleted one zone at a time, in which case it returns false until the replicas of all the zones
+// have been restarted.
+func (r *OCSInitializationReconciler) restartRookCephOperator(initialData *ocsv1.OCSInitialization, namespace string) (bool, error) {
+	if r.zonalRestartStart.IsZero() {
+		if signal, ok := r.getRookCephOperatorRestartSignal(); ok {
+			err := r.signalRookCephOperator(namespace, signal)
+			if err == nil {
+				return true, r.setRookCephOperatorConfigChecksum(namespace)
+			}
+			r.Log.Error(err, "Failed to signal rook-ceph-operator, falling back to deleting the pod", "Signal", signal)
+		}
+	}
+	replicas, err := r.getRookCephOperatorReplicas(namespace)
+	if err != nil {
+		return false, err
+	}
+	if replicas > 1 {
+		return r.restartRookCephOperatorByZone(initialData, namespace, replicas)
+	}
+	r.zonalRestartStart = time.Time{}
+	if err := r.rolloutRestartRookCephOperator(namespace); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// rolloutRestartRookCephOperator restarts the rook-ceph-operator by setting the restartedAt annotation on the pod
+// template of its Deployment, like "kubectl rollout restart" does, so that the Deployment controller replaces the
+// pod. The checksum of the ocs-operator-config data is set on the pod template and the Deployment as well, so that
+// the config a rook-ceph-operator pod was started with can be verified against the current one. If there is no
+// Deployment the pods are deleted instead, and nothing carries the checksum.
+func (r *OCSInitializationReconciler) rolloutRestartRookCephOperator(namespace string) error {
+	deployment := &appsv1.Deployment{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: rookCephOperatorName, Namespace: namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return r.deleteRookCephOperatorPods(namespace)
+	} else if err != nil {
+		r.Log.Error(err, "Failed to get rook-ceph-operator Deployment")
+		return err
+	}
+
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	configChecksum, err := r.getOcsOperatorConfigChecksum(namespace)
+	if err != nil {
+		r.Log.Error(err, "Failed to get the checksum of the ocs-operator-config data")
+		return err
+	}
+	restartedAt := time.Now().Format(time.RFC3339)
+	deployment.Spec.Template.Annotations[restartedAtAnnotation] = restartedAt
+	if r.restartID != "" {
+		deployment.Spec.Template.Annotations[RookCephOperatorRestartIDAnnotation] = r.restartID
+	}
+	if configChecksum != "" {
+		deployment.Spec.Template.Annotations[ConfigDataChecksumAnnotation] = configChecksum
+		util.AddAnnotation(deployment, ConfigDataChecksumAnnotation, configChecksum)
+	}
+	// a zonal restart that was in progress while the Deployment was scaled down is replaced by the rollout
+	removeZonalRestartRecord(deployment)
+	r.Log.Info("Restarting rook-ceph-operator through a rollout of its Deployment", "RestartID", r.restartID, "RestartedAt", restartedAt,
+		"ConfigDataChecksum", configChecksum)
+	if err := r.Client.Update(r.ctx, deployment); err != nil {
+		r.Log.Error(err, "Failed to roll out the rook-ceph-operator Deployment")
+		return err
+	}
+	return nil
+}
+
+// setRookCephOperatorConfigChecksum sets the checksum of the ocs-operator-config data on the rook-ceph-operator
+// Deployment after a restart that does not roll it out, i.e. a signal or a zonal restart. It is not set on the pod
+// template, as changing the template would make the Deployment controller replace the signaled pods, or all the
+// zones at once. If there is no Deployment the pods were deleted, and nothing carries the checksum.
+// The record of a finished zonal restart is removed from the Deployment as well.
+func (r *OCSInitializationReconciler) setRookCephOperatorConfigChecksum(namespace string) error {
+	deployment := &appsv1.Deployment{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: rookCephOperatorName, Namespace: namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		r.Log.Error(err, "Failed to get rook-ceph-operator Deployment")
+		return err
+	}
+	configChecksum, err := r.getOcsOperatorConfigChecksum(namespace)
+	if err != nil {
+		r.Log.Error(err, "Failed to get the checksum of the ocs-operator-config data")
+		return err
+	}
+	recorded := removeZonalRestartRecord(deployment)
+	if !recorded && (configChecksum == "" || deployment.GetAnnotations()[ConfigDataChecksumAnnotation] == configChecksum) {
+		return nil
+	}
+	if configChecksum != "" {
+		util.AddAnnotation(deployment, ConfigDataChecksumAnnotation, configChecksum)
+	}
+	if err := r.Client.Update(r.ctx, deployment); err != nil {
+		r.Log.Error(err, "Failed to set the config checksum on the rook-ceph-operator Deployment")
+		return err
+	}
+	return nil
+}
+
+// getRookCephOperatorPodSelectors returns the label selectors of the rook-ceph-operator pods, a pod is selected if
+// it matches any of them. The selector set on the first storagecluster that sets a valid one overrides the selector of
+// the reconciler. Without either, both DefaultRookCephOperatorPodSelector and rookCephOperatorNamePodSelector are used,
+// so that a repackaged rook-ceph-operator is still restarted.
+func (r *OCSInitializationReconciler) getRookCephOperatorPodSelectors() []labels.Selector {
+	for _, sc := range r.clusters.GetStorageClusters() {
+		if sc.Spec.RookCephOperatorPodSelector == "" {
+			continue
+		}
+		selector, err := labels.Parse(sc.Spec.RookCephOperatorPodSelector)
+		if err != nil {
+			r.Log.Error(err, "Ignoring invalid rook-ceph-operator pod selector", "StorageCluster", klog.KRef(sc.Namespace, sc.Name),
+				"Selector", sc.Spec.RookCephOperatorPodSelector)
+			continue
+		}
+		return []labels.Selector{selector}
+	}
+	if r.RookCephOperatorPodSelector != nil {
+		return []labels.Selector{r.RookCephOperatorPodSelector}
+	}
+	return []labels.Selector{
+		labels.SelectorFromSet(labels.Set{"app": rookCephOperatorName}),
+		labels.SelectorFromSet(labels.Set{"app.kubernetes.io/name": rookCephOperatorName}),
+	}
+}
+
+// listRookCephOperatorPods lists the rook-ceph-operator pods in the namespace that match any of the pod selectors
+func (r *OCSInitializationReconciler) listRookCephOperatorPods(namespace string) (*corev1.PodList, error) {
+	pods := &corev1.PodList{}
+	listed := sets.New[string]()
+	for _, selector := range r.getRookCephOperatorPodSelectors() {
+		selected := &corev1.PodList{}
+		if err := r.Client.List(r.ctx, selected, client.InNamespace(namespace),
+			client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, err
+		}
+		// a pod with both labels is only listed once
+		for _, pod := range selected.Items {
+			if !listed.Has(pod.Name) {
+				listed.Insert(pod.Name)
+				pods.Items = append(pods.Items, pod)
+			}
+		}
+	}
+	return pods, nil
+}
+
+// deleteRookCephOperatorPods deletes the rook-ceph-operator pods, for when there is no Deployment to roll out. It
+// returns errRookCephOperatorNotFound if no pod matches the pod selectors.
+func (r *OCSInitializationReconciler) deleteRookCephOperatorPods(namespace string) error {
+	pods, err := r.listRookCephOperatorPods(namespace)
+	if err != nil {
+		r.Log.Error(err, "Failed to list rook-ceph-operator pods")
+		return err
+	}
+	if len(pods.Items) == 0 {
+		selectors := []string{}
+		for _, selector := range r.getRookCephOperatorPodSelectors() {
+			selectors = append(selectors, selector.String())
+		}
+		return fmt.Errorf("%w: there is no %s Deployment in namespace %s and no pod matches any of the selectors %q",
+			errRookCephOperatorNotFound, rookCephOperatorName, namespace, selectors)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		r.Log.Info("Deleting rook-ceph-operator pod, there is no Deployment to roll out", "Pod", pod.Name)
+		if err := r.Client.Delete(r.ctx, pod); err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to delete rook-ceph-operator pod", "Pod", pod.Name)
+			return err
+		}
+	}
+	return nil
+}
+
+// getRookCephOperatorRestartSignal returns the signal to restart the rook-ceph-operator with, if the
+// "Signal" restart policy is set on any storagecluster and no storagecluster asks for the "Delete" policy.
+func (r *OCSInitializationReconciler) getRookCephOperatorRestartSignal() (string, bool) {
+	signal := ""
+	for _, sc := range r.clusters.GetStorageClusters() {
+		switch sc.GetAnnotations()[RookCephOperatorRestartPolicyAnnotation] {
+		case restartPolicyDelete:
+			return "", false
+		case restartPolicySignal:
+			if signal == "" {
+				signal = sc.GetAnnotations()[RookCephOperatorRestartSignalAnnotation]
+				if signal == "" {
+					signal = "HUP"
+				}
+			}
+		}
+	}
+	return signal, signal != ""
+}
+
+// signalRookCephOperator sends the signal to the operator process in all the rook-ceph-operator pods
+func (r *OCSInitializationReconciler) signalRookCephOperator(namespace, signal string) error {
+	if r.PodExecutor == nil {
+		return fmt.Errorf("executing commands in pods is not supported")
+	}
+	command, err := getSignalCommand(signal)
+	if err != nil {
+		return err
+	}
+	pods, err := r.listRookCephOperatorPods(namespace)
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no %s pods found in namespace %s", rookCephOperatorName, namespace)
+	}
+	for _, pod := range pods.Items {
+		r.Log.Info("Signaling rook-ceph-operator", "Pod", pod.Name, "Signal", signal)
+		if err := r.PodExecutor.ExecInPod(r.ctx, namespace, pod.Name, rookCephOperatorName, command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getSignalCommand returns the command that sends the signal to the main process of a container
+func getSignalCommand(signal string) ([]string, error) {
+	signal = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(signal)), "SIG")
+	switch signal {
+	case "HUP", "INT", "TERM", "USR1", "USR2":
+		return []string{"kill", "-s", signal, "1"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported signal %q", signal)
+	}
+}
+
+// getMaintenanceModeReason returns a message naming the storageclusters that are under maintenance,
+// or an empty string if there are none.
+func (r *OCSInitializationReconciler) getMaintenanceModeReason() string {
+	underMaintenance := []string{}
+	for _, sc := range r.clusters.GetStorageClusters() {
+		if sc.GetLabels()[MaintenanceModeLabel] == "true" {
+			underMaintenance = append(underMaintenance, fmt.Sprintf("%s/%s", sc.Namespace, sc.Name))
+		}
+	}
+	if len(underMaintenance) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("rook-ceph-operator restarts are suppressed while StorageCluster %s is under maintenance",
+		strings.Join(underMaintenance, ", "))
+}
+
+// getRookCephOperatorRestartDeferReason returns the reason for deferring the rook-ceph-operator restart,
+// or an empty string if the restart can proceed.
+func (r *OCSInitializationReconciler) getRookCephOperatorRestartDeferReason() string {
+	for i := range r.clusters.GetStorageClusters() {
+		sc := &r.clusters.GetStorageClusters()[i]
+		if reason := r.getCephHealthDeferReason(sc); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// getCephHealthDeferReason checks the ceph health of the StorageCluster against the acceptable health
+// statuses configured via the RestartCephHealthAnnotation
+func (r *OCSInitializationReconciler) getCephHealthDeferReason(sc *ocsv1.StorageCluster) string {
+	acceptableHealth, ok := sc.GetAnnotations()[RestartCephHealthAnnotation]
+	if !ok {
+		return ""
+	}
+
+	cephCluster := &rookCephv1.CephCluster{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: util.GenerateNameForCephCluster(sc), Namespace: sc.Namespace}, cephCluster)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to get CephCluster", "StorageCluster", sc.Name)
+		}
+		return fmt.Sprintf("unable to determine ceph health of StorageCluster %s/%s", sc.Namespace, sc.Name)
+	}
+
+	health := ""
+	if cephCluster.Status.CephStatus != nil {
+		health = cephCluster.Status.CephStatus.Health
+	}
+	for _, acceptable := range strings.Split(acceptableHealth, ",") {
+		if strings.TrimSpace(acceptable) == health && health != "" {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("ceph health of StorageCluster %s/%s is %q, restart is allowed only in %q",
+		sc.Namespace, sc.Name, health, acceptableHealth)
+}
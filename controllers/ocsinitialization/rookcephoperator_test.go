@@ -0,0 +1,545 @@
+package ocsinitialization
+
+import (
+	"context"
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/defaults"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func newTestRookCephOperatorPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rook-ceph-operator-5d8f9c7b4-abcde",
+			Namespace: testOperatorNamespace,
+			Labels:    map[string]string{"app": rookCephOperatorName},
+		},
+	}
+}
+
+func newTestCephCluster(namespace, health string) *rookCephv1.CephCluster {
+	return &rookCephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      util.GenerateNameForCephClusterFromString("ocs-storagecluster"),
+			Namespace: namespace,
+		},
+		Status: rookCephv1.ClusterStatus{
+			CephStatus: &rookCephv1.CephStatus{Health: health},
+		},
+	}
+}
+
+func isRookCephOperatorPodRestarted(t *testing.T, reconciler OCSInitializationReconciler) bool {
+	deployment := newTestRookCephOperatorDeployment()
+	err := reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(deployment), deployment)
+	if err == nil {
+		// the pod of a Deployment is restarted through a rollout
+		_, ok := deployment.Spec.Template.Annotations[restartedAtAnnotation]
+		return ok
+	}
+	assert.True(t, errors.IsNotFound(err))
+
+	pod := newTestRookCephOperatorPod()
+	err = reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(pod), pod)
+	if errors.IsNotFound(err) {
+		return true
+	}
+	assert.NoError(t, err)
+	return false
+}
+
+func isRookCephOperatorRestartPending(t *testing.T, reconciler OCSInitializationReconciler) bool {
+	return getRookCephOperatorRestartPendingKeys(t, reconciler, util.OcsOperatorConfigName) != ""
+}
+
+func getRookCephOperatorRestartPendingKeys(t *testing.T, reconciler OCSInitializationReconciler, configMapName string) string {
+	cm := &corev1.ConfigMap{}
+	err := reconciler.Client.Get(reconciler.ctx, types.NamespacedName{Name: configMapName, Namespace: testOperatorNamespace}, cm)
+	assert.NoError(t, err)
+	return cm.Annotations[rookCephOperatorRestartPendingAnnotation]
+}
+
+func TestRestartGatedOnCephHealth(t *testing.T) {
+	testcases := []struct {
+		label            string
+		acceptableHealth string
+		health           string
+		expectRestart    bool
+	}{
+		{
+			label:            "HEALTH_OK allows the restart",
+			acceptableHealth: "HEALTH_OK",
+			health:           "HEALTH_OK",
+			expectRestart:    true,
+		},
+		{
+			label:            "HEALTH_WARN defers the restart when it is not acceptable",
+			acceptableHealth: "HEALTH_OK",
+			health:           "HEALTH_WARN",
+			expectRestart:    false,
+		},
+		{
+			label:            "HEALTH_WARN allows the restart when it is acceptable",
+			acceptableHealth: "HEALTH_OK,HEALTH_WARN",
+			health:           "HEALTH_WARN",
+			expectRestart:    true,
+		},
+		{
+			label:            "HEALTH_ERR defers the restart",
+			acceptableHealth: "HEALTH_OK,HEALTH_WARN",
+			health:           "HEALTH_ERR",
+			expectRestart:    false,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Annotations = map[string]string{RestartCephHealthAnnotation: tc.acceptableHealth}
+
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, newTestCephCluster(testOperatorNamespace, tc.health), newTestRookCephOperatorPod())
+		err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to ensure ocs-operator-config", tc.label)
+		result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to reconcile rook-ceph-operator restart", tc.label)
+
+		assert.Equalf(t, tc.expectRestart, isRookCephOperatorPodRestarted(t, reconciler), "[%s]: unexpected restart state", tc.label)
+		assert.Equalf(t, !tc.expectRestart, isRookCephOperatorRestartPending(t, reconciler), "[%s]: unexpected pending restart state", tc.label)
+		assert.Equalf(t, !tc.expectRestart, result.RequeueAfter > 0, "[%s]: unexpected requeue", tc.label)
+	}
+}
+
+func TestDeferredRestartIsRetried(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Annotations = map[string]string{RestartCephHealthAnnotation: "HEALTH_OK"}
+	cephCluster := newTestCephCluster(testOperatorNamespace, "HEALTH_ERR")
+
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, cephCluster, newTestRookCephOperatorPod())
+	err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+	assert.NoError(t, err)
+	_, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.False(t, isRookCephOperatorPodRestarted(t, reconciler))
+
+	// the config is unchanged in the next reconcile, but the pending restart must still happen once ceph is healthy
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(cephCluster), cephCluster))
+	cephCluster.Status.CephStatus.Health = "HEALTH_OK"
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, cephCluster))
+
+	err = reconciler.ensureOcsOperatorConfigExists(ocsInit)
+	assert.NoError(t, err)
+	result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.False(t, isRookCephOperatorRestartPending(t, reconciler))
+}
+
+func TestRestartOnlyForOcsOperatorConfig(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Spec.Placement = rookCephv1.PlacementSpec{
+		rookCephv1.KeyType(defaults.CsiPluginKey): rookCephv1.Placement{
+			Tolerations: []corev1.Toleration{{Key: "node.ocs.openshift.io/storage", Operator: corev1.TolerationOpExists}},
+		},
+	}
+
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, newTestRookCephOperatorPod())
+
+	// count the restarts, which delete the rook-ceph-operator pod
+	restarts := 0
+	reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			if _, ok := obj.(*corev1.Pod); ok {
+				restarts++
+			}
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+
+	assert.NoError(t, reconciler.ensureRookCephOperatorConfigExists(ocsInit))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	// the rook-ceph-operator picks up the changes of its own configmap without a restart
+	assert.Empty(t, getRookCephOperatorRestartPendingKeys(t, reconciler, util.RookCephOperatorConfigName))
+	assert.Contains(t, getRookCephOperatorRestartPendingKeys(t, reconciler, util.OcsOperatorConfigName), util.EnableTopologyKey)
+
+	result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.Equal(t, 1, restarts, "both configmaps changed, but the rook-ceph-operator must be restarted only once")
+	assert.Empty(t, getRookCephOperatorRestartPendingKeys(t, reconciler, util.OcsOperatorConfigName))
+
+	// nothing is pending anymore, so there is no further restart
+	_, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, restarts)
+}
+
+func TestRestartSuppressedInMaintenanceMode(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Labels = map[string]string{MaintenanceModeLabel: "true"}
+
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, newTestRookCephOperatorPod())
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+
+	// the config is applied, but the restart is suppressed
+	assert.Equal(t, "false", getOcsOperatorConfigData(t, reconciler)[util.EnableNFSKey])
+	assert.Zero(t, result.RequeueAfter)
+	assert.False(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.True(t, isRookCephOperatorRestartPending(t, reconciler))
+	assert.NotNil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionMaintenanceMode))
+
+	// exiting maintenance flushes the pending restart
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(sc), sc))
+	delete(sc.Labels, MaintenanceModeLabel)
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, sc))
+	reconciler.clusters, err = util.GetClusters(reconciler.ctx, reconciler.Client)
+	assert.NoError(t, err)
+
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	result, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.False(t, isRookCephOperatorRestartPending(t, reconciler))
+	assert.Nil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionMaintenanceMode))
+}
+
+type fakePodExecutor struct {
+	commands [][]string
+	err      error
+}
+
+func (e *fakePodExecutor) ExecInPod(_ context.Context, _, _, _ string, command []string) error {
+	e.commands = append(e.commands, command)
+	return e.err
+}
+
+func TestGetSignalCommand(t *testing.T) {
+	testcases := []struct {
+		signal      string
+		expected    []string
+		expectError bool
+	}{
+		{signal: "HUP", expected: []string{"kill", "-s", "HUP", "1"}},
+		{signal: "SIGHUP", expected: []string{"kill", "-s", "HUP", "1"}},
+		{signal: " usr1 ", expected: []string{"kill", "-s", "USR1", "1"}},
+		{signal: "KILL", expectError: true},
+		{signal: "HUP; rm -rf /", expectError: true},
+	}
+
+	for _, tc := range testcases {
+		command, err := getSignalCommand(tc.signal)
+		if tc.expectError {
+			assert.Errorf(t, err, "[%s]: expected an error", tc.signal)
+			continue
+		}
+		assert.NoErrorf(t, err, "[%s]: unexpected error", tc.signal)
+		assert.Equalf(t, tc.expected, command, "[%s]: unexpected command", tc.signal)
+	}
+}
+
+func TestRestartWithSignalPolicy(t *testing.T) {
+	testcases := []struct {
+		label           string
+		annotations     map[string]string
+		executor        *fakePodExecutor
+		expectedCommand []string
+		expectDeletion  bool
+	}{
+		{
+			label:          "default policy deletes the pod",
+			annotations:    map[string]string{},
+			executor:       &fakePodExecutor{},
+			expectDeletion: true,
+		},
+		{
+			label:           "signal policy signals the operator process",
+			annotations:     map[string]string{RookCephOperatorRestartPolicyAnnotation: restartPolicySignal},
+			executor:        &fakePodExecutor{},
+			expectedCommand: []string{"kill", "-s", "HUP", "1"},
+		},
+		{
+			label: "signal policy with a configured signal",
+			annotations: map[string]string{
+				RookCephOperatorRestartPolicyAnnotation: restartPolicySignal,
+				RookCephOperatorRestartSignalAnnotation: "USR1",
+			},
+			executor:        &fakePodExecutor{},
+			expectedCommand: []string{"kill", "-s", "USR1", "1"},
+		},
+		{
+			label:           "signal policy falls back to deletion when the exec fails",
+			annotations:     map[string]string{RookCephOperatorRestartPolicyAnnotation: restartPolicySignal},
+			executor:        &fakePodExecutor{err: errors.NewBadRequest("exec not supported")},
+			expectedCommand: []string{"kill", "-s", "HUP", "1"},
+			expectDeletion:  true,
+		},
+		{
+			label: "signal policy falls back to deletion with an unsupported signal",
+			annotations: map[string]string{
+				RookCephOperatorRestartPolicyAnnotation: restartPolicySignal,
+				RookCephOperatorRestartSignalAnnotation: "KILL",
+			},
+			executor:       &fakePodExecutor{},
+			expectDeletion: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Annotations = tc.annotations
+
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, newTestRookCephOperatorPod())
+		reconciler.PodExecutor = tc.executor
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to reconcile rook-ceph-operator restart", tc.label)
+
+		if tc.expectedCommand != nil {
+			assert.Equalf(t, [][]string{tc.expectedCommand}, tc.executor.commands, "[%s]: unexpected signal command", tc.label)
+		} else {
+			assert.Emptyf(t, tc.executor.commands, "[%s]: no command should have been executed", tc.label)
+		}
+		assert.Equalf(t, tc.expectDeletion, isRookCephOperatorPodRestarted(t, reconciler), "[%s]: unexpected pod deletion", tc.label)
+		assert.Falsef(t, isRookCephOperatorRestartPending(t, reconciler), "[%s]: the restart should not be pending", tc.label)
+	}
+}
+
+func TestRestartRookCephOperatorPodSelector(t *testing.T) {
+	testcases := []struct {
+		label            string
+		globalSelector   string
+		clusterSelector  string
+		expectedRestarts []string
+	}{
+		{label: "default selector", expectedRestarts: []string{"default"}},
+		{label: "storagecluster override", clusterSelector: "app=custom-rook-ceph-operator", expectedRestarts: []string{"custom"}},
+		{label: "global selector", globalSelector: "app=custom-rook-ceph-operator", expectedRestarts: []string{"custom"}},
+		{
+			label:            "storagecluster override of the global selector",
+			globalSelector:   "app=custom-rook-ceph-operator",
+			clusterSelector:  DefaultRookCephOperatorPodSelector,
+			expectedRestarts: []string{"default"},
+		},
+		{
+			label:            "set based override",
+			clusterSelector:  "app in (rook-ceph-operator,custom-rook-ceph-operator)",
+			expectedRestarts: []string{"custom", "default"},
+		},
+		{
+			label:            "invalid override falls back to the global selector",
+			globalSelector:   "app=custom-rook-ceph-operator",
+			clusterSelector:  "app==(rook",
+			expectedRestarts: []string{"custom"},
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Spec.RookCephOperatorPodSelector = tc.clusterSelector
+		pods := map[string]*corev1.Pod{"default": newTestRookCephOperatorPod(), "custom": newTestRookCephOperatorPod()}
+		pods["custom"].Name = "custom-rook-ceph-operator-7c6d5b9f8-fghij"
+		pods["custom"].Labels = map[string]string{"app": "custom-rook-ceph-operator"}
+
+		// without a Deployment to roll out, the selected pods are deleted
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, pods["default"], pods["custom"])
+		if tc.globalSelector != "" {
+			selector, err := labels.Parse(tc.globalSelector)
+			assert.NoError(t, err)
+			reconciler.RookCephOperatorPodSelector = selector
+		}
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to restart rook-ceph-operator", tc.label)
+
+		restarted := []string{}
+		for _, name := range []string{"custom", "default"} {
+			err := reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(pods[name]), &corev1.Pod{})
+			if errors.IsNotFound(err) {
+				restarted = append(restarted, name)
+			} else {
+				assert.NoErrorf(t, err, "[%s]: failed to get the %s pod", tc.label, name)
+			}
+		}
+		assert.Equalf(t, tc.expectedRestarts, restarted, "[%s]: unexpected restarted pods", tc.label)
+	}
+}
+
+func TestRestartRookCephOperatorRollout(t *testing.T) {
+	testcases := []struct {
+		label        string
+		deployment   bool
+		updateErr    bool
+		expectedErr  bool
+		podDeleted   bool
+		rolledOut    bool
+		stillPending bool
+	}{
+		{label: "deployment is rolled out", deployment: true, rolledOut: true},
+		{label: "pod is deleted without a deployment", podDeleted: true},
+		{label: "failed rollout is surfaced", deployment: true, updateErr: true, expectedErr: true, stillPending: true},
+	}
+
+	for _, tc := range testcases {
+		objs := []client.Object{newTestStorageCluster("ocs-storagecluster", testOperatorNamespace), newTestRookCephOperatorPod()}
+		if tc.deployment {
+			objs = append(objs, newTestRookCephOperatorDeployment())
+		}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		if tc.updateErr {
+			reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+				Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					if _, ok := obj.(*appsv1.Deployment); ok {
+						return errors.NewInternalError(assert.AnError)
+					}
+					return c.Update(ctx, obj, opts...)
+				},
+			})
+		}
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+		if tc.expectedErr {
+			assert.Errorf(t, err, "[%s]: expected the restart to fail", tc.label)
+		} else {
+			assert.NoErrorf(t, err, "[%s]: failed to restart rook-ceph-operator", tc.label)
+		}
+
+		pod := newTestRookCephOperatorPod()
+		err = reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(pod), pod)
+		assert.Equalf(t, tc.podDeleted, errors.IsNotFound(err), "[%s]: unexpected deletion of the pod", tc.label)
+		if tc.deployment {
+			deployment := newTestRookCephOperatorDeployment()
+			assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(deployment), deployment))
+			_, ok := deployment.Spec.Template.Annotations[restartedAtAnnotation]
+			assert.Equalf(t, tc.rolledOut, ok, "[%s]: unexpected rollout of the deployment", tc.label)
+		}
+		assert.Equalf(t, tc.stillPending, isRookCephOperatorRestartPending(t, reconciler), "[%s]: unexpected pending restart", tc.label)
+	}
+}
+
+func TestRestartRookCephOperatorRolloutConfigChecksum(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, newTestRookCephOperatorDeployment())
+	getPodTemplateChecksum := func() string {
+		deployment := newTestRookCephOperatorDeployment()
+		assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(deployment), deployment))
+		return deployment.Spec.Template.Annotations[ConfigDataChecksumAnnotation]
+	}
+
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	checksum := getTestConfigMap(t, reconciler, util.OcsOperatorConfigName).Annotations[ConfigDataChecksumAnnotation]
+	assert.NotEmpty(t, checksum)
+	assert.Equal(t, checksum, getPodTemplateChecksum(), "the pod template does not have the checksum of the config")
+
+	// a config change that restarts the rook-ceph-operator changes the checksum of the pod template
+	setTestNetworkEncryption(sc, true)
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, sc))
+	reconciler.clusters, err = util.GetClusters(reconciler.ctx, reconciler.Client)
+	assert.NoError(t, err)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	_, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	updatedChecksum := getTestConfigMap(t, reconciler, util.OcsOperatorConfigName).Annotations[ConfigDataChecksumAnnotation]
+	assert.NotEqual(t, checksum, updatedChecksum)
+	assert.Equal(t, updatedChecksum, getPodTemplateChecksum(), "the pod template does not have the checksum of the updated config")
+	assert.Equal(t, updatedChecksum, getTestRookCephOperatorDeploymentChecksum(t, reconciler))
+}
+
+func getTestRookCephOperatorDeploymentChecksum(t *testing.T, reconciler OCSInitializationReconciler) string {
+	deployment := newTestRookCephOperatorDeployment()
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(deployment), deployment))
+	return deployment.Annotations[ConfigDataChecksumAnnotation]
+}
+
+func TestRestartRookCephOperatorConfigChecksumPaths(t *testing.T) {
+	testcases := []struct {
+		label              string
+		annotations        map[string]string
+		withDeployment     bool
+		expectPodRestarted bool
+	}{
+		{
+			label:          "signal policy sets the checksum on the Deployment only",
+			annotations:    map[string]string{RookCephOperatorRestartPolicyAnnotation: restartPolicySignal},
+			withDeployment: true,
+		},
+		{
+			label:              "deleted pods without a Deployment carry no checksum",
+			annotations:        map[string]string{},
+			expectPodRestarted: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Annotations = tc.annotations
+		objs := []client.Object{sc, newTestRookCephOperatorPod()}
+		if tc.withDeployment {
+			objs = append(objs, newTestRookCephOperatorDeployment())
+		}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		reconciler.PodExecutor = &fakePodExecutor{}
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to reconcile rook-ceph-operator restart", tc.label)
+		assert.Equalf(t, tc.expectPodRestarted, isRookCephOperatorPodRestarted(t, reconciler), "[%s]: unexpected pod deletion", tc.label)
+		assert.Falsef(t, isRookCephOperatorRestartPending(t, reconciler), "[%s]: the restart should not be pending", tc.label)
+
+		if tc.withDeployment {
+			checksum := getTestConfigMap(t, reconciler, util.OcsOperatorConfigName).Annotations[ConfigDataChecksumAnnotation]
+			assert.Equalf(t, checksum, getTestRookCephOperatorDeploymentChecksum(t, reconciler), "[%s]: unexpected Deployment checksum", tc.label)
+			deployment := newTestRookCephOperatorDeployment()
+			assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(deployment), deployment))
+			assert.Emptyf(t, deployment.Spec.Template.Annotations, "[%s]: the pod template must not be rolled out", tc.label)
+		}
+	}
+}
+
+func TestRestartOnlyForRestartRequiredKeys(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, newTestRookCephOperatorPod())
+	resolver := &fakeTopologyResolver{}
+	reconciler.TopologyResolver = resolver
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx, newTestRookCephOperatorPod()))
+
+	// a change of a key that does not require a restart is applied without one
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(sc), sc))
+	sc.Spec.NFS = &v1.NFSSpec{Enable: true}
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, sc))
+	reconciler.clusters, err = util.GetClusters(reconciler.ctx, reconciler.Client)
+	assert.NoError(t, err)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "true", getOcsOperatorConfigData(t, reconciler)[util.EnableNFSKey])
+	assert.False(t, isRookCephOperatorRestartPending(t, reconciler))
+	_, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.False(t, isRookCephOperatorPodRestarted(t, reconciler))
+
+	// a topology change requires one
+	resolver.topology = TopologyConfig{Enabled: true, DomainLabels: corev1.LabelTopologyZone}
+	reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "CSI_ENABLE_TOPOLOGY,CSI_TOPOLOGY_DOMAIN_LABELS", getRookCephOperatorRestartPendingKeys(t, reconciler, util.OcsOperatorConfigName))
+	_, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+}
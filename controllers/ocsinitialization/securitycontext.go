@@ -0,0 +1,211 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	secv1 "github.com/openshift/api/security/v1"
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// ConditionRestartBlockedBySecurityContext is set while the rook-ceph-operator restart is deferred, because
+	// the Pod Security Admission level of the operator namespace or the SCC that admitted the rook-ceph-operator
+	// pod would reject the replacement pod
+	ConditionRestartBlockedBySecurityContext conditionsv1.ConditionType = "RookCephOperatorRestartBlockedBySecurityContext"
+
+	podSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+	podSecurityBaseline     = "baseline"
+	podSecurityRestricted   = "restricted"
+
+	// sccAnnotation is set by the SCC admission on OpenShift to the SCC that admitted the pod
+	sccAnnotation = "openshift.io/scc"
+)
+
+// podSecurityBaselineCapabilities are the capabilities that the baseline Pod Security Standard allows to be added
+var podSecurityBaselineCapabilities = []corev1.Capability{
+	"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD", "NET_BIND_SERVICE",
+	"SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT",
+}
+
+// getRookCephOperatorPodSpec returns the spec the replacement rook-ceph-operator pod is created from, which is the
+// template of the Deployment, or the spec of the running pod if there is no Deployment. The running pod is returned
+// as well, if there is one.
+func (r *OCSInitializationReconciler) getRookCephOperatorPodSpec(namespace string) (*corev1.PodSpec, *corev1.Pod, error) {
+	pods, err := r.listRookCephOperatorPods(namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pod *corev1.Pod
+	if len(pods.Items) > 0 {
+		pod = &pods.Items[0]
+	}
+
+	deployment := &appsv1.Deployment{}
+	err = r.Client.Get(r.ctx, types.NamespacedName{Name: rookCephOperatorName, Namespace: namespace}, deployment)
+	if err == nil {
+		return &deployment.Spec.Template.Spec, pod, nil
+	} else if !errors.IsNotFound(err) {
+		return nil, nil, err
+	}
+	if pod == nil {
+		return nil, nil, nil
+	}
+	return &pod.Spec, pod, nil
+}
+
+// getRestartSecurityContextBlockReason returns a message explaining why the security context of the
+// rook-ceph-operator pod would prevent rescheduling it, or an empty string if the replacement pod is admitted.
+// The pod is checked against the Pod Security Admission level enforced on the operator namespace, and against
+// the SCC that admitted the running pod.
+func (r *OCSInitializationReconciler) getRestartSecurityContextBlockReason(namespace string) (string, error) {
+	podSpec, pod, err := r.getRookCephOperatorPodSpec(namespace)
+	if err != nil || podSpec == nil {
+		return "", err
+	}
+
+	ns := &corev1.Namespace{}
+	if err := r.Client.Get(r.ctx, types.NamespacedName{Name: namespace}, ns); err != nil && !errors.IsNotFound(err) {
+		r.Log.Error(err, "Failed to get namespace", "Namespace", namespace)
+		return "", err
+	}
+	level := ns.Labels[podSecurityEnforceLabel]
+	if violations := getPodSecurityViolations(podSpec, level); len(violations) > 0 {
+		return fmt.Sprintf("the rook-ceph-operator pod violates the %q Pod Security Standard enforced on namespace %s: %s. "+
+			"Label the namespace with %s=privileged or fix the security context of the rook-ceph-operator Deployment",
+			level, namespace, strings.Join(violations, ", "), podSecurityEnforceLabel), nil
+	}
+
+	if pod == nil || r.SecurityClient == nil {
+		return "", nil
+	}
+	sccName, ok := pod.Annotations[sccAnnotation]
+	if !ok {
+		return "", nil
+	}
+	scc, err := r.SecurityClient.SecurityContextConstraints().Get(r.ctx, sccName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return fmt.Sprintf("the SCC %s that admitted the rook-ceph-operator pod no longer exists. "+
+			"Restore the SCC or grant the %s service account an SCC that allows the pod", sccName, podSpec.ServiceAccountName), nil
+	} else if err != nil {
+		r.Log.Error(err, "Failed to get SecurityContextConstraints", "SCC", sccName)
+		return "", err
+	}
+	if violations := getSCCViolations(podSpec, scc); len(violations) > 0 {
+		return fmt.Sprintf("the SCC %s that admitted the rook-ceph-operator pod no longer allows it: %s. "+
+			"Restore the SCC or grant the %s service account an SCC that allows the pod",
+			sccName, strings.Join(violations, ", "), podSpec.ServiceAccountName), nil
+	}
+	return "", nil
+}
+
+// getPodContainers returns the init and regular containers of the pod
+func getPodContainers(podSpec *corev1.PodSpec) []corev1.Container {
+	return append(slices.Clone(podSpec.InitContainers), podSpec.Containers...)
+}
+
+// getPodSecurityViolations returns the checks of the baseline and restricted Pod Security Standards that the pod
+// fails at the given level. Unknown levels and the privileged level are not checked.
+func getPodSecurityViolations(podSpec *corev1.PodSpec, level string) []string {
+	if level != podSecurityBaseline && level != podSecurityRestricted {
+		return nil
+	}
+	violations := []string{}
+	if podSpec.HostNetwork || podSpec.HostPID || podSpec.HostIPC {
+		violations = append(violations, "host namespaces")
+	}
+	for _, volume := range podSpec.Volumes {
+		if volume.HostPath != nil {
+			violations = append(violations, fmt.Sprintf("hostPath volume %s", volume.Name))
+		}
+	}
+	podSecurityContext := podSpec.SecurityContext
+	if podSecurityContext == nil {
+		podSecurityContext = &corev1.PodSecurityContext{}
+	}
+
+	for _, container := range getPodContainers(podSpec) {
+		securityContext := container.SecurityContext
+		if securityContext == nil {
+			securityContext = &corev1.SecurityContext{}
+		}
+		if securityContext.Privileged != nil && *securityContext.Privileged {
+			violations = append(violations, fmt.Sprintf("privileged container %s", container.Name))
+		}
+		if slices.ContainsFunc(container.Ports, func(port corev1.ContainerPort) bool { return port.HostPort != 0 }) {
+			violations = append(violations, fmt.Sprintf("host ports of container %s", container.Name))
+		}
+		capabilities := securityContext.Capabilities
+		if capabilities == nil {
+			capabilities = &corev1.Capabilities{}
+		}
+		for _, capability := range capabilities.Add {
+			// the restricted level only allows adding NET_BIND_SERVICE
+			allowed := capability == "NET_BIND_SERVICE"
+			if level == podSecurityBaseline {
+				allowed = slices.Contains(podSecurityBaselineCapabilities, capability)
+			}
+			if !allowed {
+				violations = append(violations, fmt.Sprintf("capability %s added to container %s", capability, container.Name))
+			}
+		}
+		if level != podSecurityRestricted {
+			continue
+		}
+
+		if securityContext.AllowPrivilegeEscalation == nil || *securityContext.AllowPrivilegeEscalation {
+			violations = append(violations, fmt.Sprintf("container %s allows privilege escalation", container.Name))
+		}
+		runAsNonRoot := securityContext.RunAsNonRoot
+		if runAsNonRoot == nil {
+			runAsNonRoot = podSecurityContext.RunAsNonRoot
+		}
+		if runAsNonRoot == nil || !*runAsNonRoot {
+			violations = append(violations, fmt.Sprintf("container %s may run as root", container.Name))
+		}
+		seccompProfile := securityContext.SeccompProfile
+		if seccompProfile == nil {
+			seccompProfile = podSecurityContext.SeccompProfile
+		}
+		if seccompProfile == nil || seccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			violations = append(violations, fmt.Sprintf("container %s has no seccomp profile", container.Name))
+		}
+		if !slices.Contains(capabilities.Drop, "ALL") {
+			violations = append(violations, fmt.Sprintf("container %s does not drop all the capabilities", container.Name))
+		}
+	}
+	return violations
+}
+
+// getSCCViolations returns the host access and privileges of the pod that the SCC does not allow
+func getSCCViolations(podSpec *corev1.PodSpec, scc *secv1.SecurityContextConstraints) []string {
+	violations := []string{}
+	if podSpec.HostNetwork && !scc.AllowHostNetwork {
+		violations = append(violations, "host network")
+	}
+	if podSpec.HostPID && !scc.AllowHostPID {
+		violations = append(violations, "host PID")
+	}
+	if podSpec.HostIPC && !scc.AllowHostIPC {
+		violations = append(violations, "host IPC")
+	}
+	if !scc.AllowHostDirVolumePlugin && slices.ContainsFunc(podSpec.Volumes, func(volume corev1.Volume) bool { return volume.HostPath != nil }) {
+		violations = append(violations, "hostPath volumes")
+	}
+	for _, container := range getPodContainers(podSpec) {
+		if !scc.AllowPrivilegedContainer && container.SecurityContext != nil &&
+			container.SecurityContext.Privileged != nil && *container.SecurityContext.Privileged {
+			violations = append(violations, fmt.Sprintf("privileged container %s", container.Name))
+		}
+		if !scc.AllowHostPorts && slices.ContainsFunc(container.Ports, func(port corev1.ContainerPort) bool { return port.HostPort != 0 }) {
+			violations = append(violations, fmt.Sprintf("host ports of container %s", container.Name))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,168 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	secv1 "github.com/openshift/api/security/v1"
+	fakeSecClient "github.com/openshift/client-go/security/clientset/versioned/typed/security/v1/fake"
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	testingClient "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestNamespace(level string) *corev1.Namespace {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testOperatorNamespace}}
+	if level != "" {
+		namespace.Labels = map[string]string{podSecurityEnforceLabel: level}
+	}
+	return namespace
+}
+
+func newTestRestrictedSecurityContext() *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		AllowPrivilegeEscalation: ptr.To(false),
+		RunAsNonRoot:             ptr.To(true),
+		SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+	}
+}
+
+func TestRestartDeferredOnSecurityContext(t *testing.T) {
+	testcases := []struct {
+		label           string
+		level           string
+		hostNetwork     bool
+		securityContext *corev1.SecurityContext
+		expectRestart   bool
+	}{
+		{
+			label:         "namespace without an enforced level",
+			hostNetwork:   true,
+			expectRestart: true,
+		},
+		{
+			label:         "privileged level",
+			level:         "privileged",
+			hostNetwork:   true,
+			expectRestart: true,
+		},
+		{
+			label:         "baseline level with an unprivileged pod",
+			level:         podSecurityBaseline,
+			expectRestart: true,
+		},
+		{
+			label:       "baseline level with host network",
+			level:       podSecurityBaseline,
+			hostNetwork: true,
+		},
+		{
+			label:           "baseline level with a privileged container",
+			level:           podSecurityBaseline,
+			securityContext: &corev1.SecurityContext{Privileged: ptr.To(true)},
+		},
+		{
+			label:           "baseline level with an added capability",
+			level:           podSecurityBaseline,
+			securityContext: &corev1.SecurityContext{Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_ADMIN"}}},
+		},
+		{
+			label:           "restricted level with a restricted security context",
+			level:           podSecurityRestricted,
+			securityContext: newTestRestrictedSecurityContext(),
+			expectRestart:   true,
+		},
+		{
+			label: "restricted level with a baseline security context",
+			level: podSecurityRestricted,
+		},
+		{
+			label: "restricted level with a container allowed to escalate privileges",
+			level: podSecurityRestricted,
+			securityContext: func() *corev1.SecurityContext {
+				securityContext := newTestRestrictedSecurityContext()
+				securityContext.AllowPrivilegeEscalation = ptr.To(true)
+				return securityContext
+			}(),
+		},
+	}
+
+	for _, tc := range testcases {
+		deployment := newTestRookCephOperatorDeployment()
+		deployment.Spec.Template.Spec.HostNetwork = tc.hostNetwork
+		deployment.Spec.Template.Spec.Containers[0].SecurityContext = tc.securityContext
+		objs := []client.Object{
+			newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+			newTestNamespace(tc.level),
+			deployment,
+			newTestRookCephOperatorPod(),
+		}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to reconcile the restart", tc.label)
+		assert.Equalf(t, tc.expectRestart, isRookCephOperatorPodRestarted(t, reconciler), "[%s]: unexpected restart", tc.label)
+		assert.Equalf(t, tc.expectRestart, !isRookCephOperatorRestartPending(t, reconciler), "[%s]: unexpected pending restart", tc.label)
+		assert.Equalf(t, tc.expectRestart, result.RequeueAfter == 0, "[%s]: unexpected requeue", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionRestartBlockedBySecurityContext)
+		assert.Equalf(t, tc.expectRestart, condition == nil, "[%s]: unexpected condition %v", tc.label, condition)
+		if condition != nil {
+			assert.Containsf(t, condition.Message, tc.level, "[%s]: condition does not name the level", tc.label)
+		}
+	}
+}
+
+func TestRestartDeferredOnSCC(t *testing.T) {
+	testcases := []struct {
+		label         string
+		scc           *secv1.SecurityContextConstraints
+		expectRestart bool
+	}{
+		{
+			label:         "SCC allowing the pod",
+			scc:           &secv1.SecurityContextConstraints{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"}, AllowHostNetwork: true},
+			expectRestart: true,
+		},
+		{
+			label: "SCC no longer allowing host network",
+			scc:   &secv1.SecurityContextConstraints{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"}},
+		},
+		{
+			label: "SCC removed",
+		},
+	}
+
+	for _, tc := range testcases {
+		deployment := newTestRookCephOperatorDeployment()
+		deployment.Spec.Template.Spec.HostNetwork = true
+		pod := newTestRookCephOperatorPod()
+		pod.Annotations = map[string]string{sccAnnotation: "rook-ceph"}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace), deployment, pod)
+		fakeClient := &testingClient.Fake{}
+		fakeClient.AddReactor("get", "securitycontextconstraints", func(testingClient.Action) (bool, runtime.Object, error) {
+			if tc.scc == nil {
+				return true, nil, errors.NewNotFound(secv1.Resource("securitycontextconstraints"), "rook-ceph")
+			}
+			return true, tc.scc, nil
+		})
+		reconciler.SecurityClient = &fakeSecClient.FakeSecurityV1{Fake: fakeClient}
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to reconcile the restart", tc.label)
+		assert.Equalf(t, tc.expectRestart, isRookCephOperatorPodRestarted(t, reconciler), "[%s]: unexpected restart", tc.label)
+		assert.Equalf(t, tc.expectRestart, result.RequeueAfter == 0, "[%s]: unexpected requeue", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionRestartBlockedBySecurityContext)
+		assert.Equalf(t, tc.expectRestart, condition == nil, "[%s]: unexpected condition %v", tc.label, condition)
+		if tc.scc == nil {
+			assert.Containsf(t, condition.Message, "no longer exists", "[%s]: unexpected condition message", tc.label)
+		}
+	}
+}
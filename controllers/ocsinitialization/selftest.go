@@ -0,0 +1,89 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// selfTestClusterID is the cluster ID the self-test computes the ocs-operator-config data with
+const selfTestClusterID = "self-test-cluster-id"
+
+// selfTestExpectedKeys are the keys the ocs-operator-config data computed for newSelfTestStorageCluster must have
+var selfTestExpectedKeys = []string{
+	util.ClusterNameKey,
+	util.RookCurrentNamespaceOnlyKey,
+	util.EnableTopologyKey,
+	util.TopologyDomainLabelsKey,
+	util.EnableNFSKey,
+	util.EnableReadAffinityKey,
+	util.DisableCSIDriverKey,
+	util.CephFSSubvolumeGroupPinningKey,
+	util.RbdRadosNamespaceKey,
+	util.RbdThickProvisionKey,
+	util.RbdMirrorModeKey,
+	util.CSIProvisionerImageTagKey,
+	util.CSIAttacherImageTagKey,
+	util.EnableNetworkEncryptionKey,
+	util.CephFSKernelMountOptionsKey,
+}
+
+// newSelfTestStorageCluster returns a synthetic internal storagecluster that sets all the spec fields the
+// ocs-operator-config data is computed from
+func newSelfTestStorageCluster() *ocsv1.StorageCluster {
+	sc := &ocsv1.StorageCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "self-test-storagecluster",
+			Namespace: "self-test",
+		},
+		Spec: ocsv1.StorageClusterSpec{
+			NFS:       &ocsv1.NFSSpec{Enable: true},
+			CSI:       &ocsv1.CSIDriverSpec{ProvisionerImage: "csi-provisioner:self-test", AttacherImage: "csi-attacher:self-test"},
+			Mirroring: &ocsv1.MirroringSpec{Enabled: true},
+			Network: &rookCephv1.NetworkSpec{
+				Connections: &rookCephv1.ConnectionsSpec{Encryption: &rookCephv1.EncryptionSpec{Enabled: true}},
+			},
+		},
+		Status: ocsv1.StorageClusterStatus{FailureDomainKey: corev1.LabelTopologyZone},
+	}
+	sc.Spec.ManagedResources.CephNonResilientPools.Enable = true
+	sc.Spec.ManagedResources.CephFilesystems.SubvolumeGroupPinning = "distributed"
+	sc.Spec.ManagedResources.CephBlockPools.RadosNamespace = "self-test"
+	sc.Spec.ManagedResources.CephBlockPools.ThickProvision = ptr.To(true)
+	return sc
+}
+
+// SelfTestOCSOperatorConfigData computes the ocs-operator-config data for a synthetic storagecluster, and returns
+// an error if the computation panics or the data misses any of the expected keys. It is run on startup to fail
+// fast, before a broken computation writes an incomplete config.
+func SelfTestOCSOperatorConfigData() error {
+	return selfTestOcsOperatorConfigData(ComputeOCSOperatorConfigData)
+}
+
+// selfTestOcsOperatorConfigData runs the self-test against the given computation of the ocs-operator-config data
+func selfTestOcsOperatorConfigData(compute func(*ocsv1.StorageCluster, string) map[string]string) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("ocs-operator-config self-test failed, the computation panicked: %v", p)
+		}
+	}()
+
+	data := compute(newSelfTestStorageCluster(), selfTestClusterID)
+	missing := []string{}
+	for _, key := range selfTestExpectedKeys {
+		if _, ok := data[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("ocs-operator-config self-test failed, the computed data is missing the keys %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelfTestOCSOperatorConfigData(t *testing.T) {
+	assert.NoError(t, SelfTestOCSOperatorConfigData())
+
+	testcases := []struct {
+		label         string
+		compute       func(*ocsv1.StorageCluster, string) map[string]string
+		expectedError string
+	}{
+		{
+			label: "missing key",
+			compute: func(sc *ocsv1.StorageCluster, clusterID string) map[string]string {
+				data := ComputeOCSOperatorConfigData(sc, clusterID)
+				delete(data, util.RbdRadosNamespaceKey)
+				return data
+			},
+			expectedError: "missing the keys " + util.RbdRadosNamespaceKey,
+		},
+		{
+			label: "panic",
+			compute: func(sc *ocsv1.StorageCluster, clusterID string) map[string]string {
+				var data map[string]string
+				data[util.ClusterNameKey] = clusterID
+				return data
+			},
+			expectedError: "the computation panicked",
+		},
+	}
+
+	for _, tc := range testcases {
+		err := selfTestOcsOperatorConfigData(tc.compute)
+		assert.Errorf(t, err, "[%s]: broken computation is not detected", tc.label)
+		assert.ErrorContainsf(t, err, tc.expectedError, "[%s]: unexpected error", tc.label)
+	}
+}
@@ -0,0 +1,54 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+)
+
+// listScheduledCephFilesystems returns the CephFilesystems of the internal storageclusters that have snapshot
+// schedules configured. Rook only applies the schedules of mirrored filesystems.
+func (r *OCSInitializationReconciler) listScheduledCephFilesystems() ([]rookCephv1.CephFilesystem, error) {
+	cephFilesystems, _, err := r.listCephFilesystems()
+	if err != nil {
+		return nil, err
+	}
+	scheduled := []rookCephv1.CephFilesystem{}
+	for _, cephFilesystem := range cephFilesystems {
+		mirroring := cephFilesystem.Spec.Mirroring
+		if mirroring != nil && mirroring.Enabled && len(mirroring.SnapshotSchedules) > 0 {
+			scheduled = append(scheduled, cephFilesystem)
+		}
+	}
+	return scheduled, nil
+}
+
+// getCephFSSnapshotScheduleKeyValue returns the snapshot schedules of the CephFilesystems as a sorted, comma
+// separated list of <filesystem>:<path>=<interval>[@<startTime>] entries, or an empty string if there are none.
+func (r *OCSInitializationReconciler) getCephFSSnapshotScheduleKeyValue() (string, error) {
+	cephFilesystems, err := r.listScheduledCephFilesystems()
+	if err != nil {
+		return "", err
+	}
+	schedules := []string{}
+	for _, cephFilesystem := range cephFilesystems {
+		for _, schedule := range cephFilesystem.Spec.Mirroring.SnapshotSchedules {
+			if schedule.Interval == "" {
+				continue
+			}
+			path := schedule.Path
+			if path == "" {
+				path = "/"
+			}
+			entry := fmt.Sprintf("%s:%s=%s", cephFilesystem.Name, path, schedule.Interval)
+			if schedule.StartTime != "" {
+				entry += "@" + schedule.StartTime
+			}
+			schedules = append(schedules, entry)
+		}
+	}
+	slices.Sort(schedules)
+	return strings.Join(slices.Compact(schedules), ","), nil
+}
@@ -0,0 +1,94 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestCephFilesystem(name string, mirroring *rookCephv1.FSMirroringSpec) *rookCephv1.CephFilesystem {
+	return &rookCephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testOperatorNamespace,
+		},
+		Spec: rookCephv1.FilesystemSpec{Mirroring: mirroring},
+	}
+}
+
+func TestOcsOperatorConfigCephFSSnapshotSchedule(t *testing.T) {
+	testcases := []struct {
+		label    string
+		objs     []client.Object
+		expected string
+	}{
+		{
+			label: "no CephFilesystem",
+		},
+		{
+			label: "CephFilesystem without snapshot schedules",
+			objs: []client.Object{
+				newTestCephFilesystem("ocs-storagecluster-cephfilesystem", &rookCephv1.FSMirroringSpec{Enabled: true}),
+			},
+		},
+		{
+			label: "snapshot schedules of a CephFilesystem that is not mirrored",
+			objs: []client.Object{
+				newTestCephFilesystem("ocs-storagecluster-cephfilesystem", &rookCephv1.FSMirroringSpec{
+					SnapshotSchedules: []rookCephv1.SnapshotScheduleSpec{{Path: "/", Interval: "24h"}},
+				}),
+			},
+		},
+		{
+			label: "snapshot schedules of mirrored CephFilesystems",
+			objs: []client.Object{
+				newTestCephFilesystem("ocs-storagecluster-cephfilesystem", &rookCephv1.FSMirroringSpec{
+					Enabled: true,
+					SnapshotSchedules: []rookCephv1.SnapshotScheduleSpec{
+						{Path: "/volumes", Interval: "1h", StartTime: "14:00:00-05:00"},
+						{Interval: "24h"},
+					},
+				}),
+				newTestCephFilesystem("archive", &rookCephv1.FSMirroringSpec{
+					Enabled:           true,
+					SnapshotSchedules: []rookCephv1.SnapshotScheduleSpec{{Path: "/", Interval: "7d"}},
+				}),
+			},
+			expected: "archive:/=7d,ocs-storagecluster-cephfilesystem:/=24h,ocs-storagecluster-cephfilesystem:/volumes=1h@14:00:00-05:00",
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := append([]client.Object{newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)}, tc.objs...)
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		if tc.expected == "" {
+			assert.NotContainsf(t, data, util.CephFSSnapshotScheduleKey, "[%s]: unexpected snapshot schedule key", tc.label)
+			continue
+		}
+		assert.Equalf(t, tc.expected, data[util.CephFSSnapshotScheduleKey], "[%s]: unexpected snapshot schedule", tc.label)
+	}
+}
+
+func TestOcsOperatorConfigCephFSSnapshotScheduleRemoved(t *testing.T) {
+	cephFilesystem := newTestCephFilesystem("ocs-storagecluster-cephfilesystem", &rookCephv1.FSMirroringSpec{
+		Enabled:           true,
+		SnapshotSchedules: []rookCephv1.SnapshotScheduleSpec{{Path: "/", Interval: "24h"}},
+	})
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace), cephFilesystem)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "ocs-storagecluster-cephfilesystem:/=24h", getOcsOperatorConfigData(t, reconciler)[util.CephFSSnapshotScheduleKey])
+
+	// removing the schedule changes the inputs, and the key is dropped
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(cephFilesystem), cephFilesystem))
+	cephFilesystem.Spec.Mirroring.SnapshotSchedules = nil
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, cephFilesystem))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.NotContains(t, getOcsOperatorConfigData(t, reconciler), util.CephFSSnapshotScheduleKey)
+}
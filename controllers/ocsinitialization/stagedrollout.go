@@ -0,0 +1,163 @@
+package ocsinitialization
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// StagedRolloutAnnotation can be set to "true" on a StorageCluster to roll out ocs-operator-config changes
+	// in stages. The config change is only applied, and the resulting rook-ceph-operator restart only happens,
+	// once the rollout health check passes. Multiple rook-ceph-operator replicas are restarted one zone at a time,
+	// and the health check has to pass again before the replicas of each zone are restarted. The progress is
+	// recorded in the OCSInitialization status.
+	StagedRolloutAnnotation = "ocs.openshift.io/ocs-operator-config-staged-rollout"
+)
+
+// RolloutHealthChecker checks the health of the storageclusters before a stage of a staged rollout proceeds. The
+// zone is the zone whose rook-ceph-operator replicas are restarted next in the ZoneRestartPending stage, and is
+// empty otherwise. Deployments with their own notion of health, e.g. per availability zone, can set their own
+// implementation on the OCSInitializationReconciler, DefaultRolloutHealthChecker is used otherwise.
+type RolloutHealthChecker interface {
+	CheckRolloutHealth(ctx context.Context, cl client.Client, storageClusters []ocsv1.StorageCluster,
+		stage ocsv1.OcsOperatorConfigRolloutStage, zone string) error
+}
+
+// DefaultRolloutHealthChecker requires the CephCluster of every storagecluster to report HEALTH_OK
+type DefaultRolloutHealthChecker struct{}
+
+func (r *OCSInitializationReconciler) getRolloutHealthChecker() RolloutHealthChecker {
+	if r.RolloutHealthChecker != nil {
+		return r.RolloutHealthChecker
+	}
+	return DefaultRolloutHealthChecker{}
+}
+
+// CheckRolloutHealth returns an error describing the first storagecluster whose CephCluster is not healthy. The
+// health of Ceph is not broken down by zone, so it is checked the same way for every zone.
+func (DefaultRolloutHealthChecker) CheckRolloutHealth(ctx context.Context, cl client.Client, storageClusters []ocsv1.StorageCluster,
+	_ ocsv1.OcsOperatorConfigRolloutStage, _ string) error {
+	for i := range storageClusters {
+		sc := &storageClusters[i]
+		cephCluster := &rookCephv1.CephCluster{}
+		err := cl.Get(ctx, types.NamespacedName{Name: util.GenerateNameForCephCluster(sc), Namespace: sc.Namespace}, cephCluster)
+		if err != nil {
+			return fmt.Errorf("failed to get the CephCluster of StorageCluster %s/%s: %v", sc.Namespace, sc.Name, err)
+		}
+		health := ""
+		if cephCluster.Status.CephStatus != nil {
+			health = cephCluster.Status.CephStatus.Health
+		}
+		if health != "HEALTH_OK" {
+			return fmt.Errorf("ceph health of StorageCluster %s/%s is %q", sc.Namespace, sc.Name, health)
+		}
+	}
+	return nil
+}
+
+// isStagedRolloutEnabled returns true if any storagecluster asks for a staged rollout of the config changes
+func (r *OCSInitializationReconciler) isStagedRolloutEnabled() bool {
+	for _, sc := range r.clusters.GetStorageClusters() {
+		if sc.GetAnnotations()[StagedRolloutAnnotation] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// isOcsOperatorConfigUpdateGated returns true if the change of the ocs-operator-config data has to wait for the
+// rollout health check to pass. Creating the configmap is not gated, as there is nothing to roll out yet.
+func (r *OCSInitializationReconciler) isOcsOperatorConfigUpdateGated(initialData *ocsv1.OCSInitialization,
+	ocsOperatorConfigData map[string]string) (bool, error) {
+	if !r.isStagedRolloutEnabled() {
+		initialData.Status.OcsOperatorConfig.Rollout = ocsv1.OcsOperatorConfigRolloutStatus{}
+		return false, nil
+	}
+
+	current := &corev1.ConfigMap{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: r.getOcsOperatorConfigName(), Namespace: initialData.Namespace}, current)
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if reflect.DeepEqual(current.Data, ocsOperatorConfigData) {
+		return false, nil
+	}
+
+	if !r.checkRolloutStage(initialData, ocsv1.RolloutStageConfigUpdatePending, "") {
+		return true, nil
+	}
+	setRolloutStage(initialData, ocsv1.RolloutStageRestartPending, "")
+	return false, nil
+}
+
+// checkRolloutStage runs the rollout health check before the stage proceeds and records the progress in the
+// status. It returns false if the stage has to wait for the health check to pass.
+func (r *OCSInitializationReconciler) checkRolloutStage(initialData *ocsv1.OCSInitialization, stage ocsv1.OcsOperatorConfigRolloutStage,
+	zone string) bool {
+	err := r.getRolloutHealthChecker().CheckRolloutHealth(r.ctx, r.Client, r.clusters.GetStorageClusters(), stage, zone)
+	if err != nil {
+		r.Log.Info("Staged rollout of ocs-operator-config is waiting for the health check to pass", "Stage", stage, "Zone", zone,
+			"Reason", err.Error())
+		setRolloutStage(initialData, stage, err.Error())
+		initialData.Status.OcsOperatorConfig.Rollout.Zone = zone
+		return false
+	}
+	return true
+}
+
+// checkRolloutZone runs the rollout health check before the rook-ceph-operator replicas of the zone are restarted,
+// and records the zones whose replicas have been restarted. It returns false if the restart of the zone has to wait
+// for the health check to pass.
+func (r *OCSInitializationReconciler) checkRolloutZone(initialData *ocsv1.OCSInitialization, zone string) bool {
+	rollout := &initialData.Status.OcsOperatorConfig.Rollout
+	if rollout.Stage == ocsv1.RolloutStageZoneRestartPending && rollout.Zone == zone && rollout.Message == "" {
+		// the replicas of the zone are being restarted already
+		return true
+	}
+	completeRolloutZone(initialData)
+	if !r.checkRolloutStage(initialData, ocsv1.RolloutStageZoneRestartPending, zone) {
+		return false
+	}
+	setRolloutStage(initialData, ocsv1.RolloutStageZoneRestartPending, "")
+	rollout.Zone = zone
+	return true
+}
+
+// completeRolloutZone records the zone whose rook-ceph-operator replicas were restarted last as completed
+func completeRolloutZone(initialData *ocsv1.OCSInitialization) {
+	rollout := &initialData.Status.OcsOperatorConfig.Rollout
+	if rollout.Stage == ocsv1.RolloutStageZoneRestartPending && rollout.Message == "" && rollout.Zone != "" &&
+		!slices.Contains(rollout.CompletedZones, rollout.Zone) {
+		rollout.CompletedZones = append(rollout.CompletedZones, rollout.Zone)
+	}
+	rollout.Zone = ""
+}
+
+// setRolloutStage records the current stage of the staged rollout, the transition time only changes with the stage.
+// The zones of a previous rollout are cleared once the next config change is rolled out.
+func setRolloutStage(initialData *ocsv1.OCSInitialization, stage ocsv1.OcsOperatorConfigRolloutStage, message string) {
+	rollout := &initialData.Status.OcsOperatorConfig.Rollout
+	if rollout.Stage != stage {
+		rollout.LastTransitionTime = metav1.Now()
+		if stage == ocsv1.RolloutStageConfigUpdatePending || stage == ocsv1.RolloutStageRestartPending {
+			rollout.Zone = ""
+			rollout.CompletedZones = nil
+		}
+	}
+	rollout.Stage = stage
+	rollout.Message = message
+}
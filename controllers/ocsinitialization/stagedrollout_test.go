@@ -0,0 +1,179 @@
+package ocsinitialization
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type fakeRolloutHealthChecker struct {
+	err    error
+	stages []v1.OcsOperatorConfigRolloutStage
+	zones  []string
+}
+
+func (f *fakeRolloutHealthChecker) CheckRolloutHealth(_ context.Context, _ client.Client, _ []v1.StorageCluster,
+	stage v1.OcsOperatorConfigRolloutStage, zone string) error {
+	f.stages = append(f.stages, stage)
+	f.zones = append(f.zones, zone)
+	return f.err
+}
+
+// getStagedRolloutTestReconciler returns a reconciler with a staged rollout enabled and an ocs-operator-config
+// that has been rolled out already
+func getStagedRolloutTestReconciler(t *testing.T, checker *fakeRolloutHealthChecker) (*v1.OCSInitialization, OCSInitializationReconciler, *fakeTopologyResolver) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Annotations = map[string]string{StagedRolloutAnnotation: "true"}
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, newTestRookCephOperatorPod())
+	resolver := &fakeTopologyResolver{}
+	reconciler.TopologyResolver = resolver
+	reconciler.RolloutHealthChecker = checker
+
+	// creating the configmap is not gated
+	checker.err = fmt.Errorf("unhealthy")
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "false", getOcsOperatorConfigData(t, reconciler)[util.EnableTopologyKey])
+	assert.Empty(t, checker.stages)
+	checker.err = nil
+	_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx, newTestRookCephOperatorPod()))
+	checker.stages = nil
+
+	// change the desired config
+	resolver.topology = TopologyConfig{Enabled: true, DomainLabels: "topology.kubernetes.io/zone"}
+	reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+	return ocsInit, reconciler, resolver
+}
+
+func TestStagedRolloutPassingHealthCheck(t *testing.T) {
+	checker := &fakeRolloutHealthChecker{}
+	ocsInit, reconciler, _ := getStagedRolloutTestReconciler(t, checker)
+
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "true", getOcsOperatorConfigData(t, reconciler)[util.EnableTopologyKey])
+	assert.Equal(t, v1.RolloutStageRestartPending, ocsInit.Status.OcsOperatorConfig.Rollout.Stage)
+	assert.False(t, ocsInit.Status.OcsOperatorConfig.Rollout.LastTransitionTime.IsZero())
+
+	result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.Equal(t, v1.RolloutStageCompleted, ocsInit.Status.OcsOperatorConfig.Rollout.Stage)
+	assert.Empty(t, ocsInit.Status.OcsOperatorConfig.Rollout.Message)
+	assert.Equal(t, []v1.OcsOperatorConfigRolloutStage{v1.RolloutStageConfigUpdatePending, v1.RolloutStageRestartPending}, checker.stages)
+}
+
+func TestStagedRolloutFailingHealthCheck(t *testing.T) {
+	checker := &fakeRolloutHealthChecker{}
+	ocsInit, reconciler, _ := getStagedRolloutTestReconciler(t, checker)
+
+	// the config change waits for the health check
+	checker.err = fmt.Errorf("ceph is unhealthy")
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "false", getOcsOperatorConfigData(t, reconciler)[util.EnableTopologyKey])
+	assert.Equal(t, v1.RolloutStageConfigUpdatePending, ocsInit.Status.OcsOperatorConfig.Rollout.Stage)
+	assert.Equal(t, "ceph is unhealthy", ocsInit.Status.OcsOperatorConfig.Rollout.Message)
+	assert.Equal(t, ocsOperatorConfigObservation{}, reconciler.lastOcsOperatorConfig)
+
+	// the config change is applied once the health check passes, the restart waits for it to pass again
+	checker.err = nil
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "true", getOcsOperatorConfigData(t, reconciler)[util.EnableTopologyKey])
+	assert.Equal(t, v1.RolloutStageRestartPending, ocsInit.Status.OcsOperatorConfig.Rollout.Stage)
+
+	checker.err = fmt.Errorf("ceph is unhealthy")
+	result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Equal(t, rookCephOperatorRestartRequeueDelay, result.RequeueAfter)
+	assert.False(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.True(t, isRookCephOperatorRestartPending(t, reconciler))
+	assert.Equal(t, v1.RolloutStageRestartPending, ocsInit.Status.OcsOperatorConfig.Rollout.Stage)
+	assert.Equal(t, "ceph is unhealthy", ocsInit.Status.OcsOperatorConfig.Rollout.Message)
+
+	checker.err = nil
+	result, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.Equal(t, v1.RolloutStageCompleted, ocsInit.Status.OcsOperatorConfig.Rollout.Stage)
+}
+
+func TestStagedRolloutByZone(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Annotations = map[string]string{StagedRolloutAnnotation: "true"}
+	deployment := newTestRookCephOperatorDeployment()
+	deployment.Spec.Replicas = ptr.To(int32(2))
+	created := time.Now().Add(-time.Hour)
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, deployment,
+		newTestZoneNode("node-a", "zone-a"),
+		newTestZoneNode("node-b", "zone-b"),
+		newTestReadyRookCephOperatorPod("rook-ceph-operator-a", "node-a", created),
+		newTestReadyRookCephOperatorPod("rook-ceph-operator-b", "node-b", created),
+	)
+	checker := &fakeRolloutHealthChecker{}
+	reconciler.RolloutHealthChecker = checker
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	rollout := &ocsInit.Status.OcsOperatorConfig.Rollout
+
+	// the replicas of the first zone are restarted once the health check passes for it
+	_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"rook-ceph-operator-b"}, getRookCephOperatorPodNames(t, reconciler))
+	assert.Equal(t, v1.RolloutStageZoneRestartPending, rollout.Stage)
+	assert.Equal(t, "zone-a", rollout.Zone)
+	assert.Empty(t, rollout.CompletedZones)
+
+	// the replicas of the next zone wait for the health check to pass
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx,
+		newTestReadyRookCephOperatorPod("rook-ceph-operator-a2", "node-a", time.Now().Add(time.Hour))))
+	checker.err = fmt.Errorf("ceph is unhealthy")
+	result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Equal(t, zonalRestartPollInterval, result.RequeueAfter)
+	assert.ElementsMatch(t, []string{"rook-ceph-operator-a2", "rook-ceph-operator-b"}, getRookCephOperatorPodNames(t, reconciler))
+	assert.True(t, isRookCephOperatorRestartPending(t, reconciler))
+	assert.Equal(t, v1.RolloutStageZoneRestartPending, rollout.Stage)
+	assert.Equal(t, "zone-b", rollout.Zone)
+	assert.Equal(t, "ceph is unhealthy", rollout.Message)
+	assert.Equal(t, []string{"zone-a"}, rollout.CompletedZones)
+
+	checker.err = nil
+	_, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"rook-ceph-operator-a2"}, getRookCephOperatorPodNames(t, reconciler))
+	assert.Equal(t, "zone-b", rollout.Zone)
+	assert.Empty(t, rollout.Message)
+
+	// the rollout completes once the replicas of all the zones have been restarted
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx,
+		newTestReadyRookCephOperatorPod("rook-ceph-operator-b2", "node-b", time.Now().Add(time.Hour))))
+	result, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.False(t, isRookCephOperatorRestartPending(t, reconciler))
+	assert.Equal(t, v1.RolloutStageCompleted, rollout.Stage)
+	assert.Empty(t, rollout.Zone)
+	assert.Equal(t, []string{"zone-a", "zone-b"}, rollout.CompletedZones)
+
+	assert.Equal(t, []v1.OcsOperatorConfigRolloutStage{v1.RolloutStageRestartPending, v1.RolloutStageZoneRestartPending,
+		v1.RolloutStageZoneRestartPending, v1.RolloutStageZoneRestartPending}, checker.stages)
+	assert.Equal(t, []string{"", "zone-a", "zone-b", "zone-b"}, checker.zones)
+}
+
+func TestDefaultRolloutHealthChecker(t *testing.T) {
+	for _, health := range []string{"HEALTH_OK", "HEALTH_WARN"} {
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t,
+			newTestStorageCluster("ocs-storagecluster", testOperatorNamespace), newTestCephCluster(testOperatorNamespace, health))
+		err := DefaultRolloutHealthChecker{}.CheckRolloutHealth(reconciler.ctx, reconciler.Client,
+			reconciler.clusters.GetStorageClusters(), ocsInit.Status.OcsOperatorConfig.Rollout.Stage, "")
+		assert.Equalf(t, health == "HEALTH_OK", err == nil, "[%s]: unexpected health check result %v", health, err)
+	}
+}
@@ -0,0 +1,22 @@
+package ocsinitialization
+
+import (
+	"errors"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+)
+
+const (
+	// ConditionCSIConfigStale is set when the configmaps consumed by the rook-ceph-operator changed, but there is
+	// neither a rook-ceph-operator Deployment nor a pod matching the pod selector to restart, so the CSI drivers
+	// may still run with the previous config. The restart is retried with a backoff until a rook-ceph-operator is found.
+	ConditionCSIConfigStale conditionsv1.ConditionType = "CSIConfigStale"
+)
+
+// errRookCephOperatorNotFound is returned when there is no rook-ceph-operator to restart for a pending config change
+var errRookCephOperatorNotFound = errors.New("no rook-ceph-operator found to restart")
+
+// isRookCephOperatorNotFound returns true if the restart failed as there is no rook-ceph-operator to restart
+func isRookCephOperatorNotFound(err error) bool {
+	return errors.Is(err, errRookCephOperatorNotFound)
+}
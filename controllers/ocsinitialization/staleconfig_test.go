@@ -0,0 +1,51 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestartWithoutRookCephOperator(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+
+	// the restart fails and stays pending while there is no rook-ceph-operator
+	_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.True(t, isRookCephOperatorNotFound(err), "unexpected error %v", err)
+	assert.True(t, isRookCephOperatorRestartPending(t, reconciler))
+	assert.NotNil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionCSIConfigStale))
+
+	// a repackaged rook-ceph-operator pod is selected by its app.kubernetes.io/name label
+	pod := newTestRookCephOperatorPod()
+	pod.Labels = map[string]string{"app.kubernetes.io/name": rookCephOperatorName}
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx, pod))
+	_, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.False(t, isRookCephOperatorRestartPending(t, reconciler))
+	assert.Nil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionCSIConfigStale))
+}
+
+func TestListRookCephOperatorPodsByEitherLabel(t *testing.T) {
+	legacyPod := newTestRookCephOperatorPod()
+	namedPod := newTestRookCephOperatorPod()
+	namedPod.Name = "rook-ceph-operator-7f9c6d5b8-fghij"
+	namedPod.Labels = map[string]string{"app.kubernetes.io/name": rookCephOperatorName}
+	bothPod := newTestRookCephOperatorPod()
+	bothPod.Name = "rook-ceph-operator-6b8d7c9f5-klmno"
+	bothPod.Labels["app.kubernetes.io/name"] = rookCephOperatorName
+	otherPod := newTestRookCephOperatorPod()
+	otherPod.Name = "other-operator-5c7b9d8f6-pqrst"
+	otherPod.Labels = map[string]string{"app.kubernetes.io/name": "other-operator"}
+
+	_, reconciler := getOcsOperatorConfigTestReconciler(t, legacyPod, namedPod, bothPod, otherPod)
+	pods, err := reconciler.listRookCephOperatorPods(testOperatorNamespace)
+	assert.NoError(t, err)
+	names := []string{}
+	for _, pod := range pods.Items {
+		names = append(names, pod.Name)
+	}
+	assert.ElementsMatch(t, []string{legacyPod.Name, namedPod.Name, bothPod.Name}, names)
+}
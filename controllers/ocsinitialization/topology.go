@@ -0,0 +1,376 @@
+package ocsinitialization
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	configv1 "github.com/openshift/api/config/v1"
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// MirroringPeerTopologyDomainLabelsAnnotation can be set on a StorageCluster with mirroring enabled to the
+	// topology domain labels used by the mirroring peer, so that the local topology is aligned with it.
+	MirroringPeerTopologyDomainLabelsAnnotation = "ocs.openshift.io/mirroring-peer-topology-domain-labels"
+
+	// ConditionMirroringTopologyIncompatible is set when the local topology domain labels are different
+	// from the ones used by the mirroring peer, in which case failover placement may not be sane.
+	ConditionMirroringTopologyIncompatible conditionsv1.ConditionType = "MirroringTopologyIncompatible"
+
+	// ConditionMultipleTopologySources is set when the topology domain labels are configured with different
+	// values by more than one defaults or overrides source. The source with the highest precedence wins.
+	ConditionMultipleTopologySources conditionsv1.ConditionType = "MultipleTopologySources"
+
+	// ConditionSingleNodeTopologyDisabled is set when topology is disabled because the cluster is a single-node
+	// cluster, on which there is only one failure domain.
+	ConditionSingleNodeTopologyDisabled conditionsv1.ConditionType = "SingleNodeTopologyDisabled"
+
+	// ConditionStretchTopologyDetected is set when a stretched cluster with two data zones and an
+	// arbiter zone has been detected from the zone labels of the nodes
+	ConditionStretchTopologyDetected conditionsv1.ConditionType = "StretchTopologyDetected"
+
+	// ConditionStretchTopologyInvalid is set when a stretched cluster is configured, but the zone labels
+	// of the nodes do not describe two data zones and an arbiter zone
+	ConditionStretchTopologyInvalid conditionsv1.ConditionType = "StretchTopologyInvalid"
+
+	// stretchClusterZoneCount is the number of zones of a stretched cluster, two data zones and an arbiter zone
+	stretchClusterZoneCount = 3
+
+	// nodeListPageSize is the number of nodes that are listed per request
+	nodeListPageSize = 500
+
+	// incompleteNodeListRequeueDelay is the delay after which the reconcile is retried when the node list was incomplete
+	incompleteNodeListRequeueDelay = 30 * time.Second
+
+	// osdAppName is the app label of the OSD pods
+	osdAppName = "rook-ceph-osd"
+)
+
+// osdPodPredicate passes the events of the OSD pods that can change the nodes hosting OSDs, which are their
+// creation, deletion and scheduling to a node
+var osdPodPredicate = predicate.And(
+	predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()["app"] == osdAppName
+	}),
+	predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldPod, oldOk := e.ObjectOld.(*corev1.Pod)
+			newPod, newOk := e.ObjectNew.(*corev1.Pod)
+			return oldOk && newOk && oldPod.Spec.NodeName != newPod.Spec.NodeName
+		},
+	},
+)
+
+// errIncompleteNodeList is returned when no complete and consistent list of the nodes could be obtained.
+// The topology must not be derived from a partial list, so the reconcile of the config is skipped instead.
+var errIncompleteNodeList = errors.New("incomplete node list")
+
+// isIncompleteNodeList returns true if the error is caused by an incomplete node list
+func isIncompleteNodeList(err error) bool {
+	return errors.Is(err, errIncompleteNodeList)
+}
+
+// topologySource is a user provided source of the topology domain labels
+type topologySource struct {
+	name   string
+	labels string
+}
+
+// alignTopologyWithMirroringPeer adjusts the topology domain labels for storageclusters that mirror to a peer.
+// If no local topology domain labels could be determined, the ones of the peer are used.
+// If both are known but differ, the local ones are kept and a condition is set.
+func (r *OCSInitializationReconciler) alignTopologyWithMirroringPeer(initialData *ocsv1.OCSInitialization, ocsOperatorConfigData map[string]string) {
+	incompatible := ""
+	for _, sc := range r.clusters.GetStorageClusters() {
+		if sc.Spec.Mirroring == nil || !sc.Spec.Mirroring.Enabled {
+			continue
+		}
+		peerLabels := sc.GetAnnotations()[MirroringPeerTopologyDomainLabelsAnnotation]
+		if peerLabels == "" {
+			continue
+		}
+		localLabels := ocsOperatorConfigData[util.TopologyDomainLabelsKey]
+		if localLabels == "" {
+			r.Log.Info("Using the topology domain labels of the mirroring peer", "StorageCluster", sc.Name, "Labels", peerLabels)
+			ocsOperatorConfigData[util.TopologyDomainLabelsKey] = peerLabels
+		} else if localLabels != peerLabels {
+			incompatible = fmt.Sprintf("topology domain labels %q of StorageCluster %s/%s are incompatible with %q used by the mirroring peer",
+				localLabels, sc.Namespace, sc.Name, peerLabels)
+			r.Log.Info("Local topology is incompatible with the mirroring peer", "StorageCluster", sc.Name, "Local", localLabels, "Peer", peerLabels)
+		}
+	}
+
+	setOcsOperatorConfigCondition(initialData, ConditionMirroringTopologyIncompatible, incompatible != "",
+		"TopologyDomainLabelsMismatch", incompatible)
+}
+
+// validateTopologySources flags a configuration where more than one source sets the topology domain labels
+// to different values. The sources are expected in increasing order of precedence, so the last one wins.
+func (r *OCSInitializationReconciler) validateTopologySources(initialData *ocsv1.OCSInitialization, sources []topologySource) {
+	conflicting := false
+	for _, source := range sources {
+		if source.labels != sources[len(sources)-1].labels {
+			conflicting = true
+			break
+		}
+	}
+
+	message := ""
+	if conflicting {
+		names := make([]string, 0, len(sources))
+		for _, source := range sources {
+			names = append(names, fmt.Sprintf("%s (%q)", source.name, source.labels))
+		}
+		winner := sources[len(sources)-1]
+		message = fmt.Sprintf("topology domain labels are set by multiple sources: %s; using %q from %s",
+			strings.Join(names, ", "), winner.labels, winner.name)
+		r.Log.Info("Topology domain labels are set by multiple sources", "Sources", names, "Using", winner.name)
+	}
+
+	setOcsOperatorConfigCondition(initialData, ConditionMultipleTopologySources, conflicting,
+		"ConflictingTopologySources", message)
+}
+
+// disableTopologyOnSingleNodeCluster forces topology off on single-node clusters like SNO, where there is
+// effectively a single failure domain and topology constrained provisioning can only fail.
+// This takes precedence over all the other sources of the topology config.
+func (r *OCSInitializationReconciler) disableTopologyOnSingleNodeCluster(initialData *ocsv1.OCSInitialization, ocsOperatorConfigData map[string]string,
+	nodes []corev1.Node) error {
+	singleNode, err := r.isSingleNodeCluster(nodes)
+	if err != nil {
+		return err
+	}
+
+	if singleNode {
+		if ocsOperatorConfigData[util.EnableTopologyKey] != "false" {
+			r.Log.Info("Disabling topology on a single-node cluster")
+		}
+		ocsOperatorConfigData[util.EnableTopologyKey] = "false"
+	}
+
+	setOcsOperatorConfigCondition(initialData, ConditionSingleNodeTopologyDisabled, singleNode,
+		"SingleNodeCluster", "topology is disabled as the cluster is a single-node cluster")
+	return nil
+}
+
+// isSingleNodeCluster returns true if the cluster is a single-node cluster. On OpenShift this is the control plane
+// topology of the cluster Infrastructure, elsewhere the cluster has to consist of a single node. The nodes are
+// counted regardless of their taints or cordons, so that neither tainted nodes nor a drain switch topology off.
+func (r *OCSInitializationReconciler) isSingleNodeCluster(nodes []corev1.Node) (bool, error) {
+	infrastructure := &configv1.Infrastructure{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: "cluster"}, infrastructure)
+	if err == nil && infrastructure.Status.ControlPlaneTopology != "" {
+		return infrastructure.Status.ControlPlaneTopology == configv1.SingleReplicaTopologyMode, nil
+	}
+	if err != nil && !kerrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return false, err
+	}
+	return len(nodes) == 1, nil
+}
+
+// guardTopologyDomainLabels refuses to enable topology without any domain labels, which the CSI drivers do not
+// reject but fail topology aware provisioning with. The topology config of the current ocs-operator-config is
+// kept instead, unless it is such a config itself, in which case topology is disabled.
+func (r *OCSInitializationReconciler) guardTopologyDomainLabels(initialData *ocsv1.OCSInitialization, ocsOperatorConfigData map[string]string) error {
+	missing := ocsOperatorConfigData[util.EnableTopologyKey] == "true" && strings.TrimSpace(ocsOperatorConfigData[util.TopologyDomainLabelsKey]) == ""
+	setOcsOperatorConfigCondition(initialData, ocsv1.ConditionTopologyDomainLabelsMissing, missing, "TopologyDomainLabelsMissing",
+		fmt.Sprintf("topology is enabled without any %s, keeping the previous topology config", util.TopologyDomainLabelsKey))
+	if !missing {
+		return nil
+	}
+
+	current := &corev1.ConfigMap{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: r.getOcsOperatorConfigName(), Namespace: initialData.Namespace}, current)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	if current.Data[util.EnableTopologyKey] == "true" && strings.TrimSpace(current.Data[util.TopologyDomainLabelsKey]) == "" {
+		current.Data = nil
+	}
+	r.Log.Info("Topology is enabled without any domain labels, keeping the previous topology config",
+		"EnableTopology", current.Data[util.EnableTopologyKey], "DomainLabels", current.Data[util.TopologyDomainLabelsKey])
+	for _, key := range []string{util.EnableTopologyKey, util.TopologyDomainLabelsKey} {
+		if value, ok := current.Data[key]; ok {
+			ocsOperatorConfigData[key] = value
+		} else {
+			delete(ocsOperatorConfigData, key)
+		}
+	}
+	if _, ok := ocsOperatorConfigData[util.EnableTopologyKey]; !ok {
+		ocsOperatorConfigData[util.EnableTopologyKey] = "false"
+	}
+	return nil
+}
+
+// isSchedulableNode returns true if the node is not cordoned and has no taint preventing scheduling
+func isSchedulableNode(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == corev1.TaintEffectNoSchedule || taint.Effect == corev1.TaintEffectNoExecute {
+			return false
+		}
+	}
+	return true
+}
+
+// detectStretchTopology detects the zones of a stretched cluster from the zone labels of the nodes running the CSI
+// node plugins. For a valid layout topology is enabled, the number of zones is set, and the zone label is used as
+// the topology domain label, unless it has already been determined otherwise. The user provided defaults and
+// overrides are applied later, so they still take precedence.
+func (r *OCSInitializationReconciler) detectStretchTopology(initialData *ocsv1.OCSInitialization, ocsOperatorConfigData map[string]string,
+	pluginNodes []corev1.Node) {
+	var stretchCluster *ocsv1.StorageCluster
+	for i := range r.clusters.GetInternalStorageClusters() {
+		if sc := &r.clusters.GetInternalStorageClusters()[i]; sc.Spec.Arbiter.Enable {
+			stretchCluster = sc
+			break
+		}
+	}
+
+	detected, invalid := "", ""
+	if stretchCluster != nil {
+		zones := getZonesOfNodes(pluginNodes)
+		arbiterZone := ""
+		if stretchCluster.Spec.NodeTopologies != nil {
+			arbiterZone = stretchCluster.Spec.NodeTopologies.ArbiterLocation
+		}
+		if len(zones) == stretchClusterZoneCount && slices.Contains(zones, arbiterZone) {
+			detected = fmt.Sprintf("detected stretched cluster with %d zones %s and arbiter zone %s",
+				len(zones), strings.Join(zones, ", "), arbiterZone)
+			ocsOperatorConfigData[util.EnableTopologyKey] = "true"
+			ocsOperatorConfigData[util.TopologyZoneCountKey] = strconv.Itoa(len(zones))
+			if ocsOperatorConfigData[util.TopologyDomainLabelsKey] == "" {
+				ocsOperatorConfigData[util.TopologyDomainLabelsKey] = corev1.LabelTopologyZone
+			}
+		} else {
+			invalid = fmt.Sprintf("StorageCluster %s/%s is stretched, but the nodes are in %d zones [%s], expected %d zones including arbiter zone %q",
+				stretchCluster.Namespace, stretchCluster.Name, len(zones), strings.Join(zones, ", "), stretchClusterZoneCount, arbiterZone)
+			r.Log.Info("Invalid stretched cluster layout", "StorageCluster", stretchCluster.Name, "Zones", zones, "ArbiterZone", arbiterZone)
+		}
+	}
+
+	setOcsOperatorConfigCondition(initialData, ConditionStretchTopologyDetected, detected != "", "StretchLayoutDetected", detected)
+	setOcsOperatorConfigCondition(initialData, ConditionStretchTopologyInvalid, invalid != "", "InvalidStretchLayout", invalid)
+}
+
+// getTopologyDomainNodes returns the nodes that contribute their zone to the topology domains. A cordoned node
+// still does while it hosts OSDs or bound local volumes, as their data remains in its zone, and is excluded once
+// it hosts neither.
+func (r *OCSInitializationReconciler) getTopologyDomainNodes(nodes []corev1.Node) ([]corev1.Node, error) {
+	if !slices.ContainsFunc(nodes, func(node corev1.Node) bool { return node.Spec.Unschedulable }) {
+		return nodes, nil
+	}
+	storageNodeNames, err := r.getStorageNodeNames()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(slices.Clone(nodes), func(node corev1.Node) bool {
+		return node.Spec.Unschedulable && !storageNodeNames.Has(node.Name)
+	}), nil
+}
+
+// getStorageNodeNames returns the names of the nodes that the OSDs of the internal storageclusters are scheduled
+// to, or that bound local volumes are bound to
+func (r *OCSInitializationReconciler) getStorageNodeNames() (sets.Set[string], error) {
+	nodeNames := sets.New[string]()
+	namespaces := sets.New[string]()
+	for _, sc := range r.clusters.GetInternalStorageClusters() {
+		namespaces.Insert(sc.Namespace)
+	}
+	for _, namespace := range sets.List(namespaces) {
+		pods := &corev1.PodList{}
+		if err := r.Client.List(r.ctx, pods, client.InNamespace(namespace), client.MatchingLabels{"app": osdAppName}); err != nil {
+			return nil, err
+		}
+		for i := range pods.Items {
+			if pods.Items[i].Spec.NodeName != "" {
+				nodeNames.Insert(pods.Items[i].Spec.NodeName)
+			}
+		}
+	}
+
+	pvList := &corev1.PersistentVolumeList{}
+	if err := r.Client.List(r.ctx, pvList); err != nil {
+		return nil, err
+	}
+	for i := range pvList.Items {
+		if pvList.Items[i].Status.Phase == corev1.VolumeBound {
+			nodeNames.Insert(getLocalVolumeNodeNames(&pvList.Items[i])...)
+		}
+	}
+	return nodeNames, nil
+}
+
+// getZonesOfNodes returns the sorted list of distinct zones the nodes in the list are labeled with
+func getZonesOfNodes(nodes []corev1.Node) []string {
+	zones := sets.New[string]()
+	for _, node := range nodes {
+		if zone := node.Labels[corev1.LabelTopologyZone]; zone != "" {
+			zones.Insert(zone)
+		}
+	}
+	return sets.List(zones)
+}
+
+// listNodes lists all the nodes. With a NodeReader the nodes are listed page by page, and all the pages have to be
+// served from the same snapshot of the nodes; if a page cannot be listed or the snapshot changed in between,
+// errIncompleteNodeList is returned. Otherwise they are listed from the cache at once, as it does not paginate.
+// The topology labels of the nodes are overridden by the topology node group overrides of the storageclusters.
+func (r *OCSInitializationReconciler) listNodes() ([]corev1.Node, error) {
+	nodes, err := r.listNodePages()
+	if err != nil {
+		return nil, err
+	}
+	overrides := r.getTopologyNodeGroupOverrides()
+	for i := range nodes {
+		nodes[i] = *applyTopologyNodeGroupOverrides(overrides, &nodes[i])
+	}
+	return nodes, nil
+}
+
+func (r *OCSInitializationReconciler) listNodePages() ([]corev1.Node, error) {
+	if r.NodeReader == nil {
+		nodeList := &corev1.NodeList{}
+		if err := r.Client.List(r.ctx, nodeList); err != nil {
+			return nil, err
+		}
+		return nodeList.Items, nil
+	}
+
+	nodes := []corev1.Node{}
+	resourceVersion, continueToken := "", ""
+	for {
+		page := &corev1.NodeList{}
+		if err := r.NodeReader.List(r.ctx, page, client.Limit(nodeListPageSize), client.Continue(continueToken)); err != nil {
+			return nil, fmt.Errorf("%w: failed to list the nodes after %d nodes: %w", errIncompleteNodeList, len(nodes), err)
+		}
+		if continueToken == "" {
+			resourceVersion = page.ResourceVersion
+		} else if page.ResourceVersion != resourceVersion {
+			return nil, fmt.Errorf("%w: the nodes changed from resourceVersion %s to %s while listing them",
+				errIncompleteNodeList, resourceVersion, page.ResourceVersion)
+		}
+		nodes = append(nodes, page.Items...)
+		if page.Continue == "" {
+			return nodes, nil
+		}
+		continueToken = page.Continue
+	}
+}
@@ -0,0 +1,633 @@
+package ocsinitialization
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestTopologyStorageCluster(failureDomainKey string) *v1.StorageCluster {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Spec.ManagedResources.CephNonResilientPools.Enable = true
+	sc.Status.FailureDomainKey = failureDomainKey
+	return sc
+}
+
+func newTestNode(name string, taints ...corev1.Taint) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{Taints: taints},
+	}
+}
+
+func TestTopologyWithMirroring(t *testing.T) {
+	testcases := []struct {
+		label              string
+		mirroring          bool
+		localLabels        string
+		peerLabels         string
+		expectedLabels     string
+		expectIncompatible bool
+	}{
+		{
+			label:          "mirroring disabled ignores the peer topology",
+			mirroring:      false,
+			localLabels:    "",
+			peerLabels:     "topology.kubernetes.io/zone",
+			expectedLabels: "",
+		},
+		{
+			label:          "mirroring enabled uses the peer topology when local is unknown",
+			mirroring:      true,
+			localLabels:    "",
+			peerLabels:     "topology.kubernetes.io/zone",
+			expectedLabels: "topology.kubernetes.io/zone",
+		},
+		{
+			label:          "mirroring enabled with matching topology",
+			mirroring:      true,
+			localLabels:    "topology.kubernetes.io/zone",
+			peerLabels:     "topology.kubernetes.io/zone",
+			expectedLabels: "topology.kubernetes.io/zone",
+		},
+		{
+			label:              "mirroring enabled with incompatible topology",
+			mirroring:          true,
+			localLabels:        "topology.rook.io/rack",
+			peerLabels:         "topology.kubernetes.io/zone",
+			expectedLabels:     "topology.rook.io/rack",
+			expectIncompatible: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestTopologyStorageCluster(tc.localLabels)
+		sc.Spec.Mirroring = &v1.MirroringSpec{Enabled: tc.mirroring}
+		sc.Annotations = map[string]string{MirroringPeerTopologyDomainLabelsAnnotation: tc.peerLabels}
+
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+		err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		assert.Equalf(t, tc.expectedLabels, data[util.TopologyDomainLabelsKey], "[%s]: unexpected topology domain labels", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionMirroringTopologyIncompatible)
+		assert.Equalf(t, tc.expectIncompatible, condition != nil, "[%s]: unexpected incompatible topology condition", tc.label)
+	}
+}
+
+func TestMultipleTopologySources(t *testing.T) {
+	testcases := []struct {
+		label            string
+		defaultsLabels   string
+		overridesLabels  string
+		expectedLabels   string
+		expectConflicted bool
+	}{
+		{
+			label:          "topology domain labels from the defaults configmap only",
+			defaultsLabels: "topology.kubernetes.io/zone",
+			expectedLabels: "topology.kubernetes.io/zone",
+		},
+		{
+			label:           "topology domain labels from the overrides annotation only",
+			overridesLabels: "topology.rook.io/rack",
+			expectedLabels:  "topology.rook.io/rack",
+		},
+		{
+			label:           "same topology domain labels from both sources",
+			defaultsLabels:  "topology.kubernetes.io/zone",
+			overridesLabels: "topology.kubernetes.io/zone",
+			expectedLabels:  "topology.kubernetes.io/zone",
+		},
+		{
+			label:            "different topology domain labels from both sources are flagged and the overrides win",
+			defaultsLabels:   "topology.kubernetes.io/zone",
+			overridesLabels:  "topology.rook.io/rack",
+			expectedLabels:   "topology.rook.io/rack",
+			expectConflicted: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		objs := []client.Object{sc}
+		if tc.overridesLabels != "" {
+			sc.Annotations = map[string]string{
+				OcsOperatorConfigOverridesAnnotation: `{"` + util.TopologyDomainLabelsKey + `":"` + tc.overridesLabels + `"}`,
+			}
+		}
+		if tc.defaultsLabels != "" {
+			objs = append(objs, newTestConfigMap(OcsOperatorConfigDefaultsName, testOperatorNamespace, map[string]string{
+				util.TopologyDomainLabelsKey: tc.defaultsLabels,
+			}))
+		}
+
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		assert.Equalf(t, tc.expectedLabels, data[util.TopologyDomainLabelsKey], "[%s]: unexpected topology domain labels", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionMultipleTopologySources)
+		assert.Equalf(t, tc.expectConflicted, condition != nil, "[%s]: unexpected multiple topology sources condition", tc.label)
+	}
+}
+
+func TestTopologyOnSingleNodeCluster(t *testing.T) {
+	masterTaint := corev1.Taint{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule}
+	cordoned := newTestNode("worker-1")
+	cordoned.Spec.Unschedulable = true
+
+	testcases := []struct {
+		label                string
+		objs                 []client.Object
+		expectedTopology     string
+		expectSingleNodeCond bool
+	}{
+		{
+			label:                "single node cluster disables topology",
+			objs:                 []client.Object{newTestNode("sno")},
+			expectedTopology:     "false",
+			expectSingleNodeCond: true,
+		},
+		{
+			label: "single replica control plane disables topology",
+			objs: []client.Object{
+				newTestInfrastructure(configv1.SingleReplicaTopologyMode),
+				newTestNode("sno"), newTestNode("worker-0"),
+			},
+			expectedTopology:     "false",
+			expectSingleNodeCond: true,
+		},
+		{
+			label: "highly available control plane keeps topology enabled",
+			objs: []client.Object{
+				newTestInfrastructure(configv1.HighlyAvailableTopologyMode),
+				newTestNode("worker-0"),
+			},
+			expectedTopology: "true",
+		},
+		{
+			label: "single untainted node with tainted control plane nodes keeps topology enabled",
+			objs: []client.Object{
+				newTestNode("master-0", masterTaint),
+				newTestNode("master-1", masterTaint),
+				newTestNode("worker-0"),
+			},
+			expectedTopology: "true",
+		},
+		{
+			label: "cordoned node keeps topology enabled",
+			objs: []client.Object{
+				newTestNode("worker-0"),
+				cordoned,
+			},
+			expectedTopology: "true",
+		},
+		{
+			label: "multi node cluster keeps topology enabled",
+			objs: []client.Object{
+				newTestNode("worker-0"),
+				newTestNode("worker-1"),
+				newTestNode("worker-2"),
+			},
+			expectedTopology: "true",
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestTopologyStorageCluster("topology.kubernetes.io/zone")
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, append(tc.objs, sc)...)
+		err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		assert.Equalf(t, tc.expectedTopology, data[util.EnableTopologyKey], "[%s]: unexpected topology enablement", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionSingleNodeTopologyDisabled)
+		assert.Equalf(t, tc.expectSingleNodeCond, condition != nil, "[%s]: unexpected single node condition", tc.label)
+	}
+}
+
+func newTestInfrastructure(controlPlaneTopology configv1.TopologyMode) *configv1.Infrastructure {
+	return &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status:     configv1.InfrastructureStatus{ControlPlaneTopology: controlPlaneTopology},
+	}
+}
+
+// getTestNodeZones returns the zones of the nodes running the CSI node plugins
+func getTestNodeZones(t *testing.T, reconciler OCSInitializationReconciler) []string {
+	nodes, err := reconciler.listNodes()
+	assert.NoError(t, err)
+	pluginNodes, err := reconciler.getCSINodePluginNodes(nodes)
+	assert.NoError(t, err)
+	return getZonesOfNodes(pluginNodes)
+}
+
+func newTestZoneNode(name, zone string) *corev1.Node {
+	node := newTestNode(name)
+	node.Labels = map[string]string{corev1.LabelTopologyZone: zone}
+	return node
+}
+
+func TestStretchTopologyDetection(t *testing.T) {
+	testcases := []struct {
+		label             string
+		nodes             []client.Object
+		expectedLabels    string
+		expectedTopology  string
+		expectedZoneCount string
+		expectDetected    bool
+		expectInvalid     bool
+	}{
+		{
+			label: "valid stretch layout with two data zones and an arbiter zone",
+			nodes: []client.Object{
+				newTestZoneNode("node-a1", "zone-a"),
+				newTestZoneNode("node-a2", "zone-a"),
+				newTestZoneNode("node-b1", "zone-b"),
+				newTestZoneNode("node-b2", "zone-b"),
+				newTestZoneNode("arbiter", "zone-arbiter"),
+			},
+			expectedLabels:    corev1.LabelTopologyZone,
+			expectedTopology:  "true",
+			expectedZoneCount: "3",
+			expectDetected:    true,
+		},
+		{
+			label: "invalid stretch layout with only two zones",
+			nodes: []client.Object{
+				newTestZoneNode("node-a", "zone-a"),
+				newTestZoneNode("node-b", "zone-b"),
+			},
+			expectedLabels:   "",
+			expectedTopology: "false",
+			expectInvalid:    true,
+		},
+		{
+			label: "invalid stretch layout with three zones but none of them the arbiter zone",
+			nodes: []client.Object{
+				newTestZoneNode("node-a", "zone-a"),
+				newTestZoneNode("node-b", "zone-b"),
+				newTestZoneNode("node-c", "zone-c"),
+			},
+			expectedLabels:   "",
+			expectedTopology: "false",
+			expectInvalid:    true,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Spec.Arbiter.Enable = true
+		sc.Spec.NodeTopologies = &v1.NodeTopologyMap{ArbiterLocation: "zone-arbiter"}
+
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, append(tc.nodes, sc)...)
+		err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+		assert.NoErrorf(t, err, "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		assert.Equalf(t, tc.expectedLabels, data[util.TopologyDomainLabelsKey], "[%s]: unexpected topology domain labels", tc.label)
+		assert.Equalf(t, tc.expectedTopology, data[util.EnableTopologyKey], "[%s]: unexpected topology enablement", tc.label)
+		assert.Equalf(t, tc.expectedZoneCount, data[util.TopologyZoneCountKey], "[%s]: unexpected zone count", tc.label)
+		detected := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionStretchTopologyDetected)
+		assert.Equalf(t, tc.expectDetected, detected != nil, "[%s]: unexpected stretch topology detected condition", tc.label)
+		invalid := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionStretchTopologyInvalid)
+		assert.Equalf(t, tc.expectInvalid, invalid != nil, "[%s]: unexpected invalid stretch topology condition", tc.label)
+	}
+
+	// the detected topology domain labels can still be overridden
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	sc.Spec.Arbiter.Enable = true
+	sc.Spec.NodeTopologies = &v1.NodeTopologyMap{ArbiterLocation: "zone-arbiter"}
+	sc.Annotations = map[string]string{
+		OcsOperatorConfigOverridesAnnotation: `{"` + util.TopologyDomainLabelsKey + `":"topology.rook.io/datacenter"}`,
+	}
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc,
+		newTestZoneNode("node-a", "zone-a"), newTestZoneNode("node-b", "zone-b"), newTestZoneNode("arbiter", "zone-arbiter"))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "topology.rook.io/datacenter", getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey])
+
+	// and so can the topology enablement
+	ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t, sc,
+		newTestConfigMap(OcsOperatorConfigDefaultsName, testOperatorNamespace, map[string]string{util.EnableTopologyKey: "false"}),
+		newTestZoneNode("node-a", "zone-a"), newTestZoneNode("node-b", "zone-b"), newTestZoneNode("arbiter", "zone-arbiter"))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "false", getOcsOperatorConfigData(t, reconciler)[util.EnableTopologyKey])
+}
+
+// pagedNodeReader serves the nodes in pages, optionally failing the listing of a page
+type pagedNodeReader struct {
+	client.Reader
+	pages []corev1.NodeList
+	// failPage is the index of the page that cannot be listed, or -1
+	failPage int
+	// listed is the number of times the nodes have been listed
+	listed int
+}
+
+func (r *pagedNodeReader) List(_ context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	page := 0
+	if listOpts.Continue != "" {
+		page, _ = strconv.Atoi(listOpts.Continue)
+	} else {
+		r.listed++
+	}
+	if page == r.failPage {
+		return errors.NewResourceExpired("the provided continue parameter is too old")
+	}
+	nodes := list.(*corev1.NodeList)
+	r.pages[page].DeepCopyInto(nodes)
+	if page < len(r.pages)-1 {
+		nodes.Continue = strconv.Itoa(page + 1)
+	}
+	return nil
+}
+
+func newTestNodeListPage(resourceVersion string, nodes ...*corev1.Node) corev1.NodeList {
+	page := corev1.NodeList{ListMeta: metav1.ListMeta{ResourceVersion: resourceVersion}}
+	for _, node := range nodes {
+		page.Items = append(page.Items, *node)
+	}
+	return page
+}
+
+func TestTopologyWithIncompleteNodeList(t *testing.T) {
+	testcases := []struct {
+		label        string
+		reader       *pagedNodeReader
+		expectFailed bool
+	}{
+		{
+			label: "nodes listed in multiple pages",
+			reader: &pagedNodeReader{
+				pages: []corev1.NodeList{
+					newTestNodeListPage("10", newTestNode("worker-0"), newTestNode("worker-1")),
+					newTestNodeListPage("10", newTestNode("worker-2")),
+				},
+				failPage: -1,
+			},
+		},
+		{
+			label: "listing a page fails",
+			reader: &pagedNodeReader{
+				pages: []corev1.NodeList{
+					newTestNodeListPage("20", newTestNode("worker-0")),
+					newTestNodeListPage("20", newTestNode("worker-1"), newTestNode("worker-2")),
+				},
+				failPage: 1,
+			},
+			expectFailed: true,
+		},
+		{
+			label: "nodes changed while listing the pages",
+			reader: &pagedNodeReader{
+				pages: []corev1.NodeList{
+					newTestNodeListPage("20", newTestNode("worker-0")),
+					newTestNodeListPage("21", newTestNode("worker-1"), newTestNode("worker-2")),
+				},
+				failPage: -1,
+			},
+			expectFailed: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := []client.Object{
+			newTestTopologyStorageCluster("topology.kubernetes.io/zone"),
+			newTestNode("worker-0"), newTestNode("worker-1"), newTestNode("worker-2"),
+		}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		assert.Equalf(t, "true", getOcsOperatorConfigData(t, reconciler)[util.EnableTopologyKey], "[%s]", tc.label)
+
+		// an incomplete list that only has a single node must not disable the topology
+		tc.reader.Reader = reconciler.Client
+		reconciler.NodeReader = tc.reader
+		reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+		err := reconciler.ensureOcsOperatorConfigExists(ocsInit)
+		assert.Equalf(t, tc.expectFailed, isIncompleteNodeList(err), "[%s]: unexpected error %v", tc.label, err)
+		assert.Equalf(t, 1, tc.reader.listed, "[%s]: the nodes are expected to be listed once per reconcile", tc.label)
+		assert.Equalf(t, "true", getOcsOperatorConfigData(t, reconciler)[util.EnableTopologyKey], "[%s]: unexpected topology enablement", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionSingleNodeTopologyDisabled)
+		assert.Nilf(t, condition, "[%s]: unexpected single node condition", tc.label)
+	}
+}
+
+func newTestCSINodePluginDaemonSet(name string, nodeSelector map[string]string) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testOperatorNamespace,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{NodeSelector: nodeSelector},
+			},
+		},
+	}
+}
+
+func TestTopologyDomainsOfCSINodePlugins(t *testing.T) {
+	storageNodeLabel := map[string]string{"cluster.ocs.openshift.io/openshift-storage": ""}
+	newTestStorageZoneNode := func(name, zone string) *corev1.Node {
+		node := newTestZoneNode(name, zone)
+		maps.Copy(node.Labels, storageNodeLabel)
+		return node
+	}
+
+	testcases := []struct {
+		label          string
+		daemonSets     []client.Object
+		expectDetected bool
+	}{
+		{
+			label:          "node plugins are not deployed yet",
+			expectDetected: false,
+		},
+		{
+			label: "node selector excludes the nodes of a zone",
+			daemonSets: []client.Object{
+				newTestCSINodePluginDaemonSet("csi-rbdplugin", storageNodeLabel),
+				newTestCSINodePluginDaemonSet("csi-cephfsplugin", storageNodeLabel),
+			},
+			expectDetected: true,
+		},
+		{
+			label: "node plugin without a node selector runs on all the nodes",
+			daemonSets: []client.Object{
+				newTestCSINodePluginDaemonSet("csi-rbdplugin", storageNodeLabel),
+				newTestCSINodePluginDaemonSet("csi-cephfsplugin", nil),
+			},
+			expectDetected: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Spec.Arbiter.Enable = true
+		sc.Spec.NodeTopologies = &v1.NodeTopologyMap{ArbiterLocation: "zone-arbiter"}
+		// the nodes of zone-compute do not run the node plugins, which leaves the two data zones and the arbiter zone
+		objs := append([]client.Object{
+			sc,
+			newTestStorageZoneNode("node-a", "zone-a"),
+			newTestStorageZoneNode("node-b", "zone-b"),
+			newTestStorageZoneNode("arbiter", "zone-arbiter"),
+			newTestZoneNode("compute", "zone-compute"),
+		}, tc.daemonSets...)
+
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		zones := getTestNodeZones(t, reconciler)
+		assert.Equalf(t, tc.expectDetected, !slices.Contains(zones, "zone-compute"), "[%s]: unexpected zones %v", tc.label, zones)
+		detected := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionStretchTopologyDetected)
+		assert.Equalf(t, tc.expectDetected, detected != nil, "[%s]: unexpected stretch topology detected condition", tc.label)
+		invalid := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionStretchTopologyInvalid)
+		assert.Equalf(t, !tc.expectDetected, invalid != nil, "[%s]: unexpected invalid stretch topology condition", tc.label)
+	}
+}
+
+func TestTopologyDomainsOfCordonedNodes(t *testing.T) {
+	newTestOSDPod := func(namespace, nodeName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rook-ceph-osd-0-" + nodeName,
+				Namespace: namespace,
+				Labels:    map[string]string{"app": osdAppName},
+			},
+			Spec: corev1.PodSpec{NodeName: nodeName},
+		}
+	}
+	releasedVolume := newTestLocalVolume("local-pv-released", "cordoned", testOperatorNamespace)
+	releasedVolume.Status.Phase = corev1.VolumeReleased
+
+	testcases := []struct {
+		label         string
+		cordoned      bool
+		objs          []client.Object
+		expectedZones []string
+	}{
+		{
+			label:         "schedulable node without storage",
+			expectedZones: []string{"zone-a", "zone-b"},
+		},
+		{
+			label:         "cordoned node without storage",
+			cordoned:      true,
+			expectedZones: []string{"zone-a"},
+		},
+		{
+			label:         "cordoned node hosting an OSD",
+			cordoned:      true,
+			objs:          []client.Object{newTestOSDPod(testOperatorNamespace, "cordoned")},
+			expectedZones: []string{"zone-a", "zone-b"},
+		},
+		{
+			label:         "cordoned node hosting a bound local volume",
+			cordoned:      true,
+			objs:          []client.Object{newTestLocalVolume("local-pv-bound", "cordoned", testOperatorNamespace)},
+			expectedZones: []string{"zone-a", "zone-b"},
+		},
+		{
+			label:         "cordoned node hosting a released local volume",
+			cordoned:      true,
+			objs:          []client.Object{releasedVolume},
+			expectedZones: []string{"zone-a"},
+		},
+		{
+			label:         "cordoned node hosting an OSD of another namespace",
+			cordoned:      true,
+			objs:          []client.Object{newTestOSDPod("other-namespace", "cordoned")},
+			expectedZones: []string{"zone-a"},
+		},
+	}
+
+	for _, tc := range testcases {
+		cordonedNode := newTestZoneNode("cordoned", "zone-b")
+		cordonedNode.Spec.Unschedulable = tc.cordoned
+		objs := append([]client.Object{
+			newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+			newTestZoneNode("node-a", "zone-a"),
+			cordonedNode,
+		}, tc.objs...)
+
+		_, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		zones := getTestNodeZones(t, reconciler)
+		assert.Equalf(t, tc.expectedZones, zones, "[%s]: unexpected zones", tc.label)
+	}
+}
+
+func TestTopologyWithoutDomainLabels(t *testing.T) {
+	testcases := []struct {
+		label            string
+		current          map[string]string
+		expectedTopology string
+		expectedLabels   string
+		expectLabelsKey  bool
+	}{
+		{
+			label:            "topology is disabled without a previous config",
+			expectedTopology: "false",
+		},
+		{
+			label:            "previous topology config is kept",
+			current:          map[string]string{util.EnableTopologyKey: "true", util.TopologyDomainLabelsKey: corev1.LabelTopologyZone},
+			expectedTopology: "true",
+			expectedLabels:   corev1.LabelTopologyZone,
+			expectLabelsKey:  true,
+		},
+		{
+			label:            "previous disabled topology is kept",
+			current:          map[string]string{util.EnableTopologyKey: "false", util.TopologyDomainLabelsKey: ""},
+			expectedTopology: "false",
+			expectLabelsKey:  true,
+		},
+		{
+			label:            "previous topology config without domain labels is not kept",
+			current:          map[string]string{util.EnableTopologyKey: "true", util.TopologyDomainLabelsKey: ""},
+			expectedTopology: "false",
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := []client.Object{newTestTopologyStorageCluster("")}
+		if tc.current != nil {
+			objs = append(objs, newTestConfigMap(util.OcsOperatorConfigName, testOperatorNamespace, tc.current))
+		}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		assert.Equalf(t, tc.expectedTopology, data[util.EnableTopologyKey], "[%s]: unexpected topology enablement", tc.label)
+		labels, ok := data[util.TopologyDomainLabelsKey]
+		assert.Equalf(t, tc.expectLabelsKey, ok, "[%s]: unexpected presence of the domain labels", tc.label)
+		assert.Equalf(t, tc.expectedLabels, labels, "[%s]: unexpected domain labels", tc.label)
+		assert.Truef(t, conditionsv1.IsStatusConditionTrue(ocsInit.Status.Conditions, v1.ConditionTopologyDomainLabelsMissing),
+			"[%s]: expected the missing domain labels condition", tc.label)
+	}
+
+	// the condition is cleared once the failure domain key is determined
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestTopologyStorageCluster(corev1.LabelTopologyZone))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "true", data[util.EnableTopologyKey])
+	assert.Equal(t, corev1.LabelTopologyZone, data[util.TopologyDomainLabelsKey])
+	assert.False(t, conditionsv1.IsStatusConditionTrue(ocsInit.Status.Conditions, v1.ConditionTopologyDomainLabelsMissing))
+}
@@ -0,0 +1,150 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// ConditionTopologyDomainRemovalDeferred is set when topology domain labels are no longer desired, but are
+	// kept as bound volumes are still constrained to them. They are removed once no bound volume uses them.
+	ConditionTopologyDomainRemovalDeferred conditionsv1.ConditionType = "TopologyDomainRemovalDeferred"
+)
+
+// topologyVolumePredicate passes the events of the PersistentVolumes of the topology CSI drivers that can
+// change the topology domains in use, and of the local volumes that can change the placement of LSO devices,
+// which are their creation, deletion and phase changes
+var topologyVolumePredicate = predicate.And(
+	predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		pv, ok := obj.(*corev1.PersistentVolume)
+		return ok && (pv.Spec.Local != nil || pv.Spec.CSI != nil && slices.Contains(topologyCSIDriverNames, pv.Spec.CSI.Driver))
+	}),
+	predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldPV, oldOk := e.ObjectOld.(*corev1.PersistentVolume)
+			newPV, newOk := e.ObjectNew.(*corev1.PersistentVolume)
+			return oldOk && newOk && oldPV.Status.Phase != newPV.Status.Phase
+		},
+	},
+)
+
+// getCSITopologyKey returns the key of the node affinity the CSI driver sets on volumes constrained to the
+// topology domain label, which is prefixed by the driver name
+func getCSITopologyKey(driverName, domainLabel string) string {
+	return fmt.Sprintf("topology.%s/%s", driverName, domainLabel[strings.LastIndex(domainLabel, "/")+1:])
+}
+
+// getBoundVolumeTopologyKeys returns the sorted, distinct node affinity keys of the bound volumes of the
+// topology CSI drivers
+func (r *OCSInitializationReconciler) getBoundVolumeTopologyKeys() ([]string, error) {
+	pvList := &corev1.PersistentVolumeList{}
+	if err := r.Client.List(r.ctx, pvList); err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for _, pv := range pvList.Items {
+		if pv.Status.Phase != corev1.VolumeBound || pv.Spec.CSI == nil ||
+			!slices.Contains(topologyCSIDriverNames, pv.Spec.CSI.Driver) ||
+			pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+			continue
+		}
+		for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+			for _, expression := range term.MatchExpressions {
+				keys = append(keys, expression.Key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys), nil
+}
+
+// isTopologyDomainLabelInUse returns true if any bound volume is constrained to the topology domain label,
+// either directly or through the topology key of one of the CSI drivers
+func isTopologyDomainLabelInUse(domainLabel string, volumeTopologyKeys []string) bool {
+	if slices.Contains(volumeTopologyKeys, domainLabel) {
+		return true
+	}
+	for _, driverName := range topologyCSIDriverNames {
+		if slices.Contains(volumeTopologyKeys, getCSITopologyKey(driverName, domainLabel)) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandTopologyDomainLabels updates the topology domain labels additively when the cluster expands. The new
+// labels are appended to the current ones, and a current label is only removed once no bound volume is
+// constrained to it anymore, as the volumes could otherwise not be attached.
+func (r *OCSInitializationReconciler) expandTopologyDomainLabels(initialData *ocsv1.OCSInitialization,
+	ocsOperatorConfigData map[string]string) error {
+	current := &corev1.ConfigMap{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: r.getOcsOperatorConfigName(), Namespace: initialData.Namespace}, current)
+	if errors.IsNotFound(err) {
+		setOcsOperatorConfigCondition(initialData, ConditionTopologyDomainRemovalDeferred, false, "", "")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !isTopologyDomainLabelsSwitch(current.Data, ocsOperatorConfigData) {
+		setOcsOperatorConfigCondition(initialData, ConditionTopologyDomainRemovalDeferred, false, "", "")
+		return nil
+	}
+
+	// a renamed topology label is migrated instead of removed, its previous volumes are covered by the legacy labels
+	currentLabels := splitTopologyDomainLabels(migrateTopologyDomainLabels(initialData, current.Data[util.TopologyDomainLabelsKey]))
+	desiredLabels := splitTopologyDomainLabels(ocsOperatorConfigData[util.TopologyDomainLabelsKey])
+	volumeTopologyKeys, err := r.getBoundVolumeTopologyKeys()
+	if err != nil {
+		return err
+	}
+
+	expandedLabels := []string{}
+	deferred := []string{}
+	for _, label := range currentLabels {
+		if slices.Contains(desiredLabels, label) {
+			expandedLabels = append(expandedLabels, label)
+		} else if isTopologyDomainLabelInUse(label, volumeTopologyKeys) {
+			expandedLabels = append(expandedLabels, label)
+			deferred = append(deferred, label)
+		}
+	}
+	for _, label := range desiredLabels {
+		if !slices.Contains(expandedLabels, label) {
+			expandedLabels = append(expandedLabels, label)
+		}
+	}
+
+	if len(deferred) > 0 {
+		r.Log.Info("Deferring the removal of topology domain labels that bound volumes are constrained to",
+			"Labels", deferred, "RequestedLabels", ocsOperatorConfigData[util.TopologyDomainLabelsKey])
+	}
+	ocsOperatorConfigData[util.TopologyDomainLabelsKey] = strings.Join(expandedLabels, ",")
+	setOcsOperatorConfigCondition(initialData, ConditionTopologyDomainRemovalDeferred, len(deferred) > 0,
+		"BoundVolumesInTopologyDomain",
+		fmt.Sprintf("The topology domain labels %s are kept until no bound volume is constrained to them",
+			strings.Join(deferred, ", ")))
+	return nil
+}
+
+// splitTopologyDomainLabels splits a comma separated list of topology domain labels
+func splitTopologyDomainLabels(domainLabels string) []string {
+	labels := []string{}
+	for _, label := range strings.Split(domainLabels, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
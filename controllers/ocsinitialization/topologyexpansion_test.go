@@ -0,0 +1,123 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestTopologyVolume(name, driverName, topologyKey string, phase corev1.PersistentVolumePhase) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{Driver: driverName, VolumeHandle: name},
+			},
+			NodeAffinity: &corev1.VolumeNodeAffinity{
+				Required: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key:      topologyKey,
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{"zone1"},
+						}},
+					}},
+				},
+			},
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: phase},
+	}
+}
+
+func TestTopologyDomainLabelsExpansion(t *testing.T) {
+	zoneTopologyKey := getCSITopologyKey(util.RbdDriverName, corev1.LabelTopologyZone)
+
+	testcases := []struct {
+		label           string
+		volumes         []client.Object
+		desiredLabels   string
+		expectedLabels  string
+		expectCondition bool
+	}{
+		{
+			label:          "new topology domain label is added",
+			volumes:        []client.Object{newTestTopologyVolume("pv1", util.RbdDriverName, zoneTopologyKey, corev1.VolumeBound)},
+			desiredLabels:  "topology.kubernetes.io/zone,topology.rook.io/rack",
+			expectedLabels: "topology.kubernetes.io/zone,topology.rook.io/rack",
+		},
+		{
+			label:          "topology domain label without bound volumes is removed",
+			desiredLabels:  "topology.rook.io/rack",
+			expectedLabels: "topology.rook.io/rack",
+		},
+		{
+			label:          "topology domain label of released volumes is removed",
+			volumes:        []client.Object{newTestTopologyVolume("pv1", util.RbdDriverName, zoneTopologyKey, corev1.VolumeReleased)},
+			desiredLabels:  "topology.rook.io/rack",
+			expectedLabels: "topology.rook.io/rack",
+		},
+		{
+			label:          "topology domain label of volumes of other drivers is removed",
+			volumes:        []client.Object{newTestTopologyVolume("pv1", "ebs.csi.aws.com", corev1.LabelTopologyZone, corev1.VolumeBound)},
+			desiredLabels:  "topology.rook.io/rack",
+			expectedLabels: "topology.rook.io/rack",
+		},
+		{
+			label:           "removal of a topology domain label with bound volumes is deferred",
+			volumes:         []client.Object{newTestTopologyVolume("pv1", util.RbdDriverName, zoneTopologyKey, corev1.VolumeBound)},
+			desiredLabels:   "topology.rook.io/rack",
+			expectedLabels:  "topology.kubernetes.io/zone,topology.rook.io/rack",
+			expectCondition: true,
+		},
+		{
+			label: "removal of a topology domain label the volumes are directly constrained to is deferred",
+			volumes: []client.Object{
+				newTestTopologyVolume("pv1", util.CephFSDriverName, corev1.LabelTopologyZone, corev1.VolumeBound),
+			},
+			desiredLabels:   "topology.rook.io/rack",
+			expectedLabels:  "topology.kubernetes.io/zone,topology.rook.io/rack",
+			expectCondition: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		resolver := &fakeTopologyResolver{topology: TopologyConfig{Enabled: true, DomainLabels: corev1.LabelTopologyZone}}
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t,
+			append(tc.volumes, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))...)
+		reconciler.TopologyResolver = resolver
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		resolver.topology.DomainLabels = tc.desiredLabels
+		reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+		assert.Equalf(t, tc.expectedLabels, getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey], "[%s]: unexpected domain labels", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionTopologyDomainRemovalDeferred)
+		assert.Equalf(t, tc.expectCondition, condition != nil && condition.Status == corev1.ConditionTrue,
+			"[%s]: unexpected condition %v", tc.label, condition)
+	}
+}
+
+func TestTopologyDomainLabelRemovedOnceVolumesAreGone(t *testing.T) {
+	volume := newTestTopologyVolume("pv1", util.RbdDriverName, getCSITopologyKey(util.RbdDriverName, corev1.LabelTopologyZone), corev1.VolumeBound)
+	resolver := &fakeTopologyResolver{topology: TopologyConfig{Enabled: true, DomainLabels: corev1.LabelTopologyZone}}
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, volume, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+	reconciler.TopologyResolver = resolver
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+
+	resolver.topology.DomainLabels = "topology.rook.io/rack"
+	reconciler.lastOcsOperatorConfig = ocsOperatorConfigObservation{}
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "topology.kubernetes.io/zone,topology.rook.io/rack", getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey])
+
+	// deleting the volume changes the inputs, and the deferred label is removed
+	assert.NoError(t, reconciler.Client.Delete(reconciler.ctx, volume))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "topology.rook.io/rack", getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey])
+	condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionTopologyDomainRemovalDeferred)
+	assert.True(t, condition == nil || condition.Status == corev1.ConditionFalse, "unexpected condition %v", condition)
+}
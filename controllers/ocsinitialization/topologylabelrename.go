@@ -0,0 +1,140 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// topologyLabelRename is the rename of a standard node topology label
+type topologyLabelRename struct {
+	oldLabel string
+	newLabel string
+}
+
+// topologyLabelRenames are the renamed standard topology labels. The nodes are labeled with the new name after an
+// upgrade of OCP, while a topology domain label of the config, e.g. the failure domain of a storagecluster, may
+// still have the old one.
+var topologyLabelRenames = []topologyLabelRename{
+	{oldLabel: corev1.LabelFailureDomainBetaZone, newLabel: corev1.LabelTopologyZone},
+	{oldLabel: corev1.LabelFailureDomainBetaRegion, newLabel: corev1.LabelTopologyRegion},
+}
+
+// isTopologyLabelRenamed returns true if the nodes have been relabeled by the rename, i.e. none of them carries the
+// old label anymore while the new label is present
+func isTopologyLabelRenamed(nodes []corev1.Node, rename topologyLabelRename) bool {
+	renamed := false
+	for i := range nodes {
+		if _, ok := nodes[i].Labels[rename.oldLabel]; ok {
+			return false
+		}
+		if _, ok := nodes[i].Labels[rename.newLabel]; ok {
+			renamed = true
+		}
+	}
+	return renamed
+}
+
+// reconcileTopologyLabelRenameStatus records the renamed topology labels of the nodes in the status. The migration
+// of a renamed label starts when the rename is first observed, and is completed once the transition period of the
+// renamed ocs-operator-config keys has passed. A migration ends when the nodes carry the old label again.
+func (r *OCSInitializationReconciler) reconcileTopologyLabelRenameStatus(initialData *ocsv1.OCSInitialization, nodes []corev1.Node, now time.Time) {
+	existing := initialData.Status.OcsOperatorConfig.TopologyLabelRenames
+	statuses := []ocsv1.TopologyLabelRenameStatus{}
+	for _, rename := range topologyLabelRenames {
+		idx := slices.IndexFunc(existing, func(status ocsv1.TopologyLabelRenameStatus) bool {
+			return status.OldLabel == rename.oldLabel && status.NewLabel == rename.newLabel
+		})
+		if !isTopologyLabelRenamed(nodes, rename) {
+			if idx >= 0 {
+				r.Log.Info("Nodes carry the old topology label again, ending its migration", "OldLabel", rename.oldLabel, "NewLabel", rename.newLabel)
+			}
+			continue
+		}
+		var status ocsv1.TopologyLabelRenameStatus
+		if idx >= 0 {
+			status = existing[idx]
+		} else {
+			r.Log.Info("Detected a renamed topology label on the nodes, migrating the topology domain labels", "OldLabel", rename.oldLabel,
+				"NewLabel", rename.newLabel, "Period", r.getConfigKeyRenameTransitionPeriod())
+			status = ocsv1.TopologyLabelRenameStatus{OldLabel: rename.oldLabel, NewLabel: rename.newLabel, StartTime: metav1.NewTime(now)}
+		}
+		if !status.Completed && !now.Before(status.StartTime.Add(r.getConfigKeyRenameTransitionPeriod())) {
+			r.Log.Info("Transition of a renamed topology label has passed, no longer passing on the previous topology domain labels",
+				"OldLabel", status.OldLabel, "NewLabel", status.NewLabel)
+			status.Completed = true
+		}
+		statuses = append(statuses, status)
+	}
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	initialData.Status.OcsOperatorConfig.TopologyLabelRenames = statuses
+}
+
+// getTopologyLabelRenameInput returns the state of the topology label migrations as an input of ocs-operator-config
+func getTopologyLabelRenameInput(initialData *ocsv1.OCSInitialization) string {
+	inputs := []string{}
+	for _, status := range initialData.Status.OcsOperatorConfig.TopologyLabelRenames {
+		inputs = append(inputs, fmt.Sprintf("%s->%s,completed=%t", status.OldLabel, status.NewLabel, status.Completed))
+	}
+	return strings.Join(inputs, ";")
+}
+
+// migrateTopologyDomainLabels returns the comma separated topology domain labels with the renamed labels replaced
+// by their new names
+func migrateTopologyDomainLabels(initialData *ocsv1.OCSInitialization, domainLabels string) string {
+	labels := []string{}
+	for _, label := range splitTopologyDomainLabels(domainLabels) {
+		for _, status := range initialData.Status.OcsOperatorConfig.TopologyLabelRenames {
+			if label == status.OldLabel {
+				label = status.NewLabel
+			}
+		}
+		if !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	return strings.Join(labels, ",")
+}
+
+// applyTopologyLabelRenames migrates the renamed labels of the topology domain labels to their new names. Until the
+// transition has passed, the previous topology domain labels are passed on in CSI_TOPOLOGY_DOMAIN_LABELS_LEGACY as
+// well, as the existing volumes are constrained to them.
+func (r *OCSInitializationReconciler) applyTopologyLabelRenames(initialData *ocsv1.OCSInitialization, ocsOperatorConfigData map[string]string) {
+	domainLabels := ocsOperatorConfigData[util.TopologyDomainLabelsKey]
+	migrated := migrateTopologyDomainLabels(initialData, domainLabels)
+	if migrated == strings.Join(splitTopologyDomainLabels(domainLabels), ",") {
+		return
+	}
+	r.Log.Info("Migrating the renamed topology domain labels", "DomainLabels", domainLabels, "MigratedDomainLabels", migrated)
+	ocsOperatorConfigData[util.TopologyDomainLabelsKey] = migrated
+	inTransition := slices.ContainsFunc(initialData.Status.OcsOperatorConfig.TopologyLabelRenames, func(status ocsv1.TopologyLabelRenameStatus) bool {
+		return !status.Completed && slices.Contains(splitTopologyDomainLabels(domainLabels), status.OldLabel)
+	})
+	if inTransition {
+		ocsOperatorConfigData[util.TopologyDomainLabelsLegacyKey] = domainLabels
+	}
+}
+
+// getTopologyLabelRenameRequeueDelay returns the time left until the first topology label migration in progress has
+// passed, after which the previous topology domain labels have to be removed, or zero if there is none in progress
+func (r *OCSInitializationReconciler) getTopologyLabelRenameRequeueDelay(initialData *ocsv1.OCSInitialization, now time.Time) time.Duration {
+	delay := time.Duration(0)
+	for _, status := range initialData.Status.OcsOperatorConfig.TopologyLabelRenames {
+		if status.Completed {
+			continue
+		}
+		if remaining := max(status.StartTime.Add(r.getConfigKeyRenameTransitionPeriod()).Sub(now), time.Second); delay == 0 || remaining < delay {
+			delay = remaining
+		}
+	}
+	return delay
+}
@@ -0,0 +1,130 @@
+package ocsinitialization
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestBetaZoneNode(name, zone string) *corev1.Node {
+	node := newTestNode(name)
+	node.Labels = map[string]string{corev1.LabelFailureDomainBetaZone: zone}
+	return node
+}
+
+// relabelTestNodes simulates the rename of the zone label by an upgrade of OCP
+func relabelTestNodes(t *testing.T, reconciler OCSInitializationReconciler, names ...string) {
+	for _, name := range names {
+		node := &corev1.Node{}
+		assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKey{Name: name}, node))
+		node.Labels[corev1.LabelTopologyZone] = node.Labels[corev1.LabelFailureDomainBetaZone]
+		delete(node.Labels, corev1.LabelFailureDomainBetaZone)
+		assert.NoError(t, reconciler.Client.Update(reconciler.ctx, node))
+	}
+}
+
+func TestOcsOperatorConfigTopologyLabelRename(t *testing.T) {
+	sc := newTestTopologyStorageCluster(corev1.LabelFailureDomainBetaZone)
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, newTestBetaZoneNode("node-a", "zone-a"),
+		newTestBetaZoneNode("node-b", "zone-b"), newTestBetaZoneNode("node-c", "zone-c"))
+	reconciler.ConfigKeyRenameTransitionPeriod = time.Hour
+
+	// the domain labels are kept while the nodes carry the old label
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, corev1.LabelFailureDomainBetaZone, data[util.TopologyDomainLabelsKey])
+	assert.NotContains(t, data, util.TopologyDomainLabelsLegacyKey)
+	assert.Nil(t, ocsInit.Status.OcsOperatorConfig.TopologyLabelRenames)
+
+	// some nodes are relabeled, the others still carry the old label
+	relabelTestNodes(t, reconciler, "node-a")
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, corev1.LabelFailureDomainBetaZone, getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey])
+	assert.Nil(t, ocsInit.Status.OcsOperatorConfig.TopologyLabelRenames)
+
+	// the domain labels are migrated once all the nodes are relabeled, both are written during the transition
+	relabelTestNodes(t, reconciler, "node-b", "node-c")
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data = getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, corev1.LabelTopologyZone, data[util.TopologyDomainLabelsKey])
+	assert.Equal(t, corev1.LabelFailureDomainBetaZone, data[util.TopologyDomainLabelsLegacyKey])
+	assert.Len(t, ocsInit.Status.OcsOperatorConfig.TopologyLabelRenames, 1)
+	status := ocsInit.Status.OcsOperatorConfig.TopologyLabelRenames[0]
+	assert.Equal(t, corev1.LabelFailureDomainBetaZone, status.OldLabel)
+	assert.Equal(t, corev1.LabelTopologyZone, status.NewLabel)
+	assert.False(t, status.Completed)
+
+	// the transition is not restarted by later reconciles
+	delay := reconciler.getTopologyLabelRenameRequeueDelay(ocsInit, status.StartTime.Add(20*time.Minute))
+	assert.Equal(t, 40*time.Minute, delay)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, status.StartTime, ocsInit.Status.OcsOperatorConfig.TopologyLabelRenames[0].StartTime)
+	assert.Contains(t, getOcsOperatorConfigData(t, reconciler), util.TopologyDomainLabelsLegacyKey)
+
+	// only the new label is written once the transition has passed
+	ocsInit.Status.OcsOperatorConfig.TopologyLabelRenames[0].StartTime = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data = getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, corev1.LabelTopologyZone, data[util.TopologyDomainLabelsKey])
+	assert.NotContains(t, data, util.TopologyDomainLabelsLegacyKey)
+	assert.True(t, ocsInit.Status.OcsOperatorConfig.TopologyLabelRenames[0].Completed)
+	assert.Zero(t, reconciler.getTopologyLabelRenameRequeueDelay(ocsInit, time.Now()))
+}
+
+func TestApplyTopologyLabelRenames(t *testing.T) {
+	testcases := []struct {
+		label          string
+		domainLabels   string
+		completed      bool
+		expectedLabels string
+		expectedLegacy string
+	}{
+		{
+			label:          "renamed label",
+			domainLabels:   corev1.LabelFailureDomainBetaZone,
+			expectedLabels: corev1.LabelTopologyZone,
+			expectedLegacy: corev1.LabelFailureDomainBetaZone,
+		},
+		{
+			label:          "renamed label next to another label",
+			domainLabels:   corev1.LabelFailureDomainBetaZone + ",topology.rook.io/rack",
+			expectedLabels: corev1.LabelTopologyZone + ",topology.rook.io/rack",
+			expectedLegacy: corev1.LabelFailureDomainBetaZone + ",topology.rook.io/rack",
+		},
+		{
+			label:          "renamed label next to its new name",
+			domainLabels:   corev1.LabelFailureDomainBetaZone + "," + corev1.LabelTopologyZone,
+			expectedLabels: corev1.LabelTopologyZone,
+			expectedLegacy: corev1.LabelFailureDomainBetaZone + "," + corev1.LabelTopologyZone,
+		},
+		{
+			label:          "completed transition",
+			domainLabels:   corev1.LabelFailureDomainBetaZone,
+			completed:      true,
+			expectedLabels: corev1.LabelTopologyZone,
+		},
+		{
+			label:          "no renamed label",
+			domainLabels:   "topology.rook.io/rack",
+			expectedLabels: "topology.rook.io/rack",
+		},
+	}
+
+	for _, tc := range testcases {
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t)
+		ocsInit.Status.OcsOperatorConfig.TopologyLabelRenames = []v1.TopologyLabelRenameStatus{
+			{OldLabel: corev1.LabelFailureDomainBetaZone, NewLabel: corev1.LabelTopologyZone, StartTime: metav1.Now(), Completed: tc.completed},
+		}
+		data := map[string]string{util.TopologyDomainLabelsKey: tc.domainLabels}
+		reconciler.applyTopologyLabelRenames(ocsInit, data)
+		assert.Equalf(t, tc.expectedLabels, data[util.TopologyDomainLabelsKey], "[%s]: unexpected domain labels", tc.label)
+		assert.Equalf(t, tc.expectedLegacy, data[util.TopologyDomainLabelsLegacyKey], "[%s]: unexpected legacy domain labels", tc.label)
+	}
+}
@@ -0,0 +1,122 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ConditionTopologyNodeGroupOverrideInvalid is set when a topology node group override of a storagecluster
+	// has an invalid node selector or topology labels. The invalid override is ignored, so its nodes keep the
+	// topology values they are labeled with.
+	ConditionTopologyNodeGroupOverrideInvalid conditionsv1.ConditionType = "TopologyNodeGroupOverrideInvalid"
+)
+
+// topologyNodeGroupOverride is a validated topology node group override of a storagecluster
+type topologyNodeGroupOverride struct {
+	name           string
+	selector       labels.Selector
+	topologyLabels map[string]string
+}
+
+// validateTopologyNodeGroupOverride returns the node selector of the override, or an error if the selector is
+// empty or invalid, or the topology labels are not valid node labels
+func validateTopologyNodeGroupOverride(override *ocsv1.TopologyNodeGroupOverride) (labels.Selector, error) {
+	if len(override.NodeSelector.MatchLabels) == 0 && len(override.NodeSelector.MatchExpressions) == 0 {
+		return nil, fmt.Errorf("node group %q has an empty node selector, which selects all the nodes", override.Name)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&override.NodeSelector)
+	if err != nil {
+		return nil, fmt.Errorf("node group %q has an invalid node selector: %w", override.Name, err)
+	}
+	if len(override.TopologyLabels) == 0 {
+		return nil, fmt.Errorf("node group %q has no topology labels", override.Name)
+	}
+	for _, key := range slices.Sorted(maps.Keys(override.TopologyLabels)) {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("node group %q has an invalid topology label %q: %s", override.Name, key, strings.Join(errs, ", "))
+		}
+		value := override.TopologyLabels[key]
+		if value == "" {
+			return nil, fmt.Errorf("node group %q has an empty value of topology label %q", override.Name, key)
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("node group %q has an invalid value %q of topology label %q: %s",
+				override.Name, value, key, strings.Join(errs, ", "))
+		}
+	}
+	return selector, nil
+}
+
+// getTopologyNodeGroupOverrides returns the valid topology node group overrides of the first storagecluster that
+// sets any, and the errors of its invalid ones
+func getTopologyNodeGroupOverrides(storageClusters []ocsv1.StorageCluster) ([]topologyNodeGroupOverride, []error) {
+	for _, sc := range storageClusters {
+		if len(sc.Spec.TopologyNodeGroupOverrides) == 0 {
+			continue
+		}
+		overrides := []topologyNodeGroupOverride{}
+		errs := []error{}
+		for i := range sc.Spec.TopologyNodeGroupOverrides {
+			override := &sc.Spec.TopologyNodeGroupOverrides[i]
+			selector, err := validateTopologyNodeGroupOverride(override)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("StorageCluster %s/%s: %w", sc.Namespace, sc.Name, err))
+				continue
+			}
+			overrides = append(overrides, topologyNodeGroupOverride{name: override.Name, selector: selector, topologyLabels: override.TopologyLabels})
+		}
+		return overrides, errs
+	}
+	return nil, nil
+}
+
+// applyTopologyNodeGroupOverrides returns the node with its topology labels set to the values of the first node
+// group it belongs to. The node is copied before its labels are changed.
+func applyTopologyNodeGroupOverrides(overrides []topologyNodeGroupOverride, node *corev1.Node) *corev1.Node {
+	for _, override := range overrides {
+		if !override.selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		node = node.DeepCopy()
+		if node.Labels == nil {
+			node.Labels = map[string]string{}
+		}
+		maps.Copy(node.Labels, override.topologyLabels)
+		return node
+	}
+	return node
+}
+
+// getTopologyNodeGroupOverrides returns the valid topology node group overrides of the storageclusters
+func (r *OCSInitializationReconciler) getTopologyNodeGroupOverrides() []topologyNodeGroupOverride {
+	if r.clusters == nil {
+		return nil
+	}
+	overrides, _ := getTopologyNodeGroupOverrides(r.clusters.GetStorageClusters())
+	return overrides
+}
+
+// checkTopologyNodeGroupOverrides sets the ConditionTopologyNodeGroupOverrideInvalid condition for the invalid
+// topology node group overrides, which are ignored when the topology domains are derived
+func (r *OCSInitializationReconciler) checkTopologyNodeGroupOverrides(initialData *ocsv1.OCSInitialization) {
+	_, errs := getTopologyNodeGroupOverrides(r.clusters.GetStorageClusters())
+	invalid := []string{}
+	for _, err := range errs {
+		r.Log.Info("Warning: Ignoring the invalid topology node group override", "OCSInitialization", klog.KObj(initialData), "Reason", err.Error())
+		invalid = append(invalid, err.Error())
+	}
+	setOcsOperatorConfigCondition(initialData, ConditionTopologyNodeGroupOverrideInvalid, len(invalid) > 0, "InvalidTopologyNodeGroupOverride",
+		fmt.Sprintf("the invalid topology node group overrides are ignored: %s", strings.Join(invalid, "; ")))
+}
@@ -0,0 +1,156 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const testEdgeNodeLabel = "node-role.kubernetes.io/edge"
+
+func newTestTopologyNodeGroupOverride(name string, matchLabels, topologyLabels map[string]string) v1.TopologyNodeGroupOverride {
+	return v1.TopologyNodeGroupOverride{
+		Name:           name,
+		NodeSelector:   metav1.LabelSelector{MatchLabels: matchLabels},
+		TopologyLabels: topologyLabels,
+	}
+}
+
+func TestTopologyNodeGroupOverrideValidation(t *testing.T) {
+	testcases := []struct {
+		label       string
+		override    v1.TopologyNodeGroupOverride
+		expectValid bool
+	}{
+		{
+			label:       "valid override",
+			override:    newTestTopologyNodeGroupOverride("edge", map[string]string{testEdgeNodeLabel: ""}, map[string]string{corev1.LabelTopologyZone: "zone-edge"}),
+			expectValid: true,
+		},
+		{
+			label:    "empty node selector",
+			override: newTestTopologyNodeGroupOverride("edge", nil, map[string]string{corev1.LabelTopologyZone: "zone-edge"}),
+		},
+		{
+			label: "invalid node selector operator",
+			override: v1.TopologyNodeGroupOverride{
+				Name: "edge",
+				NodeSelector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: testEdgeNodeLabel, Operator: "Matches"},
+				}},
+				TopologyLabels: map[string]string{corev1.LabelTopologyZone: "zone-edge"},
+			},
+		},
+		{
+			label:    "invalid node selector label",
+			override: newTestTopologyNodeGroupOverride("edge", map[string]string{"edge nodes": "true"}, map[string]string{corev1.LabelTopologyZone: "zone-edge"}),
+		},
+		{
+			label:    "no topology labels",
+			override: newTestTopologyNodeGroupOverride("edge", map[string]string{testEdgeNodeLabel: ""}, nil),
+		},
+		{
+			label:    "invalid topology label",
+			override: newTestTopologyNodeGroupOverride("edge", map[string]string{testEdgeNodeLabel: ""}, map[string]string{"topology/zone/edge": "zone-edge"}),
+		},
+		{
+			label:    "empty topology value",
+			override: newTestTopologyNodeGroupOverride("edge", map[string]string{testEdgeNodeLabel: ""}, map[string]string{corev1.LabelTopologyZone: ""}),
+		},
+	}
+
+	for _, tc := range testcases {
+		_, err := validateTopologyNodeGroupOverride(&tc.override)
+		assert.Equalf(t, tc.expectValid, err == nil, "[%s]: unexpected validation result %v", tc.label, err)
+	}
+}
+
+func TestTopologyNodeGroupOverrideDomains(t *testing.T) {
+	edgeNode := newTestZoneNode("edge", "zone-b")
+	edgeNode.Labels[testEdgeNodeLabel] = ""
+
+	testcases := []struct {
+		label         string
+		overrides     []v1.TopologyNodeGroupOverride
+		expectedZones []string
+		expectInvalid bool
+	}{
+		{
+			label:         "no overrides",
+			expectedZones: []string{"zone-a", "zone-b"},
+		},
+		{
+			label: "node group pinned to a zone",
+			overrides: []v1.TopologyNodeGroupOverride{
+				newTestTopologyNodeGroupOverride("edge", map[string]string{testEdgeNodeLabel: ""}, map[string]string{corev1.LabelTopologyZone: "zone-edge"}),
+			},
+			expectedZones: []string{"zone-a", "zone-b", "zone-edge"},
+		},
+		{
+			label: "node group pinned to the zone of the other nodes",
+			overrides: []v1.TopologyNodeGroupOverride{
+				newTestTopologyNodeGroupOverride("zone-a", map[string]string{corev1.LabelTopologyZone: "zone-b"}, map[string]string{corev1.LabelTopologyZone: "zone-a"}),
+			},
+			expectedZones: []string{"zone-a"},
+		},
+		{
+			label: "first matching node group is applied",
+			overrides: []v1.TopologyNodeGroupOverride{
+				newTestTopologyNodeGroupOverride("edge", map[string]string{testEdgeNodeLabel: ""}, map[string]string{corev1.LabelTopologyZone: "zone-edge"}),
+				newTestTopologyNodeGroupOverride("zone-b", map[string]string{corev1.LabelTopologyZone: "zone-b"}, map[string]string{corev1.LabelTopologyZone: "zone-c"}),
+			},
+			expectedZones: []string{"zone-a", "zone-c", "zone-edge"},
+		},
+		{
+			label: "invalid node group is ignored",
+			overrides: []v1.TopologyNodeGroupOverride{
+				newTestTopologyNodeGroupOverride("all", nil, map[string]string{corev1.LabelTopologyZone: "zone-edge"}),
+			},
+			expectedZones: []string{"zone-a", "zone-b"},
+			expectInvalid: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Spec.TopologyNodeGroupOverrides = tc.overrides
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, newTestZoneNode("node-a", "zone-a"),
+			newTestZoneNode("node-b", "zone-b"), edgeNode.DeepCopy())
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		zones := getTestNodeZones(t, reconciler)
+		assert.Equalf(t, tc.expectedZones, zones, "[%s]: unexpected zones", tc.label)
+		invalid := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionTopologyNodeGroupOverrideInvalid)
+		assert.Equalf(t, tc.expectInvalid, invalid != nil, "[%s]: unexpected invalid topology node group override condition", tc.label)
+
+		// the nodes themselves are not changed
+		node := &corev1.Node{}
+		assert.NoErrorf(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(edgeNode), node), "[%s]", tc.label)
+		assert.Equalf(t, "zone-b", node.Labels[corev1.LabelTopologyZone], "[%s]: node labels are changed", tc.label)
+	}
+}
+
+func TestTopologyNodeGroupOverrideWithLSODevices(t *testing.T) {
+	// node-c holding a disk is not labeled with the rack, which the override pins it to
+	sc := newTestLSOStorageCluster("topology.rook.io/rack", testLSOStorageClassName)
+	sc.Spec.TopologyNodeGroupOverrides = []v1.TopologyNodeGroupOverride{
+		newTestTopologyNodeGroupOverride("rack2", map[string]string{corev1.LabelHostname: "node-c"}, map[string]string{"topology.rook.io/rack": "rack2"}),
+	}
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc,
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: testLSOStorageClassName}, Provisioner: lsoProvisioner},
+		newTestRackNode("node-a", "rack0"),
+		newTestRackNode("node-c", ""),
+		newTestLocalVolume("local-pv-a", "node-a", testOperatorNamespace),
+		newTestLocalVolume("local-pv-c", "node-c", testOperatorNamespace),
+	)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "topology.rook.io/rack", getOcsOperatorConfigData(t, reconciler)[util.TopologyDomainLabelsKey])
+}
@@ -0,0 +1,78 @@
+package ocsinitialization
+
+import (
+	"context"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	storagev1 "k8s.io/api/storage/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TopologyConfig is the topology config passed to the CSI drivers
+type TopologyConfig struct {
+	// Enabled enables topology aware provisioning
+	Enabled bool
+	// DomainLabels is the comma separated list of node labels describing the topology domains
+	DomainLabels string
+}
+
+// TopologyResolver resolves the topology config of the CSI drivers for the storageclusters.
+// Deployments with a different notion of topology can set their own implementation on the
+// OCSInitializationReconciler, DefaultTopologyResolver is used otherwise.
+type TopologyResolver interface {
+	ResolveTopology(ctx context.Context, cl client.Client, storageClusters []ocsv1.StorageCluster) (TopologyConfig, error)
+}
+
+// DefaultTopologyResolver resolves the topology from the failure domain of the node labels that the
+// storagecluster has been deployed with, or from the non-resilient storageclass of an external storagecluster
+type DefaultTopologyResolver struct{}
+
+func (r *OCSInitializationReconciler) getTopologyResolver() TopologyResolver {
+	if r.TopologyResolver != nil {
+		return r.TopologyResolver
+	}
+	return DefaultTopologyResolver{}
+}
+
+// ResolveTopology enables topology if replica-1 is enabled for any storagecluster.
+// In case of multiple storageClusters when replica-1 is enabled for both an internal and an external cluster, different failure domain keys can lead to complications.
+// To prevent this, when gathering information for the external cluster, ensure that the failure domain is specified to match that of the internal cluster (sc.Status.FailureDomain).
+func (DefaultTopologyResolver) ResolveTopology(ctx context.Context, cl client.Client, storageClusters []ocsv1.StorageCluster) (TopologyConfig, error) {
+	overrides, _ := getTopologyNodeGroupOverrides(storageClusters)
+
+	for _, sc := range storageClusters {
+		if !sc.Spec.ExternalStorage.Enable && sc.Spec.ManagedResources.CephNonResilientPools.Enable {
+			// In internal mode return the failure domain key from the storageCluster, unless it does not
+			// reflect the placement of the local disks of LSO devices on the nodes, with the node group overrides applied
+			domainLabel, err := resolveLSODeviceDomainLabel(ctx, cl, &sc, sc.Status.FailureDomainKey, overrides)
+			if err != nil {
+				return TopologyConfig{}, err
+			}
+			return TopologyConfig{Enabled: true, DomainLabels: domainLabel}, nil
+		} else if sc.Spec.ExternalStorage.Enable {
+			// In external mode, check if the non-resilient storageClass exists and describes the topology of the
+			// external cluster, determine the failure domain key from the storageClass parameter
+			scName := util.GenerateNameForNonResilientCephBlockPoolStorageClass(&sc)
+			storageClass := util.GetStorageClassWithName(ctx, cl, scName)
+			if storageClass != nil && validateExternalTopologyData(storageClass) == nil {
+				return TopologyConfig{Enabled: true, DomainLabels: getFailureDomainKeyFromStorageClassParameter(storageClass)}, nil
+			}
+		}
+	}
+
+	return TopologyConfig{}, nil
+}
+
+func getFailureDomainKeyFromStorageClassParameter(sc *storagev1.StorageClass) string {
+	failuredomain := sc.Parameters["topologyFailureDomainLabel"]
+	if failuredomain == "zone" {
+		return "topology.kubernetes.io/zone"
+	} else if failuredomain == "rack" {
+		return "topology.rook.io/rack"
+	} else if failuredomain == "hostname" || failuredomain == "host" {
+		return "kubernetes.io/hostname"
+	}
+	return ""
+}
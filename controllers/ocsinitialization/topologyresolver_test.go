@@ -0,0 +1,61 @@
+package ocsinitialization
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type fakeTopologyResolver struct {
+	topology        TopologyConfig
+	err             error
+	storageClusters []string
+}
+
+func (f *fakeTopologyResolver) ResolveTopology(_ context.Context, _ client.Client, storageClusters []v1.StorageCluster) (TopologyConfig, error) {
+	for _, sc := range storageClusters {
+		f.storageClusters = append(f.storageClusters, sc.Name)
+	}
+	return f.topology, f.err
+}
+
+func TestCustomTopologyResolver(t *testing.T) {
+	sc := newTestTopologyStorageCluster("topology.rook.io/rack")
+
+	// the default resolver uses the failure domain of the storagecluster
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "true", data[util.EnableTopologyKey])
+	assert.Equal(t, "topology.rook.io/rack", data[util.TopologyDomainLabelsKey])
+
+	// a custom resolver replaces the default one
+	resolver := &fakeTopologyResolver{
+		topology: TopologyConfig{Enabled: true, DomainLabels: "topology.kubernetes.io/region,topology.kubernetes.io/zone"},
+	}
+	ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t, sc)
+	reconciler.TopologyResolver = resolver
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data = getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "true", data[util.EnableTopologyKey])
+	assert.Equal(t, "topology.kubernetes.io/region,topology.kubernetes.io/zone", data[util.TopologyDomainLabelsKey])
+	assert.Equal(t, []string{sc.Name}, resolver.storageClusters)
+
+	// a custom resolver disabling topology
+	ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t, sc)
+	reconciler.TopologyResolver = &fakeTopologyResolver{}
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data = getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "false", data[util.EnableTopologyKey])
+	assert.Equal(t, "", data[util.TopologyDomainLabelsKey])
+
+	// errors of the resolver fail the reconcile
+	ocsInit, reconciler = getOcsOperatorConfigTestReconciler(t, sc)
+	reconciler.TopologyResolver = &fakeTopologyResolver{err: errors.NewServiceUnavailable("topology service unavailable")}
+	assert.Error(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+}
@@ -0,0 +1,129 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/ocsconfig"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// ConditionRestrictedConfigKeysIgnored is set when a defaults configmap in a storagecluster namespace, an
+	// OCSConfig or the overrides annotation of a StorageCluster configures ocs-operator-config keys that are not
+	// tunable from there. They can be written by anyone who can create them in the namespace, while their values end
+	// up in the single ocs-operator-config of the cluster, so only the tunable keys are taken from them.
+	ConditionRestrictedConfigKeysIgnored conditionsv1.ConditionType = "RestrictedConfigKeysIgnored"
+
+	// ConditionConflictingNamespaceDefaults is set when the defaults configmaps of more than one storagecluster
+	// namespace set the same key to different values. The value of the first namespace in name order is used.
+	ConditionConflictingNamespaceDefaults conditionsv1.ConditionType = "ConflictingNamespaceDefaults"
+
+	// ConditionTunablesOutOfBounds is set when an OCSConfig sets a numeric tunable outside of its bounds. The OCSConfig
+	// webhook rejects such values, but it is not deployed everywhere, so the bounds are enforced again on the merge.
+	ConditionTunablesOutOfBounds conditionsv1.ConditionType = "TunablesOutOfBounds"
+
+	// msModeKernelMountOption is the kernel mount option of the messenger mode, which follows the network encryption
+	msModeKernelMountOption = "ms_mode"
+)
+
+// ocsOperatorConfigTunableKeys are the ocs-operator-config keys that the defaults of a storagecluster namespace
+// and the OCSConfigs may set. They tune the CSI drivers without changing the identity of the cluster, which
+// drivers are deployed or the network encryption. The numeric tunables bounded by the OCSConfig webhook are
+// tunable as well.
+var ocsOperatorConfigTunableKeys = sets.New(
+	util.EnableReadAffinityKey,
+	util.CephFSKernelMountOptionsKey,
+	util.CephFSSubvolumeGroupPinningKey,
+).Insert(slices.Collect(maps.Keys(ocsconfig.DefaultTunableBounds))...)
+
+// ocsOperatorConfigOverridableKeys are the ocs-operator-config keys that the overrides annotation of a StorageCluster
+// may set: the tunable keys, and the topology of the storagecluster, which is detected from the nodes and may have
+// to be corrected. The identity of the cluster, e.g. CSI_CLUSTER_NAME, cannot be overridden.
+var ocsOperatorConfigOverridableKeys = ocsOperatorConfigTunableKeys.Clone().Insert(
+	util.EnableTopologyKey,
+	util.TopologyDomainLabelsKey,
+)
+
+// getMsMode returns the value of the ms_mode option of comma separated kernel mount options
+func getMsMode(mountOptions string) string {
+	for _, option := range strings.Split(mountOptions, ",") {
+		if name, value, _ := strings.Cut(strings.TrimSpace(option), "="); name == msModeKernelMountOption {
+			return value
+		}
+	}
+	return ""
+}
+
+// filterTunableKeys returns the values of the tunable keys, and the keys that are ignored. The CephFS kernel mount
+// options are only tunable with the ms_mode of the resolved values, as the ms_mode follows the network encryption.
+func filterTunableKeys(values, resolved map[string]string) (map[string]string, []string) {
+	return filterAllowedKeys(values, resolved, ocsOperatorConfigTunableKeys)
+}
+
+// filterOverridableKeys returns the values of the keys the overrides annotation may set, and the keys that are ignored
+func filterOverridableKeys(values, resolved map[string]string) (map[string]string, []string) {
+	return filterAllowedKeys(values, resolved, ocsOperatorConfigOverridableKeys)
+}
+
+func filterAllowedKeys(values, resolved map[string]string, allowedKeys sets.Set[string]) (map[string]string, []string) {
+	tunables := map[string]string{}
+	ignored := []string{}
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		if !allowedKeys.Has(key) ||
+			(key == util.CephFSKernelMountOptionsKey && getMsMode(values[key]) != getMsMode(resolved[util.CephFSKernelMountOptionsKey])) {
+			ignored = append(ignored, key)
+			continue
+		}
+		tunables[key] = values[key]
+	}
+	return tunables, ignored
+}
+
+// withEncryptionKeys returns the tunables with the network encryption key of the resolved values added to tuned
+// CephFS kernel mount options, so that the encryption keys are applied together
+func withEncryptionKeys(tunables, resolved map[string]string) map[string]string {
+	if _, ok := tunables[util.CephFSKernelMountOptionsKey]; ok {
+		tunables[util.EnableNetworkEncryptionKey] = resolved[util.EnableNetworkEncryptionKey]
+	}
+	return tunables
+}
+
+// filterTunableBounds returns the tunables that are within their bounds, and the ones that are not. A bounded
+// tunable that is not an integer is out of bounds as well.
+func filterTunableBounds(tunables map[string]string, bounds map[string]ocsconfig.TunableBounds) (map[string]string, []string) {
+	inBounds := map[string]string{}
+	outOfBounds := []string{}
+	for _, key := range slices.Sorted(maps.Keys(tunables)) {
+		if tunableBounds, ok := bounds[key]; ok {
+			if parsed, err := strconv.ParseInt(strings.TrimSpace(tunables[key]), 10, 64); err != nil || !tunableBounds.Contains(parsed) {
+				outOfBounds = append(outOfBounds, fmt.Sprintf("%s=%s (bounds %s)", key, tunables[key], tunableBounds))
+				continue
+			}
+		}
+		inBounds[key] = tunables[key]
+	}
+	return inBounds, outOfBounds
+}
+
+// validateTunableSources sets or removes the ConditionRestrictedConfigKeysIgnored,
+// ConditionConflictingNamespaceDefaults and ConditionTunablesOutOfBounds conditions
+func validateTunableSources(initialData *ocsv1.OCSInitialization, restricted, conflicts, outOfBounds []string) {
+	setOcsOperatorConfigCondition(initialData, ConditionRestrictedConfigKeysIgnored, len(restricted) > 0, "NotTunableKeys",
+		fmt.Sprintf("only the keys %s are tunable, and the overrides annotation may set %s as well, ignoring the other keys of: %s",
+			strings.Join(sets.List(ocsOperatorConfigTunableKeys), ", "),
+			strings.Join(sets.List(ocsOperatorConfigOverridableKeys.Difference(ocsOperatorConfigTunableKeys)), ", "),
+			strings.Join(restricted, "; ")))
+	setOcsOperatorConfigCondition(initialData, ConditionConflictingNamespaceDefaults, len(conflicts) > 0, "ConflictingValues",
+		fmt.Sprintf("the namespace defaults set different values, the first namespace is used: %s", strings.Join(conflicts, "; ")))
+	setOcsOperatorConfigCondition(initialData, ConditionTunablesOutOfBounds, len(outOfBounds) > 0, "OutOfBounds",
+		fmt.Sprintf("ignoring the tunables outside of their bounds, which can be changed in the %s configmap: %s",
+			ocsconfig.TunableBoundsConfigMapName, strings.Join(outOfBounds, "; ")))
+}
@@ -0,0 +1,227 @@
+package ocsinitialization
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/ocsconfig"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestFilterTunableKeys(t *testing.T) {
+	resolved := map[string]string{util.CephFSKernelMountOptionsKey: "ms_mode=secure"}
+	testcases := []struct {
+		label           string
+		values          map[string]string
+		expected        map[string]string
+		expectedIgnored []string
+	}{
+		{
+			label:           "tunable keys",
+			values:          map[string]string{util.EnableReadAffinityKey: "false", "CSI_GRPC_TIMEOUT_SECONDS": "60"},
+			expected:        map[string]string{util.EnableReadAffinityKey: "false", "CSI_GRPC_TIMEOUT_SECONDS": "60"},
+			expectedIgnored: []string{},
+		},
+		{
+			label: "keys that are not tunable",
+			values: map[string]string{
+				util.ClusterNameKey:        "other-cluster",
+				util.DisableCSIDriverKey:   "false",
+				util.EnableTopologyKey:     "true",
+				util.EnableReadAffinityKey: "false",
+			},
+			expected:        map[string]string{util.EnableReadAffinityKey: "false"},
+			expectedIgnored: []string{util.ClusterNameKey, util.EnableTopologyKey, util.DisableCSIDriverKey},
+		},
+		{
+			label:           "kernel mount options keeping the ms_mode",
+			values:          map[string]string{util.CephFSKernelMountOptionsKey: "ms_mode=secure,wsync"},
+			expected:        map[string]string{util.CephFSKernelMountOptionsKey: "ms_mode=secure,wsync"},
+			expectedIgnored: []string{},
+		},
+		{
+			label:           "kernel mount options changing the ms_mode",
+			values:          map[string]string{util.CephFSKernelMountOptionsKey: "ms_mode=crc"},
+			expected:        map[string]string{},
+			expectedIgnored: []string{util.CephFSKernelMountOptionsKey},
+		},
+		{
+			label:           "network encryption",
+			values:          map[string]string{util.EnableNetworkEncryptionKey: "false"},
+			expected:        map[string]string{},
+			expectedIgnored: []string{util.EnableNetworkEncryptionKey},
+		},
+	}
+
+	for _, tc := range testcases {
+		tunables, ignored := filterTunableKeys(tc.values, resolved)
+		assert.Equalf(t, tc.expected, tunables, "[%s]: unexpected tunables", tc.label)
+		assert.Equalf(t, tc.expectedIgnored, ignored, "[%s]: unexpected ignored keys", tc.label)
+	}
+}
+
+func TestNamespaceDefaultsTunableKeys(t *testing.T) {
+	testcases := []struct {
+		label            string
+		objs             []client.Object
+		expected         map[string]string
+		expectRestricted bool
+		expectConflict   bool
+	}{
+		{
+			label: "keys that are not tunable are ignored",
+			objs: []client.Object{
+				newTestConfigMap(OcsOperatorConfigDefaultsName, "tenant-a", map[string]string{
+					util.ClusterNameKey:        "tenant-cluster",
+					util.DisableCSIDriverKey:   "false",
+					util.EnableReadAffinityKey: "false",
+				}),
+			},
+			expected: map[string]string{
+				util.ClusterNameKey:        "",
+				util.DisableCSIDriverKey:   "true",
+				util.EnableReadAffinityKey: "false",
+			},
+			expectRestricted: true,
+		},
+		{
+			label: "kernel mount options are tuned together with the encryption keys",
+			objs: []client.Object{
+				newTestConfigMap(OcsOperatorConfigDefaultsName, "tenant-a", map[string]string{
+					util.CephFSKernelMountOptionsKey: "ms_mode=prefer-crc,wsync",
+				}),
+			},
+			expected: map[string]string{
+				util.CephFSKernelMountOptionsKey: "ms_mode=prefer-crc,wsync",
+				util.EnableNetworkEncryptionKey:  "false",
+			},
+		},
+		{
+			label: "first namespace wins a conflict",
+			objs: []client.Object{
+				newTestConfigMap(OcsOperatorConfigDefaultsName, "tenant-b", map[string]string{
+					util.EnableReadAffinityKey: "true",
+				}),
+				newTestConfigMap(OcsOperatorConfigDefaultsName, "tenant-a", map[string]string{
+					util.EnableReadAffinityKey: "false",
+				}),
+			},
+			expected:       map[string]string{util.EnableReadAffinityKey: "false"},
+			expectConflict: true,
+		},
+		{
+			label: "same value in several namespaces",
+			objs: []client.Object{
+				newTestConfigMap(OcsOperatorConfigDefaultsName, "tenant-a", map[string]string{
+					util.EnableReadAffinityKey: "false",
+				}),
+				newTestConfigMap(OcsOperatorConfigDefaultsName, "tenant-b", map[string]string{
+					util.EnableReadAffinityKey: "false",
+				}),
+			},
+			expected: map[string]string{util.EnableReadAffinityKey: "false"},
+		},
+	}
+
+	for _, tc := range testcases {
+		objs := append([]client.Object{newTestStorageCluster("ocs-storagecluster", "tenant-a"),
+			newTestStorageCluster("ocs-storagecluster", "tenant-b")}, tc.objs...)
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		for key, value := range tc.expected {
+			assert.Equalf(t, value, data[key], "[%s]: unexpected value of %s", tc.label, key)
+		}
+		restricted := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionRestrictedConfigKeysIgnored)
+		assert.Equalf(t, tc.expectRestricted, restricted != nil, "[%s]: unexpected restricted keys condition", tc.label)
+		conflict := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionConflictingNamespaceDefaults)
+		assert.Equalf(t, tc.expectConflict, conflict != nil, "[%s]: unexpected conflicting namespace defaults condition", tc.label)
+		assert.Nilf(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionIncompleteEncryptionConfig),
+			"[%s]: unexpected incomplete encryption config", tc.label)
+	}
+}
+
+func TestOverridesAnnotationKeys(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", "tenant-a")
+	sc.Annotations = map[string]string{
+		OcsOperatorConfigOverridesAnnotation: `{"` + util.ClusterNameKey + `":"other-cluster","` + util.DisableCSIDriverKey + `":"false","` +
+			util.EnableReadAffinityKey + `":"false","` + util.TopologyDomainLabelsKey + `":"topology.rook.io/rack"}`,
+	}
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc)
+	recorder := record.NewFakeRecorder(10)
+	reconciler.recorder = util.NewEventReporter(recorder)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+
+	// the tunable and topology keys are overridden, the identity of the cluster and the deployed drivers are not
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.NotEqual(t, "other-cluster", data[util.ClusterNameKey])
+	assert.Equal(t, "true", data[util.DisableCSIDriverKey])
+	assert.Equal(t, "false", data[util.EnableReadAffinityKey])
+	assert.Equal(t, "topology.rook.io/rack", data[util.TopologyDomainLabelsKey])
+
+	restricted := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionRestrictedConfigKeysIgnored)
+	assert.NotNil(t, restricted)
+	assert.Contains(t, restricted.Message, util.ClusterNameKey)
+	warnings := []string{}
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.HasPrefix(event, corev1.EventTypeWarning) {
+			warnings = append(warnings, event)
+		}
+	}
+	assert.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], util.EventReasonOcsOperatorConfigKeysIgnored)
+	assert.Contains(t, warnings[0], util.ClusterNameKey+", "+util.DisableCSIDriverKey)
+}
+
+func TestOCSConfigTunableBounds(t *testing.T) {
+	sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+	ocsConfig := &v1.OCSConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: sc.Name, Namespace: sc.Namespace},
+		Spec: v1.OCSConfigSpec{
+			Tunables: map[string]string{
+				testGRPCTimeoutTunable:     "5",
+				"CSI_LOG_LEVEL":            "debug",
+				util.EnableReadAffinityKey: "false",
+			},
+		},
+	}
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, sc, ocsConfig)
+
+	// the tunables outside of their bounds are ignored, the others are applied
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.NotContains(t, data, testGRPCTimeoutTunable)
+	assert.NotContains(t, data, "CSI_LOG_LEVEL")
+	assert.Equal(t, "false", data[util.EnableReadAffinityKey])
+	condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionTunablesOutOfBounds)
+	if assert.NotNil(t, condition) {
+		assert.Contains(t, condition.Message, testGRPCTimeoutTunable+"=5 (bounds 30:600)")
+	}
+
+	// the bounds configmap changes the bounds
+	boundsConfigMap := newTestConfigMap(ocsconfig.TunableBoundsConfigMapName, testOperatorNamespace, map[string]string{
+		testGRPCTimeoutTunable: "1:",
+	})
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx, boundsConfigMap))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Equal(t, "5", getOcsOperatorConfigData(t, reconciler)[testGRPCTimeoutTunable])
+	condition = conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionTunablesOutOfBounds)
+	if assert.NotNil(t, condition) {
+		assert.NotContains(t, condition.Message, testGRPCTimeoutTunable)
+	}
+
+	// the condition is cleared once all the tunables are within their bounds
+	delete(ocsConfig.Spec.Tunables, "CSI_LOG_LEVEL")
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, ocsConfig))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	assert.Nil(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionTunablesOutOfBounds))
+}
@@ -0,0 +1,254 @@
+package ocsinitialization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/ocsconfig"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// tuningAnalysisInterval is the interval at which the tuning suggestions are refreshed from the CSI metrics
+	tuningAnalysisInterval = 15 * time.Minute
+
+	// DefaultTuningMetricsWindow is the range over which the CSI metrics are analyzed
+	DefaultTuningMetricsWindow = time.Hour
+
+	csiGRPCTimeoutTunable = "CSI_GRPC_TIMEOUT_SECONDS"
+	csiLogLevelTunable    = "CSI_LOG_LEVEL"
+
+	// deadlineExceededRatioThreshold is the ratio of timed out CSI operations above which a longer gRPC timeout is suggested
+	deadlineExceededRatioThreshold = 0.01
+	// failureRatioThreshold is the ratio of failed CSI operations above which a more verbose log level is suggested
+	failureRatioThreshold = 0.05
+	// debugCSILogLevel is the log level suggested to diagnose failing CSI operations
+	debugCSILogLevel = 5
+)
+
+// rookTunableDefaults are the values Rook uses for the tunables that are not set in ocs-operator-config
+var rookTunableDefaults = map[string]int64{
+	csiGRPCTimeoutTunable: 150,
+	csiLogLevelTunable:    0,
+}
+
+// CSIMetrics are the CSI performance metrics observed over the analysis window
+type CSIMetrics struct {
+	// OperationLatencyP99 is the 99th percentile of the duration of the CSI operations
+	OperationLatencyP99 time.Duration
+	// DeadlineExceededRatio is the ratio of the CSI operations that timed out
+	DeadlineExceededRatio float64
+	// FailureRatio is the ratio of the CSI operations that failed for any other reason
+	FailureRatio float64
+}
+
+// CSIMetricsSource provides the CSI metrics that the tuning suggestions are based on. The tuning advisor is
+// disabled unless an implementation is set on the OCSInitializationReconciler.
+type CSIMetricsSource interface {
+	GetCSIMetrics(ctx context.Context) (CSIMetrics, error)
+}
+
+// PrometheusCSIMetricsSource queries the metrics of the CSI sidecars from the Prometheus HTTP API
+type PrometheusCSIMetricsSource struct {
+	// URL is the base URL of the Prometheus API
+	URL string
+	// Window is the range over which the metrics are analyzed, DefaultTuningMetricsWindow is used if unset
+	Window time.Duration
+	// Client is the HTTP client of the queries, http.DefaultClient is used if unset
+	Client *http.Client
+}
+
+type prometheusQueryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Data   struct {
+		Result []struct {
+			Value [2]any `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// query returns the value of an instant query that results in a single sample, or zero if there is no sample
+func (s PrometheusCSIMetricsSource) query(ctx context.Context, query string) (float64, error) {
+	queryURL := strings.TrimSuffix(s.URL, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, queryURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	httpClient := s.Client
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	queryResponse := prometheusQueryResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&queryResponse); err != nil {
+		return 0, fmt.Errorf("failed to decode the response to query %q: %v", query, err)
+	}
+	if queryResponse.Status != "success" {
+		return 0, fmt.Errorf("query %q failed with status %s: %s", query, response.Status, queryResponse.Error)
+	}
+	if len(queryResponse.Data.Result) == 0 {
+		return 0, nil
+	}
+	sample, ok := queryResponse.Data.Result[0].Value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected sample %v of query %q", queryResponse.Data.Result[0].Value, query)
+	}
+	value, err := strconv.ParseFloat(sample, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected sample %q of query %q: %v", sample, query, err)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		// there were no operations in the window
+		return 0, nil
+	}
+	return value, nil
+}
+
+// GetCSIMetrics returns the latency and the error ratios of the operations of the CSI sidecars
+func (s PrometheusCSIMetricsSource) GetCSIMetrics(ctx context.Context) (CSIMetrics, error) {
+	window := s.Window
+	if window == 0 {
+		window = DefaultTuningMetricsWindow
+	}
+	rangeSelector := "[" + strconv.FormatInt(int64(window.Seconds()), 10) + "s]"
+	operations := "sum(rate(csi_sidecar_operations_seconds_count" + rangeSelector + "))"
+
+	latency, err := s.query(ctx, "histogram_quantile(0.99, sum(rate(csi_sidecar_operations_seconds_bucket"+rangeSelector+")) by (le))")
+	if err != nil {
+		return CSIMetrics{}, err
+	}
+	deadlineExceeded, err := s.query(ctx,
+		`sum(rate(csi_sidecar_operations_seconds_count{grpc_status_code="DeadlineExceeded"}`+rangeSelector+`)) / `+operations)
+	if err != nil {
+		return CSIMetrics{}, err
+	}
+	failures, err := s.query(ctx,
+		`sum(rate(csi_sidecar_operations_seconds_count{grpc_status_code!~"OK|DeadlineExceeded"}`+rangeSelector+`)) / `+operations)
+	if err != nil {
+		return CSIMetrics{}, err
+	}
+
+	return CSIMetrics{
+		OperationLatencyP99:   time.Duration(latency * float64(time.Second)),
+		DeadlineExceededRatio: deadlineExceeded,
+		FailureRatio:          failures,
+	}, nil
+}
+
+// getTunableValue returns the value of a numeric tunable in the config data, or its Rook default if it is not set
+// or invalid
+func getTunableValue(data map[string]string, tunable string) int64 {
+	if value, err := strconv.ParseInt(strings.TrimSpace(data[tunable]), 10, 64); err == nil {
+		return value
+	}
+	return rookTunableDefaults[tunable]
+}
+
+// clampToTunableBounds limits the value to the default bounds of the tunable, outside of which the OCSConfig
+// would be rejected
+func clampToTunableBounds(tunable string, value int64) int64 {
+	bounds, ok := ocsconfig.DefaultTunableBounds[tunable]
+	if !ok {
+		return value
+	}
+	if bounds.Min != nil && value < *bounds.Min {
+		value = *bounds.Min
+	}
+	if bounds.Max != nil && value > *bounds.Max {
+		value = *bounds.Max
+	}
+	return value
+}
+
+// getTuningSuggestions analyzes the CSI metrics against the current config data and returns the suggested tunable
+// values, sorted by tunable
+func getTuningSuggestions(data map[string]string, metrics CSIMetrics) []ocsv1.TunableSuggestion {
+	suggestions := []ocsv1.TunableSuggestion{}
+
+	// the timeout should leave the slowest operations twice their usual duration, and is doubled if operations
+	// still time out so that the latency of the operations that were cut short is accounted for
+	timeout := getTunableValue(data, csiGRPCTimeoutTunable)
+	latency := int64(math.Ceil(metrics.OperationLatencyP99.Seconds()))
+	suggestedTimeout := 2 * latency
+	reasons := []string{}
+	if suggestedTimeout > timeout {
+		reasons = append(reasons, fmt.Sprintf("the 99th percentile of the CSI operation latency is %ds", latency))
+	}
+	if metrics.DeadlineExceededRatio > deadlineExceededRatioThreshold {
+		suggestedTimeout = max(suggestedTimeout, 2*timeout)
+		reasons = append(reasons, fmt.Sprintf("%.1f%% of the CSI operations exceeded their deadline", 100*metrics.DeadlineExceededRatio))
+	}
+	if suggestedTimeout = clampToTunableBounds(csiGRPCTimeoutTunable, suggestedTimeout); len(reasons) > 0 && suggestedTimeout > timeout {
+		suggestions = append(suggestions, ocsv1.TunableSuggestion{
+			Tunable:        csiGRPCTimeoutTunable,
+			CurrentValue:   strconv.FormatInt(timeout, 10),
+			SuggestedValue: strconv.FormatInt(suggestedTimeout, 10),
+			Reason:         strings.Join(reasons, ", "),
+		})
+	}
+
+	logLevel := getTunableValue(data, csiLogLevelTunable)
+	if metrics.FailureRatio > failureRatioThreshold && logLevel < debugCSILogLevel {
+		suggestions = append(suggestions, ocsv1.TunableSuggestion{
+			Tunable:        csiLogLevelTunable,
+			CurrentValue:   strconv.FormatInt(logLevel, 10),
+			SuggestedValue: strconv.Itoa(debugCSILogLevel),
+			Reason:         fmt.Sprintf("%.1f%% of the CSI operations failed, a verbose log level helps to diagnose the failures", 100*metrics.FailureRatio),
+		})
+	}
+
+	return suggestions
+}
+
+// reconcileTuningSuggestions records the tunable values suggested from the CSI metrics in the status. The
+// suggestions are advisory only and are never applied to ocs-operator-config. A failure to get the metrics keeps
+// the previous suggestions, as they do not affect the config.
+func (r *OCSInitializationReconciler) reconcileTuningSuggestions(initialData *ocsv1.OCSInitialization) {
+	if r.CSIMetricsSource == nil {
+		initialData.Status.OcsOperatorConfig.TuningSuggestions = nil
+		return
+	}
+
+	metrics, err := r.CSIMetricsSource.GetCSIMetrics(r.ctx)
+	if err != nil {
+		r.Log.Error(err, "Failed to get the CSI metrics, keeping the previous tuning suggestions")
+		return
+	}
+	ocsOperatorConfig := &corev1.ConfigMap{}
+	err = r.Client.Get(r.ctx, types.NamespacedName{Name: r.getOcsOperatorConfigName(), Namespace: initialData.Namespace}, ocsOperatorConfig)
+	if err != nil && !errors.IsNotFound(err) {
+		r.Log.Error(err, "Failed to get ocs-operator-config, keeping the previous tuning suggestions")
+		return
+	}
+
+	suggestions := getTuningSuggestions(ocsOperatorConfig.Data, metrics)
+	if len(suggestions) == 0 {
+		suggestions = nil
+	}
+	if slices.Equal(initialData.Status.OcsOperatorConfig.TuningSuggestions, suggestions) {
+		return
+	}
+	for _, suggestion := range suggestions {
+		r.Log.Info("Suggesting a tunable value from the CSI metrics", "Tunable", suggestion.Tunable,
+			"CurrentValue", suggestion.CurrentValue, "SuggestedValue", suggestion.SuggestedValue, "Reason", suggestion.Reason)
+	}
+	initialData.Status.OcsOperatorConfig.TuningSuggestions = suggestions
+}
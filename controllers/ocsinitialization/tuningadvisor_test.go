@@ -0,0 +1,165 @@
+package ocsinitialization
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCSIMetricsSource struct {
+	metrics CSIMetrics
+	err     error
+}
+
+func (s fakeCSIMetricsSource) GetCSIMetrics(_ context.Context) (CSIMetrics, error) {
+	return s.metrics, s.err
+}
+
+func TestTuningSuggestions(t *testing.T) {
+	testcases := []struct {
+		label    string
+		data     map[string]string
+		metrics  CSIMetrics
+		expected map[string]string
+	}{
+		{
+			label:   "healthy operations",
+			metrics: CSIMetrics{OperationLatencyP99: 20 * time.Second, DeadlineExceededRatio: 0.001, FailureRatio: 0.01},
+		},
+		{
+			label:    "latency close to the default timeout",
+			metrics:  CSIMetrics{OperationLatencyP99: 100 * time.Second},
+			expected: map[string]string{csiGRPCTimeoutTunable: "200"},
+		},
+		{
+			label:   "latency within the configured timeout",
+			data:    map[string]string{csiGRPCTimeoutTunable: "300"},
+			metrics: CSIMetrics{OperationLatencyP99: 100 * time.Second},
+		},
+		{
+			label:    "operations exceeding their deadline",
+			data:     map[string]string{csiGRPCTimeoutTunable: "60"},
+			metrics:  CSIMetrics{OperationLatencyP99: 10 * time.Second, DeadlineExceededRatio: 0.05},
+			expected: map[string]string{csiGRPCTimeoutTunable: "120"},
+		},
+		{
+			label:    "suggested timeout is limited to the bounds",
+			metrics:  CSIMetrics{OperationLatencyP99: 900 * time.Second},
+			expected: map[string]string{csiGRPCTimeoutTunable: "600"},
+		},
+		{
+			label:   "timeout already at the maximum",
+			data:    map[string]string{csiGRPCTimeoutTunable: "600"},
+			metrics: CSIMetrics{OperationLatencyP99: 590 * time.Second, DeadlineExceededRatio: 0.2},
+		},
+		{
+			label:    "failing operations",
+			metrics:  CSIMetrics{OperationLatencyP99: 5 * time.Second, FailureRatio: 0.1},
+			expected: map[string]string{csiLogLevelTunable: "5"},
+		},
+		{
+			label:   "failing operations with a verbose log level",
+			data:    map[string]string{csiLogLevelTunable: "5"},
+			metrics: CSIMetrics{FailureRatio: 0.1},
+		},
+		{
+			label:    "slow and failing operations",
+			data:     map[string]string{csiGRPCTimeoutTunable: "invalid", csiLogLevelTunable: "2"},
+			metrics:  CSIMetrics{OperationLatencyP99: 80 * time.Second, FailureRatio: 0.5},
+			expected: map[string]string{csiGRPCTimeoutTunable: "160", csiLogLevelTunable: "5"},
+		},
+	}
+
+	for _, tc := range testcases {
+		suggestions := getTuningSuggestions(tc.data, tc.metrics)
+		suggested := map[string]string{}
+		for _, suggestion := range suggestions {
+			suggested[suggestion.Tunable] = suggestion.SuggestedValue
+			assert.NotEmptyf(t, suggestion.CurrentValue, "[%s]: missing current value of %s", tc.label, suggestion.Tunable)
+			assert.NotEmptyf(t, suggestion.Reason, "[%s]: missing reason of %s", tc.label, suggestion.Tunable)
+		}
+		if tc.expected == nil {
+			tc.expected = map[string]string{}
+		}
+		assert.Equalf(t, tc.expected, suggested, "[%s]: unexpected suggestions", tc.label)
+	}
+}
+
+func TestReconcileTuningSuggestions(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+
+	// without a metrics source the advisor is disabled
+	reconciler.reconcileTuningSuggestions(ocsInit)
+	assert.Empty(t, ocsInit.Status.OcsOperatorConfig.TuningSuggestions)
+
+	reconciler.CSIMetricsSource = fakeCSIMetricsSource{metrics: CSIMetrics{OperationLatencyP99: 100 * time.Second}}
+	reconciler.reconcileTuningSuggestions(ocsInit)
+	assert.Equal(t, []ocsv1.TunableSuggestion{{
+		Tunable:        csiGRPCTimeoutTunable,
+		CurrentValue:   "150",
+		SuggestedValue: "200",
+		Reason:         "the 99th percentile of the CSI operation latency is 100s",
+	}}, ocsInit.Status.OcsOperatorConfig.TuningSuggestions)
+	// the suggestions are advisory only
+	assert.Equal(t, data, getOcsOperatorConfigData(t, reconciler))
+	assert.NotContains(t, data, csiGRPCTimeoutTunable)
+
+	// the previous suggestions are kept if the metrics are not available
+	reconciler.CSIMetricsSource = fakeCSIMetricsSource{err: fmt.Errorf("prometheus is not reachable")}
+	reconciler.reconcileTuningSuggestions(ocsInit)
+	assert.Len(t, ocsInit.Status.OcsOperatorConfig.TuningSuggestions, 1)
+
+	reconciler.CSIMetricsSource = fakeCSIMetricsSource{metrics: CSIMetrics{OperationLatencyP99: time.Second}}
+	reconciler.reconcileTuningSuggestions(ocsInit)
+	assert.Empty(t, ocsInit.Status.OcsOperatorConfig.TuningSuggestions)
+}
+
+func TestPrometheusCSIMetricsSource(t *testing.T) {
+	// the failures are matched before the timeouts, whose selector is a substring of theirs
+	samples := [][2]string{
+		{"histogram_quantile", `[{"metric":{},"value":[1700000000,"42.5"]}]`},
+		{"OK|DeadlineExceeded", `[{"metric":{},"value":[1700000000,"NaN"]}]`},
+		{`"DeadlineExceeded"`, `[{"metric":{},"value":[1700000000,"0.02"]}]`},
+	}
+	queries := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		query := r.URL.Query().Get("query")
+		queries = append(queries, query)
+		result := "[]"
+		for _, sample := range samples {
+			if strings.Contains(query, sample[0]) {
+				result = sample[1]
+				break
+			}
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":%s}}`, result)
+	}))
+	defer server.Close()
+
+	source := PrometheusCSIMetricsSource{URL: server.URL + "/", Window: 30 * time.Minute}
+	metrics, err := source.GetCSIMetrics(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, CSIMetrics{OperationLatencyP99: 42500 * time.Millisecond, DeadlineExceededRatio: 0.02}, metrics)
+	assert.Len(t, queries, 3)
+	for _, query := range queries {
+		assert.Contains(t, query, "[1800s]")
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`)
+	}))
+	defer failing.Close()
+	_, err = PrometheusCSIMetricsSource{URL: failing.URL}.GetCSIMetrics(context.TODO())
+	assert.ErrorContains(t, err, "parse error")
+}
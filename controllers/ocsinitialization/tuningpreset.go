@@ -0,0 +1,77 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+)
+
+const (
+	// TuningPresetAnnotation can be set on a StorageCluster to select a tuning preset of ocs-operator-config, e.g.
+	// ocs.openshift.io/tuning-preset=low-latency. A preset adjusts the tunable keys of the resolved defaults, and is
+	// overridden by the OCSConfig tunables and the ocs-operator-config overrides of the storagecluster.
+	TuningPresetAnnotation = "ocs.openshift.io/tuning-preset"
+
+	// TuningPresetLowLatency tunes CSI for latency-sensitive workloads
+	TuningPresetLowLatency = "low-latency"
+
+	// ConditionInvalidTuningPreset is set when a storagecluster selects a tuning preset that does not exist. The
+	// preset is ignored, so the config is resolved as if the annotation was not set.
+	ConditionInvalidTuningPreset conditionsv1.ConditionType = "InvalidTuningPreset"
+
+	// lowLatencyCephFSKernelMountOption makes the CephFS kernel client complete the namespace operations, e.g.
+	// creates and unlinks, without waiting on a round trip to the MDS
+	lowLatencyCephFSKernelMountOption = "nowsync"
+)
+
+// tuningPresets return the values a tuning preset sets on top of the config values resolved so far
+var tuningPresets = map[string]func(sc *ocsv1.StorageCluster, resolved map[string]string) map[string]string{
+	TuningPresetLowLatency: getLowLatencyTuningPresetValues,
+}
+
+// getLowLatencyTuningPresetValues serves the reads from the nearest OSD and adds the low latency CephFS kernel
+// mount option in place of wsync. The ms_mode of the mount options is kept, as a preset is limited to the tunable
+// keys like the other per-StorageCluster sources.
+func getLowLatencyTuningPresetValues(_ *ocsv1.StorageCluster, resolved map[string]string) map[string]string {
+	values := map[string]string{
+		util.EnableReadAffinityKey: "true",
+	}
+	mountOptions := []string{}
+	for _, option := range strings.Split(resolved[util.CephFSKernelMountOptionsKey], ",") {
+		if option = strings.TrimSpace(option); option != "" && option != "wsync" && !slices.Contains(mountOptions, option) {
+			mountOptions = append(mountOptions, option)
+		}
+	}
+	if !slices.Contains(mountOptions, lowLatencyCephFSKernelMountOption) {
+		mountOptions = append(mountOptions, lowLatencyCephFSKernelMountOption)
+	}
+	values[util.CephFSKernelMountOptionsKey] = strings.Join(mountOptions, ",")
+	return values
+}
+
+// getTuningPresetValues returns the values of the tuning preset selected by the storagecluster on top of the
+// resolved values, or an error if the preset does not exist
+func getTuningPresetValues(sc *ocsv1.StorageCluster, resolved map[string]string) (map[string]string, error) {
+	name, ok := sc.GetAnnotations()[TuningPresetAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	preset, ok := tuningPresets[name]
+	if !ok {
+		return nil, fmt.Errorf("StorageCluster %s/%s selects the unknown tuning preset %q, the valid presets are %s",
+			sc.Namespace, sc.Name, name, strings.Join(slices.Sorted(maps.Keys(tuningPresets)), ", "))
+	}
+	return preset(sc, resolved), nil
+}
+
+// validateTuningPresets sets or removes the ConditionInvalidTuningPreset condition
+func validateTuningPresets(initialData *ocsv1.OCSInitialization, errs []string) {
+	setOcsOperatorConfigCondition(initialData, ConditionInvalidTuningPreset, len(errs) > 0, "UnknownTuningPreset",
+		fmt.Sprintf("the invalid tuning presets are ignored: %s", strings.Join(errs, "; ")))
+}
@@ -0,0 +1,104 @@
+package ocsinitialization
+
+import (
+	"testing"
+
+	v1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestLowLatencyTuningPreset(t *testing.T) {
+	testcases := []struct {
+		label         string
+		preset        string
+		mutate        func(sc *v1.StorageCluster)
+		objs          []client.Object
+		expected      map[string]string
+		expectInvalid bool
+	}{
+		{
+			label:  "low-latency preset on the built-in values",
+			preset: TuningPresetLowLatency,
+			expected: map[string]string{
+				util.EnableReadAffinityKey:       "true",
+				util.EnableTopologyKey:           "false",
+				util.CephFSKernelMountOptionsKey: "ms_mode=prefer-crc,nowsync",
+			},
+		},
+		{
+			label:  "low-latency preset composed over the operator defaults",
+			preset: TuningPresetLowLatency,
+			objs: []client.Object{
+				newTestConfigMap(OcsOperatorConfigDefaultsName, testOperatorNamespace, map[string]string{
+					util.EnableReadAffinityKey:   "false",
+					util.TopologyDomainLabelsKey: corev1.LabelTopologyZone,
+				}),
+			},
+			expected: map[string]string{
+				util.EnableReadAffinityKey:       "true",
+				util.EnableTopologyKey:           "false",
+				util.TopologyDomainLabelsKey:     corev1.LabelTopologyZone,
+				util.CephFSKernelMountOptionsKey: "ms_mode=prefer-crc,nowsync",
+			},
+		},
+		{
+			label:  "low-latency preset keeps the network encryption",
+			preset: TuningPresetLowLatency,
+			mutate: func(sc *v1.StorageCluster) {
+				setTestNetworkEncryption(sc, true)
+				sc.Spec.ManagedResources.CephFilesystems.KernelMountOptions = map[string]string{"wsync": ""}
+			},
+			expected: map[string]string{
+				util.EnableNetworkEncryptionKey:  "true",
+				util.CephFSKernelMountOptionsKey: "ms_mode=secure,nowsync",
+			},
+		},
+		{
+			label:  "overrides take precedence over the low-latency preset",
+			preset: TuningPresetLowLatency,
+			mutate: func(sc *v1.StorageCluster) {
+				sc.Annotations[OcsOperatorConfigOverridesAnnotation] = `{"` + util.EnableReadAffinityKey + `":"false"}`
+			},
+			expected: map[string]string{
+				util.EnableReadAffinityKey:       "false",
+				util.CephFSKernelMountOptionsKey: "ms_mode=prefer-crc,nowsync",
+			},
+		},
+		{
+			label:  "unknown preset is ignored",
+			preset: "fastest",
+			expected: map[string]string{
+				util.CephFSKernelMountOptionsKey: "ms_mode=prefer-crc",
+			},
+			expectInvalid: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := newTestStorageCluster("ocs-storagecluster", testOperatorNamespace)
+		sc.Annotations = map[string]string{TuningPresetAnnotation: tc.preset}
+		if tc.mutate != nil {
+			tc.mutate(sc)
+		}
+		objs := append([]client.Object{sc, newTestZoneNode("node-a", "zone-a"), newTestZoneNode("node-b", "zone-b"),
+			newTestZoneNode("node-c", "zone-c")}, tc.objs...)
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, objs...)
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		for key, value := range tc.expected {
+			assert.Equalf(t, value, data[key], "[%s]: unexpected value of %s", tc.label, key)
+		}
+		invalid := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionInvalidTuningPreset)
+		assert.Nilf(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionRestrictedConfigKeysIgnored),
+			"[%s]: the preset must only set tunable keys", tc.label)
+		assert.Equalf(t, tc.expectInvalid, invalid != nil, "[%s]: unexpected invalid tuning preset condition", tc.label)
+		assert.Nilf(t, conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionIncompleteEncryptionConfig),
+			"[%s]: the preset must pass on the encryption keys together", tc.label)
+	}
+}
@@ -0,0 +1,62 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ValueTransformer rewrites the computed values of the ocs-operator-config keys matching its pattern, to
+// adapt them to the environment, like replacing a placeholder domain
+type ValueTransformer struct {
+	// Name identifies the transformer in the logs and errors
+	Name string
+	// KeyPattern matches the keys whose values are transformed
+	KeyPattern *regexp.Regexp
+	// Transform returns the new value of a matched key
+	Transform func(key, value string) (string, error)
+}
+
+// NewReplaceValueTransformer returns a transformer that replaces all the occurrences of old with new in the
+// values of the keys matching the keyPattern regular expression
+func NewReplaceValueTransformer(name, keyPattern, old, new string) (ValueTransformer, error) {
+	pattern, err := regexp.Compile(keyPattern)
+	if err != nil {
+		return ValueTransformer{}, fmt.Errorf("invalid key pattern of value transformer %s: %v", name, err)
+	}
+	return ValueTransformer{
+		Name:       name,
+		KeyPattern: pattern,
+		Transform: func(_, value string) (string, error) {
+			return strings.ReplaceAll(value, old, new), nil
+		},
+	}, nil
+}
+
+// applyValueTransformers applies the chain of value transformers to the config data in order, so that each
+// transformer sees the values rewritten by the previous ones. The chain is empty by default.
+func (r *OCSInitializationReconciler) applyValueTransformers(ocsOperatorConfigData map[string]string) error {
+	if len(r.ValueTransformers) == 0 {
+		return nil
+	}
+	keys := slices.Sorted(maps.Keys(ocsOperatorConfigData))
+	for _, transformer := range r.ValueTransformers {
+		for _, key := range keys {
+			if transformer.KeyPattern == nil || !transformer.KeyPattern.MatchString(key) {
+				continue
+			}
+			value := ocsOperatorConfigData[key]
+			transformed, err := transformer.Transform(key, value)
+			if err != nil {
+				return fmt.Errorf("value transformer %s failed to transform the value of %s: %v", transformer.Name, key, err)
+			}
+			if transformed != value {
+				r.Log.V(1).Info("Transformed ocs-operator-config value", "Transformer", transformer.Name, "Key", key)
+				ocsOperatorConfigData[key] = transformed
+			}
+		}
+	}
+	return nil
+}
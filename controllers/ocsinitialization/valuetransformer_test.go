@@ -0,0 +1,102 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyValueTransformers(t *testing.T) {
+	domainTransformer, err := NewReplaceValueTransformer("domain", "^CSI_RGW_", "example.placeholder", "apps.cluster.local")
+	assert.NoError(t, err)
+	_, err = NewReplaceValueTransformer("invalid", "(", "", "")
+	assert.Error(t, err)
+
+	testcases := []struct {
+		label        string
+		transformers []ValueTransformer
+		data         map[string]string
+		expected     map[string]string
+		expectedErr  bool
+	}{
+		{
+			label:    "default empty chain",
+			data:     map[string]string{util.RgwEndpointKey: "rgw.example.placeholder:443"},
+			expected: map[string]string{util.RgwEndpointKey: "rgw.example.placeholder:443"},
+		},
+		{
+			label:        "matched key is rewritten",
+			transformers: []ValueTransformer{domainTransformer},
+			data: map[string]string{
+				util.RgwEndpointKey: "rgw.example.placeholder:443",
+				util.ClusterNameKey: "example.placeholder",
+			},
+			expected: map[string]string{
+				util.RgwEndpointKey: "rgw.apps.cluster.local:443",
+				util.ClusterNameKey: "example.placeholder",
+			},
+		},
+		{
+			label: "transformers are applied in order",
+			transformers: []ValueTransformer{domainTransformer, {
+				Name:       "port",
+				KeyPattern: regexp.MustCompile("^" + util.RgwEndpointKey + "$"),
+				Transform: func(_, value string) (string, error) {
+					return value + "/s3", nil
+				},
+			}},
+			data:     map[string]string{util.RgwEndpointKey: "rgw.example.placeholder:443"},
+			expected: map[string]string{util.RgwEndpointKey: "rgw.apps.cluster.local:443/s3"},
+		},
+		{
+			label: "failing transformer",
+			transformers: []ValueTransformer{{
+				Name:       "failing",
+				KeyPattern: regexp.MustCompile(".*"),
+				Transform: func(key, _ string) (string, error) {
+					return "", fmt.Errorf("cannot transform %s", key)
+				},
+			}},
+			data:        map[string]string{util.RgwEndpointKey: "rgw.example.placeholder:443"},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		_, reconciler := getOcsOperatorConfigTestReconciler(t)
+		reconciler.ValueTransformers = tc.transformers
+		err := reconciler.applyValueTransformers(tc.data)
+		if tc.expectedErr {
+			assert.Errorf(t, err, "[%s]: expected the transformation to fail", tc.label)
+			continue
+		}
+		assert.NoErrorf(t, err, "[%s]: failed to transform the values", tc.label)
+		assert.Equalf(t, tc.expected, tc.data, "[%s]: unexpected values", tc.label)
+	}
+}
+
+func TestOcsOperatorConfigValueTransformers(t *testing.T) {
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testOperatorNamespace,
+			Labels: map[string]string{
+				managedServiceLabel:          "true",
+				managedServiceClusterIDLabel: "cluster-placeholder",
+			},
+		},
+	}
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t, namespace, newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))
+	transformer, err := NewReplaceValueTransformer("cluster", "^"+util.ClusterNameKey+"$", "placeholder", "east")
+	assert.NoError(t, err)
+	reconciler.ValueTransformers = []ValueTransformer{transformer}
+
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	data := getOcsOperatorConfigData(t, reconciler)
+	assert.Equal(t, "cluster-east", data[util.ClusterNameKey])
+	assert.Equal(t, "true", data[util.RookCurrentNamespaceOnlyKey])
+}
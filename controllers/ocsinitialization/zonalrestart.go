@@ -0,0 +1,207 @@
+package ocsinitialization
+
+import (
+	"slices"
+	"time"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// zonalRestartPollInterval is the delay after which the next zone of a zonal rook-ceph-operator restart is retried
+	zonalRestartPollInterval = 10 * time.Second
+
+	// zonalRestartStartAnnotation records on the rook-ceph-operator Deployment when the zonal restart in progress
+	// started, so that it is resumed rather than started over when the operator is restarted in the middle of it
+	zonalRestartStartAnnotation = "ocs.openshift.io/zonal-restart-start"
+	// zonalRestartIDAnnotation records the restart ID of the zonal restart in progress on the rook-ceph-operator Deployment
+	zonalRestartIDAnnotation = "ocs.openshift.io/zonal-restart-id"
+)
+
+// resumeZonalRestart resumes the zonal restart recorded on the rook-ceph-operator Deployment, unless a zonal
+// restart is already in progress
+func (r *OCSInitializationReconciler) resumeZonalRestart(namespace string) error {
+	if !r.zonalRestartStart.IsZero() {
+		return nil
+	}
+	deployment := &appsv1.Deployment{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: rookCephOperatorName, Namespace: namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		r.Log.Error(err, "Failed to get rook-ceph-operator Deployment")
+		return err
+	}
+	recordedStart, ok := deployment.GetAnnotations()[zonalRestartStartAnnotation]
+	if !ok {
+		return nil
+	}
+	start, err := time.Parse(time.RFC3339, recordedStart)
+	if err != nil {
+		r.Log.Error(err, "Ignoring the invalid start of the zonal restart recorded on the rook-ceph-operator Deployment",
+			"Start", recordedStart)
+		return nil
+	}
+	r.zonalRestartStart = start
+	r.restartID = deployment.GetAnnotations()[zonalRestartIDAnnotation]
+	if r.restartID == "" {
+		r.restartID = newRestartID()
+	}
+	r.Log.Info("Resuming the zonal restart of the rook-ceph-operator replicas", "RestartID", r.restartID, "Start", start)
+	return nil
+}
+
+// recordZonalRestart records the start and the restart ID of the zonal restart in progress on the
+// rook-ceph-operator Deployment
+func (r *OCSInitializationReconciler) recordZonalRestart(namespace string) error {
+	deployment := &appsv1.Deployment{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: rookCephOperatorName, Namespace: namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		r.Log.Error(err, "Failed to get rook-ceph-operator Deployment")
+		return err
+	}
+	start := r.zonalRestartStart.UTC().Format(time.RFC3339)
+	if deployment.GetAnnotations()[zonalRestartStartAnnotation] == start && deployment.GetAnnotations()[zonalRestartIDAnnotation] == r.restartID {
+		return nil
+	}
+	util.AddAnnotation(deployment, zonalRestartStartAnnotation, start)
+	util.AddAnnotation(deployment, zonalRestartIDAnnotation, r.restartID)
+	if err := r.Client.Update(r.ctx, deployment); err != nil {
+		r.Log.Error(err, "Failed to record the zonal restart on the rook-ceph-operator Deployment")
+		return err
+	}
+	return nil
+}
+
+// removeZonalRestartRecord removes the record of a zonal restart from the rook-ceph-operator Deployment, and
+// returns true if there was one
+func removeZonalRestartRecord(deployment *appsv1.Deployment) bool {
+	_, recorded := deployment.GetAnnotations()[zonalRestartStartAnnotation]
+	delete(deployment.Annotations, zonalRestartStartAnnotation)
+	delete(deployment.Annotations, zonalRestartIDAnnotation)
+	return recorded
+}
+
+// getRookCephOperatorReplicas returns the desired number of rook-ceph-operator replicas, or zero if the
+// Deployment does not exist
+func (r *OCSInitializationReconciler) getRookCephOperatorReplicas(namespace string) (int32, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: rookCephOperatorName, Namespace: namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if deployment.Spec.Replicas == nil {
+		return 1, nil
+	}
+	return *deployment.Spec.Replicas, nil
+}
+
+// isPodReady returns true if the pod is not being deleted and reports the Ready condition
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// restartRookCephOperatorByZone restarts the rook-ceph-operator replicas one zone at a time, so that the replicas
+// keep their spread across the zones. The pods of the next zone are only deleted once all the desired replicas are
+// ready again. It returns true once all the pods that existed when the restart started have been replaced.
+// The restart in progress is recorded on the Deployment, and the record is removed along with setting the checksum.
+// With a staged rollout, the pods of each zone are only deleted once the rollout health check passes for the zone.
+func (r *OCSInitializationReconciler) restartRookCephOperatorByZone(initialData *ocsv1.OCSInitialization, namespace string,
+	replicas int32) (bool, error) {
+	if r.zonalRestartStart.IsZero() {
+		// the creation timestamps of the pods only have a resolution of seconds
+		r.zonalRestartStart = time.Now().Truncate(time.Second)
+		r.Log.Info("Restarting the rook-ceph-operator replicas one zone at a time", "Replicas", replicas)
+	}
+	// the restart is recorded before any of the pods is deleted
+	if err := r.recordZonalRestart(namespace); err != nil {
+		return false, err
+	}
+
+	pods, err := r.listRookCephOperatorPods(namespace)
+	if err != nil {
+		return false, err
+	}
+	ready := 0
+	for i := range pods.Items {
+		if isPodReady(&pods.Items[i]) {
+			ready++
+		}
+	}
+	if ready < int(replicas) {
+		r.Log.Info("Waiting for the rook-ceph-operator replicas to be ready before restarting the next zone",
+			"Ready", ready, "Replicas", replicas)
+		return false, nil
+	}
+
+	podsByZone := map[string][]*corev1.Pod{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !pod.CreationTimestamp.Time.Before(r.zonalRestartStart) {
+			continue
+		}
+		zone, err := r.getPodZone(pod)
+		if err != nil {
+			return false, err
+		}
+		podsByZone[zone] = append(podsByZone[zone], pod)
+	}
+	if len(podsByZone) == 0 {
+		r.Log.Info("Restarted the rook-ceph-operator replicas in all the zones")
+		r.zonalRestartStart = time.Time{}
+		return true, r.setRookCephOperatorConfigChecksum(namespace)
+	}
+
+	zones := make([]string, 0, len(podsByZone))
+	for zone := range podsByZone {
+		zones = append(zones, zone)
+	}
+	slices.Sort(zones)
+	zone := zones[0]
+	if r.isStagedRolloutEnabled() && !r.checkRolloutZone(initialData, zone) {
+		return false, nil
+	}
+	r.Log.Info("Restarting the rook-ceph-operator replicas of a zone", "RestartID", r.restartID, "Zone", zone, "Pods", len(podsByZone[zone]),
+		"RemainingZones", len(zones)-1)
+	for _, pod := range podsByZone[zone] {
+		if err := r.Client.Delete(r.ctx, pod); err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to delete rook-ceph-operator pod", "Pod", klog.KObj(pod))
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// getPodZone returns the zone of the node the pod is scheduled on, or an empty string if it is not known
+func (r *OCSInitializationReconciler) getPodZone(pod *corev1.Pod) (string, error) {
+	if pod.Spec.NodeName == "" {
+		return "", nil
+	}
+	node := &corev1.Node{}
+	err := r.Client.Get(r.ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node)
+	if errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return node.Labels[corev1.LabelTopologyZone], nil
+}
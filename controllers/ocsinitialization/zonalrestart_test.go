@@ -0,0 +1,150 @@
+package ocsinitialization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestReadyRookCephOperatorPod(name, nodeName string, created time.Time) *corev1.Pod {
+	pod := newTestRookCephOperatorPod()
+	pod.Name = name
+	pod.CreationTimestamp = metav1.NewTime(created)
+	pod.Spec.NodeName = nodeName
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	return pod
+}
+
+func getRookCephOperatorPodNames(t *testing.T, reconciler OCSInitializationReconciler) []string {
+	pods, err := util.GetPodsWithLabels(reconciler.ctx, reconciler.Client, testOperatorNamespace, map[string]string{"app": rookCephOperatorName})
+	assert.NoError(t, err)
+	names := []string{}
+	for _, pod := range pods.Items {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+func TestRestartRookCephOperatorByZone(t *testing.T) {
+	deployment := newTestRookCephOperatorDeployment()
+	deployment.Spec.Replicas = ptr.To(int32(3))
+	created := time.Now().Add(-time.Hour)
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t,
+		newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+		deployment,
+		newTestZoneNode("node-a", "zone-a"),
+		newTestZoneNode("node-b", "zone-b"),
+		newTestZoneNode("node-c", "zone-c"),
+		newTestReadyRookCephOperatorPod("rook-ceph-operator-a", "node-a", created),
+		newTestReadyRookCephOperatorPod("rook-ceph-operator-b", "node-b", created),
+		newTestReadyRookCephOperatorPod("rook-ceph-operator-c", "node-c", created),
+	)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+
+	// the zones are restarted in order, each one only once the replacement of the previous one is ready
+	replacements := []*corev1.Pod{
+		newTestReadyRookCephOperatorPod("rook-ceph-operator-a2", "node-a", time.Now().Add(time.Hour)),
+		newTestReadyRookCephOperatorPod("rook-ceph-operator-b2", "node-b", time.Now().Add(time.Hour)),
+	}
+	expectedPods := [][]string{
+		{"rook-ceph-operator-b", "rook-ceph-operator-c"},
+		{"rook-ceph-operator-a2", "rook-ceph-operator-c"},
+		{"rook-ceph-operator-a2", "rook-ceph-operator-b2"},
+	}
+	for i, expected := range expectedPods {
+		result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+		assert.NoError(t, err)
+		assert.NotZerof(t, result.RequeueAfter, "[zone %d]: expected the restart to be requeued", i)
+		assert.ElementsMatchf(t, expected, getRookCephOperatorPodNames(t, reconciler), "[zone %d]: unexpected pods", i)
+		assert.Truef(t, isRookCephOperatorRestartPending(t, reconciler), "[zone %d]: expected the restart to be pending", i)
+
+		// the next zone is not restarted while a replica is missing
+		result, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+		assert.NoError(t, err)
+		assert.NotZerof(t, result.RequeueAfter, "[zone %d]: expected the restart to be requeued", i)
+		assert.ElementsMatchf(t, expected, getRookCephOperatorPodNames(t, reconciler), "[zone %d]: unexpected pods", i)
+
+		if i < len(replacements) {
+			assert.NoError(t, reconciler.Client.Create(reconciler.ctx, replacements[i]))
+		}
+	}
+
+	// the restart completes once all the replicas that existed when it started have been replaced
+	replacement := newTestReadyRookCephOperatorPod("rook-ceph-operator-c2", "node-c", time.Now().Add(time.Hour))
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx, replacement))
+	result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.ElementsMatch(t, []string{"rook-ceph-operator-a2", "rook-ceph-operator-b2", "rook-ceph-operator-c2"},
+		getRookCephOperatorPodNames(t, reconciler))
+	assert.False(t, isRookCephOperatorRestartPending(t, reconciler))
+	assert.True(t, reconciler.zonalRestartStart.IsZero())
+
+	// the checksum is set on the Deployment, its pod template is not rolled out
+	checksum := getTestConfigMap(t, reconciler, util.OcsOperatorConfigName).Annotations[ConfigDataChecksumAnnotation]
+	assert.NotEmpty(t, checksum)
+	assert.Equal(t, checksum, getTestRookCephOperatorDeploymentChecksum(t, reconciler))
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(deployment), deployment))
+	assert.Empty(t, deployment.Spec.Template.Annotations)
+	assert.NotContains(t, deployment.Annotations, zonalRestartStartAnnotation)
+	assert.NotContains(t, deployment.Annotations, zonalRestartIDAnnotation)
+}
+
+func TestZonalRestartResumed(t *testing.T) {
+	deployment := newTestRookCephOperatorDeployment()
+	deployment.Spec.Replicas = ptr.To(int32(2))
+	created := time.Now().Add(-time.Hour)
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t,
+		newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+		deployment,
+		newTestZoneNode("node-a", "zone-a"),
+		newTestZoneNode("node-b", "zone-b"),
+		newTestReadyRookCephOperatorPod("rook-ceph-operator-a", "node-a", created),
+		newTestReadyRookCephOperatorPod("rook-ceph-operator-b", "node-b", created),
+	)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+
+	// the first zone is restarted, and the restart is recorded on the Deployment
+	_, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"rook-ceph-operator-b"}, getRookCephOperatorPodNames(t, reconciler))
+	start, restartID := reconciler.zonalRestartStart, reconciler.restartID
+	assert.NoError(t, reconciler.Client.Get(reconciler.ctx, client.ObjectKeyFromObject(deployment), deployment))
+	assert.Equal(t, start.UTC().Format(time.RFC3339), deployment.Annotations[zonalRestartStartAnnotation])
+	assert.Equal(t, restartID, deployment.Annotations[zonalRestartIDAnnotation])
+
+	// after an operator restart the recorded restart is resumed rather than started over, which would restart
+	// the replacement of the first zone again
+	start = created.Add(10 * time.Minute).Truncate(time.Second)
+	deployment.Annotations[zonalRestartStartAnnotation] = start.UTC().Format(time.RFC3339)
+	assert.NoError(t, reconciler.Client.Update(reconciler.ctx, deployment))
+	reconciler.zonalRestartStart, reconciler.restartID = time.Time{}, ""
+	replacement := newTestReadyRookCephOperatorPod("rook-ceph-operator-a2", "node-a", created.Add(20*time.Minute))
+	assert.NoError(t, reconciler.Client.Create(reconciler.ctx, replacement))
+	_, err = reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.True(t, start.Equal(reconciler.zonalRestartStart), "unexpected start %v of the resumed restart", reconciler.zonalRestartStart)
+	assert.Equal(t, restartID, reconciler.restartID)
+	assert.ElementsMatch(t, []string{"rook-ceph-operator-a2"}, getRookCephOperatorPodNames(t, reconciler),
+		"the replaced pod of the first zone must not be restarted again")
+}
+
+func TestRestartRookCephOperatorWithSingleReplica(t *testing.T) {
+	ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t,
+		newTestStorageCluster("ocs-storagecluster", testOperatorNamespace),
+		newTestRookCephOperatorDeployment(),
+		newTestRookCephOperatorPod(),
+	)
+	assert.NoError(t, reconciler.ensureOcsOperatorConfigExists(ocsInit))
+	result, err := reconciler.reconcileRookCephOperatorRestart(ocsInit)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.True(t, isRookCephOperatorPodRestarted(t, reconciler))
+	assert.False(t, isRookCephOperatorRestartPending(t, reconciler))
+}
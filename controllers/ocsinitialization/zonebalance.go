@@ -0,0 +1,84 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ConditionHeterogeneousZoneSizes is set when topology is enabled on the zones, but the zones have very
+	// different numbers of schedulable nodes. Topology constrained placement can overload the small zones,
+	// so the placement should be tuned.
+	ConditionHeterogeneousZoneSizes conditionsv1.ConditionType = "HeterogeneousZoneSizes"
+
+	// zoneImbalanceRatio is the ratio of the schedulable nodes of the largest to the smallest zone from which
+	// the zone sizes are considered heterogeneous
+	zoneImbalanceRatio = 2
+)
+
+// getZoneSizes returns the number of schedulable nodes of each zone the nodes are labeled with
+func getZoneSizes(nodes []corev1.Node) map[string]int {
+	sizes := map[string]int{}
+	for i := range nodes {
+		if zone := nodes[i].Labels[corev1.LabelTopologyZone]; zone != "" && isSchedulableNode(&nodes[i]) {
+			sizes[zone]++
+		}
+	}
+	return sizes
+}
+
+// formatZoneSizes returns the zone sizes sorted by zone, e.g. "zone-a=3,zone-b=1"
+func formatZoneSizes(sizes map[string]int) string {
+	formatted := []string{}
+	for _, zone := range slices.Sorted(maps.Keys(sizes)) {
+		formatted = append(formatted, fmt.Sprintf("%s=%d", zone, sizes[zone]))
+	}
+	return strings.Join(formatted, ",")
+}
+
+// isZoneSizeImbalanced returns true if the largest zone has at least zoneImbalanceRatio times the schedulable
+// nodes of the smallest one
+func isZoneSizeImbalanced(sizes map[string]int) bool {
+	if len(sizes) < 2 {
+		return false
+	}
+	smallest, largest := slices.Min(slices.Collect(maps.Values(sizes))), slices.Max(slices.Collect(maps.Values(sizes)))
+	return largest >= zoneImbalanceRatio*smallest
+}
+
+// checkZoneSizeBalance sets the ConditionHeterogeneousZoneSizes condition when topology is enabled on the zones
+// and the zones have heterogeneous sizes. With AdjustTopologyForZoneImbalance set, the zone label is then removed
+// from the topology domain labels if they contain a finer grained label, so that the volumes are placed by that
+// label instead. A zone label that bound volumes are constrained to is kept by expandTopologyDomainLabels.
+func (r *OCSInitializationReconciler) checkZoneSizeBalance(initialData *ocsv1.OCSInitialization, ocsOperatorConfigData map[string]string,
+	nodes []corev1.Node) {
+	domainLabels := splitTopologyDomainLabels(ocsOperatorConfigData[util.TopologyDomainLabelsKey])
+	if ocsOperatorConfigData[util.EnableTopologyKey] != "true" || !slices.Contains(domainLabels, corev1.LabelTopologyZone) {
+		setOcsOperatorConfigCondition(initialData, ConditionHeterogeneousZoneSizes, false, "", "")
+		return
+	}
+
+	sizes := getZoneSizes(nodes)
+	imbalanced := isZoneSizeImbalanced(sizes)
+	message := fmt.Sprintf("the zones have heterogeneous numbers of schedulable nodes %s, topology constrained placement "+
+		"can overload the small zones; consider tuning the placement", formatZoneSizes(sizes))
+	if imbalanced && r.AdjustTopologyForZoneImbalance {
+		finerLabels := slices.DeleteFunc(slices.Clone(domainLabels), func(label string) bool { return label == corev1.LabelTopologyZone })
+		if len(finerLabels) > 0 {
+			r.Log.Info("Removing the zone label from the topology domain labels as the zones have heterogeneous sizes",
+				"ZoneSizes", formatZoneSizes(sizes), "DomainLabels", finerLabels)
+			ocsOperatorConfigData[util.TopologyDomainLabelsKey] = strings.Join(finerLabels, ",")
+			message = fmt.Sprintf("the zones have heterogeneous numbers of schedulable nodes %s, the topology domain "+
+				"labels have been adjusted to %s", formatZoneSizes(sizes), strings.Join(finerLabels, ","))
+		}
+	}
+	setOcsOperatorConfigCondition(initialData, ConditionHeterogeneousZoneSizes, imbalanced, "HeterogeneousZoneSizes", message)
+}
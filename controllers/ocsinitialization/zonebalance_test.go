@@ -0,0 +1,105 @@
+package ocsinitialization
+
+import (
+	"fmt"
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newTestZoneNodes returns the given number of schedulable nodes in each zone
+func newTestZoneNodes(zoneSizes map[string]int) []client.Object {
+	nodes := []client.Object{}
+	for zone, size := range zoneSizes {
+		for i := range size {
+			nodes = append(nodes, newTestZoneNode(fmt.Sprintf("%s-node-%d", zone, i), zone))
+		}
+	}
+	return nodes
+}
+
+func TestHeterogeneousZoneSizes(t *testing.T) {
+	testcases := []struct {
+		label           string
+		zoneSizes       map[string]int
+		domainLabels    string
+		adjust          bool
+		expectCondition bool
+		expectedLabels  string
+	}{
+		{
+			label:          "balanced zones",
+			zoneSizes:      map[string]int{"zone-a": 3, "zone-b": 3, "zone-c": 2},
+			domainLabels:   corev1.LabelTopologyZone,
+			expectedLabels: corev1.LabelTopologyZone,
+		},
+		{
+			label:           "imbalanced zones",
+			zoneSizes:       map[string]int{"zone-a": 6, "zone-b": 3, "zone-c": 1},
+			domainLabels:    corev1.LabelTopologyZone,
+			expectCondition: true,
+			expectedLabels:  corev1.LabelTopologyZone,
+		},
+		{
+			label:          "imbalanced zones without topology on the zones",
+			zoneSizes:      map[string]int{"zone-a": 6, "zone-b": 1},
+			domainLabels:   "topology.rook.io/rack",
+			expectedLabels: "topology.rook.io/rack",
+		},
+		{
+			label:           "imbalanced zones are not adjusted by default",
+			zoneSizes:       map[string]int{"zone-a": 6, "zone-b": 1},
+			domainLabels:    corev1.LabelTopologyZone + ",topology.rook.io/rack",
+			expectCondition: true,
+			expectedLabels:  corev1.LabelTopologyZone + ",topology.rook.io/rack",
+		},
+		{
+			label:           "imbalanced zones are adjusted to the finer grained label",
+			zoneSizes:       map[string]int{"zone-a": 6, "zone-b": 1},
+			domainLabels:    corev1.LabelTopologyZone + ",topology.rook.io/rack",
+			adjust:          true,
+			expectCondition: true,
+			expectedLabels:  "topology.rook.io/rack",
+		},
+		{
+			label:           "imbalanced zones keep the only zone label",
+			zoneSizes:       map[string]int{"zone-a": 6, "zone-b": 1},
+			domainLabels:    corev1.LabelTopologyZone,
+			adjust:          true,
+			expectCondition: true,
+			expectedLabels:  corev1.LabelTopologyZone,
+		},
+	}
+
+	for _, tc := range testcases {
+		ocsInit, reconciler := getOcsOperatorConfigTestReconciler(t,
+			append(newTestZoneNodes(tc.zoneSizes), newTestStorageCluster("ocs-storagecluster", testOperatorNamespace))...)
+		reconciler.TopologyResolver = &fakeTopologyResolver{topology: TopologyConfig{Enabled: true, DomainLabels: tc.domainLabels}}
+		reconciler.AdjustTopologyForZoneImbalance = tc.adjust
+		assert.NoErrorf(t, reconciler.ensureOcsOperatorConfigExists(ocsInit), "[%s]: failed to ensure ocs-operator-config", tc.label)
+
+		data := getOcsOperatorConfigData(t, reconciler)
+		assert.Equalf(t, tc.expectedLabels, data[util.TopologyDomainLabelsKey], "[%s]: unexpected topology domain labels", tc.label)
+		condition := conditionsv1.FindStatusCondition(ocsInit.Status.Conditions, ConditionHeterogeneousZoneSizes)
+		assert.Equalf(t, tc.expectCondition, condition != nil, "[%s]: unexpected condition %v", tc.label, condition)
+	}
+}
+
+func TestIsZoneSizeImbalanced(t *testing.T) {
+	assert.False(t, isZoneSizeImbalanced(map[string]int{}))
+	assert.False(t, isZoneSizeImbalanced(map[string]int{"zone-a": 5}))
+	assert.False(t, isZoneSizeImbalanced(map[string]int{"zone-a": 3, "zone-b": 2}))
+	assert.True(t, isZoneSizeImbalanced(map[string]int{"zone-a": 4, "zone-b": 2}))
+
+	// cordoned nodes do not count towards the zone size
+	cordoned := newTestZoneNode("cordoned", "zone-b")
+	cordoned.Spec.Unschedulable = true
+	nodes := []corev1.Node{*newTestZoneNode("node-a-1", "zone-a"), *newTestZoneNode("node-a-2", "zone-a"),
+		*newTestZoneNode("node-b-1", "zone-b"), *cordoned}
+	assert.Equal(t, "zone-a=2,zone-b=1", formatZoneSizes(getZoneSizes(nodes)))
+	assert.True(t, isZoneSizeImbalanced(getZoneSizes(nodes)))
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
 	objectreferencesv1 "github.com/openshift/custom-resource-status/objectreferences/v1"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	monitoringclient "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned"
@@ -227,6 +228,20 @@ func (obj *ocsCephCluster) ensureCreated(r *StorageClusterReconciler, sc *ocsv1.
 		}
 		r.Log.Info("Monitoring Information found. Monitoring will be enabled on the external cluster.", "CephCluster", klog.KRef(sc.Namespace, sc.Name))
 		cephCluster = newExternalCephCluster(sc, monitoringIP, monitoringPort)
+
+		kernelMountOptions, downgradeMessage := negotiateExternalMsMode(cephCluster.Spec.CSI.CephFS.KernelMountOptions, extRArr)
+		cephCluster.Spec.CSI.CephFS.KernelMountOptions = kernelMountOptions
+		if downgradeMessage != "" {
+			r.Log.Info("CephFS kernel mount ms_mode is not supported by the external cluster.", "StorageCluster", klog.KRef(sc.Namespace, sc.Name), "Message", downgradeMessage)
+			conditionsv1.SetStatusCondition(&sc.Status.Conditions, conditionsv1.Condition{
+				Type:    ocsv1.ConditionMsModeDowngraded,
+				Status:  corev1.ConditionTrue,
+				Reason:  "MsModeUnsupported",
+				Message: downgradeMessage,
+			})
+		} else {
+			conditionsv1.RemoveStatusCondition(&sc.Status.Conditions, ocsv1.ConditionMsModeDowngraded)
+		}
 	} else {
 		// Add KMS details to CephCluster spec, only if
 		// cluster-wide encryption is enabled or any of the device set is encrypted
@@ -492,6 +507,8 @@ func newCephCluster(sc *ocsv1.StorageCluster, cephImage string, kmsConfigMap *co
 		MaxLogSize:  &maxLogSize,
 	}
 
+	kernelMountOptions, _ := util.GetCephFSKernelMountOptions(sc)
+
 	cephCluster := &rookCephv1.CephCluster{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      statusutil.GenerateNameForCephCluster(sc),
@@ -563,7 +580,7 @@ func newCephCluster(sc *ocsv1.StorageCluster, cephImage string, kmsConfigMap *co
 			CSI: rookCephv1.CSIDriverSpec{
 				ReadAffinity: util.GetReadAffinityOptions(sc),
 				CephFS: rookCephv1.CSICephFSSpec{
-					KernelMountOptions: util.GetCephFSKernelMountOptions(sc),
+					KernelMountOptions: kernelMountOptions,
 				},
 			},
 			SkipUpgradeChecks:            sc.Spec.ManagedResources.CephCluster.SkipUpgradeChecks,
@@ -719,6 +736,8 @@ func newExternalCephCluster(sc *ocsv1.StorageCluster, monitoringIP, monitoringPo
 		MaxLogSize:  &maxLogSize,
 	}
 
+	kernelMountOptions, _ := util.GetCephFSKernelMountOptions(sc)
+
 	var monitoringSpec = rookCephv1.MonitoringSpec{Enabled: false}
 
 	if monitoringIP != "" {
@@ -763,7 +782,7 @@ func newExternalCephCluster(sc *ocsv1.StorageCluster, monitoringIP, monitoringPo
 			CSI: rookCephv1.CSIDriverSpec{
 				ReadAffinity: util.GetReadAffinityOptions(sc),
 				CephFS: rookCephv1.CSICephFSSpec{
-					KernelMountOptions: util.GetCephFSKernelMountOptions(sc),
+					KernelMountOptions: kernelMountOptions,
 				},
 			},
 		},
@@ -886,7 +905,7 @@ func newStorageClassDeviceSets(sc *ocsv1.StorageCluster) []rookCephv1.StorageCla
 		}
 
 		if topologyMap != nil {
-			topologyKey, topologyKeyValues = topologyMap.GetKeyValues(topologyKey)
+			topologyKey, topologyKeyValues = getCrushBucketKeyValues(sc, topologyKey)
 		}
 
 		count, replica := countAndReplicaOf(&ds)
@@ -1162,7 +1181,7 @@ func allowUnsupportedCephVersion() bool {
 func generateStretchClusterSpec(sc *ocsv1.StorageCluster) *rookCephv1.StretchClusterSpec {
 	var zones []string
 	stretchClusterSpec := rookCephv1.StretchClusterSpec{}
-	stretchClusterSpec.FailureDomainLabel, zones = sc.Status.NodeTopologies.GetKeyValues(getFailureDomain(sc))
+	stretchClusterSpec.FailureDomainLabel, zones = getCrushBucketKeyValues(sc, getFailureDomain(sc))
 
 	for _, zone := range zones {
 		if zone == sc.Spec.NodeTopologies.ArbiterLocation {
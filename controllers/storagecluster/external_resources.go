@@ -8,6 +8,7 @@ import (
 	"net"
 	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +24,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -39,8 +41,15 @@ const (
 	externalCephRgwEndpointKey                  = "endpoint"
 	cephRgwTLSSecretKey                         = "ceph-rgw-tls-cert"
 	storageClassSkippedError                    = "some storage classes were skipped while waiting for pre-requisites to be met"
+	// externalConnectionCapabilities is the optional external resource advertising the
+	// messenger v2 connection modes supported by the external cluster, e.g. "crc,secure"
+	externalConnectionCapabilities = "connection-capabilities"
+	externalMsModesKey             = "MsModes"
 )
 
+// msModeFallbacks is the order in which an unsupported ms_mode is downgraded
+var msModeFallbacks = []string{"secure", "prefer-crc", "legacy"}
+
 // store the name of the rados-namespace
 var radosNamespaceName string
 
@@ -619,6 +628,54 @@ func verifyMonitoringEndpoints(monitoringIP, monitoringPort string,
 	return
 }
 
+// negotiateExternalMsMode validates the ms_mode of the kernel mount options against the connection modes
+// advertised by the external cluster. If the external cluster does not support it, the ms_mode is downgraded
+// to the next supported one and a message describing the downgrade is returned. If the external cluster does
+// not advertise its connection modes, the kernel mount options are returned as they are.
+func negotiateExternalMsMode(kernelMountOptions string, extRArr []ExternalResource) (string, string) {
+	capabilities, err := findNamedResourceFromArray(extRArr, externalConnectionCapabilities)
+	if err != nil || capabilities.Data[externalMsModesKey] == "" {
+		return kernelMountOptions, ""
+	}
+	advertised := sets.New(strings.FieldsFunc(capabilities.Data[externalMsModesKey], func(r rune) bool {
+		return r == ',' || r == ' '
+	})...)
+
+	// the ms_mode is the first option, the additional options of the StorageCluster follow it unchanged
+	msModeOption, additionalOptions, _ := strings.Cut(kernelMountOptions, ",")
+	if additionalOptions != "" {
+		additionalOptions = "," + additionalOptions
+	}
+	msMode := strings.TrimPrefix(msModeOption, "ms_mode=")
+	if isMsModeSupported(msMode, advertised) {
+		return kernelMountOptions, ""
+	}
+
+	fallbacks := msModeFallbacks
+	if idx := slices.Index(msModeFallbacks, msMode); idx >= 0 {
+		fallbacks = msModeFallbacks[idx+1:]
+	}
+	for _, fallback := range fallbacks {
+		if isMsModeSupported(fallback, advertised) {
+			return "ms_mode=" + fallback + additionalOptions, fmt.Sprintf("ms_mode %q is not supported by the external cluster, which supports %q, using %q instead",
+				msMode, capabilities.Data[externalMsModesKey], fallback)
+		}
+	}
+	return kernelMountOptions, fmt.Sprintf("ms_mode %q is not supported by the external cluster, which supports %q, and no fallback is available",
+		msMode, capabilities.Data[externalMsModesKey])
+}
+
+// isMsModeSupported returns true if the ms_mode can be used with the advertised connection modes.
+// The prefer-* modes work with either crc or secure connections.
+func isMsModeSupported(msMode string, advertised sets.Set[string]) bool {
+	switch msMode {
+	case "prefer-crc", "prefer-secure":
+		return advertised.HasAny("crc", "secure")
+	default:
+		return advertised.Has(msMode)
+	}
+}
+
 // createExternalStorageClusterConfigMap creates configmap for external cluster
 func (r *StorageClusterReconciler) createExternalStorageClusterConfigMap(cm *corev1.ConfigMap, found *corev1.ConfigMap, objectKey types.NamespacedName) error {
 	err := r.Client.Get(context.TODO(), objectKey, found)
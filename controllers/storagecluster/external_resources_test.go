@@ -580,3 +580,87 @@ func TestErasureCodedExternalResources(t *testing.T) {
 		})
 	}
 }
+
+func TestNegotiateExternalMsMode(t *testing.T) {
+	testcases := []struct {
+		label              string
+		msModes            string
+		kernelMountOptions string
+		expectedOptions    string
+		expectDowngrade    bool
+	}{
+		{
+			label:              "no advertised modes keeps the chosen mode",
+			msModes:            "",
+			kernelMountOptions: "ms_mode=secure",
+			expectedOptions:    "ms_mode=secure",
+		},
+		{
+			label:              "secure is supported",
+			msModes:            "crc,secure",
+			kernelMountOptions: "ms_mode=secure",
+			expectedOptions:    "ms_mode=secure",
+		},
+		{
+			label:              "prefer-crc is supported with crc",
+			msModes:            "crc",
+			kernelMountOptions: "ms_mode=prefer-crc",
+			expectedOptions:    "ms_mode=prefer-crc",
+		},
+		{
+			label:              "prefer-crc is supported with secure",
+			msModes:            "secure",
+			kernelMountOptions: "ms_mode=prefer-crc",
+			expectedOptions:    "ms_mode=prefer-crc",
+		},
+		{
+			label:              "unsupported secure is downgraded to prefer-crc",
+			msModes:            "crc legacy",
+			kernelMountOptions: "ms_mode=secure",
+			expectedOptions:    "ms_mode=prefer-crc",
+			expectDowngrade:    true,
+		},
+		{
+			label:              "unsupported prefer-crc is downgraded to legacy",
+			msModes:            "legacy",
+			kernelMountOptions: "ms_mode=prefer-crc",
+			expectedOptions:    "ms_mode=legacy",
+			expectDowngrade:    true,
+		},
+		{
+			label:              "unsupported secure is downgraded to legacy when crc is unsupported",
+			msModes:            "legacy",
+			kernelMountOptions: "ms_mode=secure",
+			expectedOptions:    "ms_mode=legacy",
+			expectDowngrade:    true,
+		},
+		{
+			label:              "additional options are kept when downgrading",
+			msModes:            "crc",
+			kernelMountOptions: "ms_mode=secure,recover_session=clean,wsync",
+			expectedOptions:    "ms_mode=prefer-crc,recover_session=clean,wsync",
+			expectDowngrade:    true,
+		},
+		{
+			label:              "unsupported mode without a fallback is kept and reported",
+			msModes:            "crc,secure",
+			kernelMountOptions: "ms_mode=legacy",
+			expectedOptions:    "ms_mode=legacy",
+			expectDowngrade:    true,
+		},
+	}
+
+	for _, tc := range testcases {
+		extRArr := []ExternalResource{}
+		if tc.msModes != "" {
+			extRArr = append(extRArr, ExternalResource{
+				Name: externalConnectionCapabilities,
+				Kind: "CephCluster",
+				Data: map[string]string{externalMsModesKey: tc.msModes},
+			})
+		}
+		options, message := negotiateExternalMsMode(tc.kernelMountOptions, extRArr)
+		assert.Equalf(t, tc.expectedOptions, options, "[%s]: unexpected kernel mount options", tc.label)
+		assert.Equalf(t, tc.expectDowngrade, message != "", "[%s]: unexpected downgrade message %q", tc.label, message)
+	}
+}
@@ -94,7 +94,7 @@ func getPlacement(sc *ocsv1.StorageCluster, component string) rookCephv1.Placeme
 	}
 
 	topologyKey := getFailureDomain(sc)
-	topologyKey, _ = topologyMap.GetKeyValues(topologyKey)
+	topologyKey, _ = getCrushBucketKeyValues(sc, topologyKey)
 	if component == "mon" || component == "mds" || component == "rgw" {
 		if placement.PodAntiAffinity != nil {
 			if placement.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution != nil {
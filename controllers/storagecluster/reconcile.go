@@ -4,6 +4,7 @@ import (
 	"context"
 	error1 "errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -23,6 +24,8 @@ import (
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
@@ -88,6 +91,9 @@ const labelZoneRegionWithoutBeta = "failure-domain.kubernetes.io/region"
 const labelZoneFailureDomainWithoutBeta = "failure-domain.kubernetes.io/zone"
 const labelRookPrefix = "topology.rook.io"
 
+// crushBucketTypes are the CRUSH bucket types of the default CRUSH map that nodes can be mapped to
+var crushBucketTypes = []string{"host", "chassis", "rack", "row", "pdu", "pod", "room", "datacenter", "zone", "region"}
+
 var validTopologyLabelKeys = []string{
 	// This is the most preferred key as kubernetes recommends zone and region
 	// labels under this key.
@@ -304,6 +310,28 @@ func (r *StorageClusterReconciler) validateStorageClusterSpec(instance *ocsv1.St
 		return err
 	}
 
+	if err := validateCrushHierarchy(instance); err != nil {
+		r.Log.Error(err, "Failed to validate CrushHierarchy.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
+		r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, statusutil.EventReasonValidationFailed, err.Error())
+		instance.Status.Phase = statusutil.PhaseError
+		if updateErr := r.Client.Status().Update(context.TODO(), instance); updateErr != nil {
+			r.Log.Error(updateErr, "Could not update StorageCluster.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
+			return updateErr
+		}
+		return err
+	}
+
+	if err := validateRookCephOperatorPodSelector(instance); err != nil {
+		r.Log.Error(err, "Failed to validate RookCephOperatorPodSelector.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
+		r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, statusutil.EventReasonValidationFailed, err.Error())
+		instance.Status.Phase = statusutil.PhaseError
+		if updateErr := r.Client.Status().Update(context.TODO(), instance); updateErr != nil {
+			r.Log.Error(updateErr, "Could not update StorageCluster.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
+			return updateErr
+		}
+		return err
+	}
+
 	return nil
 }
 
@@ -943,6 +971,40 @@ func validateCustomStorageClassNames(sc *ocsv1.StorageCluster) error {
 	return nil
 }
 
+// validateCrushHierarchy ensures that the custom CRUSH hierarchy maps each label to a single known CRUSH bucket type
+func validateCrushHierarchy(sc *ocsv1.StorageCluster) error {
+	bucketTypes := map[string]bool{}
+	labels := map[string]bool{}
+	for _, mapping := range sc.Spec.CrushHierarchy {
+		if !slices.Contains(crushBucketTypes, mapping.BucketType) {
+			return fmt.Errorf("unknown CRUSH bucket type %q for label %q, supported bucket types are %v", mapping.BucketType, mapping.Label, crushBucketTypes)
+		}
+		if errs := validation.IsQualifiedName(mapping.Label); len(errs) > 0 {
+			return fmt.Errorf("invalid label %q for CRUSH bucket type %q: %s", mapping.Label, mapping.BucketType, strings.Join(errs, ", "))
+		}
+		if bucketTypes[mapping.BucketType] {
+			return fmt.Errorf("CRUSH bucket type %q is mapped more than once", mapping.BucketType)
+		}
+		if labels[mapping.Label] {
+			return fmt.Errorf("label %q is mapped to more than one CRUSH bucket type", mapping.Label)
+		}
+		bucketTypes[mapping.BucketType] = true
+		labels[mapping.Label] = true
+	}
+	return nil
+}
+
+// validateRookCephOperatorPodSelector ensures that the label selector of the rook-ceph-operator pods parses
+func validateRookCephOperatorPodSelector(sc *ocsv1.StorageCluster) error {
+	if sc.Spec.RookCephOperatorPodSelector == "" {
+		return nil
+	}
+	if _, err := k8slabels.Parse(sc.Spec.RookCephOperatorPodSelector); err != nil {
+		return fmt.Errorf("invalid rook-ceph-operator pod selector %q: %v", sc.Spec.RookCephOperatorPodSelector, err)
+	}
+	return nil
+}
+
 func getUnsupportedClientsCount(r *StorageClusterReconciler, namespace string) (int, error) {
 	scList := &ocsv1alpha1.StorageConsumerList{}
 	err := r.Client.List(r.ctx, scList, client.InNamespace(namespace))
@@ -896,6 +896,25 @@ func TestStorageDeviceSets(t *testing.T) {
 	}
 }
 
+func TestValidateRookCephOperatorPodSelector(t *testing.T) {
+	testcases := []struct {
+		label       string
+		selector    string
+		expectError bool
+	}{
+		{label: "no selector"},
+		{label: "equality selector", selector: "app=rook-ceph-operator"},
+		{label: "set based selector", selector: "app in (rook-ceph-operator,rook-ceph-operator-canary),tier!=test"},
+		{label: "invalid selector", selector: "app==(rook", expectError: true},
+	}
+
+	for _, tc := range testcases {
+		sc := &api.StorageCluster{Spec: api.StorageClusterSpec{RookCephOperatorPodSelector: tc.selector}}
+		err := validateRookCephOperatorPodSelector(sc)
+		assert.Equalf(t, tc.expectError, err != nil, "[%s]: unexpected validation result %v", tc.label, err)
+	}
+}
+
 func TestStorageClusterInitConditions(t *testing.T) {
 	cc := &rookCephv1.CephCluster{}
 	mockCephCluster.DeepCopyInto(cc)
@@ -16,11 +16,19 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
 	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// standardFailureDomainLabels are the node labels of the failure domains known to NodeTopologyMap.GetKeyValues
+var standardFailureDomainLabels = map[string]string{
+	"host": corev1.LabelHostname,
+	"rack": defaults.RackTopologyKey,
+	"zone": corev1.LabelZoneFailureDomainStable,
+}
+
 type ocsTopologyMap struct{}
 
 // ensureCreated ensures that StorageCluster.Status.Topology is up to date
@@ -29,10 +37,31 @@ func (obj *ocsTopologyMap) ensureCreated(r *StorageClusterReconciler, instance *
 		r.Log.Error(err, "Failed to set node Topology Map for StorageCluster.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
 		return reconcile.Result{}, err
 	}
+	r.setTopologyDomainLabelsCondition(instance)
 
 	return reconcile.Result{}, nil
 }
 
+// setTopologyDomainLabelsCondition reports a non-resilient pools topology without a failure domain key. The
+// CSI topology domain labels are taken from the failure domain key, so the topology config of ocs-operator-config
+// is not updated while it is missing.
+func (r *StorageClusterReconciler) setTopologyDomainLabelsCondition(sc *ocsv1.StorageCluster) {
+	if sc.Spec.ExternalStorage.Enable || !sc.Spec.ManagedResources.CephNonResilientPools.Enable || getFailureDomainKey(sc) != "" {
+		conditionsv1.RemoveStatusCondition(&sc.Status.Conditions, ocsv1.ConditionTopologyDomainLabelsMissing)
+		return
+	}
+	message := fmt.Sprintf("the non-resilient pools enable topology, but there is no node label for the failure domain %q "+
+		"to use as the topology domain label; the topology config of the CSI drivers is not updated", getFailureDomain(sc))
+	r.Log.Info("Topology domain label of the StorageCluster is missing.", "StorageCluster", klog.KRef(sc.Namespace, sc.Name),
+		"FailureDomain", getFailureDomain(sc))
+	conditionsv1.SetStatusCondition(&sc.Status.Conditions, conditionsv1.Condition{
+		Type:    ocsv1.ConditionTopologyDomainLabelsMissing,
+		Status:  corev1.ConditionTrue,
+		Reason:  "FailureDomainKeyMissing",
+		Message: message,
+	})
+}
+
 // ensureDeleted is dummy func for the ocsTopologyMap
 func (obj *ocsTopologyMap) ensureDeleted(_ *StorageClusterReconciler, _ *ocsv1.StorageCluster) (reconcile.Result, error) {
 	return reconcile.Result{}, nil
@@ -74,7 +103,7 @@ func setFailureDomain(sc *ocsv1.StorageCluster) {
 
 	// We don't change the failure domain after it is determined
 	if sc.Status.FailureDomain != "" {
-		sc.Status.FailureDomainKey, sc.Status.FailureDomainValues = sc.Status.NodeTopologies.GetKeyValues(sc.Status.FailureDomain)
+		sc.Status.FailureDomainKey, sc.Status.FailureDomainValues = getCrushBucketKeyValues(sc, sc.Status.FailureDomain)
 		return
 	}
 
@@ -84,7 +113,7 @@ func setFailureDomain(sc *ocsv1.StorageCluster) {
 	if statusutil.IsSingleNodeDeployment() {
 		sc.Status.FailureDomain = "osd"
 		// Since nodes do not have a label for "osd" as a failure domain, setting it to "host".
-		sc.Status.FailureDomainKey, sc.Status.FailureDomainValues = sc.Status.NodeTopologies.GetKeyValues("host")
+		sc.Status.FailureDomainKey, sc.Status.FailureDomainValues = getCrushBucketKeyValues(sc, "host")
 		return
 	}
 
@@ -93,22 +122,112 @@ func setFailureDomain(sc *ocsv1.StorageCluster) {
 	if sc.Spec.FlexibleScaling {
 		failureDomain = "host"
 		sc.Status.FailureDomain = failureDomain
-		sc.Status.FailureDomainKey, sc.Status.FailureDomainValues = sc.Status.NodeTopologies.GetKeyValues(sc.Status.FailureDomain)
+		sc.Status.FailureDomainKey, sc.Status.FailureDomainValues = getCrushBucketKeyValues(sc, sc.Status.FailureDomain)
 		return
 	}
 
+	// A configured failure domain name takes precedence over the automatic selection
+	if name := getFailureDomainName(sc); name != "" {
+		sc.Status.FailureDomain = name
+		sc.Status.FailureDomainKey, sc.Status.FailureDomainValues = getCrushBucketKeyValues(sc, sc.Status.FailureDomain)
+		return
+	}
+
+	// With a custom CRUSH hierarchy we select the first bucket type with sufficient buckets
+	for _, mapping := range sc.Spec.CrushHierarchy {
+		if _, labelValues := getCrushBucketKeyValues(sc, mapping.BucketType); hasSufficientFailureDomainValues(sc, labelValues) {
+			sc.Status.FailureDomain = mapping.BucketType
+			sc.Status.FailureDomainKey, sc.Status.FailureDomainValues = getCrushBucketKeyValues(sc, sc.Status.FailureDomain)
+			return
+		}
+	}
+
 	// If sufficient zones are available then we select zone as the failure domain
 	topologyMap := sc.Status.NodeTopologies
 	for label, labelValues := range topologyMap.Labels {
 		if label == corev1.LabelZoneFailureDomainStable || label == labelZoneFailureDomainWithoutBeta {
-			if (len(labelValues) >= 2 && arbiterEnabled(sc)) || (len(labelValues) >= 3) {
+			if hasSufficientFailureDomainValues(sc, labelValues) {
 				failureDomain = "zone"
 			}
 		}
 	}
 
 	sc.Status.FailureDomain = failureDomain
-	sc.Status.FailureDomainKey, sc.Status.FailureDomainValues = sc.Status.NodeTopologies.GetKeyValues(sc.Status.FailureDomain)
+	sc.Status.FailureDomainKey, sc.Status.FailureDomainValues = getCrushBucketKeyValues(sc, sc.Status.FailureDomain)
+}
+
+// hasSufficientFailureDomainValues returns true if there are enough locations to distribute the data replicas
+func hasSufficientFailureDomainValues(sc *ocsv1.StorageCluster, values []string) bool {
+	return (len(values) >= 2 && arbiterEnabled(sc)) || (len(values) >= 3)
+}
+
+// getCrushBucketLabel returns the label mapped to the CRUSH bucket type in the custom CRUSH hierarchy
+// of the storagecluster, or an empty string if the bucket type is not mapped
+func getCrushBucketLabel(sc *ocsv1.StorageCluster, bucketType string) string {
+	for _, mapping := range sc.Spec.CrushHierarchy {
+		if mapping.BucketType == bucketType {
+			return mapping.Label
+		}
+	}
+	return ""
+}
+
+// getCrushBucketKeyValues returns the node label of the CRUSH bucket type and all values for that label
+// across all storage nodes. A label mapped in the custom CRUSH hierarchy takes precedence over the
+// default label of the bucket type.
+func getCrushBucketKeyValues(sc *ocsv1.StorageCluster, bucketType string) (string, []string) {
+	topologyMap := sc.Status.NodeTopologies
+	if topologyMap == nil {
+		return "", []string{}
+	}
+	if label := getCrushBucketLabel(sc, bucketType); label != "" {
+		if values, ok := topologyMap.Labels[label]; ok {
+			return label, values
+		}
+		return "", []string{}
+	}
+	if _, ok := standardFailureDomainLabels[bucketType]; ok {
+		return topologyMap.GetKeyValues(bucketType)
+	}
+	// like Rook, the other bucket types are taken from the topology.rook.io/<bucketType> label
+	label := labelRookPrefix + "/" + bucketType
+	if values, ok := topologyMap.Labels[label]; ok {
+		return label, values
+	}
+	return "", []string{}
+}
+
+// getFailureDomainName returns the failure domain name configured in the node topologies of the storagecluster
+func getFailureDomainName(sc *ocsv1.StorageCluster) string {
+	if sc.Spec.NodeTopologies == nil {
+		return ""
+	}
+	return sc.Spec.NodeTopologies.FailureDomainName
+}
+
+// getFailureDomainLabel returns the node label of the failure domain
+func getFailureDomainLabel(sc *ocsv1.StorageCluster, name string) string {
+	if label := getCrushBucketLabel(sc, name); label != "" {
+		return label
+	}
+	if label, ok := standardFailureDomainLabels[name]; ok {
+		return label
+	}
+	return labelRookPrefix + "/" + name
+}
+
+// validateFailureDomainName checks that the storage nodes have the label of the configured failure domain name,
+// while the failure domain is yet to be determined. The nodes are labeled with racks by ocs-operator.
+func validateFailureDomainName(sc *ocsv1.StorageCluster) error {
+	name := getFailureDomainName(sc)
+	if name == "" || name == "rack" || sc.Status.FailureDomain != "" {
+		return nil
+	}
+	if label, _ := getCrushBucketKeyValues(sc, name); label == "" {
+		return fmt.Errorf("failure domain %q is not set on any of the storage nodes, expected the node label %q",
+			name, getFailureDomainLabel(sc, name))
+	}
+	return nil
 }
 
 // determinePlacementRack sorts the list of known racks in alphabetical order,
@@ -304,6 +423,12 @@ func (r *StorageClusterReconciler) reconcileNodeTopologyMap(sc *ocsv1.StorageClu
 					}
 				}
 			}
+			for _, mapping := range sc.Spec.CrushHierarchy {
+				if label == mapping.Label && !topologyMap.Contains(label, value) {
+					r.Log.Info("Adding CRUSH hierarchy label from Node.", "Node", node.Name, "Label", label, "BucketType", mapping.BucketType, "Value", value)
+					topologyMap.Add(label, value)
+				}
+			}
 			if strings.Contains(label, "rack") {
 				if !nodeRacks.Contains(value, node.Name) {
 					nodeRacks.Add(value, node.Name)
@@ -316,9 +441,13 @@ func (r *StorageClusterReconciler) reconcileNodeTopologyMap(sc *ocsv1.StorageClu
 	filterDuplicateLabels(sc, nodes, topologyMap)
 	sortTopologyMapLabelValues(topologyMap)
 	sc.Status.NodeTopologies = topologyMap
+	if err := validateFailureDomainName(sc); err != nil {
+		return err
+	}
 	setFailureDomain(sc)
 
-	if getFailureDomain(sc) == "rack" {
+	// The racks of a custom CRUSH hierarchy are taken from the mapped label, the nodes are not labeled
+	if getFailureDomain(sc) == "rack" && getCrushBucketLabel(sc, "rack") == "" {
 		err = r.ensureNodeRacks(nodes, minNodes, nodeRacks, topologyMap)
 		if err != nil {
 			return err
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"testing"
 
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
 	"github.com/red-hat-storage/ocs-operator/v4/controllers/defaults"
@@ -726,6 +728,250 @@ func TestFailureDomain(t *testing.T) {
 	}
 }
 
+func TestFailureDomainWithCrushHierarchy(t *testing.T) {
+	customHierarchy := []ocsv1.CrushBucketMapping{
+		{Label: "example.com/row", BucketType: "row"},
+		{Label: "example.com/room", BucketType: "room"},
+		{Label: "example.com/rack", BucketType: "rack"},
+	}
+	testcases := []struct {
+		label                       string
+		crushHierarchy              []ocsv1.CrushBucketMapping
+		failureDomain               string
+		labels                      map[string]ocsv1.TopologyLabelValues
+		expectedFailureDomain       string
+		expectedFailureDomainKey    string
+		expectedFailureDomainValues []string
+	}{
+		{
+			label:          "the first bucket type with sufficient buckets is selected",
+			crushHierarchy: customHierarchy,
+			labels: map[string]ocsv1.TopologyLabelValues{
+				"example.com/row":                   []string{"row1", "row2"},
+				"example.com/room":                  []string{"room1", "room2", "room3"},
+				corev1.LabelZoneFailureDomainStable: []string{"zone1", "zone2", "zone3"},
+			},
+			expectedFailureDomain:       "room",
+			expectedFailureDomainKey:    "example.com/room",
+			expectedFailureDomainValues: []string{"room1", "room2", "room3"},
+		},
+		{
+			label:          "the mapped label takes precedence over the default label",
+			crushHierarchy: customHierarchy,
+			labels: map[string]ocsv1.TopologyLabelValues{
+				"example.com/rack":      []string{"r1", "r2", "r3"},
+				"topology.rook.io/rack": []string{"rack1", "rack2", "rack3"},
+			},
+			expectedFailureDomain:       "rack",
+			expectedFailureDomainKey:    "example.com/rack",
+			expectedFailureDomainValues: []string{"r1", "r2", "r3"},
+		},
+		{
+			label:          "the standard failure domain is used without sufficient buckets",
+			crushHierarchy: customHierarchy,
+			labels: map[string]ocsv1.TopologyLabelValues{
+				"example.com/row":                   []string{"row1"},
+				corev1.LabelZoneFailureDomainStable: []string{"zone1", "zone2", "zone3"},
+			},
+			expectedFailureDomain:       "zone",
+			expectedFailureDomainKey:    corev1.LabelZoneFailureDomainStable,
+			expectedFailureDomainValues: []string{"zone1", "zone2", "zone3"},
+		},
+		{
+			label:          "a mapped zone label is used for the zone failure domain",
+			crushHierarchy: []ocsv1.CrushBucketMapping{{Label: "example.com/zone", BucketType: "zone"}},
+			failureDomain:  "zone",
+			labels: map[string]ocsv1.TopologyLabelValues{
+				"example.com/zone":                  []string{"a", "b", "c"},
+				corev1.LabelZoneFailureDomainStable: []string{"zone1", "zone2", "zone3"},
+			},
+			expectedFailureDomain:       "zone",
+			expectedFailureDomainKey:    "example.com/zone",
+			expectedFailureDomainValues: []string{"a", "b", "c"},
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := &ocsv1.StorageCluster{
+			Spec: ocsv1.StorageClusterSpec{
+				CrushHierarchy: tc.crushHierarchy,
+				ManagedResources: ocsv1.ManagedResourcesSpec{
+					CephNonResilientPools: ocsv1.ManageCephNonResilientPools{Enable: true},
+				},
+			},
+			Status: ocsv1.StorageClusterStatus{
+				FailureDomain:  tc.failureDomain,
+				NodeTopologies: &ocsv1.NodeTopologyMap{Labels: tc.labels},
+			},
+		}
+		setFailureDomain(sc)
+		assert.Equalf(t, tc.expectedFailureDomain, getFailureDomain(sc), "[%s]: failed to get correct failure domain", tc.label)
+		// the failure domain key is used for the topology domain labels and the values for the non-resilient pools
+		assert.Equalf(t, tc.expectedFailureDomainKey, getFailureDomainKey(sc), "[%s]: failed to get correct failure domain key", tc.label)
+		assert.Equalf(t, tc.expectedFailureDomainValues, sc.Status.FailureDomainValues, "[%s]: failed to get correct failure domain values", tc.label)
+	}
+}
+
+func TestValidateCrushHierarchy(t *testing.T) {
+	testcases := []struct {
+		label          string
+		crushHierarchy []ocsv1.CrushBucketMapping
+		expectError    bool
+	}{
+		{
+			label: "custom hierarchy with known bucket types",
+			crushHierarchy: []ocsv1.CrushBucketMapping{
+				{Label: "example.com/datacenter", BucketType: "datacenter"},
+				{Label: "example.com/row", BucketType: "row"},
+				{Label: "example.com/chassis", BucketType: "chassis"},
+			},
+		},
+		{
+			label:          "unknown bucket type",
+			crushHierarchy: []ocsv1.CrushBucketMapping{{Label: "example.com/shelf", BucketType: "shelf"}},
+			expectError:    true,
+		},
+		{
+			label:          "invalid label",
+			crushHierarchy: []ocsv1.CrushBucketMapping{{Label: "example.com/not a label", BucketType: "row"}},
+			expectError:    true,
+		},
+		{
+			label: "bucket type mapped twice",
+			crushHierarchy: []ocsv1.CrushBucketMapping{
+				{Label: "example.com/row", BucketType: "row"},
+				{Label: "example.com/aisle", BucketType: "row"},
+			},
+			expectError: true,
+		},
+		{
+			label: "label mapped twice",
+			crushHierarchy: []ocsv1.CrushBucketMapping{
+				{Label: "example.com/row", BucketType: "row"},
+				{Label: "example.com/row", BucketType: "room"},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := &ocsv1.StorageCluster{Spec: ocsv1.StorageClusterSpec{CrushHierarchy: tc.crushHierarchy}}
+		err := validateCrushHierarchy(sc)
+		assert.Equalf(t, tc.expectError, err != nil, "[%s]: unexpected validation result %v", tc.label, err)
+	}
+}
+
+func TestFailureDomainName(t *testing.T) {
+	zoneLabels := map[string]ocsv1.TopologyLabelValues{
+		corev1.LabelZoneFailureDomainStable: []string{"zone1", "zone2", "zone3"},
+	}
+	testcases := []struct {
+		label                       string
+		failureDomainName           string
+		crushHierarchy              []ocsv1.CrushBucketMapping
+		labels                      map[string]ocsv1.TopologyLabelValues
+		expectError                 bool
+		expectedFailureDomain       string
+		expectedFailureDomainKey    string
+		expectedFailureDomainValues []string
+	}{
+		{
+			label:                       "standard failure domain name",
+			failureDomainName:           "host",
+			labels:                      map[string]ocsv1.TopologyLabelValues{corev1.LabelHostname: []string{"node1", "node2", "node3"}},
+			expectedFailureDomain:       "host",
+			expectedFailureDomainKey:    corev1.LabelHostname,
+			expectedFailureDomainValues: []string{"node1", "node2", "node3"},
+		},
+		{
+			label:             "standard failure domain name that is not set on the nodes",
+			failureDomainName: "host",
+			labels:            zoneLabels,
+			expectError:       true,
+		},
+		{
+			label:             "custom failure domain name",
+			failureDomainName: "datacenter",
+			labels: map[string]ocsv1.TopologyLabelValues{
+				"topology.rook.io/datacenter":       []string{"dc1", "dc2", "dc3"},
+				corev1.LabelZoneFailureDomainStable: []string{"zone1", "zone2", "zone3"},
+			},
+			expectedFailureDomain:       "datacenter",
+			expectedFailureDomainKey:    "topology.rook.io/datacenter",
+			expectedFailureDomainValues: []string{"dc1", "dc2", "dc3"},
+		},
+		{
+			label:                       "custom failure domain name mapped in the CRUSH hierarchy",
+			failureDomainName:           "datacenter",
+			crushHierarchy:              []ocsv1.CrushBucketMapping{{Label: "example.com/dc", BucketType: "datacenter"}},
+			labels:                      map[string]ocsv1.TopologyLabelValues{"example.com/dc": []string{"dc1", "dc2", "dc3"}},
+			expectedFailureDomain:       "datacenter",
+			expectedFailureDomainKey:    "example.com/dc",
+			expectedFailureDomainValues: []string{"dc1", "dc2", "dc3"},
+		},
+		{
+			label:             "custom failure domain name that is not set on the nodes",
+			failureDomainName: "datacenter",
+			labels:            zoneLabels,
+			expectError:       true,
+		},
+	}
+
+	for _, tc := range testcases {
+		sc := &ocsv1.StorageCluster{
+			Spec: ocsv1.StorageClusterSpec{
+				CrushHierarchy: tc.crushHierarchy,
+				NodeTopologies: &ocsv1.NodeTopologyMap{FailureDomainName: tc.failureDomainName},
+			},
+			Status: ocsv1.StorageClusterStatus{
+				NodeTopologies: &ocsv1.NodeTopologyMap{Labels: tc.labels},
+			},
+		}
+		err := validateFailureDomainName(sc)
+		assert.Equalf(t, tc.expectError, err != nil, "[%s]: unexpected validation result %v", tc.label, err)
+		if tc.expectError {
+			continue
+		}
+		setFailureDomain(sc)
+		assert.Equalf(t, tc.expectedFailureDomain, getFailureDomain(sc), "[%s]: failed to get correct failure domain", tc.label)
+		assert.Equalf(t, tc.expectedFailureDomainKey, getFailureDomainKey(sc), "[%s]: failed to get correct failure domain key", tc.label)
+		assert.Equalf(t, tc.expectedFailureDomainValues, sc.Status.FailureDomainValues, "[%s]: failed to get correct failure domain values", tc.label)
+	}
+}
+
+func TestTopologyDomainLabelsCondition(t *testing.T) {
+	testcases := []struct {
+		label            string
+		nonResilient     bool
+		external         bool
+		failureDomainKey string
+		expectCondition  bool
+	}{
+		{label: "non-resilient pools with a failure domain key", nonResilient: true, failureDomainKey: corev1.LabelZoneFailureDomainStable},
+		{label: "non-resilient pools without a failure domain key", nonResilient: true, expectCondition: true},
+		{label: "no non-resilient pools"},
+		{label: "external mode", nonResilient: true, external: true},
+	}
+
+	r := &StorageClusterReconciler{Log: logf.Log.WithName("controller_storagecluster_test")}
+	for _, tc := range testcases {
+		sc := &ocsv1.StorageCluster{}
+		sc.Spec.ManagedResources.CephNonResilientPools.Enable = tc.nonResilient
+		sc.Spec.ExternalStorage.Enable = tc.external
+		sc.Status.FailureDomain = "zone"
+		sc.Status.FailureDomainKey = tc.failureDomainKey
+		r.setTopologyDomainLabelsCondition(sc)
+		assert.Equalf(t, tc.expectCondition, conditionsv1.IsStatusConditionTrue(sc.Status.Conditions, ocsv1.ConditionTopologyDomainLabelsMissing),
+			"[%s]: unexpected missing topology domain labels condition", tc.label)
+
+		// the condition is removed once the failure domain key is determined
+		sc.Status.FailureDomainKey = corev1.LabelZoneFailureDomainStable
+		r.setTopologyDomainLabelsCondition(sc)
+		assert.Nilf(t, conditionsv1.FindStatusCondition(sc.Status.Conditions, ocsv1.ConditionTopologyDomainLabelsMissing),
+			"[%s]: expected the condition to be removed", tc.label)
+	}
+}
+
 func TestStorageClusterEligibleNodes(t *testing.T) {
 	testcases := []struct {
 		label             string
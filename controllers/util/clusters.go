@@ -75,13 +75,19 @@ func GetClusters(ctx context.Context, cli client.Client) (*Clusters, error) {
 		return nil, err
 	}
 
+	return NewClusters(storageClusters.Items), nil
+}
+
+// NewClusters returns the Clusters of the given storageclusters, without listing them from the cluster
+func NewClusters(storageClusters []ocsv1.StorageCluster) *Clusters {
+
 	internalStorageClusters := make([]ocsv1.StorageCluster, 0)
 	externalStorageClusters := make([]ocsv1.StorageCluster, 0)
 	names := make([]string, 0)
 	namespaces := make([]string, 0)
 	namespacedNames := make([]string, 0)
 
-	for _, storageCluster := range storageClusters.Items {
+	for _, storageCluster := range storageClusters {
 		names = append(names, storageCluster.Name)
 		namespaces = append(namespaces, storageCluster.Namespace)
 		namespacedNames = append(namespacedNames, storageCluster.Namespace+"/"+storageCluster.Name)
@@ -123,7 +129,7 @@ func GetClusters(ctx context.Context, cli client.Client) (*Clusters, error) {
 		names:                   names,
 		namespaces:              namespaces,
 		namespacedNames:         namespacedNames,
-	}, nil
+	}
 }
 
 // AreOtherStorageClustersReady checks if all other storage clusters (internal and external) are ready.
@@ -1,29 +1,74 @@
 package util
 
 import (
+	"maps"
+	"slices"
+	"strings"
+
 	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
 	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 )
 
-// GetCephFSKernelMountOptions returns the kernel mount options for CephFS based on the spec on the StorageCluster
-func GetCephFSKernelMountOptions(sc *ocsv1.StorageCluster) string {
+// msModeMountOption is the kernel mount option of the messenger mode, which is always chosen by the operator
+const msModeMountOption = "ms_mode"
+
+// GetCephFSKernelMountOptions returns the kernel mount options for CephFS based on the spec on the StorageCluster,
+// along with a short rationale for the chosen ms_mode
+func GetCephFSKernelMountOptions(sc *ocsv1.StorageCluster) (string, string) {
+	msMode, rationale := "prefer-crc", "network encryption is not enabled, prefer-crc mode is used"
 	// If Encryption is enabled, Always use secure mode
 	if sc.Spec.Network != nil && sc.Spec.Network.Connections != nil &&
 		sc.Spec.Network.Connections.Encryption != nil && sc.Spec.Network.Connections.Encryption.Enabled {
-		return "ms_mode=secure"
+		msMode, rationale = "secure", "network encryption is enabled, secure mode is required"
 	}
 
-	// If encryption is not enabled, use prefer-crc mode
-	return "ms_mode=prefer-crc"
+	return strings.Join(append([]string{msModeMountOption + "=" + msMode},
+		getAdditionalCephFSKernelMountOptions(sc)...), ","), rationale
 }
 
-// getReadAffinityyOptions returns the read affinity options based on the spec on the StorageCluster.
-func GetReadAffinityOptions(sc *ocsv1.StorageCluster) rookCephv1.ReadAffinitySpec {
-	if sc.Spec.CSI != nil && sc.Spec.CSI.ReadAffinity != nil {
-		return *sc.Spec.CSI.ReadAffinity
+// getAdditionalCephFSKernelMountOptions returns the additional CephFS kernel mount options of the StorageCluster,
+// sorted by name so that the resulting options do not change between reconciles. The ms_mode option, and the
+// options whose name or value would not parse in a comma separated list, are skipped.
+func getAdditionalCephFSKernelMountOptions(sc *ocsv1.StorageCluster) []string {
+	// the names are trimmed before they are de-duplicated, keeping the greatest value of a name that is set more
+	// than once so that the result does not depend on the order of the map
+	options := map[string]string{}
+	for name, value := range sc.Spec.ManagedResources.CephFilesystems.KernelMountOptions {
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if name == "" || name == msModeMountOption || strings.ContainsAny(name, ",=") || strings.Contains(value, ",") {
+			continue
+		}
+		if previous, ok := options[name]; ok && previous > value {
+			continue
+		}
+		options[name] = value
+	}
+	mountOptions := make([]string, 0, len(options))
+	for _, name := range slices.Sorted(maps.Keys(options)) {
+		if options[name] == "" {
+			mountOptions = append(mountOptions, name)
+		} else {
+			mountOptions = append(mountOptions, name+"="+options[name])
+		}
 	}
+	return mountOptions
+}
 
-	return rookCephv1.ReadAffinitySpec{
+// getReadAffinityyOptions returns the read affinity options based on the spec on the StorageCluster.
+// The explicit EnableReadAffinity toggle takes precedence over the enabled setting of ReadAffinity, which
+// takes precedence over the default of enabling read affinity for internal mode clusters only.
+func GetReadAffinityOptions(sc *ocsv1.StorageCluster) rookCephv1.ReadAffinitySpec {
+	options := rookCephv1.ReadAffinitySpec{
 		Enabled: !sc.Spec.ExternalStorage.Enable,
 	}
+	if sc.Spec.CSI == nil {
+		return options
+	}
+	if sc.Spec.CSI.ReadAffinity != nil {
+		options = *sc.Spec.CSI.ReadAffinity
+	}
+	if sc.Spec.CSI.EnableReadAffinity != nil {
+		options.Enabled = *sc.Spec.CSI.EnableReadAffinity
+	}
+	return options
 }
@@ -6,6 +6,7 @@ import (
 
 	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v4/v1"
 	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"k8s.io/utils/ptr"
 )
 
 func Test_getReadAffinityOptions(t *testing.T) {
@@ -81,6 +82,59 @@ func Test_getReadAffinityOptions(t *testing.T) {
 				CrushLocationLabels: []string{"topology.io/zone"},
 			},
 		},
+		{
+			name: "Internal ceph cluster: ReadAffinity disabled by the toggle",
+			args: args{
+				sc: &ocsv1.StorageCluster{
+					Spec: ocsv1.StorageClusterSpec{
+						CSI: &ocsv1.CSIDriverSpec{
+							EnableReadAffinity: ptr.To(false),
+						},
+					},
+				},
+			},
+			want: rookCephv1.ReadAffinitySpec{
+				Enabled: false,
+			},
+		},
+		{
+			name: "External ceph cluster: ReadAffinity enabled by the toggle",
+			args: args{
+				sc: &ocsv1.StorageCluster{
+					Spec: ocsv1.StorageClusterSpec{
+						ExternalStorage: ocsv1.ExternalStorageClusterSpec{
+							Enable: true,
+						},
+						CSI: &ocsv1.CSIDriverSpec{
+							EnableReadAffinity: ptr.To(true),
+						},
+					},
+				},
+			},
+			want: rookCephv1.ReadAffinitySpec{
+				Enabled: true,
+			},
+		},
+		{
+			name: "Internal ceph cluster: toggle takes precedence over ReadAffinity and keeps crushlocationlabels",
+			args: args{
+				sc: &ocsv1.StorageCluster{
+					Spec: ocsv1.StorageClusterSpec{
+						CSI: &ocsv1.CSIDriverSpec{
+							ReadAffinity: &rookCephv1.ReadAffinitySpec{
+								Enabled:             true,
+								CrushLocationLabels: []string{"topology.io/zone"},
+							},
+							EnableReadAffinity: ptr.To(false),
+						},
+					},
+				},
+			},
+			want: rookCephv1.ReadAffinitySpec{
+				Enabled:             false,
+				CrushLocationLabels: []string{"topology.io/zone"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -91,3 +145,91 @@ func Test_getReadAffinityOptions(t *testing.T) {
 		})
 	}
 }
+
+func Test_getCephFSKernelMountOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		sc            *ocsv1.StorageCluster
+		wantOptions   string
+		wantRationale string
+	}{
+		{
+			name:          "Network not configured: prefer-crc",
+			sc:            &ocsv1.StorageCluster{},
+			wantOptions:   "ms_mode=prefer-crc",
+			wantRationale: "network encryption is not enabled, prefer-crc mode is used",
+		},
+		{
+			name: "Encryption disabled: prefer-crc",
+			sc: &ocsv1.StorageCluster{
+				Spec: ocsv1.StorageClusterSpec{
+					Network: &rookCephv1.NetworkSpec{
+						Connections: &rookCephv1.ConnectionsSpec{
+							Encryption: &rookCephv1.EncryptionSpec{Enabled: false},
+						},
+					},
+				},
+			},
+			wantOptions:   "ms_mode=prefer-crc",
+			wantRationale: "network encryption is not enabled, prefer-crc mode is used",
+		},
+		{
+			name: "Encryption enabled: secure",
+			sc: &ocsv1.StorageCluster{
+				Spec: ocsv1.StorageClusterSpec{
+					Network: &rookCephv1.NetworkSpec{
+						Connections: &rookCephv1.ConnectionsSpec{
+							Encryption: &rookCephv1.EncryptionSpec{Enabled: true},
+						},
+					},
+				},
+			},
+			wantOptions:   "ms_mode=secure",
+			wantRationale: "network encryption is enabled, secure mode is required",
+		},
+		{
+			name: "Additional options: sorted after ms_mode",
+			sc: &ocsv1.StorageCluster{
+				Spec: ocsv1.StorageClusterSpec{
+					ManagedResources: ocsv1.ManagedResourcesSpec{
+						CephFilesystems: ocsv1.ManageCephFilesystems{
+							KernelMountOptions: map[string]string{"wsync": "", "recover_session": "clean", "crc": ""},
+						},
+					},
+				},
+			},
+			wantOptions:   "ms_mode=prefer-crc,crc,recover_session=clean,wsync",
+			wantRationale: "network encryption is not enabled, prefer-crc mode is used",
+		},
+		{
+			name: "Additional options: ms_mode and malformed options are skipped",
+			sc: &ocsv1.StorageCluster{
+				Spec: ocsv1.StorageClusterSpec{
+					Network: &rookCephv1.NetworkSpec{
+						Connections: &rookCephv1.ConnectionsSpec{
+							Encryption: &rookCephv1.EncryptionSpec{Enabled: true},
+						},
+					},
+					ManagedResources: ocsv1.ManagedResourcesSpec{
+						CephFilesystems: ocsv1.ManageCephFilesystems{
+							KernelMountOptions: map[string]string{
+								"ms_mode": "crc", " nowsync ": "", "nowsync": "", "a,b": "", "c=d": "", "e": "f,g", "": "h",
+							},
+						},
+					},
+				},
+			},
+			wantOptions:   "ms_mode=secure,nowsync",
+			wantRationale: "network encryption is enabled, secure mode is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOptions, gotRationale := GetCephFSKernelMountOptions(tt.sc)
+			if gotOptions != tt.wantOptions || gotRationale != tt.wantRationale {
+				t.Errorf("GetCephFSKernelMountOptions() = (%q, %q), want (%q, %q)", gotOptions, gotRationale, tt.wantOptions, tt.wantRationale)
+			}
+		})
+	}
+}
@@ -15,6 +15,18 @@ const (
 
 	// EventReasonUninstallPending is used when the StorageCluster uninstall is Pending
 	EventReasonUninstallPending = "UninstallPending"
+
+	// EventReasonOcsOperatorConfigUpdated is used when the ocs-operator-config configmap is created or updated
+	EventReasonOcsOperatorConfigUpdated = "OCSOperatorConfigUpdated"
+
+	// EventReasonOcsOperatorConfigOwnerDisplaced is used when a previous controller of the ocs-operator-config configmap is displaced
+	EventReasonOcsOperatorConfigOwnerDisplaced = "OCSOperatorConfigOwnerDisplaced"
+
+	// EventReasonRookCephOperatorRestarted is used when the rook-ceph-operator is restarted to pick up changed configmaps
+	EventReasonRookCephOperatorRestarted = "RookCephOperatorRestarted"
+
+	// EventReasonOcsOperatorConfigKeysIgnored is used when ocs-operator-config keys that may not be overridden are ignored
+	EventReasonOcsOperatorConfigKeysIgnored = "OCSOperatorConfigKeysIgnored"
 )
 
 // EventReporter is custom events reporter type which allows user to limit the events
@@ -54,6 +66,11 @@ func (rep *EventReporter) ReportIfNotPresent(instance runtime.Object, eventType,
 	}
 }
 
+// Report will report the event, even if the same event was reported recently
+func (rep *EventReporter) Report(instance runtime.Object, eventType, eventReason, msg string) {
+	rep.recorder.Event(instance, eventType, eventReason, msg)
+}
+
 func getNameSpacedName(instance runtime.Object) (string, error) {
 	objMeta, err := meta.Accessor(instance)
 	if err != nil {
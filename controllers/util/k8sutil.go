@@ -49,13 +49,38 @@ const (
 	RookCephOperatorConfigName = "rook-ceph-operator-config"
 
 	// These are the keys in the ocs-operator-config configmap
-	ClusterNameKey              = "CSI_CLUSTER_NAME"
-	RookCurrentNamespaceOnlyKey = "ROOK_CURRENT_NAMESPACE_ONLY"
-	EnableTopologyKey           = "CSI_ENABLE_TOPOLOGY"
-	TopologyDomainLabelsKey     = "CSI_TOPOLOGY_DOMAIN_LABELS"
-	EnableNFSKey                = "ROOK_CSI_ENABLE_NFS"
-	DisableCSIDriverKey         = "ROOK_CSI_DISABLE_DRIVER"
-	EnableCephfsKey             = "ROOK_CSI_ENABLE_CEPHFS"
+	ClusterNameKey                 = "CSI_CLUSTER_NAME"
+	ClusterNameLegacyKey           = "CSI_CLUSTER_NAME_LEGACY"
+	RookCurrentNamespaceOnlyKey    = "ROOK_CURRENT_NAMESPACE_ONLY"
+	EnableTopologyKey              = "CSI_ENABLE_TOPOLOGY"
+	TopologyDomainLabelsKey        = "CSI_TOPOLOGY_DOMAIN_LABELS"
+	TopologyDomainLabelsLegacyKey  = "CSI_TOPOLOGY_DOMAIN_LABELS_LEGACY"
+	TopologyZoneCountKey           = "CSI_TOPOLOGY_ZONE_COUNT"
+	EnableNFSKey                   = "ROOK_CSI_ENABLE_NFS"
+	EnableReadAffinityKey          = "CSI_ENABLE_READ_AFFINITY"
+	DisableCSIDriverKey            = "ROOK_CSI_DISABLE_DRIVER"
+	EnableCephfsKey                = "ROOK_CSI_ENABLE_CEPHFS"
+	EnableNetworkFencingKey        = "CSI_ENABLE_NETWORK_FENCING"
+	EnableNetworkEncryptionKey     = "CSI_ENABLE_NETWORK_ENCRYPTION"
+	CephFSKernelMountOptionsKey    = "CSI_CEPHFS_KERNEL_MOUNT_OPTIONS"
+	CephFSSubvolumeGroupPinningKey = "CSI_CEPHFS_SUBVOLUMEGROUP_PINNING"
+	RbdRadosNamespaceKey           = "CSI_RBD_RADOS_NAMESPACE"
+	RbdMirrorModeKey               = "CSI_RBD_MIRROR_MODE"
+	RbdThickProvisionKey           = "CSI_RBD_THICK_PROVISION"
+	CephFSSnapshotScheduleKey      = "CSI_CEPHFS_SNAPSHOT_SCHEDULE"
+	CephFSFilesystemsConfigKey     = "CSI_CEPHFS_FILESYSTEMS_CONFIG"
+	CephFSMDSPinningKey            = "CSI_CEPHFS_MDS_PINNING"
+	RgwEndpointKey                 = "CSI_RGW_ENDPOINT"
+	RgwTLSEnabledKey               = "CSI_RGW_TLS_ENABLED"
+	RgwTLSCertSecretKey            = "CSI_RGW_TLS_CERT_SECRET"
+	RgwCABundleSecretKey           = "CSI_RGW_CA_BUNDLE_SECRET"
+	RgwZoneKey                     = "CSI_RGW_ZONE"
+	RgwZoneGroupKey                = "CSI_RGW_ZONEGROUP"
+	RbdEncryptionKMSConfigKey      = "CSI_RBD_ENCRYPTION_KMS_CONFIG"
+	RbdPoolQuotaKey                = "CSI_RBD_POOL_QUOTA"
+	CephFSQuotaKey                 = "CSI_CEPHFS_QUOTA"
+	CSIProvisionerImageTagKey      = "CSI_PROVISIONER_IMAGE_TAG"
+	CSIAttacherImageTagKey         = "CSI_ATTACHER_IMAGE_TAG"
 
 	// This is the name for the FieldIndex
 	OwnerUIDIndexName   = "ownerUID"
@@ -49,6 +49,7 @@ import (
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	apiruntime "k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -64,6 +65,7 @@ import (
 	metrics "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	"github.com/red-hat-storage/ocs-operator/v4/controllers/mirroring"
+	"github.com/red-hat-storage/ocs-operator/v4/controllers/ocsconfig"
 	"github.com/red-hat-storage/ocs-operator/v4/controllers/ocsinitialization"
 	"github.com/red-hat-storage/ocs-operator/v4/controllers/platform"
 	"github.com/red-hat-storage/ocs-operator/v4/controllers/storageautoscaler"
@@ -120,11 +122,70 @@ func main() {
 	var probeAddr string
 	var metricsAddr string
 	var enableLeaderElection bool
+	var configLockLeaseName string
+	var configLockLeaseNamespace string
+	var correctTopologyBindingMode bool
+	var blueGreenConfig bool
+	var enableOCSConfigWebhook bool
+	var remediateRookCephOperatorEnvFrom bool
+	var configApprovalWebhookURL string
+	var configApprovalWebhookTimeout time.Duration
+	var configApprovalWebhookFailOpen bool
+	var tuningAdvisorPrometheusURL string
+	var tuningAdvisorMetricsWindow time.Duration
+	var clusterIDMigrationPeriod time.Duration
+	var configKeyRenameTransitionPeriod time.Duration
+	var rookCephOperatorPodSelector string
+	var rookCephOperatorRestartCooldown time.Duration
+	var adjustTopologyForZoneImbalance bool
+	var configSelfTest bool
+	var configDegradedAlertFor time.Duration
+	var configReconcileFailureAlertThreshold int
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&configLockLeaseName, "config-lock-lease-name", "",
+		"The name of the Lease held while the ocs-operator-config configmap is updated, for coordinating with external tools "+
+			"that update it too, e.g. "+ocsinitialization.DefaultConfigLockLeaseName+". The lock is disabled by default.")
+	flag.StringVar(&configLockLeaseNamespace, "config-lock-lease-namespace", "",
+		"The namespace of the config lock Lease. Defaults to the operator namespace.")
+	flag.BoolVar(&correctTopologyBindingMode, "correct-topology-binding-mode", false,
+		"Recreate topology constrained StorageClasses that use the Immediate binding mode with WaitForFirstConsumer when topology is enabled.")
+	flag.BoolVar(&blueGreenConfig, "blue-green-config", false,
+		"Write ocs-operator-config changes to a standby configmap and swap the active reference, to allow rolling back to the previous config.")
+	flag.BoolVar(&remediateRookCephOperatorEnvFrom, "remediate-rook-ceph-operator-envfrom", false,
+		"Add the envFrom reference to the ocs-operator-config configmap to the rook-ceph-operator Deployment if it is missing.")
+	flag.BoolVar(&enableOCSConfigWebhook, "enable-ocsconfig-webhook", false,
+		"Serve the validating webhook that rejects OCSConfig tunables outside of their bounds.")
+	flag.StringVar(&configApprovalWebhookURL, "config-approval-webhook-url", "",
+		"The URL of a service that approves or denies each change of the ocs-operator-config configmap before it is applied. Empty disables the callout.")
+	flag.DurationVar(&configApprovalWebhookTimeout, "config-approval-webhook-timeout", ocsinitialization.DefaultConfigApprovalWebhookTimeout,
+		"The timeout of the config approval webhook callout.")
+	flag.BoolVar(&configApprovalWebhookFailOpen, "config-approval-webhook-fail-open", false,
+		"Apply the ocs-operator-config changes when the config approval webhook cannot be reached, instead of skipping them.")
+	flag.StringVar(&tuningAdvisorPrometheusURL, "tuning-advisor-prometheus-url", "",
+		"The URL of the Prometheus API to analyze the CSI metrics from, to suggest tunable values in the OCSInitialization status. Empty disables the tuning advisor.")
+	flag.DurationVar(&tuningAdvisorMetricsWindow, "tuning-advisor-metrics-window", ocsinitialization.DefaultTuningMetricsWindow,
+		"The range over which the tuning advisor analyzes the CSI metrics.")
+	flag.DurationVar(&clusterIDMigrationPeriod, "cluster-id-migration-period", ocsinitialization.DefaultClusterIDMigrationPeriod,
+		"How long the legacy cluster ID of a cluster ID migration requested on a StorageCluster is passed on to CSI.")
+	flag.DurationVar(&configKeyRenameTransitionPeriod, "config-key-rename-transition-period", ocsinitialization.DefaultConfigKeyRenameTransitionPeriod,
+		"How long a renamed ocs-operator-config key is written with its old name as well, and the previous topology domain labels of a renamed node topology label are passed on.")
+	flag.StringVar(&rookCephOperatorPodSelector, "rook-ceph-operator-pod-selector", "",
+		"The label selector of the rook-ceph-operator pods that are restarted, unless a StorageCluster overrides it. "+
+			"Defaults to the pods labeled with either app=rook-ceph-operator or app.kubernetes.io/name=rook-ceph-operator.")
+	flag.DurationVar(&rookCephOperatorRestartCooldown, "rook-ceph-operator-restart-cooldown", 0,
+		"The minimum time between the start of a rook-ceph-operator pod and a restart for a config change. Zero disables the cooldown.")
+	flag.BoolVar(&adjustTopologyForZoneImbalance, "adjust-topology-for-zone-imbalance", false,
+		"Remove the zone label from the topology domain labels when the zones have heterogeneous sizes and a finer grained domain label is configured.")
+	flag.DurationVar(&configDegradedAlertFor, "config-degraded-alert-for", ocsinitialization.DefaultConfigDegradedAlertFor,
+		"How long the ocs-operator-config reconcile has to stay degraded before the alert of the managed PrometheusRule fires.")
+	flag.IntVar(&configReconcileFailureAlertThreshold, "config-reconcile-failure-alert-threshold", 0,
+		"The number of ocs-operator-config reconcile failures within an hour from which the failing alert of the managed PrometheusRule fires. Zero disables the alert.")
+	flag.BoolVar(&configSelfTest, "ocs-operator-config-self-test", false,
+		"Compute the ocs-operator-config data for a synthetic StorageCluster on startup, and exit if the computation panics or misses any of the expected keys.")
 
 	loggerOpts := zap.Options{}
 	loggerOpts.BindFlags(flag.CommandLine)
@@ -138,6 +199,14 @@ func main() {
 		setupLog.Info("running in development mode")
 	}
 
+	if configSelfTest {
+		if err := ocsinitialization.SelfTestOCSOperatorConfigData(); err != nil {
+			setupLog.Error(err, "ocs-operator-config self-test failed")
+			os.Exit(1)
+		}
+		setupLog.Info("ocs-operator-config self-test passed")
+	}
+
 	operatorNamespace, err := util.GetOperatorNamespace()
 	if err != nil {
 		setupLog.Error(err, "unable to get operator namespace")
@@ -198,13 +267,52 @@ func main() {
 		os.Exit(1)
 	}
 
+	podExecutor, err := util.NewPodExecutor(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "Unable to get pod executor")
+		os.Exit(1)
+	}
+
+	var rookCephOperatorPodLabelSelector labels.Selector
+	if rookCephOperatorPodSelector != "" {
+		rookCephOperatorPodLabelSelector, err = labels.Parse(rookCephOperatorPodSelector)
+		if err != nil {
+			setupLog.Error(err, "invalid rook-ceph-operator pod selector")
+			os.Exit(1)
+		}
+	}
+
+	var csiMetricsSource ocsinitialization.CSIMetricsSource
+	if tuningAdvisorPrometheusURL != "" {
+		csiMetricsSource = ocsinitialization.PrometheusCSIMetricsSource{URL: tuningAdvisorPrometheusURL, Window: tuningAdvisorMetricsWindow}
+	}
 	if err = (&ocsinitialization.OCSInitializationReconciler{
-		Client:            mgr.GetClient(),
-		Log:               ctrl.Log.WithName("controllers").WithName("OCSInitialization"),
-		Scheme:            mgr.GetScheme(),
-		SecurityClient:    secv1client.NewForConfigOrDie(mgr.GetConfig()),
-		OperatorNamespace: operatorNamespace,
-		AvailableCrds:     availCrds,
+		Client:                               mgr.GetClient(),
+		Log:                                  ctrl.Log.WithName("controllers").WithName("OCSInitialization"),
+		Scheme:                               mgr.GetScheme(),
+		SecurityClient:                       secv1client.NewForConfigOrDie(mgr.GetConfig()),
+		OperatorNamespace:                    operatorNamespace,
+		AvailableCrds:                        availCrds,
+		PodExecutor:                          podExecutor,
+		NodeReader:                           mgr.GetAPIReader(),
+		PVCReader:                            mgr.GetAPIReader(),
+		LeaseReader:                          mgr.GetAPIReader(),
+		ConfigLockLeaseName:                  configLockLeaseName,
+		ConfigLockLeaseNamespace:             configLockLeaseNamespace,
+		CorrectTopologyBindingMode:           correctTopologyBindingMode,
+		BlueGreenConfig:                      blueGreenConfig,
+		RemediateRookCephOperatorEnvFrom:     remediateRookCephOperatorEnvFrom,
+		ConfigApprovalWebhookURL:             configApprovalWebhookURL,
+		ConfigApprovalWebhookTimeout:         configApprovalWebhookTimeout,
+		ConfigApprovalWebhookFailOpen:        configApprovalWebhookFailOpen,
+		CSIMetricsSource:                     csiMetricsSource,
+		ClusterIDMigrationPeriod:             clusterIDMigrationPeriod,
+		ConfigKeyRenameTransitionPeriod:      configKeyRenameTransitionPeriod,
+		RookCephOperatorPodSelector:          rookCephOperatorPodLabelSelector,
+		RookCephOperatorRestartCooldown:      rookCephOperatorRestartCooldown,
+		AdjustTopologyForZoneImbalance:       adjustTopologyForZoneImbalance,
+		ConfigDegradedAlertFor:               configDegradedAlertFor,
+		ConfigReconcileFailureAlertThreshold: configReconcileFailureAlertThreshold,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "OCSInitialization")
 		os.Exit(1)
@@ -237,6 +345,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	if enableOCSConfigWebhook {
+		if err = (&ocsconfig.TunableBoundsValidator{
+			Client:            mgr.GetClient(),
+			Log:               ctrl.Log.WithName("webhooks").WithName("OCSConfig"),
+			OperatorNamespace: operatorNamespace,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "OCSConfig")
+			os.Exit(1)
+		}
+	}
+
 	if err = (&storagerequest.StorageRequestReconciler{
 		Cache:             mgr.GetCache(),
 		Client:            mgr.GetClient(),
@@ -899,14 +899,7 @@ func (s *OCSProviderServer) GetStorageClaimConfig(ctx context.Context, req *pb.S
 			if err != nil {
 				return nil, err
 			}
-			var kernelMountOptions map[string]string
-			for _, option := range strings.Split(util.GetCephFSKernelMountOptions(storageCluster), ",") {
-				if kernelMountOptions == nil {
-					kernelMountOptions = map[string]string{}
-				}
-				parts := strings.Split(option, "=")
-				kernelMountOptions[parts[0]] = parts[1]
-			}
+			kernelMountOptions := getCephFSKernelMountOptionsMap(storageCluster)
 
 			// SID for RamenDR
 			storageID := calculateCephFsStorageID(
@@ -1578,20 +1571,28 @@ func (s *OCSProviderServer) appendCephConnectionKubeResources(
 	return kubeResources, nil
 }
 
+// getCephFSKernelMountOptionsMap returns the CephFS kernel mount options of the StorageCluster by name. An option
+// without a value, e.g. wsync, maps to an empty value, and a value is only split from its name at the first "=".
+func getCephFSKernelMountOptionsMap(storageCluster *ocsv1.StorageCluster) map[string]string {
+	kernelMountOptions := map[string]string{}
+	cephFSKernelMountOptions, _ := util.GetCephFSKernelMountOptions(storageCluster)
+	for _, option := range strings.Split(cephFSKernelMountOptions, ",") {
+		if option == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(option, "=")
+		kernelMountOptions[name] = value
+	}
+	return kernelMountOptions
+}
+
 func (s *OCSProviderServer) appendClientProfileKubeResources(
 	kubeResources []client.Object,
 	consumer *ocsv1alpha1.StorageConsumer,
 	consumerConfig util.StorageConsumerResources,
 	storageCluster *ocsv1.StorageCluster,
 ) ([]client.Object, error) {
-	var kernelMountOptions map[string]string
-	for _, option := range strings.Split(util.GetCephFSKernelMountOptions(storageCluster), ",") {
-		if kernelMountOptions == nil {
-			kernelMountOptions = map[string]string{}
-		}
-		parts := strings.Split(option, "=")
-		kernelMountOptions[parts[0]] = parts[1]
-	}
+	kernelMountOptions := getCephFSKernelMountOptionsMap(storageCluster)
 
 	// The client profile name for all the driver maybe the same or different, hence using a map to merge in case of
 	// same name
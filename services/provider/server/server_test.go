@@ -524,6 +524,37 @@ func TestReplaceMsgr1PortWithMsgr2(t *testing.T) {
 	}
 }
 
+func TestGetCephFSKernelMountOptionsMap(t *testing.T) {
+	tests := []struct {
+		name               string
+		kernelMountOptions map[string]string
+		expected           map[string]string
+	}{
+		{
+			name:     "only ms_mode",
+			expected: map[string]string{"ms_mode": "prefer-crc"},
+		},
+		{
+			name:               "option without a value",
+			kernelMountOptions: map[string]string{"wsync": ""},
+			expected:           map[string]string{"ms_mode": "prefer-crc", "wsync": ""},
+		},
+		{
+			name:               "value containing =",
+			kernelMountOptions: map[string]string{"crush_location": "host=node-a"},
+			expected:           map[string]string{"ms_mode": "prefer-crc", "crush_location": "host=node-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageCluster := &ocsv1.StorageCluster{}
+			storageCluster.Spec.ManagedResources.CephFilesystems.KernelMountOptions = tt.kernelMountOptions
+			assert.Equal(t, tt.expected, getCephFSKernelMountOptionsMap(storageCluster))
+		})
+	}
+}
+
 func createCephClientAndSecret(name string, server *OCSProviderServer) (*rookCephv1.CephClient, *v1.Secret) {
 	cephClient := &rookCephv1.CephClient{
 		ObjectMeta: metav1.ObjectMeta{